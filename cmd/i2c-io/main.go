@@ -21,6 +21,7 @@ import (
 
 func mainImpl() error {
 	addr := flag.Int("a", -1, "I²C device address to query")
+	tenBit := flag.Bool("10", false, "use 10 bits addressing; implied for addresses above 0x7F")
 	busName := flag.String("b", "", "I²C bus to use")
 	verbose := flag.Bool("v", false, "verbose mode")
 	// TODO(maruel): This is not generic enough.
@@ -38,8 +39,8 @@ func mainImpl() error {
 		return errors.New("unexpected argument, try -help")
 	}
 
-	if *addr < 0 || *addr >= 1<<9 {
-		return fmt.Errorf("-a is required and must be between 0 and %d", 1<<9-1)
+	if *addr < 0 || *addr >= 1<<10 {
+		return fmt.Errorf("-a is required and must be between 0 and %d", 1<<10-1)
 	}
 	if *reg < 0 || *reg > 255 {
 		return errors.New("-r must be between 0 and 255")
@@ -90,6 +91,9 @@ func mainImpl() error {
 		}
 	}
 	d := i2c.Dev{Bus: bus, Addr: uint16(*addr)}
+	if *tenBit {
+		d.Addr |= i2c.TenBit
+	}
 	if *write {
 		_, err = d.Write(buf)
 	} else {
@@ -12,6 +12,8 @@ import (
 	"io/ioutil"
 	"log"
 	"os"
+	"strconv"
+	"strings"
 
 	"periph.io/x/periph/conn/pin"
 	"periph.io/x/periph/conn/pin/pinreg"
@@ -28,6 +30,37 @@ func printPin(fn string, p pin.Pin) {
 	}
 }
 
+func printCaps(c spi.Capabilities) {
+	caps, err := c.Caps()
+	if err != nil {
+		fmt.Printf("  Failed to query capabilities: %v\n", err)
+		return
+	}
+	fmt.Printf("  Capabilities:\n")
+	m := "none"
+	if o := caps.Mode & (spi.HalfDuplex | spi.NoCS | spi.LSBFirst); o != 0 {
+		m = o.String()[len("Mode0|"):]
+	}
+	fmt.Printf("    Modes:     Mode0-3, options: %s\n", m)
+	if caps.CSHigh {
+		fmt.Printf("    CS high:   true\n")
+	} else {
+		fmt.Printf("    CS high:   unknown\n")
+	}
+	if caps.MaxSpeed != 0 {
+		fmt.Printf("    Max speed: %s\n", caps.MaxSpeed)
+	} else {
+		fmt.Printf("    Max speed: unknown\n")
+	}
+	var bits []string
+	for i := 1; i <= 32; i++ {
+		if caps.SupportsBits(i) {
+			bits = append(bits, strconv.Itoa(i))
+		}
+	}
+	fmt.Printf("    Bits:      %s\n", strings.Join(bits, " "))
+}
+
 func mainImpl() error {
 	verbose := flag.Bool("v", false, "verbose mode")
 	flag.Parse()
@@ -65,6 +98,9 @@ func mainImpl() error {
 			printPin("MISO", p.MISO())
 			printPin("CS", p.CS())
 		}
+		if c, ok := s.(spi.Capabilities); ok {
+			printCaps(c)
+		}
 		if err := s.Close(); err != nil {
 			return err
 		}
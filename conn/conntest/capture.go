@@ -0,0 +1,171 @@
+// Copyright 2019 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package conntest
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// CaptureFormat identifies a capture file.
+const CaptureFormat = "periph-capture"
+
+// CaptureVersion is the version of the capture file format.
+const CaptureVersion = 1
+
+// CaptureHeader is the first line of a capture file.
+//
+// A capture file is a JSON lines stream: a CaptureHeader followed by one
+// operation per line, whose encoding is specific to the bus. The bytes are
+// encoded in hexadecimal so the files are readable and diffable. This permits
+// saving the traffic recorded on real hardware and replaying it in unit tests.
+type CaptureHeader struct {
+	Format  string `json:"format"`
+	Version int    `json:"version"`
+	// Bus is the type of bus, for example "i2c" or "spi".
+	Bus string `json:"bus"`
+}
+
+// Hex is a byte slice encoded as an hexadecimal string in JSON.
+type Hex []byte
+
+// MarshalText implements encoding.TextMarshaler.
+func (h Hex) MarshalText() ([]byte, error) {
+	out := make([]byte, hex.EncodedLen(len(h)))
+	hex.Encode(out, h)
+	return out, nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (h *Hex) UnmarshalText(b []byte) error {
+	out := make([]byte, hex.DecodedLen(len(b)))
+	if _, err := hex.Decode(out, b); err != nil {
+		return err
+	}
+	*h = out
+	return nil
+}
+
+// CaptureWriter writes a capture file.
+type CaptureWriter struct {
+	e *json.Encoder
+}
+
+// NewCaptureWriter writes the header of a capture of a bus of type bus and
+// returns a CaptureWriter to write the operations.
+func NewCaptureWriter(w io.Writer, bus string) (*CaptureWriter, error) {
+	c := &CaptureWriter{e: json.NewEncoder(w)}
+	if err := c.e.Encode(&CaptureHeader{Format: CaptureFormat, Version: CaptureVersion, Bus: bus}); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Write writes one operation, encoded with encoding/json.
+func (c *CaptureWriter) Write(op interface{}) error {
+	return c.e.Encode(op)
+}
+
+// CaptureReader reads a capture file.
+type CaptureReader struct {
+	d *json.Decoder
+}
+
+// NewCaptureReader reads the header of a capture and returns a CaptureReader
+// to read the operations.
+//
+// It returns an error if the capture is not for a bus of type bus.
+func NewCaptureReader(r io.Reader, bus string) (*CaptureReader, error) {
+	c := &CaptureReader{d: json.NewDecoder(r)}
+	var h CaptureHeader
+	if err := c.d.Decode(&h); err != nil {
+		if err == io.EOF {
+			return nil, errors.New("conntest: empty capture")
+		}
+		return nil, err
+	}
+	if h.Format != CaptureFormat {
+		return nil, fmt.Errorf("conntest: not a capture file: %q", h.Format)
+	}
+	if h.Version != CaptureVersion {
+		return nil, fmt.Errorf("conntest: unsupported capture version %d", h.Version)
+	}
+	if h.Bus != bus {
+		return nil, fmt.Errorf("conntest: capture is for bus %q, not %q", h.Bus, bus)
+	}
+	return c, nil
+}
+
+// Read reads the next operation into op with encoding/json.
+//
+// It returns io.EOF at the end of the capture.
+func (c *CaptureReader) Read(op interface{}) error {
+	return c.d.Decode(op)
+}
+
+// SaveOps writes ops as a capture of a bus of type bus.
+func SaveOps(w io.Writer, bus string, ops []IO) error {
+	c, err := NewCaptureWriter(w, bus)
+	if err != nil {
+		return err
+	}
+	for i := range ops {
+		if err := c.Write(&captureIO{W: ops[i].W, R: ops[i].R}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadOps reads a capture of a bus of type bus written by SaveOps.
+func LoadOps(r io.Reader, bus string) ([]IO, error) {
+	c, err := NewCaptureReader(r, bus)
+	if err != nil {
+		return nil, err
+	}
+	var ops []IO
+	for {
+		var op captureIO
+		if err := c.Read(&op); err != nil {
+			if err == io.EOF {
+				return ops, nil
+			}
+			return nil, err
+		}
+		ops = append(ops, IO{W: op.W, R: op.R})
+	}
+}
+
+// Save writes the recorded operations as a capture.
+func (r *Record) Save(w io.Writer) error {
+	r.Lock()
+	defer r.Unlock()
+	return SaveOps(w, "conn", r.Ops)
+}
+
+// Load replaces the operations to play back with the ones in a capture
+// written by Record.Save().
+func (p *Playback) Load(r io.Reader) error {
+	ops, err := LoadOps(r, "conn")
+	if err != nil {
+		return err
+	}
+	p.Lock()
+	defer p.Unlock()
+	p.Ops = ops
+	p.Count = 0
+	return nil
+}
+
+//
+
+// captureIO is the encoding of IO in a capture.
+type captureIO struct {
+	W Hex `json:"w,omitempty"`
+	R Hex `json:"r,omitempty"`
+}
@@ -0,0 +1,76 @@
+// Copyright 2019 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package conntest
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRecord_Save_Playback_Load(t *testing.T) {
+	r := Record{Conn: &Playback{Ops: []IO{{W: []byte{0x10}, R: []byte{0xAB, 0xCD}}, {W: []byte{0x20}}}}}
+	if err := r.Tx([]byte{0x10}, make([]byte, 2)); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Tx([]byte{0x20}, nil); err != nil {
+		t.Fatal(err)
+	}
+	b := bytes.Buffer{}
+	if err := r.Save(&b); err != nil {
+		t.Fatal(err)
+	}
+	expected := "{\"format\":\"periph-capture\",\"version\":1,\"bus\":\"conn\"}\n{\"w\":\"10\",\"r\":\"abcd\"}\n{\"w\":\"20\"}\n"
+	if s := b.String(); s != expected {
+		t.Fatal(s)
+	}
+	p := Playback{Count: 2}
+	if err := p.Load(&b); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(p.Ops, r.Ops) || p.Count != 0 {
+		t.Fatal(p.Ops, p.Count)
+	}
+	read := make([]byte, 2)
+	if err := p.Tx([]byte{0x10}, read); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(read, []byte{0xAB, 0xCD}) {
+		t.Fatal(read)
+	}
+}
+
+func TestLoadOps_Err(t *testing.T) {
+	data := []string{
+		"",
+		"garbage",
+		"{\"format\":\"foo\",\"version\":1,\"bus\":\"conn\"}\n",
+		"{\"format\":\"periph-capture\",\"version\":2,\"bus\":\"conn\"}\n",
+		"{\"format\":\"periph-capture\",\"version\":1,\"bus\":\"spi\"}\n",
+		"{\"format\":\"periph-capture\",\"version\":1,\"bus\":\"conn\"}\n{\"w\":\"zz\"}\n",
+	}
+	for i, line := range data {
+		if _, err := LoadOps(strings.NewReader(line), "conn"); err == nil {
+			t.Fatal(i)
+		}
+	}
+	p := Playback{}
+	if err := p.Load(strings.NewReader("")); err == nil {
+		t.Fatal("empty")
+	}
+}
+
+func TestHex(t *testing.T) {
+	h := Hex{0x01, 0xFE}
+	b, err := h.MarshalText()
+	if err != nil || string(b) != "01fe" {
+		t.Fatal(string(b), err)
+	}
+	var out Hex
+	if err := out.UnmarshalText([]byte("01FE")); err != nil || !bytes.Equal(out, h) {
+		t.Fatal(out, err)
+	}
+}
@@ -0,0 +1,264 @@
+// Copyright 2019 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package gpio
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"time"
+)
+
+// EdgePollTimeout is the timeout used by WaitForEdgeContext() for each call
+// to WaitForEdge() on pins that do not implement EdgeWaiter.
+//
+// It is also used by drivers that can't be interrupted while waiting for an
+// edge.
+const EdgePollTimeout = 50 * time.Millisecond
+
+// EdgeWaiter is an optional interface implemented by a PinIn that supports
+// waiting for an edge with cancellation.
+//
+// Use WaitForEdgeContext() to use it with any PinIn.
+type EdgeWaiter interface {
+	// WaitForEdgeContext waits for the next edge as configured with In() or for
+	// ctx to be done, whichever happens first.
+	//
+	// It returns the direction of the edge, either RisingEdge or FallingEdge,
+	// and the time at which it was detected. It returns ctx.Err() when ctx is
+	// done before an edge is detected.
+	WaitForEdgeContext(ctx context.Context) (Edge, time.Time, error)
+}
+
+// WaitForEdgeContext waits for the next edge on p as configured with In() or
+// for ctx to be done, whichever happens first.
+//
+// It calls p.WaitForEdgeContext() when p implements EdgeWaiter. Otherwise it
+// calls p.WaitForEdge() with a short timeout in a loop, so ctx cancellation is
+// noticed within EdgePollTimeout. In this case, the edge direction is deduced
+// by reading the pin right after the edge and the time is when WaitForEdge()
+// returned.
+func WaitForEdgeContext(ctx context.Context, p PinIn) (Edge, time.Time, error) {
+	if w, ok := p.(EdgeWaiter); ok {
+		return w.WaitForEdgeContext(ctx)
+	}
+	for {
+		if err := ctx.Err(); err != nil {
+			return NoEdge, time.Time{}, err
+		}
+		d := EdgePollTimeout
+		if dl, ok := ctx.Deadline(); ok {
+			if r := dl.Sub(time.Now()); r < d {
+				d = r
+			}
+		}
+		if d <= 0 {
+			<-ctx.Done()
+			continue
+		}
+		start := time.Now()
+		if p.WaitForEdge(d) {
+			now := time.Now()
+			if p.Read() == High {
+				return RisingEdge, now, nil
+			}
+			return FallingEdge, now, nil
+		}
+		// Some pins return immediately when edge detection is not supported; do
+		// not busy loop on them.
+		if r := d - time.Since(start); r > 0 {
+			t := time.NewTimer(r)
+			select {
+			case <-ctx.Done():
+			case <-t.C:
+			}
+			t.Stop()
+		}
+	}
+}
+
+// EdgeEvent is an edge detected on a pin.
+type EdgeEvent struct {
+	// Level is the level of the pin right after the edge.
+	Level Level
+	// Time is when the edge was detected. Its accuracy depends on the driver;
+	// it is the kernel timestamp when available.
+	Time time.Time
+}
+
+func (e EdgeEvent) String() string {
+	return e.Level.String() + "@" + e.Time.Format("15:04:05.000000000")
+}
+
+// EdgeStreamer is an optional interface implemented by a PinIn that can queue
+// the edges detected while the client is busy.
+//
+// Use StreamEdges() to use it with any PinIn.
+type EdgeStreamer interface {
+	// StreamEdges configures the pin as input with the specified pull and
+	// edge detection, then starts queueing the edges in a buffer of size
+	// events.
+	//
+	// WaitForEdge() and WaitForEdgeContext() must not be used until the
+	// stream is closed.
+	StreamEdges(pull Pull, edge Edge, size int) (*EdgeStream, error)
+}
+
+// StreamEdges configures p as input with the specified pull and edge
+// detection, then starts queueing the edges in a buffer of size events.
+//
+// It calls p.StreamEdges() when p implements EdgeStreamer. Otherwise a
+// goroutine calls WaitForEdgeContext() in a loop, which may miss edges that
+// happen in quick succession.
+func StreamEdges(p PinIn, pull Pull, edge Edge, size int) (*EdgeStream, error) {
+	if s, ok := p.(EdgeStreamer); ok {
+		return s.StreamEdges(pull, edge, size)
+	}
+	if edge == NoEdge {
+		return nil, errors.New("gpio: edge detection is required")
+	}
+	if size <= 0 {
+		return nil, errors.New("gpio: invalid buffer size")
+	}
+	if err := p.In(pull, edge); err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	s := NewEdgeStream(size, func() error {
+		cancel()
+		<-done
+		return nil
+	})
+	go func() {
+		defer close(done)
+		for {
+			e, t, err := WaitForEdgeContext(ctx, p)
+			if err != nil {
+				return
+			}
+			s.Push(EdgeEvent{Level: e == RisingEdge, Time: t})
+		}
+	}()
+	return s, nil
+}
+
+// EdgeStream is a buffered stream of edges detected on a pin.
+//
+// The events are buffered up to the size specified to NewEdgeStream(). When
+// the buffer is full, the new events are dropped and counted by Overflows().
+type EdgeStream struct {
+	c    chan EdgeEvent
+	stop func() error
+
+	mu        sync.Mutex
+	closing   bool
+	closed    bool
+	overflows uint64
+	missed    uint64
+}
+
+// NewEdgeStream returns an EdgeStream that buffers up to size events.
+//
+// It is meant to be used by drivers implementing EdgeStreamer. stop is called
+// once by Close(); it must stop the producer, so that Push() is not called
+// anymore once it returns.
+func NewEdgeStream(size int, stop func() error) *EdgeStream {
+	return &EdgeStream{c: make(chan EdgeEvent, size), stop: stop}
+}
+
+// Events returns the channel of buffered events.
+//
+// The channel is closed by Close().
+func (s *EdgeStream) Events() <-chan EdgeEvent {
+	return s.c
+}
+
+// Next returns the next event, waiting for one if the buffer is empty.
+//
+// It returns ctx.Err() if ctx is done first, or io.EOF if the stream is
+// closed.
+func (s *EdgeStream) Next(ctx context.Context) (EdgeEvent, error) {
+	select {
+	case e, ok := <-s.c:
+		if !ok {
+			return EdgeEvent{}, io.EOF
+		}
+		return e, nil
+	case <-ctx.Done():
+		return EdgeEvent{}, ctx.Err()
+	}
+}
+
+// Overflows returns the number of events dropped because the buffer was full.
+func (s *EdgeStream) Overflows() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.overflows
+}
+
+// Missed returns the number of edges that the driver or the kernel reported
+// as lost before they reached the buffer.
+//
+// Not all drivers can detect this; it is always 0 for those.
+func (s *EdgeStream) Missed() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.missed
+}
+
+// Close stops the stream and closes the channel returned by Events().
+//
+// The events still in the buffer can be read after Close() returns.
+func (s *EdgeStream) Close() error {
+	s.mu.Lock()
+	if s.closing {
+		s.mu.Unlock()
+		return errors.New("gpio: edge stream already closed")
+	}
+	s.closing = true
+	s.mu.Unlock()
+	// Stop the producer first so the edges it is processing are accounted
+	// for.
+	var err error
+	if s.stop != nil {
+		err = s.stop()
+	}
+	s.mu.Lock()
+	s.closed = true
+	close(s.c)
+	s.mu.Unlock()
+	return err
+}
+
+// Push adds an event to the buffer without blocking.
+//
+// It is meant to be used by drivers. It returns false if the event was
+// dropped because the buffer is full or the stream is closed.
+func (s *EdgeStream) Push(e EdgeEvent) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return false
+	}
+	select {
+	case s.c <- e:
+		return true
+	default:
+		s.overflows++
+		return false
+	}
+}
+
+// AddMissed records edges lost before they reached the buffer.
+//
+// It is meant to be used by drivers, for example when the kernel reports
+// that its own queue overflowed.
+func (s *EdgeStream) AddMissed(n uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.missed += n
+}
@@ -0,0 +1,196 @@
+// Copyright 2019 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package gpio
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestWaitForEdgeContext_EdgeWaiter(t *testing.T) {
+	p := &edgeWaiterPin{edge: FallingEdge}
+	e, _, err := WaitForEdgeContext(context.Background(), p)
+	if err != nil || e != FallingEdge {
+		t.Fatal(e, err)
+	}
+}
+
+func TestWaitForEdgeContext_Poll(t *testing.T) {
+	p := &pollPin{edges: 3, level: High}
+	e, when, err := WaitForEdgeContext(context.Background(), p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e != RisingEdge || when.IsZero() {
+		t.Fatal(e, when)
+	}
+	if p.calls != 3 {
+		t.Fatal(p.calls)
+	}
+	p = &pollPin{edges: 1, level: Low}
+	if e, _, err = WaitForEdgeContext(context.Background(), p); err != nil || e != FallingEdge {
+		t.Fatal(e, err)
+	}
+}
+
+func TestWaitForEdgeContext_Cancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	p := &pollPin{edges: 1}
+	if _, _, err := WaitForEdgeContext(ctx, p); err != context.Canceled {
+		t.Fatal(err)
+	}
+	if p.calls != 0 {
+		t.Fatal(p.calls)
+	}
+}
+
+func TestWaitForEdgeContext_Deadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	// The pin returns immediately, like a pin without edge detection.
+	p := &pollPin{edges: -1}
+	if _, _, err := WaitForEdgeContext(ctx, p); err != context.DeadlineExceeded {
+		t.Fatal(err)
+	}
+	if p.calls > 2 {
+		t.Fatalf("busy looped: %d calls", p.calls)
+	}
+}
+
+func TestEdgeStream(t *testing.T) {
+	stopped := false
+	s := NewEdgeStream(2, func() error {
+		stopped = true
+		return nil
+	})
+	ts := time.Date(2019, 1, 2, 3, 4, 5, 6, time.UTC)
+	if !s.Push(EdgeEvent{Level: High, Time: ts}) {
+		t.Fatal("expected push")
+	}
+	if !s.Push(EdgeEvent{Level: Low, Time: ts}) {
+		t.Fatal("expected push")
+	}
+	if s.Push(EdgeEvent{Level: High, Time: ts}) {
+		t.Fatal("buffer is full")
+	}
+	s.AddMissed(3)
+	e, err := s.Next(context.Background())
+	if err != nil || e.Level != High {
+		t.Fatal(e, err)
+	}
+	if s := e.String(); s != "High@03:04:05.000000006" {
+		t.Fatal(s)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !stopped {
+		t.Fatal("expected stop")
+	}
+	if s.Close() == nil {
+		t.Fatal("already closed")
+	}
+	if s.Push(EdgeEvent{}) {
+		t.Fatal("closed")
+	}
+	if e, err := s.Next(context.Background()); err != nil || e.Level != Low {
+		t.Fatal(e, err)
+	}
+	if _, err := s.Next(context.Background()); err != io.EOF {
+		t.Fatal(err)
+	}
+	if o := s.Overflows(); o != 1 {
+		t.Fatal(o)
+	}
+	if m := s.Missed(); m != 3 {
+		t.Fatal(m)
+	}
+}
+
+func TestEdgeStream_Next_Cancel(t *testing.T) {
+	s := NewEdgeStream(1, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := s.Next(ctx); err != context.Canceled {
+		t.Fatal(err)
+	}
+}
+
+func TestStreamEdges_Poll(t *testing.T) {
+	p := &pollPin{edges: 1, level: High}
+	if _, err := StreamEdges(p, PullNoChange, NoEdge, 1); err == nil {
+		t.Fatal("edge detection is required")
+	}
+	if _, err := StreamEdges(p, PullNoChange, BothEdges, 0); err == nil {
+		t.Fatal("invalid buffer size")
+	}
+	s, err := StreamEdges(p, PullNoChange, BothEdges, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, err := s.Next(context.Background())
+	if err != nil || e.Level != High || e.Time.IsZero() {
+		t.Fatal(e, err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStreamEdges_EdgeStreamer(t *testing.T) {
+	p := &edgeStreamerPin{}
+	s, err := StreamEdges(p, PullNoChange, BothEdges, 1)
+	if err != nil || s != p.s {
+		t.Fatal(s, err)
+	}
+}
+
+//
+
+// edgeStreamerPin implements EdgeStreamer.
+type edgeStreamerPin struct {
+	invalidPin
+	s *EdgeStream
+}
+
+func (e *edgeStreamerPin) StreamEdges(pull Pull, edge Edge, size int) (*EdgeStream, error) {
+	e.s = NewEdgeStream(size, nil)
+	return e.s, nil
+}
+
+// edgeWaiterPin implements EdgeWaiter.
+type edgeWaiterPin struct {
+	invalidPin
+	edge Edge
+}
+
+func (e *edgeWaiterPin) WaitForEdgeContext(ctx context.Context) (Edge, time.Time, error) {
+	return e.edge, time.Now(), nil
+}
+
+// pollPin only implements WaitForEdge(). It reports an edge on the Nth call
+// and never when edges is negative.
+type pollPin struct {
+	invalidPin
+	edges int
+	calls int
+	level Level
+}
+
+func (p *pollPin) WaitForEdge(timeout time.Duration) bool {
+	p.calls++
+	return p.calls == p.edges
+}
+
+func (p *pollPin) In(pull Pull, edge Edge) error {
+	return nil
+}
+
+func (p *pollPin) Read() Level {
+	return p.level
+}
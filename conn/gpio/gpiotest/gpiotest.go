@@ -6,6 +6,7 @@
 package gpiotest
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log"
@@ -122,6 +123,58 @@ func (p *Pin) WaitForEdge(timeout time.Duration) bool {
 	}
 }
 
+// WaitForEdgeContext implements gpio.EdgeWaiter.
+//
+// The level received on EdgesChan determines the edge direction.
+func (p *Pin) WaitForEdgeContext(ctx context.Context) (gpio.Edge, time.Time, error) {
+	select {
+	case <-ctx.Done():
+		return gpio.NoEdge, time.Time{}, ctx.Err()
+	case l := <-p.EdgesChan:
+		now := time.Now()
+		_ = p.Out(l)
+		if l == gpio.High {
+			return gpio.RisingEdge, now, nil
+		}
+		return gpio.FallingEdge, now, nil
+	}
+}
+
+// StreamEdges implements gpio.EdgeStreamer.
+//
+// The levels sent on EdgesChan are queued until the stream is closed.
+func (p *Pin) StreamEdges(pull gpio.Pull, edge gpio.Edge, size int) (*gpio.EdgeStream, error) {
+	if edge == gpio.NoEdge {
+		return nil, errors.New("gpiotest: edge detection is required")
+	}
+	if size <= 0 {
+		return nil, errors.New("gpiotest: invalid buffer size")
+	}
+	if err := p.In(pull, edge); err != nil {
+		return nil, err
+	}
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	s := gpio.NewEdgeStream(size, func() error {
+		close(stop)
+		<-done
+		return nil
+	})
+	go func() {
+		defer close(done)
+		for {
+			select {
+			case <-stop:
+				return
+			case l := <-p.EdgesChan:
+				_ = p.Out(l)
+				s.Push(gpio.EdgeEvent{Level: l, Time: time.Now()})
+			}
+		}
+	}()
+	return s, nil
+}
+
 // Pull implements gpio.PinIn.
 func (p *Pin) Pull() gpio.Pull {
 	return p.P
@@ -180,6 +233,14 @@ func (p *LogPinIO) WaitForEdge(timeout time.Duration) bool {
 	return r
 }
 
+// WaitForEdgeContext implements gpio.EdgeWaiter.
+func (p *LogPinIO) WaitForEdgeContext(ctx context.Context) (gpio.Edge, time.Time, error) {
+	s := time.Now()
+	e, t, err := gpio.WaitForEdgeContext(ctx, p.PinIO)
+	log.Printf("%s.WaitForEdgeContext() -> %s, %v after %s", p, e, err, time.Since(s))
+	return e, t, err
+}
+
 // Out implements gpio.PinOut.
 func (p *LogPinIO) Out(l gpio.Level) error {
 	log.Printf("%s.Out(%s)", p, l)
@@ -193,4 +254,7 @@ func (p *LogPinIO) PWM(duty gpio.Duty, f physic.Frequency) error {
 }
 
 var _ gpio.PinIO = &Pin{}
+var _ gpio.EdgeWaiter = &Pin{}
+var _ gpio.EdgeStreamer = &Pin{}
 var _ pin.PinFunc = &Pin{}
+var _ gpio.EdgeWaiter = &LogPinIO{}
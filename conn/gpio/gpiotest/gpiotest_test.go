@@ -5,6 +5,7 @@
 package gpiotest
 
 import (
+	"context"
 	"io/ioutil"
 	"log"
 	"os"
@@ -97,6 +98,58 @@ func TestPin_edge(t *testing.T) {
 	}
 }
 
+func TestPin_WaitForEdgeContext(t *testing.T) {
+	p := &Pin{N: "GPIO1", Num: 1, EdgesChan: make(chan gpio.Level, 1)}
+	p.EdgesChan <- gpio.High
+	e, when, err := p.WaitForEdgeContext(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e != gpio.RisingEdge || when.IsZero() {
+		t.Fatal(e, when)
+	}
+	if l := p.Read(); l != gpio.High {
+		t.Fatalf("unexpected %s", l)
+	}
+	p.EdgesChan <- gpio.Low
+	if e, _, err := p.WaitForEdgeContext(context.Background()); err != nil || e != gpio.FallingEdge {
+		t.Fatal(e, err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, _, err := p.WaitForEdgeContext(ctx); err != context.Canceled {
+		t.Fatal(err)
+	}
+}
+
+func TestPin_StreamEdges(t *testing.T) {
+	p := &Pin{N: "GPIO1", Num: 1}
+	if _, err := p.StreamEdges(gpio.PullNoChange, gpio.BothEdges, 2); err == nil {
+		t.Fatal("EdgesChan is required")
+	}
+	p.EdgesChan = make(chan gpio.Level)
+	s, err := p.StreamEdges(gpio.PullNoChange, gpio.BothEdges, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, l := range []gpio.Level{gpio.High, gpio.Low, gpio.High} {
+		p.EdgesChan <- l
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	var got []gpio.Level
+	for e := range s.Events() {
+		got = append(got, e.Level)
+	}
+	if !reflect.DeepEqual(got, []gpio.Level{gpio.High, gpio.Low}) {
+		t.Fatal(got)
+	}
+	if o := s.Overflows(); o != 1 {
+		t.Fatal(o)
+	}
+}
+
 func TestPin_fail(t *testing.T) {
 	p := &Pin{N: "GPIO1", Num: 1, Fn: "I2C1_SDA"}
 	if err := p.In(gpio.Float, gpio.BothEdges); err == nil {
@@ -127,6 +180,11 @@ func TestLogPinIO(t *testing.T) {
 	if l.WaitForEdge(0) {
 		t.Fatal("unexpected edge")
 	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, _, err := l.WaitForEdgeContext(ctx); err != context.Canceled {
+		t.Fatal(err)
+	}
 	// gpio.PinOut
 	if err := l.Out(gpio.High); err != nil {
 		t.Fatal(err)
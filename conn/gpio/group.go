@@ -0,0 +1,160 @@
+// Copyright 2019 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package gpio
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"periph.io/x/periph/conn"
+)
+
+// Group is a set of GPIO pins that are read and written as a bitmask.
+//
+// Bit N of the masks maps to Pins()[N]. This is useful for bit-parallel
+// buses, like a character LCD data bus or multiplexed 7-segment displays,
+// where changing the pins one at a time would cause glitches.
+//
+// When the pins are all handled by a driver that supports it, the operation is
+// done with a minimal number of register accesses, otherwise the pins are
+// accessed one after the other. Use NewGroup to create a Group.
+type Group interface {
+	conn.Resource
+	// Pins returns the pins in the group, in bit order.
+	Pins() []PinIO
+	// In sets all the pins as input with the specified pull.
+	In(pull Pull) error
+	// Read returns the current level of the pins which bit is set in mask.
+	//
+	// The bits not set in mask are returned as 0.
+	Read(mask uint64) uint64
+	// Out sets the pins which bit is set in mask to the level of the
+	// corresponding bit in bits.
+	//
+	// The pins are set as output if they were not already. The pins which bit
+	// is not set in mask are not affected.
+	Out(bits, mask uint64) error
+}
+
+// PinGrouper is implemented by GPIO pins which driver can efficiently drive
+// several of its pins at once.
+type PinGrouper interface {
+	// Group returns a Group for pins or nil if the driver doesn't support this
+	// specific combination of pins. This may happen when the pins are not all
+	// handled by this driver or are in different register banks.
+	//
+	// pins is guaranteed to be between 1 and 64 pins, to contain the receiver
+	// and to have no duplicate.
+	Group(pins []PinIO) Group
+}
+
+// NewGroup returns a Group for the specified pins.
+//
+// Aliases are resolved when looking for the driver handling the pins but
+// Pins() returns the pins as specified.
+//
+// Up to 64 pins are supported.
+func NewGroup(pins ...PinIO) (Group, error) {
+	if len(pins) == 0 {
+		return nil, errors.New("gpio: at least one pin is required")
+	}
+	if len(pins) > 64 {
+		return nil, fmt.Errorf("gpio: at most 64 pins are supported; got %d", len(pins))
+	}
+	seen := make(map[PinIO]struct{}, len(pins))
+	for i, p := range pins {
+		if p == nil {
+			return nil, fmt.Errorf("gpio: pin #%d is nil", i)
+		}
+		r := realPin(p)
+		if _, ok := seen[r]; ok {
+			return nil, fmt.Errorf("gpio: pin %s is specified more than once", p)
+		}
+		seen[r] = struct{}{}
+	}
+	if g, ok := realPin(pins[0]).(PinGrouper); ok {
+		if grp := g.Group(pins); grp != nil {
+			return grp, nil
+		}
+	}
+	return &pinGroup{pins: pins}, nil
+}
+
+//
+
+// realPin returns the pin behind aliases.
+func realPin(p PinIO) PinIO {
+	for {
+		r, ok := p.(RealPin)
+		if !ok {
+			return p
+		}
+		p = r.Real()
+	}
+}
+
+// pinGroup is the generic Group implementation that accesses the pins one
+// after the other.
+type pinGroup struct {
+	pins []PinIO
+}
+
+func (g *pinGroup) String() string {
+	names := make([]string, len(g.pins))
+	for i, p := range g.pins {
+		names[i] = p.String()
+	}
+	return "Group(" + strings.Join(names, ", ") + ")"
+}
+
+// Halt implements conn.Resource.
+//
+// It halts all the pins and returns the first error.
+func (g *pinGroup) Halt() error {
+	var err error
+	for _, p := range g.pins {
+		if err2 := p.Halt(); err == nil {
+			err = err2
+		}
+	}
+	return err
+}
+
+func (g *pinGroup) Pins() []PinIO {
+	return g.pins
+}
+
+func (g *pinGroup) In(pull Pull) error {
+	for _, p := range g.pins {
+		if err := p.In(pull, NoEdge); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (g *pinGroup) Read(mask uint64) uint64 {
+	var out uint64
+	for i, p := range g.pins {
+		if mask&(1<<uint(i)) != 0 && p.Read() {
+			out |= 1 << uint(i)
+		}
+	}
+	return out
+}
+
+func (g *pinGroup) Out(bits, mask uint64) error {
+	for i, p := range g.pins {
+		if mask&(1<<uint(i)) != 0 {
+			if err := p.Out(bits&(1<<uint(i)) != 0); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+var _ Group = &pinGroup{}
@@ -0,0 +1,114 @@
+// Copyright 2019 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package gpio_test
+
+import (
+	"testing"
+
+	"periph.io/x/periph/conn/gpio"
+	"periph.io/x/periph/conn/gpio/gpiotest"
+)
+
+func TestNewGroup(t *testing.T) {
+	p := []*gpiotest.Pin{{N: "A", Num: 1}, {N: "B", Num: 2}, {N: "C", Num: 3}}
+	g, err := gpio.NewGroup(p[0], p[1], p[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := g.String(); s != "Group(A(1), B(2), C(3))" {
+		t.Fatal(s)
+	}
+	if len(g.Pins()) != 3 {
+		t.Fatal(g.Pins())
+	}
+	if err := g.Out(0x5, 0x7); err != nil {
+		t.Fatal(err)
+	}
+	if p[0].L != gpio.High || p[1].L != gpio.Low || p[2].L != gpio.High {
+		t.Fatal(p[0].L, p[1].L, p[2].L)
+	}
+	// Pins not in the mask are not touched.
+	if err := g.Out(0x0, 0x1); err != nil {
+		t.Fatal(err)
+	}
+	if p[0].L != gpio.Low || p[2].L != gpio.High {
+		t.Fatal(p[0].L, p[2].L)
+	}
+	if v := g.Read(0x7); v != 0x4 {
+		t.Fatal(v)
+	}
+	if v := g.Read(0x3); v != 0 {
+		t.Fatal(v)
+	}
+	if err := g.In(gpio.PullUp); err != nil {
+		t.Fatal(err)
+	}
+	if v := g.Read(0x7); v != 0x7 {
+		t.Fatal(v)
+	}
+	if err := g.Halt(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNewGroup_Err(t *testing.T) {
+	if _, err := gpio.NewGroup(); err == nil {
+		t.Fatal("no pin")
+	}
+	p := &gpiotest.Pin{N: "A", Num: 1}
+	if _, err := gpio.NewGroup(p, nil); err == nil {
+		t.Fatal("nil pin")
+	}
+	if _, err := gpio.NewGroup(p, p); err == nil {
+		t.Fatal("duplicate pin")
+	}
+	if _, err := gpio.NewGroup(p, &alias{p}); err == nil {
+		t.Fatal("duplicate pin via alias")
+	}
+	pins := make([]gpio.PinIO, 65)
+	for i := range pins {
+		pins[i] = &gpiotest.Pin{N: "A", Num: i}
+	}
+	if _, err := gpio.NewGroup(pins...); err == nil {
+		t.Fatal("too many pins")
+	}
+}
+
+func TestNewGroup_Grouper(t *testing.T) {
+	p := &grouperPin{Pin: gpiotest.Pin{N: "A", Num: 1}}
+	a := &alias{p}
+	g, err := gpio.NewGroup(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g != p.g {
+		t.Fatal("expected the driver's Group")
+	}
+	if p.pins[0] != a {
+		t.Fatal("expected the pins as specified")
+	}
+}
+
+//
+
+type alias struct {
+	gpio.PinIO
+}
+
+func (a *alias) Real() gpio.PinIO {
+	return a.PinIO
+}
+
+type grouperPin struct {
+	gpiotest.Pin
+	pins []gpio.PinIO
+	g    gpio.Group
+}
+
+func (g *grouperPin) Group(pins []gpio.PinIO) gpio.Group {
+	g.pins = pins
+	g.g, _ = gpio.NewGroup(&g.Pin)
+	return g.g
+}
@@ -64,3 +64,35 @@ func ExampleAddr_flag() {
 	flag.Var(&addr, "addr", "i2c device address")
 	flag.Parse()
 }
+
+func ExampleTargetBus() {
+	// Make sure periph is initialized.
+	if _, err := host.Init(); err != nil {
+		log.Fatal(err)
+	}
+
+	b, err := i2creg.Open("")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer b.Close()
+
+	tb, ok := b.(i2c.TargetBus)
+	if !ok {
+		log.Fatalf("%s doesn't support the target mode", b)
+	}
+
+	// Emulate a device at address 0x48 with 16 registers.
+	regs := i2c.NewRegisters(16)
+	regs.OnChange = func(reg int, v []byte) {
+		fmt.Printf("master wrote %#v at 0x%02x\n", v, reg)
+	}
+	t, err := tb.Listen(0x48, regs)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer t.Close()
+
+	// Update the value the master reads at register 0.
+	regs.Set(0, []byte{0x19, 0x80})
+}
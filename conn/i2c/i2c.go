@@ -22,6 +22,7 @@ import (
 	"errors"
 	"io"
 	"strconv"
+	"strings"
 
 	"periph.io/x/periph/conn"
 	"periph.io/x/periph/conn/gpio"
@@ -85,13 +86,20 @@ func (d *Dev) String() string {
 	if d.Bus != nil {
 		s = d.Bus.String()
 	}
-	return s + "(" + strconv.Itoa(int(d.Addr)) + ")"
+	s += "(" + strconv.Itoa(int(Addr(d.Addr).Value()))
+	if d.Addr&TenBit != 0 {
+		s += "/10"
+	}
+	return s + ")"
 }
 
 // Tx does a transaction by adding the device's address to each command.
 //
 // It's a wrapper for Bus.Tx().
 func (d *Dev) Tx(w, r []byte) error {
+	if !Addr(d.Addr).Valid() {
+		return errors.New("i2c: invalid address " + Addr(d.Addr).String())
+	}
 	return d.Bus.Tx(d.Addr, w, r)
 }
 
@@ -110,24 +118,64 @@ func (d *Dev) Duplex() conn.Duplex {
 	return conn.Half
 }
 
+// TenBit is set in an address to use 10 bits addressing.
+//
+// Addresses above 0x7F are always 10 bits addresses. TenBit is required to
+// address a device with a 10 bits address between 0x000 and 0x07F, otherwise
+// the address is sent as a 7 bits address. For example 0x50 and TenBit|0x50
+// are two different devices.
+//
+// It can be used both with Addr and with the uint16 addresses of Bus and Dev.
+const TenBit = 0x8000
+
 // Addr is an I²C slave address.
+//
+// It is a 7 bits address, unless it is a 10 bits address as described in
+// TenBit.
 type Addr uint16
 
+// IsTenBit returns true if a is a 10 bits address.
+func (a Addr) IsTenBit() bool {
+	return a&TenBit != 0 || a > 0x7F
+}
+
+// Value returns the address as sent on the bus, without TenBit.
+func (a Addr) Value() uint16 {
+	return uint16(a &^ TenBit)
+}
+
+// Valid returns true if a fits in 10 bits.
+func (a Addr) Valid() bool {
+	return a.Value() <= 0x3FF
+}
+
 // Set sets the Addr to a value represented by the string s. Values maybe in
-// decimal or hexadecimal form. Set implements the flag.Value interface.
+// decimal or hexadecimal form. A 7 bits value can be suffixed with "/10" to
+// use 10 bits addressing. Set implements the flag.Value interface.
 func (a *Addr) Set(s string) error {
+	var flag Addr
+	if strings.HasSuffix(s, "/10") {
+		s = s[:len(s)-3]
+		flag = TenBit
+	}
 	// Allow for only maximum of 10 bits for i2c addresses.
 	u, err := strconv.ParseUint(s, 0, 10)
 	if err != nil {
 		return errI2CSetError
 	}
-	*a = Addr(u)
+	*a = Addr(u) | flag
 	return nil
 }
 
 // String returns an i2c.Addr as a string formated in hexadecimal.
+//
+// 10 bits addresses using TenBit are suffixed with "/10".
 func (a Addr) String() string {
-	return "0x" + strconv.FormatInt(int64(a), 16)
+	s := "0x" + strconv.FormatInt(int64(a.Value()), 16)
+	if a&TenBit != 0 {
+		s += "/10"
+	}
+	return s
 }
 
 var errI2CSetError = errors.New("invalid i2c address")
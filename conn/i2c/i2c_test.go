@@ -114,7 +114,9 @@ func TestAddr_Set(t *testing.T) {
 		{"0x18", 0x18, nil},
 		{"24", 24, nil},
 		{"0x3ff", 0x3ff, nil},
+		{"0x50/10", TenBit | 0x50, nil},
 		{"0x400", 0, errI2CSetError},
+		{"0x400/10", 0, errI2CSetError},
 		{"-1", 0, errI2CSetError},
 	}
 
@@ -137,6 +139,7 @@ func TestAddr_String(t *testing.T) {
 		{0x01, "0x1"},
 		{0x24, "0x24"},
 		{0x3ff, "0x3ff"},
+		{TenBit | 0x50, "0x50/10"},
 	}
 
 	for _, tt := range tests {
@@ -145,3 +148,49 @@ func TestAddr_String(t *testing.T) {
 		}
 	}
 }
+
+func TestAddr_TenBit(t *testing.T) {
+	tests := []struct {
+		Addr   Addr
+		tenBit bool
+		value  uint16
+		valid  bool
+	}{
+		{0x50, false, 0x50, true},
+		{0x7f, false, 0x7f, true},
+		{0x80, true, 0x80, true},
+		{0x3ff, true, 0x3ff, true},
+		{TenBit | 0x50, true, 0x50, true},
+		{0x400, true, 0x400, false},
+		{TenBit | 0x400, true, 0x400, false},
+	}
+	for _, tt := range tests {
+		if v := tt.Addr.IsTenBit(); v != tt.tenBit {
+			t.Errorf("%s.IsTenBit() = %t", tt.Addr, v)
+		}
+		if v := tt.Addr.Value(); v != tt.value {
+			t.Errorf("%s.Value() = %#x", tt.Addr, v)
+		}
+		if v := tt.Addr.Valid(); v != tt.valid {
+			t.Errorf("%s.Valid() = %t", tt.Addr, v)
+		}
+	}
+}
+
+func TestDev_TenBit(t *testing.T) {
+	b := fakeBus{}
+	d := Dev{Bus: &b, Addr: TenBit | 0x50}
+	if s := d.String(); s != "fake(80/10)" {
+		t.Fatal(s)
+	}
+	if err := d.Tx([]byte{1}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if b.addr != TenBit|0x50 {
+		t.Fatal(b.addr)
+	}
+	d.Addr = 0x400
+	if d.Tx([]byte{1}, nil) == nil {
+		t.Fatal("invalid address")
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright 2019 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package i2ctest
+
+import (
+	"io"
+
+	"periph.io/x/periph/conn/conntest"
+	"periph.io/x/periph/conn/i2c"
+)
+
+// SaveOps writes ops as a capture file.
+//
+// See conntest.CaptureHeader for the file format. Each line is one IO, for
+// example:
+//
+//	{"addr":118,"w":"d0","r":"60"}
+//	{"msgs":[{"addr":80,"buf":"0010"},{"addr":80,"flags":1,"buf":"abcd"}]}
+func SaveOps(w io.Writer, ops []IO) error {
+	c, err := conntest.NewCaptureWriter(w, "i2c")
+	if err != nil {
+		return err
+	}
+	for i := range ops {
+		op := captureIO{Addr: ops[i].Addr, W: ops[i].W, R: ops[i].R}
+		for _, m := range ops[i].Msgs {
+			op.Msgs = append(op.Msgs, captureMsg{Addr: m.Addr, Flags: m.Flags, Buf: m.Buf})
+		}
+		if err := c.Write(&op); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadOps reads a capture file written by SaveOps.
+func LoadOps(r io.Reader) ([]IO, error) {
+	c, err := conntest.NewCaptureReader(r, "i2c")
+	if err != nil {
+		return nil, err
+	}
+	var ops []IO
+	for {
+		var op captureIO
+		if err := c.Read(&op); err != nil {
+			if err == io.EOF {
+				return ops, nil
+			}
+			return nil, err
+		}
+		o := IO{Addr: op.Addr, W: op.W, R: op.R}
+		for _, m := range op.Msgs {
+			o.Msgs = append(o.Msgs, i2c.Msg{Addr: m.Addr, Flags: m.Flags, Buf: m.Buf})
+		}
+		ops = append(ops, o)
+	}
+}
+
+// Save writes the recorded operations as a capture file.
+func (r *Record) Save(w io.Writer) error {
+	r.Lock()
+	defer r.Unlock()
+	return SaveOps(w, r.Ops)
+}
+
+// Load replaces the operations to play back with the ones in a capture file
+// written by Record.Save().
+func (p *Playback) Load(r io.Reader) error {
+	ops, err := LoadOps(r)
+	if err != nil {
+		return err
+	}
+	p.Lock()
+	defer p.Unlock()
+	p.Ops = ops
+	p.Count = 0
+	return nil
+}
+
+//
+
+type captureIO struct {
+	Addr uint16       `json:"addr,omitempty"`
+	W    conntest.Hex `json:"w,omitempty"`
+	R    conntest.Hex `json:"r,omitempty"`
+	Msgs []captureMsg `json:"msgs,omitempty"`
+}
+
+type captureMsg struct {
+	Addr  uint16       `json:"addr"`
+	Flags i2c.MsgFlags `json:"flags,omitempty"`
+	Buf   conntest.Hex `json:"buf,omitempty"`
+}
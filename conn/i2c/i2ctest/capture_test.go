@@ -0,0 +1,81 @@
+// Copyright 2019 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package i2ctest
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"periph.io/x/periph/conn/i2c"
+)
+
+func TestRecord_Save_Playback_Load(t *testing.T) {
+	src := Playback{
+		Ops: []IO{
+			{Addr: 0x76, W: []byte{0xD0}, R: []byte{0x60}},
+			{Msgs: []i2c.Msg{{Addr: 0x50, Buf: []byte{0, 0x10}}, {Addr: 0x50, Flags: i2c.MsgRead, Buf: []byte{0xAB, 0xCD}}}},
+		},
+	}
+	r := Record{Bus: &src}
+	d := i2c.Dev{Bus: &r, Addr: 0x76}
+	b := make([]byte, 1)
+	if err := d.Tx([]byte{0xD0}, b); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.TxMsgs([]i2c.Msg{{Addr: 0x50, Buf: []byte{0, 0x10}}, {Addr: 0x50, Flags: i2c.MsgRead, Buf: make([]byte, 2)}}); err != nil {
+		t.Fatal(err)
+	}
+	buf := bytes.Buffer{}
+	if err := r.Save(&buf); err != nil {
+		t.Fatal(err)
+	}
+	expected := "{\"format\":\"periph-capture\",\"version\":1,\"bus\":\"i2c\"}\n" +
+		"{\"addr\":118,\"w\":\"d0\",\"r\":\"60\"}\n" +
+		"{\"msgs\":[{\"addr\":80,\"buf\":\"0010\"},{\"addr\":80,\"flags\":1,\"buf\":\"abcd\"}]}\n"
+	if s := buf.String(); s != expected {
+		t.Fatal(s)
+	}
+
+	p := Playback{}
+	if err := p.Load(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(p.Ops, r.Ops) {
+		t.Fatalf("%#v", p.Ops)
+	}
+	d.Bus = &p
+	if err := d.Tx([]byte{0xD0}, b); err != nil || b[0] != 0x60 {
+		t.Fatal(b, err)
+	}
+	msgs := []i2c.Msg{{Addr: 0x50, Buf: []byte{0, 0x10}}, {Addr: 0x50, Flags: i2c.MsgRead, Buf: make([]byte, 2)}}
+	if err := p.TxMsgs(msgs); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(msgs[1].Buf, []byte{0xAB, 0xCD}) {
+		t.Fatal(msgs[1].Buf)
+	}
+	if err := p.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadOps_Err(t *testing.T) {
+	data := []string{
+		"",
+		"{\"format\":\"periph-capture\",\"version\":1,\"bus\":\"spi\"}\n",
+		"{\"format\":\"periph-capture\",\"version\":1,\"bus\":\"i2c\"}\n{\"addr\":\"a\"}\n",
+	}
+	for i, line := range data {
+		if _, err := LoadOps(strings.NewReader(line)); err == nil {
+			t.Fatal(i)
+		}
+	}
+	p := Playback{}
+	if err := p.Load(strings.NewReader("")); err == nil {
+		t.Fatal("empty")
+	}
+}
@@ -16,10 +16,16 @@ import (
 )
 
 // IO registers the I/O that happened on either a real or fake I²C bus.
+//
+// Msgs is set instead of Addr, W and R for a transaction done with TxMsgs().
+//
+// Record sets i2c.TenBit in the addresses of 10 bits devices, so the address
+// width is always explicit.
 type IO struct {
 	Addr uint16
 	W    []byte
 	R    []byte
+	Msgs []i2c.Msg
 }
 
 // Record implements i2c.Bus that records everything written to it.
@@ -39,7 +45,7 @@ func (r *Record) String() string {
 
 // Tx implements i2c.Bus
 func (r *Record) Tx(addr uint16, w, read []byte) error {
-	io := IO{Addr: addr}
+	io := IO{Addr: addrWidth(addr, 0)}
 	if len(w) != 0 {
 		io.W = make([]byte, len(w))
 		copy(io.W, w)
@@ -63,6 +69,34 @@ func (r *Record) Tx(addr uint16, w, read []byte) error {
 	return nil
 }
 
+// TxMsgs implements i2c.MsgBus.
+//
+// The transaction is forwarded to Bus with i2c.TxMsgs().
+func (r *Record) TxMsgs(msgs []i2c.Msg) error {
+	io := IO{Msgs: make([]i2c.Msg, len(msgs))}
+	r.Lock()
+	defer r.Unlock()
+	if r.Bus == nil {
+		for i := range msgs {
+			if msgs[i].Flags&i2c.MsgRead != 0 && len(msgs[i].Buf) != 0 {
+				return conntest.Errorf("i2ctest: read unsupported when no bus is connected")
+			}
+		}
+	} else {
+		if err := i2c.TxMsgs(r.Bus, msgs); err != nil {
+			return err
+		}
+	}
+	for i := range msgs {
+		io.Msgs[i] = msgs[i]
+		io.Msgs[i].Addr = addrWidth(msgs[i].Addr, msgs[i].Flags)
+		io.Msgs[i].Flags &^= i2c.MsgTenBit
+		io.Msgs[i].Buf = append([]byte(nil), msgs[i].Buf...)
+	}
+	r.Ops = append(r.Ops, io)
+	return nil
+}
+
 // SetSpeed implements i2c.Bus.
 func (r *Record) SetSpeed(f physic.Frequency) error {
 	if r.Bus != nil {
@@ -126,7 +160,7 @@ func (p *Playback) Tx(addr uint16, w, r []byte) error {
 	if len(p.Ops) <= p.Count {
 		return errorf(p.DontPanic, "i2ctest: unexpected Tx() (count #%d) expecting i2ctest.IO{Addr:%d, W:%#v, R:%#v}", p.Count, addr, w, r)
 	}
-	if addr != p.Ops[p.Count].Addr {
+	if addrWidth(addr, 0) != addrWidth(p.Ops[p.Count].Addr, 0) {
 		return errorf(p.DontPanic, "i2ctest: unexpected addr (count #%d) %d != %d", p.Count, addr, p.Ops[p.Count].Addr)
 	}
 	if !bytes.Equal(p.Ops[p.Count].W, w) {
@@ -140,6 +174,37 @@ func (p *Playback) Tx(addr uint16, w, r []byte) error {
 	return nil
 }
 
+// TxMsgs implements i2c.MsgBus.
+func (p *Playback) TxMsgs(msgs []i2c.Msg) error {
+	p.Lock()
+	defer p.Unlock()
+	if len(p.Ops) <= p.Count {
+		return errorf(p.DontPanic, "i2ctest: unexpected TxMsgs() (count #%d) expecting i2ctest.IO{Msgs:%#v}", p.Count, msgs)
+	}
+	exp := p.Ops[p.Count].Msgs
+	if len(exp) != len(msgs) {
+		return errorf(p.DontPanic, "i2ctest: unexpected number of messages (count #%d) %d != %d", p.Count, len(msgs), len(exp))
+	}
+	for i := range msgs {
+		if addrWidth(msgs[i].Addr, msgs[i].Flags) != addrWidth(exp[i].Addr, exp[i].Flags) || (msgs[i].Flags^exp[i].Flags)&^i2c.MsgTenBit != 0 {
+			return errorf(p.DontPanic, "i2ctest: unexpected message #%d (count #%d) addr %d flags %s != addr %d flags %s", i, p.Count, msgs[i].Addr, msgs[i].Flags, exp[i].Addr, exp[i].Flags)
+		}
+		if len(msgs[i].Buf) != len(exp[i].Buf) {
+			return errorf(p.DontPanic, "i2ctest: unexpected message #%d buffer length (count #%d) %d != %d", i, p.Count, len(msgs[i].Buf), len(exp[i].Buf))
+		}
+		if msgs[i].Flags&i2c.MsgRead == 0 && !bytes.Equal(msgs[i].Buf, exp[i].Buf) {
+			return errorf(p.DontPanic, "i2ctest: unexpected message #%d write (count #%d) %#v != %#v", i, p.Count, msgs[i].Buf, exp[i].Buf)
+		}
+	}
+	for i := range msgs {
+		if msgs[i].Flags&i2c.MsgRead != 0 {
+			copy(msgs[i].Buf, exp[i].Buf)
+		}
+	}
+	p.Count++
+	return nil
+}
+
 // SetSpeed implements i2c.Bus.
 func (p *Playback) SetSpeed(f physic.Frequency) error {
 	return nil
@@ -157,6 +222,14 @@ func (p *Playback) SDA() gpio.PinIO {
 
 //
 
+// addrWidth returns addr with i2c.TenBit set if it is a 10 bits address.
+func addrWidth(addr uint16, f i2c.MsgFlags) uint16 {
+	if f&i2c.MsgTenBit != 0 || i2c.Addr(addr).IsTenBit() {
+		return addr | i2c.TenBit
+	}
+	return addr
+}
+
 // errorf is the internal implementation that optionally panic.
 //
 // If dontPanic is false, it panics instead.
@@ -170,5 +243,7 @@ func errorf(dontPanic bool, format string, a ...interface{}) error {
 
 var _ i2c.Bus = &Record{}
 var _ i2c.Pins = &Record{}
+var _ i2c.MsgBus = &Record{}
 var _ i2c.Bus = &Playback{}
+var _ i2c.MsgBus = &Playback{}
 var _ i2c.Pins = &Playback{}
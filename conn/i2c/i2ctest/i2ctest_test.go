@@ -5,11 +5,13 @@
 package i2ctest
 
 import (
+	"reflect"
 	"testing"
 
 	"periph.io/x/periph/conn/conntest"
 	"periph.io/x/periph/conn/gpio"
 	"periph.io/x/periph/conn/gpio/gpiotest"
+	"periph.io/x/periph/conn/i2c"
 )
 
 func TestRecord_empty(t *testing.T) {
@@ -165,3 +167,99 @@ func TestRecord_Playback(t *testing.T) {
 		t.Fatal("Playback.Ops is empty")
 	}
 }
+
+func TestRecord_Playback_TxMsgs(t *testing.T) {
+	p := &Playback{
+		Ops: []IO{
+			{
+				Msgs: []i2c.Msg{
+					{Addr: 23, Buf: []byte{10}},
+					{Addr: 23, Flags: i2c.MsgNoStart, Buf: []byte{11}},
+					{Addr: 23, Flags: i2c.MsgRead, Buf: []byte{12}},
+				},
+			},
+		},
+		DontPanic: true,
+	}
+	r := Record{Bus: p}
+	v := [1]byte{}
+	msgs := []i2c.Msg{
+		{Addr: 23, Buf: []byte{10}},
+		{Addr: 23, Flags: i2c.MsgNoStart, Buf: []byte{11}},
+		{Addr: 23, Flags: i2c.MsgRead, Buf: v[:]},
+	}
+	if err := r.TxMsgs(msgs); err != nil {
+		t.Fatal(err)
+	}
+	if v[0] != 12 {
+		t.Fatalf("expected 12, got %v", v)
+	}
+	if !reflect.DeepEqual(r.Ops, p.Ops) {
+		t.Fatal(r.Ops)
+	}
+	if err := p.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if r.TxMsgs(msgs) == nil {
+		t.Fatal("Playback.Ops is empty")
+	}
+}
+
+func TestPlayback_TxMsgs_Err(t *testing.T) {
+	p := &Playback{
+		Ops:       []IO{{Msgs: []i2c.Msg{{Addr: 23, Buf: []byte{10}}}}},
+		DontPanic: true,
+	}
+	if p.TxMsgs(nil) == nil {
+		t.Fatal("unexpected number of messages")
+	}
+	if p.TxMsgs([]i2c.Msg{{Addr: 24, Buf: []byte{10}}}) == nil {
+		t.Fatal("unexpected address")
+	}
+	if p.TxMsgs([]i2c.Msg{{Addr: 23, Buf: []byte{10, 11}}}) == nil {
+		t.Fatal("unexpected length")
+	}
+	if p.TxMsgs([]i2c.Msg{{Addr: 23, Buf: []byte{11}}}) == nil {
+		t.Fatal("unexpected write")
+	}
+	r := Record{}
+	if r.TxMsgs([]i2c.Msg{{Addr: 23, Flags: i2c.MsgRead, Buf: []byte{0}}}) == nil {
+		t.Fatal("can't read without a bus")
+	}
+	if err := r.TxMsgs([]i2c.Msg{{Addr: 23, Buf: []byte{1}}}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRecord_Playback_TenBit(t *testing.T) {
+	p := &Playback{
+		Ops: []IO{
+			{Addr: i2c.TenBit | 0x150, W: []byte{1}},
+			{Addr: i2c.TenBit | 0x50, W: []byte{2}},
+			{Msgs: []i2c.Msg{{Addr: i2c.TenBit | 0x50, Buf: []byte{3}}}},
+		},
+		DontPanic: true,
+	}
+	r := Record{Bus: p}
+	if err := r.Tx(0x150, []byte{1}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Tx(i2c.TenBit|0x50, []byte{2}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.TxMsgs([]i2c.Msg{{Addr: 0x50, Flags: i2c.MsgTenBit, Buf: []byte{3}}}); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(r.Ops, p.Ops) {
+		t.Fatal(r.Ops)
+	}
+	p.Count = 1
+	// 0x50 is a 7 bits address.
+	if p.Tx(0x50, []byte{2}, nil) == nil {
+		t.Fatal("address width mismatch")
+	}
+	p.Count = 2
+	if p.TxMsgs([]i2c.Msg{{Addr: 0x50, Buf: []byte{3}}}) == nil {
+		t.Fatal("address width mismatch")
+	}
+}
@@ -0,0 +1,167 @@
+// Copyright 2019 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package i2ctest
+
+import (
+	"sync"
+
+	"periph.io/x/periph/conn/conntest"
+	"periph.io/x/periph/conn/i2c"
+)
+
+// Reg is a register of a RegMap.
+type Reg struct {
+	// Width is the size of the register in bytes, transferred in big endian
+	// order. 0 means 1.
+	Width int
+	// Value is the content of the register.
+	Value uint64
+	// ReadOnly are the bits that writes don't modify.
+	ReadOnly uint64
+	// WriteOnly are the bits that always read as 0.
+	WriteOnly uint64
+	// ClearOnRead are the bits cleared once the register is read, like
+	// interrupt flags.
+	ClearOnRead uint64
+	// OnRead, if set, is called before the register is read. It can update
+	// Value, for example to emulate a new measurement.
+	OnRead func(r *Reg)
+	// OnWrite, if set, is called after the master wrote v to the register,
+	// once Value is updated. It can emulate a command.
+	OnWrite func(r *Reg, v uint64)
+}
+
+// RegMap is an i2c.TargetHandler that simulates a device as a map of
+// registers. Use it with Sim to test a driver against the device logic
+// instead of an exact list of transactions like Playback does.
+//
+// The first byte written selects the register and the following bytes are
+// written to it. A read returns the selected register. When AutoIncrement is
+// set, the selection moves to the next register after each register read or
+// written, so consecutive registers can be accessed in a single transfer.
+//
+// The callbacks are called with the RegMap locked; they must not call its
+// methods. Accesses to a register not in Regs fail and the first failure is
+// returned by Err().
+type RegMap struct {
+	Regs          map[byte]*Reg
+	AutoIncrement bool
+
+	mu  sync.Mutex
+	ptr byte
+	err error
+}
+
+// Get returns the value of register reg.
+func (m *RegMap) Get(reg byte) uint64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if r := m.Regs[reg]; r != nil {
+		return r.Value
+	}
+	return 0
+}
+
+// Set sets the value of register reg, regardless of ReadOnly, for example
+// to update a measurement.
+func (m *RegMap) Set(reg byte, v uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if r := m.Regs[reg]; r != nil {
+		r.Value = v
+	}
+}
+
+// Err returns the first invalid access done by the master, if any.
+func (m *RegMap) Err() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.err
+}
+
+// OnWrite implements i2c.TargetHandler.
+func (m *RegMap) OnWrite(w []byte) {
+	if len(w) == 0 {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.ptr = w[0]
+	for w = w[1:]; len(w) != 0; {
+		r := m.Regs[m.ptr]
+		if r == nil {
+			m.fail("i2ctest: write to unknown register 0x%02x", m.ptr)
+			return
+		}
+		n := r.width()
+		if len(w) < n {
+			m.fail("i2ctest: partial write of register 0x%02x: %d bytes instead of %d", m.ptr, len(w), n)
+			return
+		}
+		var v uint64
+		for _, b := range w[:n] {
+			v = v<<8 | uint64(b)
+		}
+		w = w[n:]
+		r.Value = r.Value&r.ReadOnly | v&^r.ReadOnly
+		if r.OnWrite != nil {
+			r.OnWrite(r, v)
+		}
+		if m.AutoIncrement {
+			m.ptr++
+		}
+	}
+}
+
+// OnRead implements i2c.TargetHandler.
+func (m *RegMap) OnRead(b []byte) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ptr := m.ptr
+	for len(b) != 0 {
+		r := m.Regs[ptr]
+		if r == nil {
+			m.fail("i2ctest: read of unknown register 0x%02x", ptr)
+			for i := range b {
+				b[i] = 0xFF
+			}
+			return
+		}
+		if r.OnRead != nil {
+			r.OnRead(r)
+		}
+		v := r.Value &^ r.WriteOnly
+		r.Value &^= r.ClearOnRead
+		n := r.width()
+		for i := 0; i < n && len(b) != 0; i++ {
+			b[0] = byte(v >> uint(8*(n-1-i)))
+			b = b[1:]
+		}
+		if m.AutoIncrement {
+			ptr++
+		}
+	}
+	m.ptr = ptr
+}
+
+//
+
+func (r *Reg) width() int {
+	if r.Width == 0 {
+		return 1
+	}
+	return r.Width
+}
+
+// fail records the first error.
+//
+// m.mu must be held.
+func (m *RegMap) fail(format string, a ...interface{}) {
+	if m.err == nil {
+		m.err = conntest.Errorf(format, a...)
+	}
+}
+
+var _ i2c.TargetHandler = &RegMap{}
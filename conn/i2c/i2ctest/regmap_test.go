@@ -0,0 +1,147 @@
+// Copyright 2019 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package i2ctest
+
+import (
+	"bytes"
+	"testing"
+
+	"periph.io/x/periph/conn/i2c"
+)
+
+func TestRegMap(t *testing.T) {
+	conversions := 0
+	m := &RegMap{
+		Regs: map[byte]*Reg{
+			// Temperature, updated on each read.
+			0x05: {Width: 2, OnRead: func(r *Reg) { r.Value = 0x0190 + uint64(conversions) }},
+			// Configuration; bit 15 is read-only, bit 0 is write-only.
+			0x01: {Width: 2, Value: 0x8000, ReadOnly: 0x8000, WriteOnly: 0x0001},
+			// Status; bit 0 is cleared on read.
+			0x02: {Value: 0x03, ClearOnRead: 0x01},
+			// Command register.
+			0x03: {OnWrite: func(r *Reg, v uint64) { conversions += int(v) }},
+		},
+	}
+	s := Sim{}
+	if _, err := s.Listen(0x18, m); err != nil {
+		t.Fatal(err)
+	}
+	d := i2c.Dev{Bus: &s, Addr: 0x18}
+	b := make([]byte, 2)
+	if err := d.Tx([]byte{0x05}, b); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(b, []byte{0x01, 0x90}) {
+		t.Fatal(b)
+	}
+	if _, err := d.Write([]byte{0x03, 2}); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Tx([]byte{0x05}, b); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(b, []byte{0x01, 0x92}) {
+		t.Fatal(b)
+	}
+
+	// Read-only and write-only bits.
+	if _, err := d.Write([]byte{0x01, 0x01, 0x01}); err != nil {
+		t.Fatal(err)
+	}
+	if v := m.Get(0x01); v != 0x8101 {
+		t.Fatalf("0x%x", v)
+	}
+	if err := d.Tx([]byte{0x01}, b); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(b, []byte{0x81, 0x00}) {
+		t.Fatal(b)
+	}
+
+	// Clear-on-read bits.
+	if err := d.Tx([]byte{0x02}, b[:1]); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Tx(nil, b[1:]); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(b, []byte{0x03, 0x02}) {
+		t.Fatal(b)
+	}
+	m.Set(0x02, 0x01)
+	if v := m.Get(0x02); v != 0x01 {
+		t.Fatal(v)
+	}
+	if err := m.Err(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRegMap_AutoIncrement(t *testing.T) {
+	m := &RegMap{
+		Regs: map[byte]*Reg{
+			0xF7: {Value: 0x11},
+			0xF8: {Value: 0x22},
+			0xF9: {Value: 0x33},
+		},
+		AutoIncrement: true,
+	}
+	s := Sim{}
+	if _, err := s.Listen(0x76, m); err != nil {
+		t.Fatal(err)
+	}
+	d := i2c.Dev{Bus: &s, Addr: 0x76}
+	b := make([]byte, 3)
+	if err := d.Tx([]byte{0xF7}, b); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(b, []byte{0x11, 0x22, 0x33}) {
+		t.Fatal(b)
+	}
+	if _, err := d.Write([]byte{0xF8, 0xAA, 0xBB}); err != nil {
+		t.Fatal(err)
+	}
+	if m.Get(0xF8) != 0xAA || m.Get(0xF9) != 0xBB {
+		t.Fatal(m.Get(0xF8), m.Get(0xF9))
+	}
+	// The selection continues after the last register read.
+	if err := d.Tx([]byte{0xF7}, b[:1]); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Tx(nil, b[:1]); err != nil {
+		t.Fatal(err)
+	}
+	if b[0] != 0xAA {
+		t.Fatal(b)
+	}
+	if err := m.Err(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRegMap_Err(t *testing.T) {
+	m := &RegMap{Regs: map[byte]*Reg{0x01: {Width: 2}}}
+	m.OnWrite(nil)
+	m.OnWrite([]byte{0x01, 0x12})
+	if m.Err() == nil {
+		t.Fatal("partial write")
+	}
+	m = &RegMap{Regs: map[byte]*Reg{}}
+	m.OnWrite([]byte{0x01, 0x12})
+	if m.Err() == nil {
+		t.Fatal("unknown register")
+	}
+	m = &RegMap{Regs: map[byte]*Reg{}}
+	b := []byte{0, 0}
+	m.OnRead(b)
+	if m.Err() == nil || !bytes.Equal(b, []byte{0xFF, 0xFF}) {
+		t.Fatal(b)
+	}
+	if m.Get(0x10) != 0 {
+		t.Fatal("unknown register")
+	}
+	m.Set(0x10, 1)
+}
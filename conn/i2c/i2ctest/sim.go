@@ -0,0 +1,138 @@
+// Copyright 2019 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package i2ctest
+
+import (
+	"sync"
+
+	"periph.io/x/periph/conn/conntest"
+	"periph.io/x/periph/conn/i2c"
+	"periph.io/x/periph/conn/physic"
+)
+
+// Sim implements i2c.Bus and i2c.TargetBus by connecting the transactions
+// done on it to the targets listening on it, all in process.
+//
+// It permits testing a driver against an emulated device, or testing a device
+// emulation written for a real i2c.TargetBus.
+//
+// A transaction addressed to an address without a target fails like a NACK,
+// unless the message has i2c.MsgIgnoreNAK.
+type Sim struct {
+	mu      sync.Mutex
+	targets map[uint16]i2c.TargetHandler
+}
+
+func (s *Sim) String() string {
+	return "sim"
+}
+
+// Listen implements i2c.TargetBus.
+func (s *Sim) Listen(addr uint16, h i2c.TargetHandler) (i2c.Target, error) {
+	if !i2c.Addr(addr).Valid() {
+		return nil, conntest.Errorf("i2ctest: invalid address %s", i2c.Addr(addr))
+	}
+	if h == nil {
+		return nil, conntest.Errorf("i2ctest: handler must not be nil")
+	}
+	a := addrWidth(addr, 0)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.targets == nil {
+		s.targets = map[uint16]i2c.TargetHandler{}
+	}
+	if _, ok := s.targets[a]; ok {
+		return nil, conntest.Errorf("i2ctest: address %s is already used", i2c.Addr(addr))
+	}
+	s.targets[a] = h
+	return &simTarget{s: s, addr: a}, nil
+}
+
+// Tx implements i2c.Bus.
+func (s *Sim) Tx(addr uint16, w, r []byte) error {
+	msgs := make([]i2c.Msg, 0, 2)
+	if len(w) != 0 || len(r) == 0 {
+		msgs = append(msgs, i2c.Msg{Addr: addr, Buf: w})
+	}
+	if len(r) != 0 {
+		msgs = append(msgs, i2c.Msg{Addr: addr, Flags: i2c.MsgRead, Buf: r})
+	}
+	return s.TxMsgs(msgs)
+}
+
+// TxMsgs implements i2c.MsgBus.
+//
+// Consecutive writes joined with i2c.MsgNoStart are delivered to the target
+// as a single message.
+func (s *Sim) TxMsgs(msgs []i2c.Msg) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var h i2c.TargetHandler
+	var pending []byte
+	flush := func() {
+		if h != nil && pending != nil {
+			h.OnWrite(pending)
+		}
+		pending = nil
+	}
+	for i := range msgs {
+		m := &msgs[i]
+		read := m.Flags&i2c.MsgRead != 0
+		if m.Flags&i2c.MsgNoStart != 0 && i != 0 && !read && pending != nil {
+			pending = append(pending, m.Buf...)
+			continue
+		}
+		flush()
+		if !i2c.Addr(m.Addr).Valid() {
+			return conntest.Errorf("i2ctest: invalid address %s", i2c.Addr(m.Addr))
+		}
+		a := addrWidth(m.Addr, m.Flags)
+		h = s.targets[a]
+		if h == nil {
+			if m.Flags&i2c.MsgIgnoreNAK != 0 {
+				continue
+			}
+			return conntest.Errorf("i2ctest: no device at address %s", i2c.Addr(a))
+		}
+		if read {
+			h.OnRead(m.Buf)
+		} else {
+			pending = append([]byte{}, m.Buf...)
+		}
+	}
+	flush()
+	return nil
+}
+
+// SetSpeed implements i2c.Bus.
+func (s *Sim) SetSpeed(f physic.Frequency) error {
+	return nil
+}
+
+//
+
+// simTarget is a target listening on a Sim.
+type simTarget struct {
+	s    *Sim
+	addr uint16
+}
+
+func (t *simTarget) String() string {
+	return "sim(" + i2c.Addr(t.addr).String() + ")"
+}
+
+func (t *simTarget) Close() error {
+	t.s.mu.Lock()
+	defer t.s.mu.Unlock()
+	if _, ok := t.s.targets[t.addr]; !ok {
+		return conntest.Errorf("i2ctest: %s is already closed", t)
+	}
+	delete(t.s.targets, t.addr)
+	return nil
+}
+
+var _ i2c.Bus = &Sim{}
+var _ i2c.MsgBus = &Sim{}
+var _ i2c.TargetBus = &Sim{}
@@ -0,0 +1,133 @@
+// Copyright 2019 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package i2ctest
+
+import (
+	"bytes"
+	"encoding/hex"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"periph.io/x/periph/conn/i2c"
+)
+
+func TestSim(t *testing.T) {
+	s := Sim{}
+	if str := s.String(); str != "sim" {
+		t.Fatal(str)
+	}
+	if err := s.SetSpeed(100); err != nil {
+		t.Fatal(err)
+	}
+	regs := i2c.NewRegisters(16)
+	regs.Set(4, []byte{0xAB, 0xCD})
+	tgt, err := s.Listen(0x50, regs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if str := tgt.String(); str != "sim(0x50)" {
+		t.Fatal(str)
+	}
+	if _, err := s.Listen(0x50, regs); err == nil {
+		t.Fatal("address already used")
+	}
+
+	// Use it through a regular device.
+	d := i2c.Dev{Bus: &s, Addr: 0x50}
+	r := make([]byte, 2)
+	if err := d.Tx([]byte{4}, r); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(r, []byte{0xAB, 0xCD}) {
+		t.Fatal(r)
+	}
+	if _, err := d.Write([]byte{8, 1, 2}); err != nil {
+		t.Fatal(err)
+	}
+	regs.Get(8, r)
+	if !bytes.Equal(r, []byte{1, 2}) {
+		t.Fatal(r)
+	}
+	// Address only.
+	if err := s.Tx(0x50, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Tx(0x51, nil, nil); err == nil {
+		t.Fatal("expected NACK")
+	}
+	if err := s.Tx(0x400, nil, nil); err == nil {
+		t.Fatal("invalid address")
+	}
+
+	if err := tgt.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := tgt.Close(); err == nil {
+		t.Fatal("already closed")
+	}
+	if err := d.Tx([]byte{4}, r); err == nil {
+		t.Fatal("expected NACK")
+	}
+}
+
+func TestSim_TxMsgs(t *testing.T) {
+	s := Sim{}
+	h := &logHandler{}
+	if _, err := s.Listen(0x150, h); err != nil {
+		t.Fatal(err)
+	}
+	msgs := []i2c.Msg{
+		{Addr: 0x51, Flags: i2c.MsgIgnoreNAK},
+		{Addr: 0x150, Buf: []byte{1}},
+		{Addr: 0x150, Flags: i2c.MsgNoStart, Buf: []byte{2, 3}},
+		{Addr: 0x150, Flags: i2c.MsgRead, Buf: make([]byte, 2)},
+	}
+	if err := s.TxMsgs(msgs); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"W 010203", "R 2"}
+	if !reflect.DeepEqual(h.log, expected) {
+		t.Fatal(h.log)
+	}
+	// The 7 bits address 0x50 is not the 10 bits one.
+	if err := s.TxMsgs([]i2c.Msg{{Addr: 0x50, Buf: []byte{1}}}); err == nil {
+		t.Fatal("expected NACK")
+	}
+	h.log = nil
+	if err := s.TxMsgs([]i2c.Msg{{Addr: 0x50, Flags: i2c.MsgTenBit}}); err == nil {
+		t.Fatal("expected NACK")
+	}
+	if err := s.Tx(i2c.TenBit|0x150, []byte{4}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(h.log, []string{"W 04"}) {
+		t.Fatal(h.log)
+	}
+}
+
+func TestSim_Listen_Err(t *testing.T) {
+	s := Sim{}
+	if _, err := s.Listen(0x400, &logHandler{}); err == nil {
+		t.Fatal("invalid address")
+	}
+	if _, err := s.Listen(0x50, nil); err == nil {
+		t.Fatal("nil handler")
+	}
+}
+
+//
+
+type logHandler struct {
+	log []string
+}
+
+func (l *logHandler) OnWrite(w []byte) {
+	l.log = append(l.log, "W "+hex.EncodeToString(w))
+}
+
+func (l *logHandler) OnRead(r []byte) {
+	l.log = append(l.log, "R "+strconv.Itoa(len(r)))
+}
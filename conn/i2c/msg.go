@@ -0,0 +1,117 @@
+// Copyright 2019 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package i2c
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// MsgFlags modifies how a Msg is transferred on the bus.
+type MsgFlags uint16
+
+// Valid MsgFlags.
+//
+// Except MsgRead, the flags are not supported by all buses.
+const (
+	// MsgRead reads into Buf instead of writing it.
+	MsgRead MsgFlags = 1 << iota
+	// MsgTenBit uses a 10 bits address. It is equivalent to setting TenBit in
+	// Addr.
+	MsgTenBit
+	// MsgNoStart skips the repeated START and the address, so the message
+	// continues the previous one. It permits sending a write-write sequence
+	// from multiple buffers.
+	MsgNoStart
+	// MsgRevDirAddr inverts the R/W bit sent with the address.
+	MsgRevDirAddr
+	// MsgIgnoreNAK continues the transfer even if the device doesn't
+	// acknowledge a byte.
+	MsgIgnoreNAK
+	// MsgNoReadACK doesn't acknowledge the bytes read.
+	MsgNoReadACK
+	// MsgStop sends a STOP after the message, instead of a repeated START.
+	MsgStop
+)
+
+const msgFlagsName = "ReadTenBitNoStartRevDirAddrIgnoreNAKNoReadACKStop"
+
+var msgFlagsIndex = [...]uint8{0, 4, 10, 17, 27, 36, 45, 49}
+
+func (f MsgFlags) String() string {
+	if f == 0 {
+		return "0"
+	}
+	var out []string
+	for i := uint(0); i < uint(len(msgFlagsIndex)-1); i++ {
+		if f&(1<<i) != 0 {
+			out = append(out, "Msg"+msgFlagsName[msgFlagsIndex[i]:msgFlagsIndex[i+1]])
+			f &^= 1 << i
+		}
+	}
+	if f != 0 {
+		out = append(out, "0x"+strconv.FormatUint(uint64(f), 16))
+	}
+	return strings.Join(out, "|")
+}
+
+// Msg is one segment of a multi-message transaction.
+type Msg struct {
+	Addr  uint16
+	Flags MsgFlags
+	// Buf is the data written, or the buffer to read into when Flags has
+	// MsgRead.
+	Buf []byte
+}
+
+// MsgBus is an optional interface implemented by a Bus that supports
+// transactions made of an arbitrary list of messages.
+//
+// Use TxMsgs() to use it with any Bus.
+type MsgBus interface {
+	Bus
+	// TxMsgs executes msgs as a single transaction.
+	//
+	// The transaction starts with a START, the messages are separated with a
+	// repeated START unless modified by their flags, and it ends with a STOP.
+	//
+	// It returns an error if the bus doesn't support one of the flags used.
+	TxMsgs(msgs []Msg) error
+}
+
+// TxMsgs executes msgs on b as a single transaction.
+//
+// It calls b.TxMsgs() when b implements MsgBus. Otherwise only what b.Tx() can
+// do is supported: a single message, or a write followed by a read at the same
+// address, without other flags than MsgRead and MsgTenBit.
+func TxMsgs(b Bus, msgs []Msg) error {
+	if m, ok := b.(MsgBus); ok {
+		return m.TxMsgs(msgs)
+	}
+	var addrs [2]uint16
+	for i := range msgs {
+		if msgs[i].Flags&^(MsgRead|MsgTenBit) != 0 {
+			return errors.New("i2c: " + b.String() + " doesn't support " + msgs[i].Flags.String())
+		}
+		if i < len(addrs) {
+			addrs[i] = msgs[i].Addr
+			if msgs[i].Flags&MsgTenBit != 0 {
+				addrs[i] |= TenBit
+			}
+		}
+	}
+	switch {
+	case len(msgs) == 0:
+		return nil
+	case len(msgs) == 1 && msgs[0].Flags&MsgRead != 0:
+		return b.Tx(addrs[0], nil, msgs[0].Buf)
+	case len(msgs) == 1:
+		return b.Tx(addrs[0], msgs[0].Buf, nil)
+	case len(msgs) == 2 && msgs[0].Flags&MsgRead == 0 && msgs[1].Flags&MsgRead != 0 && addrs[0] == addrs[1]:
+		return b.Tx(addrs[0], msgs[0].Buf, msgs[1].Buf)
+	}
+	return errors.New("i2c: " + b.String() + " doesn't support multi-message transactions")
+}
@@ -0,0 +1,87 @@
+// Copyright 2019 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package i2c
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestMsgFlags_String(t *testing.T) {
+	data := []struct {
+		f        MsgFlags
+		expected string
+	}{
+		{0, "0"},
+		{MsgRead, "MsgRead"},
+		{MsgNoStart | MsgStop, "MsgNoStart|MsgStop"},
+		{MsgTenBit | 0x1000, "MsgTenBit|0x1000"},
+	}
+	for i, line := range data {
+		if s := line.f.String(); s != line.expected {
+			t.Fatalf("#%d: %q != %q", i, s, line.expected)
+		}
+	}
+}
+
+func TestTxMsgs_Fallback(t *testing.T) {
+	b := fakeBus{r: []byte{1, 2}}
+	r := make([]byte, 2)
+	msgs := []Msg{{Addr: 12, Buf: []byte{3}}, {Addr: 12, Flags: MsgRead, Buf: r}}
+	if err := TxMsgs(&b, msgs); err != nil {
+		t.Fatal(err)
+	}
+	if b.addr != 12 || !bytes.Equal(b.w, []byte{3}) || !bytes.Equal(r, []byte{1, 2}) {
+		t.Fatal(b, r)
+	}
+	if err := TxMsgs(&b, []Msg{{Addr: 13, Buf: []byte{4}}}); err != nil {
+		t.Fatal(err)
+	}
+	if b.addr != 13 || !bytes.Equal(b.w, []byte{3, 4}) {
+		t.Fatal(b)
+	}
+	b.r = []byte{5}
+	if err := TxMsgs(&b, []Msg{{Addr: 14, Flags: MsgRead, Buf: r[:1]}}); err != nil || r[0] != 5 {
+		t.Fatal(r, err)
+	}
+	if err := TxMsgs(&b, []Msg{{Addr: 0x50, Flags: MsgTenBit, Buf: []byte{6}}}); err != nil || b.addr != TenBit|0x50 {
+		t.Fatal(b.addr, err)
+	}
+	if err := TxMsgs(&b, nil); err != nil {
+		t.Fatal(err)
+	}
+	if TxMsgs(&b, []Msg{{Addr: 12, Flags: MsgNoStart}}) == nil {
+		t.Fatal("flags are not supported")
+	}
+	if TxMsgs(&b, []Msg{{Addr: 12}, {Addr: 12}}) == nil {
+		t.Fatal("write-write is not supported")
+	}
+	if TxMsgs(&b, []Msg{{Addr: 12}, {Addr: 13, Flags: MsgRead}}) == nil {
+		t.Fatal("different addresses are not supported")
+	}
+}
+
+func TestTxMsgs_MsgBus(t *testing.T) {
+	b := fakeMsgBus{}
+	msgs := []Msg{{Addr: 12, Flags: MsgNoStart}}
+	if err := TxMsgs(&b, msgs); err != nil {
+		t.Fatal(err)
+	}
+	if len(b.msgs) != 1 {
+		t.Fatal(b.msgs)
+	}
+}
+
+//
+
+type fakeMsgBus struct {
+	fakeBus
+	msgs []Msg
+}
+
+func (f *fakeMsgBus) TxMsgs(msgs []Msg) error {
+	f.msgs = msgs
+	return f.err
+}
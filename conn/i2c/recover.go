@@ -0,0 +1,88 @@
+// Copyright 2019 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package i2c
+
+import (
+	"errors"
+	"time"
+
+	"periph.io/x/periph/conn/gpio"
+	"periph.io/x/periph/conn/physic"
+)
+
+// Recoverer is implemented by a bus that can recover from a device holding
+// SDA low.
+//
+// This happens when the master is reset or loses power in the middle of a
+// read: the device still waits for clocks to send the rest of its byte and
+// every transaction fails until it is released.
+type Recoverer interface {
+	// Recover clocks SCL until the device releases SDA, up to 9 times, then
+	// sends a STOP.
+	//
+	// It returns an error if SDA or SCL is still held low.
+	Recover() error
+}
+
+// RecoverPins recovers a bus by bit-banging scl and sda at frequency f.
+//
+// It is the building block for implementations of Recoverer. The pins are
+// left released, that is as inputs with pull-up; the caller is responsible to
+// restore their function if they were used by an I²C controller.
+func RecoverPins(scl, sda gpio.PinIO, f physic.Frequency) error {
+	halfCycle := f.Period() / 2
+	if err := sda.In(gpio.PullUp, gpio.NoEdge); err != nil {
+		return err
+	}
+	if err := releaseSCL(scl, halfCycle); err != nil {
+		return err
+	}
+	// Page 20, section 3.1.16 Bus clear
+	for n := 0; n < 9 && sda.Read() == gpio.Low; n++ {
+		if err := scl.Out(gpio.Low); err != nil {
+			return err
+		}
+		time.Sleep(halfCycle)
+		if err := releaseSCL(scl, halfCycle); err != nil {
+			return err
+		}
+		time.Sleep(halfCycle)
+	}
+	if sda.Read() == gpio.Low {
+		return errors.New("i2c: SDA is held low after 9 clocks")
+	}
+	// STOP.
+	if err := scl.Out(gpio.Low); err != nil {
+		return err
+	}
+	if err := sda.Out(gpio.Low); err != nil {
+		return err
+	}
+	time.Sleep(halfCycle)
+	if err := releaseSCL(scl, halfCycle); err != nil {
+		return err
+	}
+	time.Sleep(halfCycle)
+	return sda.In(gpio.PullUp, gpio.NoEdge)
+}
+
+//
+
+// stretchTimeout is the maximum time a device is permitted to hold SCL low
+// during a recovery. It is the SMBus minimum timeout.
+const stretchTimeout = 25 * time.Millisecond
+
+// releaseSCL releases scl and waits for devices stretching the clock.
+func releaseSCL(scl gpio.PinIO, halfCycle time.Duration) error {
+	if err := scl.In(gpio.PullUp, gpio.NoEdge); err != nil {
+		return err
+	}
+	for start := time.Now(); scl.Read() == gpio.Low; time.Sleep(halfCycle) {
+		if time.Since(start) > stretchTimeout {
+			return errors.New("i2c: SCL is held low")
+		}
+	}
+	return nil
+}
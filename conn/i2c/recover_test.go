@@ -0,0 +1,110 @@
+// Copyright 2019 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package i2c
+
+import (
+	"testing"
+
+	"periph.io/x/periph/conn/gpio"
+	"periph.io/x/periph/conn/gpio/gpiotest"
+	"periph.io/x/periph/conn/physic"
+)
+
+func TestRecoverPins(t *testing.T) {
+	// The clocks include the one of the STOP.
+	data := []struct {
+		hold   int
+		clocks int
+	}{
+		{0, 1},
+		{1, 2},
+		{8, 9},
+		{9, 10},
+	}
+	for i, line := range data {
+		d := &stuckDev{scl: gpio.High, sda: gpio.High, hold: line.hold}
+		scl, sda := d.pins()
+		if err := RecoverPins(scl, sda, physic.MegaHertz); err != nil {
+			t.Fatal(i, err)
+		}
+		if d.clocks != line.clocks || d.stops != 1 {
+			t.Fatal(i, d.clocks, d.stops)
+		}
+	}
+}
+
+func TestRecoverPins_Err(t *testing.T) {
+	// SDA is never released.
+	d := &stuckDev{scl: gpio.High, sda: gpio.High, hold: 10}
+	scl, sda := d.pins()
+	if err := RecoverPins(scl, sda, physic.MegaHertz); err == nil {
+		t.Fatal("SDA is held low")
+	}
+	if d.clocks != 9 || d.stops != 0 {
+		t.Fatal(d.clocks, d.stops)
+	}
+	// SCL is held low.
+	d = &stuckDev{scl: gpio.High, sda: gpio.High, sclHeld: true}
+	scl, sda = d.pins()
+	if err := RecoverPins(scl, sda, physic.MegaHertz); err == nil {
+		t.Fatal("SCL is held low")
+	}
+}
+
+//
+
+// stuckDev simulates a device holding SDA low until it gets hold clocks.
+type stuckDev struct {
+	scl     gpio.Level // driven by the master
+	sda     gpio.Level // driven by the master
+	hold    int
+	sclHeld bool
+	clocks  int
+	stops   int
+}
+
+func (d *stuckDev) pins() (gpio.PinIO, gpio.PinIO) {
+	return &stuckPin{Pin: gpiotest.Pin{N: "SCL"}, d: d, clk: true}, &stuckPin{Pin: gpiotest.Pin{N: "SDA"}, d: d}
+}
+
+func (d *stuckDev) set(clk bool, l gpio.Level) {
+	if clk {
+		if !d.scl && l {
+			d.clocks++
+			if d.hold > 0 {
+				d.hold--
+			}
+		}
+		d.scl = l
+		return
+	}
+	if d.scl && !d.sda && l && d.hold == 0 {
+		d.stops++
+	}
+	d.sda = l
+}
+
+type stuckPin struct {
+	gpiotest.Pin
+	d   *stuckDev
+	clk bool
+}
+
+func (p *stuckPin) In(pull gpio.Pull, edge gpio.Edge) error {
+	p.d.set(p.clk, gpio.High)
+	return nil
+}
+
+func (p *stuckPin) Out(l gpio.Level) error {
+	p.d.set(p.clk, l)
+	return nil
+}
+
+func (p *stuckPin) Read() gpio.Level {
+	if p.clk {
+		return p.d.scl && gpio.Level(!p.d.sclHeld)
+	}
+	return p.d.sda && gpio.Level(p.d.hold == 0)
+}
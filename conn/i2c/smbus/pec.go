@@ -0,0 +1,35 @@
+// Copyright 2019 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package smbus
+
+// CalcPEC calculates the SMBus Packet Error Code of buf.
+//
+// It is a CRC-8 with the polynomial x⁸+x²+x+1 (0x07) and an initial value of
+// 0. buf must include the address bytes as sent on the wire, including the
+// R/W bit.
+func CalcPEC(buf []byte) byte {
+	var crc byte
+	for _, b := range buf {
+		crc = pecTable[crc^b]
+	}
+	return crc
+}
+
+// pecTable is the precalculated CRC-8 for each byte value.
+var pecTable = func() [256]byte {
+	var t [256]byte
+	for i := range t {
+		c := byte(i)
+		for j := 0; j < 8; j++ {
+			if c&0x80 != 0 {
+				c = c<<1 ^ 0x07
+			} else {
+				c <<= 1
+			}
+		}
+		t[i] = c
+	}
+	return t
+}()
@@ -0,0 +1,17 @@
+// Copyright 2019 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package smbus
+
+import "testing"
+
+func TestCalcPEC(t *testing.T) {
+	// Standard CRC-8 check value.
+	if c := CalcPEC([]byte("123456789")); c != 0xF4 {
+		t.Fatalf("%#x", c)
+	}
+	if c := CalcPEC(nil); c != 0 {
+		t.Fatalf("%#x", c)
+	}
+}
@@ -0,0 +1,298 @@
+// Copyright 2019 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+// Package smbus implements the SMBus protocol on top of an I²C bus.
+//
+// SMBus is a subset of I²C with well defined transactions, optionally
+// protected with a Packet Error Code (PEC). It is used by battery fuel gauges,
+// PMBus power supplies and many management controllers.
+//
+// When the bus implements Bus, for example the Linux i2c-dev adapter, the
+// transactions are executed natively. Otherwise they are emulated with
+// i2c.Bus.Tx(), including the PEC calculation.
+//
+// See http://smbus.org/specs/ for the specification.
+package smbus
+
+import (
+	"encoding/binary"
+	"errors"
+	"strconv"
+
+	"periph.io/x/periph/conn/i2c"
+)
+
+// MaxBlockSize is the maximum number of bytes in a block transfer.
+const MaxBlockSize = 32
+
+// Op is an SMBus transaction type.
+type Op uint8
+
+// Valid SMBus transactions.
+const (
+	QuickWrite       Op = iota // Address with the R/W bit cleared; no data
+	QuickRead                  // Address with the R/W bit set; no data
+	SendByte                   // Write one byte without a command code
+	ReceiveByte                // Read one byte without a command code
+	WriteByteData              // Write one byte at a command code
+	ReadByteData               // Read one byte at a command code
+	WriteWordData              // Write a 16 bits word at a command code
+	ReadWordData               // Read a 16 bits word at a command code
+	ProcessCall                // Write then read a 16 bits word
+	WriteBlockData             // Write a block prefixed with its length
+	ReadBlockData              // Read a block prefixed with its length
+	BlockProcessCall           // Write then read a block
+)
+
+const opName = "QuickWriteQuickReadSendByteReceiveByteWriteByteDataReadByteDataWriteWordDataReadWordDataProcessCallWriteBlockDataReadBlockDataBlockProcessCall"
+
+var opIndex = [...]uint8{0, 10, 19, 27, 38, 51, 63, 76, 88, 99, 113, 126, 142}
+
+func (o Op) String() string {
+	if o >= Op(len(opIndex)-1) {
+		return "Op(" + strconv.Itoa(int(o)) + ")"
+	}
+	return opName[opIndex[o]:opIndex[o+1]]
+}
+
+// Bus is implemented by an i2c.Bus that can execute SMBus transactions
+// natively.
+//
+// Use Dev to use it with any i2c.Bus.
+type Bus interface {
+	i2c.Bus
+	// SMBusSupported returns true if op can be executed natively, with packet
+	// error checking if pec is true.
+	SMBusSupported(op Op, pec bool) bool
+	// SMBusTx executes op natively.
+	//
+	// cmd is the command code, or the byte to send for SendByte. w is the data
+	// to write and r the buffer to read into; words are little endian and
+	// blocks exclude the length prefix. For block reads, r is MaxBlockSize
+	// long.
+	//
+	// It returns the number of bytes read.
+	SMBusTx(addr uint16, op Op, cmd byte, w, r []byte, pec bool) (int, error)
+}
+
+// Dev is a device on an SMBus.
+//
+// It executes the transactions natively if Bus implements smbus.Bus and
+// supports them, otherwise it emulates them with Tx().
+type Dev struct {
+	Bus  i2c.Bus
+	Addr uint16
+	// PEC enables Packet Error Checking on all transactions.
+	PEC bool
+}
+
+func (d *Dev) String() string {
+	s := "<nil>"
+	if d.Bus != nil {
+		s = d.Bus.String()
+	}
+	return s + "(" + strconv.Itoa(int(d.Addr)) + ")"
+}
+
+// Quick sends the address with the R/W bit set to read or write, without any
+// data.
+//
+// It is commonly used to turn a device on or off. It can't be emulated with
+// Tx() so it requires native support.
+func (d *Dev) Quick(read bool) error {
+	op := QuickWrite
+	if read {
+		op = QuickRead
+	}
+	_, err := d.tx(op, 0, nil, nil)
+	return err
+}
+
+// SendByte writes b without a command code.
+func (d *Dev) SendByte(b byte) error {
+	_, err := d.tx(SendByte, b, nil, nil)
+	return err
+}
+
+// ReceiveByte reads one byte without a command code.
+func (d *Dev) ReceiveByte() (byte, error) {
+	var r [1]byte
+	_, err := d.tx(ReceiveByte, 0, nil, r[:])
+	return r[0], err
+}
+
+// WriteByteData writes v at command code cmd.
+func (d *Dev) WriteByteData(cmd, v byte) error {
+	_, err := d.tx(WriteByteData, cmd, []byte{v}, nil)
+	return err
+}
+
+// ReadByteData reads one byte at command code cmd.
+func (d *Dev) ReadByteData(cmd byte) (byte, error) {
+	var r [1]byte
+	_, err := d.tx(ReadByteData, cmd, nil, r[:])
+	return r[0], err
+}
+
+// WriteWordData writes v at command code cmd.
+func (d *Dev) WriteWordData(cmd byte, v uint16) error {
+	var w [2]byte
+	binary.LittleEndian.PutUint16(w[:], v)
+	_, err := d.tx(WriteWordData, cmd, w[:], nil)
+	return err
+}
+
+// ReadWordData reads a word at command code cmd.
+func (d *Dev) ReadWordData(cmd byte) (uint16, error) {
+	var r [2]byte
+	if _, err := d.tx(ReadWordData, cmd, nil, r[:]); err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint16(r[:]), nil
+}
+
+// ProcessCall writes v at command code cmd and reads back the reply in a
+// single transaction.
+func (d *Dev) ProcessCall(cmd byte, v uint16) (uint16, error) {
+	var w, r [2]byte
+	binary.LittleEndian.PutUint16(w[:], v)
+	if _, err := d.tx(ProcessCall, cmd, w[:], r[:]); err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint16(r[:]), nil
+}
+
+// BlockWrite writes b at command code cmd, prefixed with its length.
+//
+// b must be at most MaxBlockSize long.
+func (d *Dev) BlockWrite(cmd byte, b []byte) error {
+	if len(b) > MaxBlockSize {
+		return errors.New("smbus: block too long")
+	}
+	_, err := d.tx(WriteBlockData, cmd, b, nil)
+	return err
+}
+
+// BlockRead reads a block at command code cmd into b and returns the number
+// of bytes read.
+//
+// The length is decided by the device; it is an error if b is too small.
+func (d *Dev) BlockRead(cmd byte, b []byte) (int, error) {
+	var r [MaxBlockSize]byte
+	n, err := d.tx(ReadBlockData, cmd, nil, r[:])
+	if err != nil {
+		return 0, err
+	}
+	if n > len(b) {
+		return 0, errors.New("smbus: buffer too small for " + strconv.Itoa(n) + " bytes block")
+	}
+	return copy(b, r[:n]), nil
+}
+
+// BlockProcessCall writes w at command code cmd then reads back a block into
+// r in a single transaction. It returns the number of bytes read.
+func (d *Dev) BlockProcessCall(cmd byte, w, r []byte) (int, error) {
+	if len(w) > MaxBlockSize {
+		return 0, errors.New("smbus: block too long")
+	}
+	var buf [MaxBlockSize]byte
+	n, err := d.tx(BlockProcessCall, cmd, w, buf[:])
+	if err != nil {
+		return 0, err
+	}
+	if n > len(r) {
+		return 0, errors.New("smbus: buffer too small for " + strconv.Itoa(n) + " bytes block")
+	}
+	return copy(r, buf[:n]), nil
+}
+
+//
+
+func (d *Dev) tx(op Op, cmd byte, w, r []byte) (int, error) {
+	if b, ok := d.Bus.(Bus); ok && b.SMBusSupported(op, d.PEC) {
+		return b.SMBusTx(d.Addr, op, cmd, w, r, d.PEC)
+	}
+	return emulate(d.Bus, d.Addr, op, cmd, w, r, d.PEC)
+}
+
+// emulate executes op with i2c.Bus.Tx().
+//
+// Block reads are done by reading the maximum block size, since the length
+// prefix can't be decoded in the middle of a Tx().
+func emulate(b i2c.Bus, addr uint16, op Op, cmd byte, w, r []byte, pec bool) (int, error) {
+	var out, in []byte
+	block := false
+	switch op {
+	case QuickWrite, QuickRead:
+		return 0, errors.New("smbus: " + op.String() + " requires native support")
+	case SendByte:
+		out = []byte{cmd}
+	case ReceiveByte:
+		in = make([]byte, len(r))
+	case WriteByteData, WriteWordData:
+		out = append([]byte{cmd}, w...)
+	case ReadByteData, ReadWordData:
+		out = []byte{cmd}
+		in = make([]byte, len(r))
+	case ProcessCall:
+		out = append([]byte{cmd}, w...)
+		in = make([]byte, len(r))
+	case WriteBlockData:
+		out = append([]byte{cmd, byte(len(w))}, w...)
+	case ReadBlockData:
+		out = []byte{cmd}
+		in = make([]byte, 1+MaxBlockSize)
+		block = true
+	case BlockProcessCall:
+		out = append([]byte{cmd, byte(len(w))}, w...)
+		in = make([]byte, 1+MaxBlockSize)
+		block = true
+	default:
+		return 0, errors.New("smbus: invalid " + op.String())
+	}
+	if pec && i2c.Addr(addr).IsTenBit() {
+		return 0, errors.New("smbus: PEC is not supported with 10 bits addresses")
+	}
+	addrW := byte(addr << 1)
+	if pec {
+		if len(in) == 0 {
+			out = append(out, CalcPEC(append([]byte{addrW}, out...)))
+		} else {
+			in = append(in, 0)
+		}
+	}
+	if err := b.Tx(addr, out, in); err != nil {
+		return 0, err
+	}
+	if len(in) == 0 {
+		return 0, nil
+	}
+	data := in
+	if pec {
+		data = in[:len(in)-1]
+	}
+	n := len(data)
+	if block {
+		if in[0] > MaxBlockSize {
+			return 0, errors.New("smbus: invalid block length " + strconv.Itoa(int(in[0])))
+		}
+		data = in[:1+in[0]]
+		n = int(in[0])
+	}
+	if pec {
+		var msg []byte
+		if len(out) != 0 {
+			msg = append([]byte{addrW}, out...)
+		}
+		msg = append(append(msg, addrW|1), data...)
+		if c := CalcPEC(msg); c != in[len(data)] {
+			return 0, errors.New("smbus: PEC mismatch")
+		}
+	}
+	if block {
+		data = data[1:]
+	}
+	copy(r, data)
+	return n, nil
+}
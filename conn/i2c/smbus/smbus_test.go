@@ -0,0 +1,213 @@
+// Copyright 2019 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package smbus
+
+import (
+	"bytes"
+	"testing"
+
+	"periph.io/x/periph/conn/i2c"
+	"periph.io/x/periph/conn/i2c/i2ctest"
+)
+
+func TestOp_String(t *testing.T) {
+	if s := BlockProcessCall.String(); s != "BlockProcessCall" {
+		t.Fatal(s)
+	}
+	if s := Op(100).String(); s != "Op(100)" {
+		t.Fatal(s)
+	}
+}
+
+func TestDev_String(t *testing.T) {
+	d := Dev{Addr: 0x0B}
+	if s := d.String(); s != "<nil>(11)" {
+		t.Fatal(s)
+	}
+	d.Bus = &i2ctest.Playback{}
+	if s := d.String(); s != "playback(11)" {
+		t.Fatal(s)
+	}
+}
+
+func TestDev_Emulated(t *testing.T) {
+	b := i2ctest.Playback{
+		Ops: []i2ctest.IO{
+			{Addr: 0x0B, W: []byte{0x42}},
+			{Addr: 0x0B, R: []byte{0x43}},
+			{Addr: 0x0B, W: []byte{0x10, 0x01}},
+			{Addr: 0x0B, W: []byte{0x11}, R: []byte{0x02}},
+			{Addr: 0x0B, W: []byte{0x12, 0x34, 0x12}},
+			{Addr: 0x0B, W: []byte{0x16}, R: []byte{0x34, 0x12}},
+			{Addr: 0x0B, W: []byte{0x17, 0x01, 0x00}, R: []byte{0x02, 0x00}},
+			{Addr: 0x0B, W: []byte{0x20, 0x03, 1, 2, 3}},
+			{Addr: 0x0B, W: []byte{0x21}, R: append([]byte{3, 1, 2, 3}, make([]byte, 29)...)},
+			{Addr: 0x0B, W: []byte{0x22, 0x01, 9}, R: append([]byte{2, 8, 7}, make([]byte, 30)...)},
+		},
+	}
+	d := Dev{Bus: &b, Addr: 0x0B}
+	if err := d.SendByte(0x42); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := d.ReceiveByte(); err != nil || v != 0x43 {
+		t.Fatal(v, err)
+	}
+	if err := d.WriteByteData(0x10, 1); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := d.ReadByteData(0x11); err != nil || v != 2 {
+		t.Fatal(v, err)
+	}
+	if err := d.WriteWordData(0x12, 0x1234); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := d.ReadWordData(0x16); err != nil || v != 0x1234 {
+		t.Fatal(v, err)
+	}
+	if v, err := d.ProcessCall(0x17, 1); err != nil || v != 2 {
+		t.Fatal(v, err)
+	}
+	if err := d.BlockWrite(0x20, []byte{1, 2, 3}); err != nil {
+		t.Fatal(err)
+	}
+	var buf [MaxBlockSize]byte
+	if n, err := d.BlockRead(0x21, buf[:]); err != nil || !bytes.Equal(buf[:n], []byte{1, 2, 3}) {
+		t.Fatal(buf[:n], err)
+	}
+	if n, err := d.BlockProcessCall(0x22, []byte{9}, buf[:]); err != nil || !bytes.Equal(buf[:n], []byte{8, 7}) {
+		t.Fatal(buf[:n], err)
+	}
+	if err := b.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDev_Emulated_PEC(t *testing.T) {
+	b := i2ctest.Playback{
+		Ops: []i2ctest.IO{
+			// PEC over 0x16, 0x16, 0x34, 0x12.
+			{Addr: 0x0B, W: []byte{0x16, 0x34, 0x12, 0x1F}},
+			// PEC over 0x16, 0x16, 0x17, 0x34, 0x12.
+			{Addr: 0x0B, W: []byte{0x16}, R: []byte{0x34, 0x12, 0x0D}},
+			// PEC over 0x17, 0x42.
+			{Addr: 0x0B, R: []byte{0x42, 0xF5}},
+			// PEC over 0x16, 0x20, 0x17, 0x03, 1, 2, 3.
+			{Addr: 0x0B, W: []byte{0x20}, R: append([]byte{3, 1, 2, 3, 0x4D}, make([]byte, 29)...)},
+			// Corrupted.
+			{Addr: 0x0B, W: []byte{0x16}, R: []byte{0x34, 0x13, 0x0D}},
+		},
+	}
+	d := Dev{Bus: &b, Addr: 0x0B, PEC: true}
+	if err := d.WriteWordData(0x16, 0x1234); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := d.ReadWordData(0x16); err != nil || v != 0x1234 {
+		t.Fatal(v, err)
+	}
+	if v, err := d.ReceiveByte(); err != nil || v != 0x42 {
+		t.Fatal(v, err)
+	}
+	var buf [3]byte
+	if n, err := d.BlockRead(0x20, buf[:]); err != nil || n != 3 || !bytes.Equal(buf[:], []byte{1, 2, 3}) {
+		t.Fatal(buf, err)
+	}
+	if _, err := d.ReadWordData(0x16); err == nil {
+		t.Fatal("expected PEC mismatch")
+	}
+	if err := b.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDev_Emulated_Err(t *testing.T) {
+	b := i2ctest.Playback{
+		Ops: []i2ctest.IO{
+			{Addr: 0x0B, W: []byte{0x21}, R: append([]byte{33}, make([]byte, 32)...)},
+			{Addr: 0x0B, W: []byte{0x21}, R: append([]byte{4}, make([]byte, 32)...)},
+		},
+		DontPanic: true,
+	}
+	d := Dev{Bus: &b, Addr: 0x0B}
+	if err := d.Quick(false); err == nil {
+		t.Fatal("quick requires native support")
+	}
+	if err := d.BlockWrite(0x20, make([]byte, 33)); err == nil {
+		t.Fatal("block too long")
+	}
+	if _, err := d.BlockProcessCall(0x20, make([]byte, 33), nil); err == nil {
+		t.Fatal("block too long")
+	}
+	d.PEC = true
+	d.Addr = i2c.TenBit | 0x0B
+	if err := d.SendByte(1); err == nil {
+		t.Fatal("PEC requires a 7 bits address")
+	}
+	d.PEC = false
+	d.Addr = 0x0B
+	var buf [3]byte
+	if _, err := d.BlockRead(0x21, buf[:]); err == nil {
+		t.Fatal("invalid block length")
+	}
+	if _, err := d.BlockRead(0x21, buf[:]); err == nil {
+		t.Fatal("buffer too small")
+	}
+	if _, err := d.ReadByteData(0x10); err == nil {
+		t.Fatal("playback is empty")
+	}
+}
+
+func TestDev_Native(t *testing.T) {
+	b := nativeBus{Playback: i2ctest.Playback{Ops: []i2ctest.IO{{Addr: 0x0B, W: []byte{0x11}, R: []byte{0x02}}}}}
+	d := Dev{Bus: &b, Addr: 0x0B}
+	if err := d.Quick(true); err != nil || b.op != QuickRead {
+		t.Fatal(b.op, err)
+	}
+	if v, err := d.ReadWordData(0x16); err != nil || v != 0x1234 || b.cmd != 0x16 {
+		t.Fatal(v, err)
+	}
+	var buf [MaxBlockSize]byte
+	if n, err := d.BlockRead(0x21, buf[:]); err != nil || n != 2 || b.op != ReadBlockData {
+		t.Fatal(n, err)
+	}
+	// Unsupported operations are emulated.
+	if v, err := d.ReadByteData(0x11); err != nil || v != 2 {
+		t.Fatal(v, err)
+	}
+	// PEC is not supported natively.
+	d.PEC = true
+	if err := d.Quick(true); err == nil {
+		t.Fatal("quick requires native support")
+	}
+	if err := b.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+//
+
+// nativeBus implements Bus, except for ReadByteData and PEC.
+type nativeBus struct {
+	i2ctest.Playback
+	op  Op
+	cmd byte
+}
+
+func (n *nativeBus) SMBusSupported(op Op, pec bool) bool {
+	return !pec && op != ReadByteData
+}
+
+func (n *nativeBus) SMBusTx(addr uint16, op Op, cmd byte, w, r []byte, pec bool) (int, error) {
+	n.op = op
+	n.cmd = cmd
+	switch op {
+	case ReadWordData:
+		r[0] = 0x34
+		r[1] = 0x12
+		return 2, nil
+	case ReadBlockData:
+		return copy(r, []byte{1, 2}), nil
+	}
+	return 0, nil
+}
@@ -0,0 +1,135 @@
+// Copyright 2019 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package i2c
+
+import (
+	"io"
+	"strconv"
+	"sync"
+)
+
+// TargetBus is implemented by a bus that can act as a target, also called
+// slave, answering the transactions done by another master on the bus.
+//
+// This permits emulating a device, for example to make a host impersonate a
+// sensor on a test harness.
+type TargetBus interface {
+	// Listen answers the transactions addressed to addr with h until the
+	// returned Target is closed.
+	//
+	// A bus may only support some implementations of TargetHandler, for
+	// example *Registers.
+	Listen(addr uint16, h TargetHandler) (Target, error)
+}
+
+// Target is a device emulated on a TargetBus.
+type Target interface {
+	String() string
+	// Close stops answering at the address.
+	io.Closer
+}
+
+// TargetHandler answers the messages addressed to a Target.
+//
+// The methods are called in the order of the messages on the bus, one at a
+// time. The slices must not be retained.
+type TargetHandler interface {
+	// OnWrite is called with the bytes written by the master in one message.
+	OnWrite(w []byte)
+	// OnRead is called when the master reads a message. r must be filled with
+	// the bytes to send back.
+	OnRead(r []byte)
+}
+
+// Registers is a TargetHandler emulating the register map exposed by most
+// devices.
+//
+// The first byte of a write selects the register and the following bytes are
+// written to consecutive registers. A read returns the consecutive registers
+// starting at the selected one. The register index wraps around at the end of
+// the map.
+//
+// It is safe for concurrent use, so the device state can be updated with Set()
+// while a master reads it.
+type Registers struct {
+	// OnChange, if set, is called after the master wrote registers, with the
+	// first register written and the new values.
+	OnChange func(reg int, v []byte)
+
+	mu  sync.Mutex
+	mem []byte
+	ptr int
+}
+
+// NewRegisters returns a register map of size bytes, initialized to zero.
+//
+// size must be between 1 and 256, as registers are selected with a single
+// byte.
+func NewRegisters(size int) *Registers {
+	if size < 1 || size > 256 {
+		panic("i2c: invalid register map size " + strconv.Itoa(size))
+	}
+	return &Registers{mem: make([]byte, size)}
+}
+
+func (r *Registers) String() string {
+	return "Registers(" + strconv.Itoa(len(r.mem)) + ")"
+}
+
+// Len returns the size of the register map.
+func (r *Registers) Len() int {
+	return len(r.mem)
+}
+
+// Get copies the registers starting at reg into b.
+func (r *Registers) Get(reg int, b []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range b {
+		b[i] = r.mem[(reg+i)%len(r.mem)]
+	}
+}
+
+// Set copies b into the registers starting at reg.
+//
+// It is meant to update the state of the emulated device; OnChange is not
+// called.
+func (r *Registers) Set(reg int, b []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range b {
+		r.mem[(reg+i)%len(r.mem)] = b[i]
+	}
+}
+
+// OnWrite implements TargetHandler.
+func (r *Registers) OnWrite(w []byte) {
+	if len(w) == 0 {
+		return
+	}
+	r.mu.Lock()
+	r.ptr = int(w[0]) % len(r.mem)
+	reg := r.ptr
+	for _, v := range w[1:] {
+		r.mem[r.ptr] = v
+		r.ptr = (r.ptr + 1) % len(r.mem)
+	}
+	r.mu.Unlock()
+	if len(w) > 1 && r.OnChange != nil {
+		r.OnChange(reg, w[1:])
+	}
+}
+
+// OnRead implements TargetHandler.
+func (r *Registers) OnRead(b []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range b {
+		b[i] = r.mem[r.ptr]
+		r.ptr = (r.ptr + 1) % len(r.mem)
+	}
+}
+
+var _ TargetHandler = &Registers{}
@@ -0,0 +1,65 @@
+// Copyright 2019 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package i2c
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestRegisters(t *testing.T) {
+	r := NewRegisters(4)
+	if s := r.String(); s != "Registers(4)" {
+		t.Fatal(s)
+	}
+	if l := r.Len(); l != 4 {
+		t.Fatal(l)
+	}
+	var gotReg int
+	var gotV []byte
+	r.OnChange = func(reg int, v []byte) {
+		gotReg = reg
+		gotV = append([]byte(nil), v...)
+	}
+	// Write wraps around.
+	r.OnWrite([]byte{2, 0xA, 0xB, 0xC})
+	if gotReg != 2 || !bytes.Equal(gotV, []byte{0xA, 0xB, 0xC}) {
+		t.Fatal(gotReg, gotV)
+	}
+	b := make([]byte, 4)
+	r.Get(0, b)
+	if !bytes.Equal(b, []byte{0xC, 0, 0xA, 0xB}) {
+		t.Fatal(b)
+	}
+	// Register selection only.
+	gotV = nil
+	r.OnWrite([]byte{3})
+	if gotV != nil {
+		t.Fatal("unexpected OnChange")
+	}
+	r.OnRead(b[:2])
+	if !bytes.Equal(b[:2], []byte{0xB, 0xC}) {
+		t.Fatal(b)
+	}
+	// The index continues from the previous read.
+	r.Set(1, []byte{0x42})
+	r.OnRead(b[:1])
+	if b[0] != 0x42 {
+		t.Fatal(b)
+	}
+	r.OnWrite(nil)
+	if gotV != nil {
+		t.Fatal("unexpected OnChange")
+	}
+}
+
+func TestNewRegisters_Invalid(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected panic")
+		}
+	}()
+	NewRegisters(257)
+}
@@ -2,10 +2,137 @@
 // Use of this source code is governed under the Apache License, Version 2.0
 // that can be found in the LICENSE file.
 
-// Package i2s will eventually define the API to communicate with devices over
-// the I²S protocol.
+// Package i2s defines the API to communicate with devices over the I²S
+// protocol.
 //
-// The protocol is meant to transfer audio.
+// The protocol is meant to transfer audio as a continuous stream of PCM
+// frames. A frame contains one sample per channel.
+//
+// As described in https://periph.io/x/periph/conn#hdr-Concepts, periph.io uses
+// the concepts of Bus, Port and Conn.
+//
+// In the package i2s, 'Bus' is not exposed, as the protocol is primarily
+// point-to-point.
+//
+// Use Port.Connect() converts the uninitialized Port into a Conn.
+//
+// Use i2sreg to find the ports available on the host and i2stest to test
+// device drivers without hardware.
 //
 // See https://en.wikipedia.org/wiki/I%C2%B2S for more information.
 package i2s
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"periph.io/x/periph/conn"
+	"periph.io/x/periph/conn/gpio"
+	"periph.io/x/periph/conn/physic"
+)
+
+// Format describes the PCM frames exchanged over an I²S connection.
+//
+// Samples are encoded as signed little endian integers, right aligned in the
+// smallest of 1, 2 or 4 bytes that can hold Bits. The samples of a frame are
+// interleaved, the first channel being the left one.
+//
+// For example with Bits: 24 and Channels: 2, a frame is 8 bytes long: 4 bytes
+// for the left sample, then 4 bytes for the right sample.
+type Format struct {
+	SampleRate physic.Frequency // Number of frames per second, e.g. 48kHz
+	Bits       int              // Number of significant bits per sample, between 8 and 32
+	Channels   int              // Number of channels, 1 for mono and 2 for stereo
+}
+
+func (f Format) String() string {
+	return fmt.Sprintf("%s %dbits %dch", f.SampleRate, f.Bits, f.Channels)
+}
+
+// Validate returns an error if the format is not valid.
+//
+// It doesn't verify that a port supports the format.
+func (f Format) Validate() error {
+	if f.SampleRate <= 0 {
+		return errors.New("i2s: invalid sample rate " + f.SampleRate.String())
+	}
+	if f.Bits < 8 || f.Bits > 32 {
+		return fmt.Errorf("i2s: invalid number of bits %d", f.Bits)
+	}
+	if f.Channels < 1 {
+		return fmt.Errorf("i2s: invalid number of channels %d", f.Channels)
+	}
+	return nil
+}
+
+// SampleSize returns the number of bytes used to store one sample.
+func (f Format) SampleSize() int {
+	switch {
+	case f.Bits <= 8:
+		return 1
+	case f.Bits <= 16:
+		return 2
+	default:
+		return 4
+	}
+}
+
+// FrameSize returns the number of bytes used to store one frame.
+func (f Format) FrameSize() int {
+	return f.SampleSize() * f.Channels
+}
+
+// Conn defines the interface a concrete I²S driver must implement.
+//
+// Tx() streams whole frames. w, if not empty, is played and r, if not empty, is
+// filled with captured frames. Both must be a multiple of Format().FrameSize()
+// and, if both are specified, of the same length. In this case the frames are
+// played and captured at the same time.
+//
+// Implementers can optionally implement io.Writer and io.Reader for
+// unidirectional operation.
+type Conn interface {
+	conn.Conn
+	// Format returns the format effectively in use.
+	//
+	// The sample rate may slightly differ from the one requested to Connect()
+	// depending on the clock sources available to the port.
+	Format() Format
+}
+
+// Port is the interface to be provided to device drivers.
+//
+// The device driver, that is the driver for the peripheral connected over
+// this port, calls Connect() to retrieve a configured connection as Conn.
+type Port interface {
+	String() string
+	// Connect sets the communication parameters of the connection for use by a
+	// device.
+	//
+	// The device driver must call this function exactly once.
+	Connect(f Format) (Conn, error)
+}
+
+// PortCloser is an I²S port that can be closed.
+//
+// This interface is meant to be handled by the application.
+type PortCloser interface {
+	io.Closer
+	Port
+}
+
+// Pins defines the pins that an I²S port interconnect is using on the host.
+//
+// It is expected that a implementer of PortCloser or Conn also implement Pins
+// but this is not a requirement.
+type Pins interface {
+	// SCK returns the bit clock pin.
+	SCK() gpio.PinOut
+	// WS returns the word select pin, also called LRCLK.
+	WS() gpio.PinOut
+	// IN returns the data in pin.
+	IN() gpio.PinIn
+	// OUT returns the data out pin.
+	OUT() gpio.PinOut
+}
@@ -0,0 +1,44 @@
+// Copyright 2019 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package i2s
+
+import (
+	"testing"
+
+	"periph.io/x/periph/conn/physic"
+)
+
+func TestFormat(t *testing.T) {
+	data := []struct {
+		f          Format
+		s          string
+		sample     int
+		frame      int
+		shouldFail bool
+	}{
+		{Format{48 * physic.KiloHertz, 16, 2}, "48kHz 16bits 2ch", 2, 4, false},
+		{Format{8 * physic.KiloHertz, 8, 1}, "8kHz 8bits 1ch", 1, 1, false},
+		{Format{44100 * physic.Hertz, 24, 2}, "44.100kHz 24bits 2ch", 4, 8, false},
+		{Format{16 * physic.KiloHertz, 32, 1}, "16kHz 32bits 1ch", 4, 4, false},
+		{Format{0, 16, 2}, "0Hz 16bits 2ch", 2, 4, true},
+		{Format{48 * physic.KiloHertz, 7, 2}, "48kHz 7bits 2ch", 1, 2, true},
+		{Format{48 * physic.KiloHertz, 33, 2}, "48kHz 33bits 2ch", 4, 8, true},
+		{Format{48 * physic.KiloHertz, 16, 0}, "48kHz 16bits 0ch", 2, 0, true},
+	}
+	for i, line := range data {
+		if s := line.f.String(); s != line.s {
+			t.Fatalf("#%d: %q != %q", i, s, line.s)
+		}
+		if s := line.f.SampleSize(); s != line.sample {
+			t.Fatalf("#%d: %d != %d", i, s, line.sample)
+		}
+		if s := line.f.FrameSize(); s != line.frame {
+			t.Fatalf("#%d: %d != %d", i, s, line.frame)
+		}
+		if err := line.f.Validate(); (err != nil) != line.shouldFail {
+			t.Fatalf("#%d: %v", i, err)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright 2019 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package i2sreg_test
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"strings"
+
+	"periph.io/x/periph/conn/i2s"
+	"periph.io/x/periph/conn/i2s/i2sreg"
+	"periph.io/x/periph/conn/physic"
+	"periph.io/x/periph/host"
+)
+
+func Example() {
+	// Make sure periph is initialized.
+	if _, err := host.Init(); err != nil {
+		log.Fatal(err)
+	}
+
+	// How a command line tool may let the user choose an I²S port, yet default
+	// to the first bus known.
+	name := flag.String("i2s", "", "I²S port to use")
+	flag.Parse()
+	p, err := i2sreg.Open(*name)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer p.Close()
+
+	f := i2s.Format{SampleRate: 48 * physic.KiloHertz, Bits: 16, Channels: 2}
+	c, err := p.Connect(f)
+	if err != nil {
+		log.Fatal(err)
+	}
+	// Play 100ms of silence.
+	if err := c.Tx(make([]byte, 4800*f.FrameSize()), nil); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func ExampleAll() {
+	// Make sure periph is initialized.
+	if _, err := host.Init(); err != nil {
+		log.Fatal(err)
+	}
+
+	// Enumerate all I²S ports available and the corresponding pins.
+	fmt.Print("I²S ports available:\n")
+	for _, ref := range i2sreg.All() {
+		fmt.Printf("- %s\n", ref.Name)
+		if ref.Number != -1 {
+			fmt.Printf("  %d\n", ref.Number)
+		}
+		if len(ref.Aliases) != 0 {
+			fmt.Printf("  %s\n", strings.Join(ref.Aliases, " "))
+		}
+
+		b, err := ref.Open()
+		if err != nil {
+			fmt.Printf("  Failed to open: %v", err)
+		}
+		if p, ok := b.(i2s.Pins); ok {
+			fmt.Printf("  SCK: %s", p.SCK())
+			fmt.Printf("  WS : %s", p.WS())
+			fmt.Printf("  IN : %s", p.IN())
+			fmt.Printf("  OUT: %s", p.OUT())
+		}
+		if err := b.Close(); err != nil {
+			fmt.Printf("  Failed to close: %v", err)
+		}
+	}
+}
+
+func ExampleOpen() {
+	// Make sure periph is initialized.
+	if _, err := host.Init(); err != nil {
+		log.Fatal(err)
+	}
+
+	// On linux, the following calls will likely open the same bus.
+	_, _ = i2sreg.Open("I2S0")
+	_, _ = i2sreg.Open("PCM0")
+	_, _ = i2sreg.Open("0")
+}
@@ -0,0 +1,211 @@
+// Copyright 2019 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package i2sreg
+
+import (
+	"errors"
+	"sort"
+	"testing"
+
+	"periph.io/x/periph/conn"
+	"periph.io/x/periph/conn/gpio"
+	"periph.io/x/periph/conn/i2s"
+)
+
+func TestOpen(t *testing.T) {
+	defer reset()
+	if _, err := Open(""); err == nil {
+		t.Fatal("no bus registered")
+	}
+	if err := Register("a", []string{"x"}, 1, fakePorter); err != nil {
+		t.Fatal(err)
+	}
+	if o, err := Open(""); o == nil || err != nil {
+		t.Fatal(o, err)
+	}
+	if o, err := Open("1"); o == nil || err != nil {
+		t.Fatal(o, err)
+	}
+	if o, err := Open("x"); o == nil || err != nil {
+		t.Fatal(o, err)
+	}
+	if o, err := Open("y"); o != nil || err == nil {
+		t.Fatal(o, err)
+	}
+}
+
+func TestDefault_NoNumber(t *testing.T) {
+	defer reset()
+	if err := Register("a", nil, -1, fakePorter); err != nil {
+		t.Fatal(err)
+	}
+	if o, err := Open(""); o == nil || err != nil {
+		t.Fatal(o, err)
+	}
+}
+
+func TestAll(t *testing.T) {
+	defer reset()
+	if a := All(); len(a) != 0 {
+		t.Fatal(a)
+	}
+	if err := Register("a", nil, 1, fakePorter); err != nil {
+		t.Fatal(err)
+	}
+	if err := Register("b", nil, 2, fakePorter); err != nil {
+		t.Fatal(err)
+	}
+	if a := All(); len(a) != 2 {
+		t.Fatal(a)
+	}
+}
+
+func TestRefList(t *testing.T) {
+	l := refList{&Ref{Name: "b"}, &Ref{Name: "a"}}
+	sort.Sort(l)
+	if l[0].Name != "a" || l[1].Name != "b" {
+		t.Fatal(l)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	defer reset()
+	if err := Register("a", []string{"b"}, 42, fakePorter); err != nil {
+		t.Fatal(err)
+	}
+	if Register("a", nil, -1, fakePorter) == nil {
+		t.Fatal("same bus name")
+	}
+	if Register("b", nil, -1, fakePorter) == nil {
+		t.Fatal("same bus alias name")
+	}
+	if Register("c", nil, 42, fakePorter) == nil {
+		t.Fatal("same bus number")
+	}
+	if Register("c", []string{"a"}, -1, fakePorter) == nil {
+		t.Fatal("same bus alias")
+	}
+	if Register("c", []string{"b"}, -1, fakePorter) == nil {
+		t.Fatal("same bus alias")
+	}
+}
+
+func TestRegister_fail(t *testing.T) {
+	defer reset()
+	if Register("a", nil, -1, nil) == nil {
+		t.Fatal("missing Opener")
+	}
+	if Register("a", nil, -2, fakePorter) == nil {
+		t.Fatal("bad bus number")
+	}
+	if Register("", nil, 42, fakePorter) == nil {
+		t.Fatal("missing name")
+	}
+	if Register("1", nil, 42, fakePorter) == nil {
+		t.Fatal("numeric name")
+	}
+	if Register("a:b", nil, 42, fakePorter) == nil {
+		t.Fatal("':' in name")
+	}
+	if Register("a", []string{"a"}, 0, fakePorter) == nil {
+		t.Fatal("\"a\" is already registered")
+	}
+	if Register("a", []string{""}, 0, fakePorter) == nil {
+		t.Fatal("empty alias")
+	}
+	if Register("a", []string{"1"}, 0, fakePorter) == nil {
+		t.Fatal("numeric alias")
+	}
+	if Register("a", []string{"a:b"}, 0, fakePorter) == nil {
+		t.Fatal("':' in alias")
+	}
+	if a := All(); len(a) != 0 {
+		t.Fatal(a)
+	}
+}
+
+func TestUnregister(t *testing.T) {
+	defer reset()
+	if Unregister("") == nil {
+		t.Fatal("unregister empty")
+	}
+	if Unregister("a") == nil {
+		t.Fatal("unregister non-existing")
+	}
+	if err := Register("a", []string{"b"}, 0, fakePorter); err != nil {
+		t.Fatal(err)
+	}
+	if err := Unregister("a"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+//
+
+func fakePorter() (i2s.PortCloser, error) {
+	return &fakePort{}, nil
+}
+
+// fakePort implements i2s.PortCloser.
+type fakePort struct {
+	conn fakeConn
+}
+
+func (f *fakePort) String() string {
+	return "fake"
+}
+
+func (f *fakePort) Close() error {
+	return errors.New("not implemented")
+}
+
+func (f *fakePort) Connect(format i2s.Format) (i2s.Conn, error) {
+	return &f.conn, nil
+}
+
+func (f *fakePort) SCK() gpio.PinOut { return f.conn.SCK() }
+func (f *fakePort) WS() gpio.PinOut  { return f.conn.WS() }
+func (f *fakePort) IN() gpio.PinIn   { return f.conn.IN() }
+func (f *fakePort) OUT() gpio.PinOut { return f.conn.OUT() }
+
+// fakeConn implements conn.Conn.
+type fakeConn struct {
+}
+
+func (f *fakeConn) String() string {
+	return "fake"
+}
+
+func (f *fakeConn) Tx(w, r []byte) error {
+	return errors.New("not implemented")
+}
+
+func (f *fakeConn) Duplex() conn.Duplex {
+	return conn.Full
+}
+
+func (f *fakeConn) Format() i2s.Format {
+	return i2s.Format{}
+}
+
+func (f *fakeConn) SCK() gpio.PinOut { return gpio.INVALID }
+func (f *fakeConn) WS() gpio.PinOut  { return gpio.INVALID }
+func (f *fakeConn) IN() gpio.PinIn   { return gpio.INVALID }
+func (f *fakeConn) OUT() gpio.PinOut { return gpio.INVALID }
+
+func reset() {
+	mu.Lock()
+	defer mu.Unlock()
+	byName = map[string]*Ref{}
+	byNumber = map[int]*Ref{}
+	byAlias = map[string]*Ref{}
+}
+
+//
+
+var _ i2s.PortCloser = &fakePort{}
+var _ i2s.Pins = &fakePort{}
+var _ i2s.Conn = &fakeConn{}
+var _ i2s.Pins = &fakeConn{}
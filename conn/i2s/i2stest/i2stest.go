@@ -0,0 +1,295 @@
+// Copyright 2019 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+// Package i2stest is meant to be used to test drivers over a fake I²S port.
+package i2stest
+
+import (
+	"errors"
+	"log"
+	"sync"
+
+	"periph.io/x/periph/conn"
+	"periph.io/x/periph/conn/conntest"
+	"periph.io/x/periph/conn/gpio"
+	"periph.io/x/periph/conn/i2s"
+)
+
+// Loopback implements i2s.PortCloser as if OUT was wired to IN.
+//
+// The frames written are queued and returned by the following reads, in
+// order. When the queue doesn't contain enough frames, the remaining of the
+// read buffer is filled with silence and Underruns is incremented.
+//
+// When both w and r are specified in a single Tx() call, the frames in w are
+// queued before r is filled, so a single call with the same length returns
+// the data that was previously queued followed by the head of w.
+type Loopback struct {
+	sync.Mutex
+	// Buf is the queue of frames written but not read yet.
+	Buf []byte
+	// Underruns is the number of Tx() calls that couldn't be fully served from
+	// Buf.
+	Underruns  int
+	Fmt        i2s.Format
+	Connected  bool
+	Closed     bool
+	DontPanic  bool
+	SCKPin     gpio.PinIO
+	WSPin      gpio.PinIO
+	INPin      gpio.PinIO
+	OUTPin     gpio.PinIO
+	connection loopbackConn
+}
+
+func (l *Loopback) String() string {
+	return "loopback"
+}
+
+// Close implements i2s.PortCloser.
+func (l *Loopback) Close() error {
+	l.Lock()
+	defer l.Unlock()
+	if l.Closed {
+		return errorf(l.DontPanic, "i2stest: Close() called twice")
+	}
+	l.Closed = true
+	return nil
+}
+
+// Connect implements i2s.PortCloser.
+func (l *Loopback) Connect(f i2s.Format) (i2s.Conn, error) {
+	l.Lock()
+	defer l.Unlock()
+	if l.Connected {
+		return nil, errorf(l.DontPanic, "i2stest: Connect cannot be called twice")
+	}
+	if err := f.Validate(); err != nil {
+		return nil, err
+	}
+	l.Connected = true
+	l.Fmt = f
+	l.connection.l = l
+	return &l.connection, nil
+}
+
+// SCK implements i2s.Pins.
+func (l *Loopback) SCK() gpio.PinOut {
+	return l.SCKPin
+}
+
+// WS implements i2s.Pins.
+func (l *Loopback) WS() gpio.PinOut {
+	return l.WSPin
+}
+
+// IN implements i2s.Pins.
+func (l *Loopback) IN() gpio.PinIn {
+	return l.INPin
+}
+
+// OUT implements i2s.Pins.
+func (l *Loopback) OUT() gpio.PinOut {
+	return l.OUTPin
+}
+
+func (l *Loopback) tx(w, r []byte) error {
+	l.Lock()
+	defer l.Unlock()
+	if l.Closed {
+		return errorf(l.DontPanic, "i2stest: Tx() called after Close()")
+	}
+	if err := checkTx(l.Fmt, w, r); err != nil {
+		return err
+	}
+	l.Buf = append(l.Buf, w...)
+	n := copy(r, l.Buf)
+	l.Buf = l.Buf[n:]
+	if n != len(r) {
+		for i := n; i < len(r); i++ {
+			r[i] = 0
+		}
+		l.Underruns++
+	}
+	return nil
+}
+
+type loopbackConn struct {
+	l *Loopback
+}
+
+func (c *loopbackConn) String() string {
+	return c.l.String()
+}
+
+func (c *loopbackConn) Duplex() conn.Duplex {
+	return conn.Full
+}
+
+func (c *loopbackConn) Tx(w, r []byte) error {
+	return c.l.tx(w, r)
+}
+
+func (c *loopbackConn) Format() i2s.Format {
+	c.l.Lock()
+	defer c.l.Unlock()
+	return c.l.Fmt
+}
+
+// Read implements io.Reader.
+func (c *loopbackConn) Read(b []byte) (int, error) {
+	if err := c.l.tx(nil, b); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// Write implements io.Writer.
+func (c *loopbackConn) Write(b []byte) (int, error) {
+	if err := c.l.tx(b, nil); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+func (c *loopbackConn) SCK() gpio.PinOut {
+	return c.l.SCK()
+}
+
+func (c *loopbackConn) WS() gpio.PinOut {
+	return c.l.WS()
+}
+
+func (c *loopbackConn) IN() gpio.PinIn {
+	return c.l.IN()
+}
+
+func (c *loopbackConn) OUT() gpio.PinOut {
+	return c.l.OUT()
+}
+
+//
+
+// Playback implements i2s.PortCloser and plays back a recorded I/O flow.
+//
+// While "replay" type of unit tests are of limited value, they still present
+// an easy way to do basic code coverage.
+type Playback struct {
+	conntest.Playback
+	Fmt         i2s.Format
+	Initialized bool
+}
+
+// Close implements i2s.PortCloser.
+//
+// Close() verifies that all the expected Ops have been consumed.
+func (p *Playback) Close() error {
+	return p.Playback.Close()
+}
+
+// Connect implements i2s.PortCloser.
+func (p *Playback) Connect(f i2s.Format) (i2s.Conn, error) {
+	p.Lock()
+	defer p.Unlock()
+	if p.Initialized {
+		return nil, conntest.Errorf("i2stest: Connect cannot be called twice")
+	}
+	if err := f.Validate(); err != nil {
+		return nil, err
+	}
+	p.Initialized = true
+	p.Fmt = f
+	return &playbackConn{p}, nil
+}
+
+type playbackConn struct {
+	p *Playback
+}
+
+func (p *playbackConn) String() string {
+	return p.p.String()
+}
+
+func (p *playbackConn) Duplex() conn.Duplex {
+	return conn.Full
+}
+
+func (p *playbackConn) Tx(w, r []byte) error {
+	if err := checkTx(p.Format(), w, r); err != nil {
+		return err
+	}
+	return p.p.Tx(w, r)
+}
+
+func (p *playbackConn) Format() i2s.Format {
+	p.p.Lock()
+	defer p.p.Unlock()
+	return p.p.Fmt
+}
+
+//
+
+// Log logs all operations done on an i2s.PortCloser.
+type Log struct {
+	i2s.PortCloser
+}
+
+// Close implements i2s.PortCloser.
+func (l *Log) Close() error {
+	err := l.PortCloser.Close()
+	log.Printf("%s.Close() = %v", l.PortCloser, err)
+	return err
+}
+
+// Connect implements i2s.PortCloser.
+func (l *Log) Connect(f i2s.Format) (i2s.Conn, error) {
+	c, err := l.PortCloser.Connect(f)
+	log.Printf("%s.Connect(%s) = %v", l.PortCloser, f, err)
+	return &LogConn{c}, err
+}
+
+// LogConn logs all operations done on an I²S connection.
+type LogConn struct {
+	i2s.Conn
+}
+
+// Tx implements conn.Conn.
+func (l *LogConn) Tx(w, r []byte) error {
+	err := l.Conn.Tx(w, r)
+	log.Printf("%s.Tx(%d bytes, %d bytes) = %v", l.Conn, len(w), len(r), err)
+	return err
+}
+
+//
+
+// checkTx verifies that the buffers are valid for Tx() with format f.
+func checkTx(f i2s.Format, w, r []byte) error {
+	if len(w) == 0 && len(r) == 0 {
+		return errors.New("i2stest: Tx() with empty buffers")
+	}
+	if len(w) != 0 && len(r) != 0 && len(w) != len(r) {
+		return errors.New("i2stest: Tx() buffers must have the same length")
+	}
+	if s := f.FrameSize(); len(w)%s != 0 || len(r)%s != 0 {
+		return errors.New("i2stest: Tx() buffers must contain whole frames")
+	}
+	return nil
+}
+
+func errorf(dontPanic bool, format string, a ...interface{}) error {
+	err := conntest.Errorf(format, a...)
+	if !dontPanic {
+		panic(err)
+	}
+	return err
+}
+
+var _ i2s.PortCloser = &Loopback{}
+var _ i2s.PortCloser = &Playback{}
+var _ i2s.PortCloser = &Log{}
+var _ i2s.Pins = &Loopback{}
+var _ i2s.Conn = &loopbackConn{}
+var _ i2s.Conn = &playbackConn{}
+var _ i2s.Conn = &LogConn{}
+var _ i2s.Pins = &loopbackConn{}
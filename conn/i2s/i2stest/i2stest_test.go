@@ -0,0 +1,146 @@
+// Copyright 2019 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package i2stest
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"log"
+	"testing"
+
+	"periph.io/x/periph/conn"
+	"periph.io/x/periph/conn/conntest"
+	"periph.io/x/periph/conn/gpio/gpiotest"
+	"periph.io/x/periph/conn/i2s"
+	"periph.io/x/periph/conn/physic"
+)
+
+func TestLoopback(t *testing.T) {
+	l := Loopback{SCKPin: &gpiotest.Pin{N: "SCK"}, WSPin: &gpiotest.Pin{N: "WS"}, INPin: &gpiotest.Pin{N: "IN"}, OUTPin: &gpiotest.Pin{N: "OUT"}}
+	if s := l.String(); s != "loopback" {
+		t.Fatal(s)
+	}
+	f := i2s.Format{SampleRate: 48 * physic.KiloHertz, Bits: 16, Channels: 2}
+	c, err := l.Connect(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Format() != f {
+		t.Fatal(c.Format())
+	}
+	if d := c.Duplex(); d != conn.Full {
+		t.Fatal(d)
+	}
+	p := c.(i2s.Pins)
+	if p.SCK() != l.SCKPin || p.WS() != l.WSPin || p.IN() != l.INPin || p.OUT() != l.OUTPin {
+		t.Fatal("unexpected pins")
+	}
+
+	// Full duplex; data written is returned in the same call.
+	w := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+	r := make([]byte, 8)
+	if err := c.Tx(w, r); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(w, r) {
+		t.Fatal(r)
+	}
+	// Data queued with Write() is returned by Read().
+	if _, err := c.(io.Writer).Write(w[:4]); err != nil {
+		t.Fatal(err)
+	}
+	r = []byte{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}
+	if _, err := c.(io.Reader).Read(r); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(r, []byte{1, 2, 3, 4, 0, 0, 0, 0}) || l.Underruns != 1 {
+		t.Fatal(r, l.Underruns)
+	}
+
+	if c.Tx(nil, nil) == nil {
+		t.Fatal("empty buffers")
+	}
+	if c.Tx(w[:4], r) == nil {
+		t.Fatal("different lengths")
+	}
+	if c.Tx(w[:3], nil) == nil {
+		t.Fatal("partial frame")
+	}
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoopback_err(t *testing.T) {
+	l := Loopback{DontPanic: true}
+	if _, err := l.Connect(i2s.Format{}); err == nil {
+		t.Fatal("invalid format")
+	}
+	c, err := l.Connect(i2s.Format{SampleRate: 8 * physic.KiloHertz, Bits: 8, Channels: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.Connect(i2s.Format{SampleRate: 8 * physic.KiloHertz, Bits: 8, Channels: 1}); !conntest.IsErr(err) {
+		t.Fatal("can't call Connect twice")
+	}
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Tx([]byte{1}, nil); !conntest.IsErr(err) {
+		t.Fatal("closed")
+	}
+	if err := l.Close(); !conntest.IsErr(err) {
+		t.Fatal("can't call Close twice")
+	}
+}
+
+func TestPlayback(t *testing.T) {
+	p := Playback{Playback: conntest.Playback{Ops: []conntest.IO{{W: []byte{1, 2}, R: []byte{3, 4}}}}}
+	f := i2s.Format{SampleRate: 8 * physic.KiloHertz, Bits: 16, Channels: 1}
+	c, err := p.Connect(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Connect(f); err == nil {
+		t.Fatal("can't call Connect twice")
+	}
+	if c.String() != "playback" || c.Duplex() != conn.Full || c.Format() != f {
+		t.Fatal("unexpected state")
+	}
+	if c.Tx([]byte{1}, nil) == nil {
+		t.Fatal("partial frame")
+	}
+	r := make([]byte, 2)
+	if err := c.Tx([]byte{1, 2}, r); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(r, []byte{3, 4}) {
+		t.Fatal(r)
+	}
+	if err := p.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLog(t *testing.T) {
+	l := Log{&Loopback{}}
+	c, err := l.Connect(i2s.Format{SampleRate: 8 * physic.KiloHertz, Bits: 8, Channels: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Tx([]byte{1}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+//
+
+func init() {
+	log.SetOutput(ioutil.Discard)
+}
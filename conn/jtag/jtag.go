@@ -2,8 +2,365 @@
 // Use of this source code is governed under the Apache License, Version 2.0
 // that can be found in the LICENSE file.
 
-// Package jtag will eventually define the API to communicate with devices over
-// the JTAG protocol.
+// Package jtag defines the API to communicate with devices over the JTAG
+// protocol.
+//
+// Conn is the bit level transport that clocks TMS and TDI and samples TDO.
+// TAP tracks the state of the test access port state machine on top of a Conn
+// to shift instructions and data. ScanIDCodes() identifies the devices in a
+// chain.
+//
+// Use jtagtest to test code without hardware.
 //
 // See https://en.wikipedia.org/wiki/JTAG for background information.
 package jtag
+
+import (
+	"errors"
+	"fmt"
+
+	"periph.io/x/periph/conn/gpio"
+)
+
+// Conn defines the interface a concrete JTAG driver must implement.
+//
+// The bits are packed least significant bit first; bit i is stored in
+// b[i/8]&(1<<(i%8)).
+type Conn interface {
+	String() string
+	// Shift clocks TCK bits times.
+	//
+	// For each clock, the corresponding bit of tms and tdi is presented to the
+	// target and the TDO bit is sampled in tdo. tdi and tdo can be nil; TDI is
+	// then kept low and TDO is ignored. tms must hold at least bits bits, and tdi
+	// and tdo too when specified.
+	Shift(bits int, tms, tdi, tdo []byte) error
+}
+
+// Pins defines the pins that a JTAG interconnect is using on the host.
+//
+// It is expected that a implementer of Conn also implement Pins but this is
+// not a requirement.
+type Pins interface {
+	// TCK returns the test clock pin.
+	TCK() gpio.PinOut
+	// TMS returns the test mode select pin.
+	TMS() gpio.PinOut
+	// TDI returns the test data in pin, that is the data sent to the target.
+	TDI() gpio.PinOut
+	// TDO returns the test data out pin, that is the data read from the target.
+	TDO() gpio.PinIn
+	// TRST returns the optional test reset pin.
+	TRST() gpio.PinOut
+}
+
+// State is one of the 16 states of the TAP controller.
+type State uint8
+
+// Valid states.
+const (
+	TestLogicReset State = iota
+	RunTestIdle
+	SelectDRScan
+	CaptureDR
+	ShiftDR
+	Exit1DR
+	PauseDR
+	Exit2DR
+	UpdateDR
+	SelectIRScan
+	CaptureIR
+	ShiftIR
+	Exit1IR
+	PauseIR
+	Exit2IR
+	UpdateIR
+)
+
+const stateName = "TestLogicResetRunTestIdleSelectDRScanCaptureDRShiftDRExit1DRPauseDRExit2DRUpdateDRSelectIRScanCaptureIRShiftIRExit1IRPauseIRExit2IRUpdateIR"
+
+var stateIndex = [...]uint8{0, 14, 25, 37, 46, 53, 60, 67, 74, 82, 94, 103, 110, 117, 124, 131, 139}
+
+func (s State) String() string {
+	if s >= State(len(stateIndex)-1) {
+		return fmt.Sprintf("State(%d)", s)
+	}
+	return stateName[stateIndex[s]:stateIndex[s+1]]
+}
+
+// Next returns the state the TAP controller transitions to on the next TCK
+// rising edge with the TMS level tms.
+func (s State) Next(tms gpio.Level) State {
+	if s > UpdateIR {
+		return s
+	}
+	if tms {
+		return transitions[s][1]
+	}
+	return transitions[s][0]
+}
+
+// Path returns the shortest TMS sequence to go from state s to state to.
+//
+// It returns an empty sequence when s == to.
+func (s State) Path(to State) []gpio.Level {
+	if s > UpdateIR || to > UpdateIR {
+		return nil
+	}
+	return paths[s][to]
+}
+
+// IDCode is a 32 bits device identification code as defined by IEEE 1149.1.
+//
+// The value 0 is used to represent a device in BYPASS, that is a device that
+// doesn't implement the IDCODE instruction.
+type IDCode uint32
+
+// Version returns the 4 bits version of the part.
+func (i IDCode) Version() int {
+	return int(i >> 28)
+}
+
+// Part returns the 16 bits part number.
+func (i IDCode) Part() uint16 {
+	return uint16(i >> 12)
+}
+
+// Manufacturer returns the 11 bits JEDEC JEP106 manufacturer identity.
+//
+// The 4 most significant bits are the bank number minus one and the 7 least
+// significant bits are the identity code without parity.
+func (i IDCode) Manufacturer() uint16 {
+	return uint16(i>>1) & 0x7FF
+}
+
+func (i IDCode) String() string {
+	if i == 0 {
+		return "BYPASS"
+	}
+	return fmt.Sprintf("0x%08X(mfg:0x%03X, part:0x%04X, ver:%d)", uint32(i), i.Manufacturer(), i.Part(), i.Version())
+}
+
+// TAP drives the test access port state machine of a JTAG chain over a Conn.
+//
+// The state is unknown until the first operation, which resets the chain.
+type TAP struct {
+	c     Conn
+	state State
+	known bool
+}
+
+// NewTAP returns a TAP that drives the chain connected on c.
+func NewTAP(c Conn) *TAP {
+	return &TAP{c: c}
+}
+
+func (t *TAP) String() string {
+	return t.c.String()
+}
+
+// State returns the current state of the TAP controller.
+func (t *TAP) State() State {
+	return t.state
+}
+
+// Reset puts the TAP controllers in the TestLogicReset state by clocking five
+// times with TMS high, whatever their previous state.
+//
+// Devices load either IDCODE or BYPASS as their instruction.
+func (t *TAP) Reset() error {
+	t.known = false
+	if err := t.c.Shift(5, []byte{0x1F}, nil, nil); err != nil {
+		return err
+	}
+	t.state = TestLogicReset
+	t.known = true
+	return nil
+}
+
+// GoTo moves the TAP controller to the state s with the shortest TMS
+// sequence.
+func (t *TAP) GoTo(s State) error {
+	if s > UpdateIR {
+		return errors.New("jtag: invalid state " + s.String())
+	}
+	if !t.known {
+		if err := t.Reset(); err != nil {
+			return err
+		}
+	}
+	p := t.state.Path(s)
+	if len(p) == 0 {
+		return nil
+	}
+	tms := make([]byte, (len(p)+7)/8)
+	for i, l := range p {
+		if l {
+			tms[i/8] |= 1 << uint(i%8)
+		}
+	}
+	if err := t.c.Shift(len(p), tms, nil, nil); err != nil {
+		t.known = false
+		return err
+	}
+	t.state = s
+	return nil
+}
+
+// RunTest clocks cycles times in the RunTestIdle state.
+func (t *TAP) RunTest(cycles int) error {
+	if err := t.GoTo(RunTestIdle); err != nil {
+		return err
+	}
+	if cycles <= 0 {
+		return nil
+	}
+	if err := t.c.Shift(cycles, make([]byte, (cycles+7)/8), nil, nil); err != nil {
+		t.known = false
+		return err
+	}
+	return nil
+}
+
+// ShiftIR shifts bits of tdi in the instruction registers of the chain and
+// reads the previous content in tdo, then updates the instructions.
+//
+// The instruction registers of all the devices in the chain are concatenated;
+// the first bits shifted are the ones of the device the closest to TDO. tdo
+// can be nil.
+//
+// The TAP controller is left in the RunTestIdle state.
+func (t *TAP) ShiftIR(bits int, tdi, tdo []byte) error {
+	return t.shift(ShiftIR, bits, tdi, tdo)
+}
+
+// ShiftDR shifts bits of tdi in the currently selected data registers of the
+// chain and reads the previous content in tdo, then updates the registers.
+//
+// The data registers of all the devices in the chain are concatenated; the
+// first bits shifted are the ones of the device the closest to TDO. tdo can be
+// nil.
+//
+// The TAP controller is left in the RunTestIdle state.
+func (t *TAP) ShiftDR(bits int, tdi, tdo []byte) error {
+	return t.shift(ShiftDR, bits, tdi, tdo)
+}
+
+// ScanIDCodes returns the identification codes of the devices in the chain.
+//
+// It resets the chain so each device loads IDCODE, or BYPASS if it doesn't
+// have one, then shifts ones through the data registers until they come out
+// on TDO. The first code is the device the closest to TDO. Devices in BYPASS
+// are returned as 0.
+//
+// max is the maximum number of devices expected in the chain.
+func ScanIDCodes(t *TAP, max int) ([]IDCode, error) {
+	if err := t.Reset(); err != nil {
+		return nil, err
+	}
+	if err := t.GoTo(ShiftDR); err != nil {
+		return nil, err
+	}
+	var out []IDCode
+	ones := []byte{0xFF, 0xFF, 0xFF, 0xFF}
+	zeros := make([]byte, 4)
+	var b [4]byte
+	for {
+		// A device in BYPASS captures a single 0 bit, while IDCODE always has its
+		// least significant bit set.
+		b = [4]byte{}
+		if err := t.c.Shift(1, zeros, ones, b[:]); err != nil {
+			t.known = false
+			return nil, err
+		}
+		id := IDCode(0)
+		if b[0]&1 != 0 {
+			if err := t.c.Shift(31, zeros, ones, b[:]); err != nil {
+				t.known = false
+				return nil, err
+			}
+			id = IDCode(1 | uint32(b[0])<<1 | uint32(b[1])<<9 | uint32(b[2])<<17 | uint32(b[3])<<25)
+			if id == 0xFFFFFFFF {
+				// The ones shifted in came out; all the devices were scanned.
+				return out, t.GoTo(RunTestIdle)
+			}
+		}
+		if len(out) == max {
+			break
+		}
+		out = append(out, id)
+	}
+	_ = t.GoTo(RunTestIdle)
+	return nil, fmt.Errorf("jtag: found more than %d devices; is TDO stuck low?", max)
+}
+
+//
+
+func (t *TAP) shift(s State, bits int, tdi, tdo []byte) error {
+	if bits <= 0 {
+		return errors.New("jtag: invalid number of bits")
+	}
+	n := (bits + 7) / 8
+	if len(tdi) < n || (tdo != nil && len(tdo) < n) {
+		return errors.New("jtag: buffers too short")
+	}
+	if err := t.GoTo(s); err != nil {
+		return err
+	}
+	// TMS is low while shifting, except for the last bit to exit to Exit1.
+	last := bits - 1
+	tms := make([]byte, n)
+	tms[last/8] = 1 << uint(last%8)
+	if err := t.c.Shift(bits, tms, tdi, tdo); err != nil {
+		t.known = false
+		return err
+	}
+	t.state = s + 1
+	return t.GoTo(RunTestIdle)
+}
+
+// transitions is the TAP controller state machine, indexed by state then by
+// the TMS level.
+var transitions = [...][2]State{
+	TestLogicReset: {RunTestIdle, TestLogicReset},
+	RunTestIdle:    {RunTestIdle, SelectDRScan},
+	SelectDRScan:   {CaptureDR, SelectIRScan},
+	CaptureDR:      {ShiftDR, Exit1DR},
+	ShiftDR:        {ShiftDR, Exit1DR},
+	Exit1DR:        {PauseDR, UpdateDR},
+	PauseDR:        {PauseDR, Exit2DR},
+	Exit2DR:        {ShiftDR, UpdateDR},
+	UpdateDR:       {RunTestIdle, SelectDRScan},
+	SelectIRScan:   {CaptureIR, TestLogicReset},
+	CaptureIR:      {ShiftIR, Exit1IR},
+	ShiftIR:        {ShiftIR, Exit1IR},
+	Exit1IR:        {PauseIR, UpdateIR},
+	PauseIR:        {PauseIR, Exit2IR},
+	Exit2IR:        {ShiftIR, UpdateIR},
+	UpdateIR:       {RunTestIdle, SelectDRScan},
+}
+
+// paths is the shortest TMS sequence between each pair of states.
+var paths [UpdateIR + 1][UpdateIR + 1][]gpio.Level
+
+func init() {
+	// Breadth first search from each state; the state machine is tiny.
+	for from := range paths {
+		paths[from][from] = []gpio.Level{}
+		queue := []State{State(from)}
+		for len(queue) != 0 {
+			s := queue[0]
+			queue = queue[1:]
+			for i, next := range transitions[s] {
+				if paths[from][next] != nil {
+					continue
+				}
+				p := make([]gpio.Level, len(paths[from][s])+1)
+				copy(p, paths[from][s])
+				p[len(p)-1] = i == 1
+				paths[from][next] = p
+				queue = append(queue, next)
+			}
+		}
+	}
+}
@@ -0,0 +1,217 @@
+// Copyright 2019 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package jtag_test
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+
+	"periph.io/x/periph/conn/gpio"
+	"periph.io/x/periph/conn/jtag"
+	"periph.io/x/periph/conn/jtag/jtagtest"
+)
+
+func TestState(t *testing.T) {
+	if s := jtag.TestLogicReset.String(); s != "TestLogicReset" {
+		t.Fatal(s)
+	}
+	if s := jtag.UpdateIR.String(); s != "UpdateIR" {
+		t.Fatal(s)
+	}
+	if s := jtag.State(16).String(); s != "State(16)" {
+		t.Fatal(s)
+	}
+	if s := jtag.SelectIRScan.Next(gpio.High); s != jtag.TestLogicReset {
+		t.Fatal(s)
+	}
+	if s := jtag.Exit2DR.Next(gpio.Low); s != jtag.ShiftDR {
+		t.Fatal(s)
+	}
+	if s := jtag.State(16).Next(gpio.Low); s != 16 {
+		t.Fatal(s)
+	}
+	data := []struct {
+		from, to jtag.State
+		expected []gpio.Level
+	}{
+		{jtag.RunTestIdle, jtag.RunTestIdle, []gpio.Level{}},
+		{jtag.TestLogicReset, jtag.RunTestIdle, []gpio.Level{gpio.Low}},
+		{jtag.RunTestIdle, jtag.ShiftDR, []gpio.Level{gpio.High, gpio.Low, gpio.Low}},
+		{jtag.RunTestIdle, jtag.ShiftIR, []gpio.Level{gpio.High, gpio.High, gpio.Low, gpio.Low}},
+		{jtag.Exit1DR, jtag.RunTestIdle, []gpio.Level{gpio.High, gpio.Low}},
+		{jtag.ShiftIR, jtag.TestLogicReset, []gpio.Level{gpio.High, gpio.High, gpio.High, gpio.High, gpio.High}},
+	}
+	for i, line := range data {
+		p := line.from.Path(line.to)
+		if !reflect.DeepEqual(p, line.expected) {
+			t.Fatalf("#%d: %v != %v", i, p, line.expected)
+		}
+		s := line.from
+		for _, l := range p {
+			s = s.Next(l)
+		}
+		if s != line.to {
+			t.Fatalf("#%d: %s != %s", i, s, line.to)
+		}
+	}
+	if jtag.State(16).Path(jtag.RunTestIdle) != nil {
+		t.Fatal("invalid state")
+	}
+}
+
+func TestIDCode(t *testing.T) {
+	// Xilinx XC3S200 rev 1.
+	i := jtag.IDCode(0x11414093)
+	if v := i.Version(); v != 1 {
+		t.Fatal(v)
+	}
+	if v := i.Part(); v != 0x1414 {
+		t.Fatal(v)
+	}
+	if v := i.Manufacturer(); v != 0x049 {
+		t.Fatal(v)
+	}
+	if s := i.String(); s != "0x11414093(mfg:0x049, part:0x1414, ver:1)" {
+		t.Fatal(s)
+	}
+	if s := jtag.IDCode(0).String(); s != "BYPASS" {
+		t.Fatal(s)
+	}
+}
+
+func TestScanIDCodes(t *testing.T) {
+	c := &jtagtest.Chain{
+		Devices: []*jtagtest.Device{
+			{IRLen: 6, IDCode: 0x11414093, IDCodeInstr: 0x09},
+			{IRLen: 4},
+			{IRLen: 5, IDCode: 0x4BA00477, IDCodeInstr: 0x0E},
+		},
+	}
+	tap := jtag.NewTAP(c)
+	if s := tap.String(); s != "jtagtest" {
+		t.Fatal(s)
+	}
+	ids, err := jtag.ScanIDCodes(tap, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The device the closest to TDO is first.
+	expected := []jtag.IDCode{0x4BA00477, 0, 0x11414093}
+	if !reflect.DeepEqual(ids, expected) {
+		t.Fatal(ids)
+	}
+	if s := tap.State(); s != jtag.RunTestIdle {
+		t.Fatal(s)
+	}
+	if _, err := jtag.ScanIDCodes(tap, 2); err == nil {
+		t.Fatal("too many devices")
+	}
+	// Exactly max devices.
+	if ids, err = jtag.ScanIDCodes(tap, 3); err != nil || !reflect.DeepEqual(ids, expected) {
+		t.Fatal(ids, err)
+	}
+}
+
+func TestScanIDCodes_stuck(t *testing.T) {
+	if _, err := jtag.ScanIDCodes(jtag.NewTAP(&stuck{}), 4); err == nil {
+		t.Fatal("TDO stuck low")
+	}
+	if _, err := jtag.ScanIDCodes(jtag.NewTAP(&failing{}), 4); err == nil {
+		t.Fatal("failing Conn")
+	}
+}
+
+func TestTAP_boundaryScan(t *testing.T) {
+	bsr := &jtagtest.Register{Len: 10, Value: []byte{0x55, 0x01}}
+	c := &jtagtest.Chain{
+		Devices: []*jtagtest.Device{
+			{IRLen: 4, IDCode: 0x1, IDCodeInstr: 0x1, Registers: map[uint64]*jtagtest.Register{0x2: bsr}},
+			{IRLen: 3},
+		},
+	}
+	tap := jtag.NewTAP(c)
+	// Select the boundary scan register of the first device and bypass the
+	// second one; the bits of the device the closest to TDO are shifted first.
+	if err := tap.ShiftIR(7, []byte{0x7 | 0x2<<3}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if c.Devices[0].IR != 0x2 || c.Devices[1].IR != 0x7 {
+		t.Fatal(c.Devices[0].IR, c.Devices[1].IR)
+	}
+	// 1 bit for the bypass register then 10 bits of boundary scan register.
+	r := make([]byte, 2)
+	if err := tap.ShiftDR(11, []byte{0x54, 0x05}, r); err != nil {
+		t.Fatal(err)
+	}
+	// The bypass register captured 0, then the register value follows.
+	if !bytes.Equal(r, []byte{0xAA, 0x02}) {
+		t.Fatalf("%#v", r)
+	}
+	// The new value was shifted in after the bypass bit.
+	if !bytes.Equal(bsr.Value, []byte{0xAA, 0x02}) {
+		t.Fatalf("%#v", bsr.Value)
+	}
+	if s := tap.State(); s != jtag.RunTestIdle {
+		t.Fatal(s)
+	}
+	n := c.Clocks
+	if err := tap.RunTest(10); err != nil {
+		t.Fatal(err)
+	}
+	if c.Clocks != n+10 {
+		t.Fatal(c.Clocks - n)
+	}
+}
+
+func TestTAP_err(t *testing.T) {
+	tap := jtag.NewTAP(&jtagtest.Chain{})
+	if tap.GoTo(jtag.State(16)) == nil {
+		t.Fatal("invalid state")
+	}
+	if tap.ShiftDR(0, nil, nil) == nil {
+		t.Fatal("no bits")
+	}
+	if tap.ShiftDR(9, []byte{0}, nil) == nil {
+		t.Fatal("buffer too short")
+	}
+	tap = jtag.NewTAP(&failing{})
+	if tap.GoTo(jtag.ShiftDR) == nil {
+		t.Fatal("failing Conn")
+	}
+	if tap.RunTest(1) == nil {
+		t.Fatal("failing Conn")
+	}
+	if tap.ShiftIR(1, []byte{0}, nil) == nil {
+		t.Fatal("failing Conn")
+	}
+}
+
+//
+
+// stuck is a chain where TDO is always low.
+type stuck struct{}
+
+func (s *stuck) String() string {
+	return "stuck"
+}
+
+func (s *stuck) Shift(bits int, tms, tdi, tdo []byte) error {
+	for i := range tdo {
+		tdo[i] = 0
+	}
+	return nil
+}
+
+type failing struct{}
+
+func (f *failing) String() string {
+	return "failing"
+}
+
+func (f *failing) Shift(bits int, tms, tdi, tdo []byte) error {
+	return errors.New("failing")
+}
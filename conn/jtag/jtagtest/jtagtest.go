@@ -0,0 +1,230 @@
+// Copyright 2019 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+// Package jtagtest is meant to be used to test code using a JTAG chain without
+// hardware.
+package jtagtest
+
+import (
+	"errors"
+	"sync"
+
+	"periph.io/x/periph/conn/gpio"
+	"periph.io/x/periph/conn/jtag"
+)
+
+// Register is a simulated data register.
+type Register struct {
+	// Len is the register length in bits.
+	Len int
+	// Value is loaded in the shift register on CaptureDR and is updated from it
+	// on UpdateDR. The bits are packed least significant bit first.
+	Value []byte
+	// ReadOnly specifies that Value is not updated on UpdateDR.
+	ReadOnly bool
+}
+
+// Device is a simulated device with a TAP controller.
+//
+// The instruction with all the bits set is BYPASS, as mandated by IEEE 1149.1.
+// When IDCode is not 0, the IDCODE instruction is selected on reset;
+// otherwise BYPASS is selected.
+type Device struct {
+	// IRLen is the instruction register length in bits. It must be at least 2.
+	IRLen int
+	// IDCode is the value of the identification register.
+	IDCode jtag.IDCode
+	// IDCodeInstr is the instruction selecting the identification register.
+	IDCodeInstr uint64
+	// Registers are the other data registers, by instruction.
+	Registers map[uint64]*Register
+
+	// IR is the current instruction.
+	IR uint64
+
+	shift []bool
+}
+
+// Chain implements jtag.Conn and simulates a chain of devices.
+//
+// Devices[0] is connected to TDI and the last device is connected to TDO.
+type Chain struct {
+	sync.Mutex
+	Devices []*Device
+	// State is the state of all the TAP controllers in the chain.
+	State jtag.State
+	// Clocks is the number of TCK cycles simulated so far.
+	Clocks int
+}
+
+func (c *Chain) String() string {
+	return "jtagtest"
+}
+
+// Shift implements jtag.Conn.
+func (c *Chain) Shift(bits int, tms, tdi, tdo []byte) error {
+	n := (bits + 7) / 8
+	if bits < 0 || len(tms) < n || (tdi != nil && len(tdi) < n) || (tdo != nil && len(tdo) < n) {
+		return errors.New("jtagtest: invalid buffers")
+	}
+	c.Lock()
+	defer c.Unlock()
+	for i := 0; i < bits; i++ {
+		mask := byte(1) << uint(i%8)
+		t := tms[i/8]&mask != 0
+		d := tdi != nil && tdi[i/8]&mask != 0
+		o := c.clock(gpio.Level(t), gpio.Level(d))
+		if tdo != nil {
+			if o {
+				tdo[i/8] |= mask
+			} else {
+				tdo[i/8] &^= mask
+			}
+		}
+	}
+	return nil
+}
+
+// Clock simulates one TCK cycle with the TMS and TDI levels and returns the
+// TDO level as sampled before the rising edge.
+//
+// It can be used with TDO() to wire the chain to fake pins.
+func (c *Chain) Clock(tms, tdi gpio.Level) gpio.Level {
+	c.Lock()
+	defer c.Unlock()
+	return c.clock(tms, tdi)
+}
+
+// TDO returns the current TDO level.
+//
+// It is Low outside of the ShiftDR and ShiftIR states.
+func (c *Chain) TDO() gpio.Level {
+	c.Lock()
+	defer c.Unlock()
+	return c.tdo()
+}
+
+//
+
+func (c *Chain) clock(tms, tdi gpio.Level) gpio.Level {
+	c.Clocks++
+	tdo := c.tdo()
+	switch c.State {
+	case jtag.CaptureDR:
+		for _, d := range c.Devices {
+			d.captureDR()
+		}
+	case jtag.CaptureIR:
+		for _, d := range c.Devices {
+			d.captureIR()
+		}
+	case jtag.ShiftDR, jtag.ShiftIR:
+		// The bits shift from TDI to TDO through all the devices.
+		in := bool(tdi)
+		for _, d := range c.Devices {
+			in = d.shiftBit(in)
+		}
+	}
+	c.State = c.State.Next(tms)
+	switch c.State {
+	case jtag.TestLogicReset:
+		for _, d := range c.Devices {
+			d.reset()
+		}
+	case jtag.UpdateDR:
+		for _, d := range c.Devices {
+			d.updateDR()
+		}
+	case jtag.UpdateIR:
+		for _, d := range c.Devices {
+			d.updateIR()
+		}
+	}
+	return tdo
+}
+
+func (c *Chain) tdo() gpio.Level {
+	if c.State != jtag.ShiftDR && c.State != jtag.ShiftIR {
+		return gpio.Low
+	}
+	if len(c.Devices) == 0 {
+		return gpio.Low
+	}
+	d := c.Devices[len(c.Devices)-1]
+	return gpio.Level(len(d.shift) != 0 && d.shift[0])
+}
+
+func (d *Device) reset() {
+	if d.IDCode != 0 {
+		d.IR = d.IDCodeInstr
+	} else {
+		d.IR = d.bypass()
+	}
+}
+
+func (d *Device) bypass() uint64 {
+	return 1<<uint(d.IRLen) - 1
+}
+
+func (d *Device) captureIR() {
+	// The two least significant bits must be 01.
+	d.shift = make([]bool, d.IRLen)
+	d.shift[0] = true
+}
+
+func (d *Device) updateIR() {
+	d.IR = 0
+	for i, b := range d.shift {
+		if b {
+			d.IR |= 1 << uint(i)
+		}
+	}
+}
+
+func (d *Device) captureDR() {
+	if d.IDCode != 0 && d.IR == d.IDCodeInstr {
+		d.shift = make([]bool, 32)
+		for i := range d.shift {
+			d.shift[i] = d.IDCode&(1<<uint(i)) != 0
+		}
+		return
+	}
+	if r := d.Registers[d.IR]; r != nil {
+		d.shift = make([]bool, r.Len)
+		for i := range d.shift {
+			d.shift[i] = r.Value[i/8]&(1<<uint(i%8)) != 0
+		}
+		return
+	}
+	// BYPASS and unknown instructions select the 1 bit bypass register, which
+	// captures 0.
+	d.shift = []bool{false}
+}
+
+func (d *Device) updateDR() {
+	r := d.Registers[d.IR]
+	if r == nil || r.ReadOnly || (d.IDCode != 0 && d.IR == d.IDCodeInstr) {
+		return
+	}
+	for i, b := range d.shift {
+		if b {
+			r.Value[i/8] |= 1 << uint(i%8)
+		} else {
+			r.Value[i/8] &^= 1 << uint(i%8)
+		}
+	}
+}
+
+// shiftBit shifts in the bit in and returns the bit shifted out.
+func (d *Device) shiftBit(in bool) bool {
+	if len(d.shift) == 0 {
+		return in
+	}
+	out := d.shift[0]
+	copy(d.shift, d.shift[1:])
+	d.shift[len(d.shift)-1] = in
+	return out
+}
+
+var _ jtag.Conn = &Chain{}
@@ -24,6 +24,37 @@ func CalcCRC(buf []byte) byte {
 	return crc
 }
 
+// CheckCRC16 verifies that the last two bytes of the buffer contain the
+// inverted 16-bit CRC of the previous bytes, least significant byte first, as
+// sent by the devices.
+func CheckCRC16(buf []byte) bool {
+	if len(buf) < 2 {
+		return false
+	}
+	crc := ^CalcCRC16(buf[:len(buf)-2])
+	return byte(crc) == buf[len(buf)-2] && byte(crc>>8) == buf[len(buf)-1]
+}
+
+// CalcCRC16 calculates the 16-bit CRC across the buffer of bytes and returns
+// it.
+//
+// It is used by devices with larger memory, like EEPROMs. The polynomial is
+// x¹⁶+x¹⁵+x²+1, as described in App Note 27.
+func CalcCRC16(buf []byte) uint16 {
+	var crc uint16
+	for _, b := range buf {
+		crc ^= uint16(b)
+		for i := 0; i < 8; i++ {
+			if crc&1 != 0 {
+				crc = crc>>1 ^ 0xA001
+			} else {
+				crc >>= 1
+			}
+		}
+	}
+	return crc
+}
+
 // crcTable comes from https://www.maximintegrated.com/en/app-notes/index.mvp/id/27
 var crcTable = []byte{
 	0, 94, 188, 226, 97, 63, 221, 131, 194, 156, 126, 32, 163, 253, 31, 65,
@@ -25,3 +25,23 @@ func TestCheckCRC(t *testing.T) {
 		t.Fatal("expected bad crc")
 	}
 }
+
+func TestCheckCRC16(t *testing.T) {
+	a := []byte("123456789")
+	c := CalcCRC16(a)
+	if c != 0xBB3D {
+		t.Fatalf("%#x", c)
+	}
+	b := append([]byte{}, a...)
+	b = append(b, byte(^c), byte(^c>>8))
+	if !CheckCRC16(b) {
+		t.Fatal("expected good crc")
+	}
+	b[len(b)-1]++
+	if CheckCRC16(b) {
+		t.Fatal("expected bad crc")
+	}
+	if CheckCRC16([]byte{0}) {
+		t.Fatal("expected bad crc")
+	}
+}
@@ -25,6 +25,7 @@
 package onewire
 
 import (
+	"errors"
 	"strconv"
 
 	"periph.io/x/periph/conn"
@@ -94,6 +95,40 @@ type BusCloser interface {
 	Bus
 }
 
+// BusOverdriver is a 1-wire bus that supports the overdrive speed, which is
+// about eight times faster than the standard speed.
+//
+// Devices are switched to overdrive speed by an Overdrive Skip ROM (0x3C) or
+// an Overdrive Match ROM (0x69) command sent at standard speed. The bus must
+// switch to the overdrive timings right after sending one of these commands
+// as the first byte of w in Tx(), since the rest of the transaction happens at
+// overdrive speed. The bus then stays at overdrive speed until
+// SetOverdrive(false) is called.
+//
+// It is expected that an implementer of Bus also implement BusOverdriver when
+// the hardware supports it, but this is not required.
+type BusOverdriver interface {
+	Bus
+	// SetOverdrive selects the overdrive timings when on is true, or the
+	// standard timings otherwise.
+	//
+	// Since the next reset is then done at standard speed, setting it to false
+	// switches all the devices back to standard speed.
+	SetOverdrive(on bool) error
+}
+
+// OverdriveSkip switches all the devices on the bus supporting overdrive, and
+// the bus itself, to overdrive speed with an Overdrive Skip ROM command.
+//
+// The devices that don't support overdrive ignore the following transactions
+// until the bus is switched back to standard speed with SetOverdrive(false).
+func OverdriveSkip(b BusOverdriver) error {
+	if err := b.SetOverdrive(false); err != nil {
+		return err
+	}
+	return b.Tx([]byte{0x3C}, nil, WeakPullup)
+}
+
 // Pins defines the pins that a 1-wire bus interconnect is using on the host.
 //
 // It is expected that an implementer of Bus also implement Pins but this is
@@ -148,6 +183,37 @@ type busError string
 func (e busError) Error() string  { return string(e) }
 func (e busError) BusError() bool { return true }
 
+// ROMCommand is the ROM command used by Dev to address the device.
+type ROMCommand uint8
+
+const (
+	// MatchROM addresses the device with a Match ROM command followed by its
+	// address.
+	MatchROM ROMCommand = iota
+	// OverdriveMatchROM addresses the device with an Overdrive Match ROM
+	// command followed by its address, which switches the device and the bus
+	// to overdrive speed. The bus must implement BusOverdriver, otherwise the
+	// transactions fail.
+	OverdriveMatchROM
+	// SkipROM addresses all the devices at once with a Skip ROM command. It is
+	// only valid when there is a single device on the bus, like an iButton
+	// probe, since all the devices would answer at the same time.
+	SkipROM
+)
+
+func (r ROMCommand) String() string {
+	switch r {
+	case MatchROM:
+		return "MatchROM"
+	case OverdriveMatchROM:
+		return "OverdriveMatchROM"
+	case SkipROM:
+		return "SkipROM"
+	default:
+		return "ROMCommand(" + strconv.Itoa(int(r)) + ")"
+	}
+}
+
 // Dev is a device on a 1-wire bus.
 //
 // It implements conn.Conn.
@@ -157,6 +223,12 @@ func (e busError) BusError() bool { return true }
 type Dev struct {
 	Bus  Bus     // the bus to which the device is connected
 	Addr Address // address of the device on the bus
+	// ROM is the ROM command used to address the device; the zero value is
+	// MatchROM.
+	//
+	// Use a ResumeBus as Bus to use the Resume command when the device is
+	// addressed multiple times in a row.
+	ROM ROMCommand
 }
 
 // String prints the bus name followed by the device address in parenthesis.
@@ -174,19 +246,13 @@ func (d *Dev) String() string {
 	return s + "(0x" + a + ")"
 }
 
-// Tx performs a "match ROM" command on the bus to select the device
-// and then transmits and receives the specified bytes. It ends by
-// leaving a weak pull-up on the bus.
+// Tx addresses the device with the ROM command selected by d.ROM and then
+// transmits and receives the specified bytes. It ends by leaving a weak
+// pull-up on the bus.
 //
 // It's a wrapper for Dev.Bus.Tx().
 func (d *Dev) Tx(w, r []byte) error {
-	// Issue ROM match command to select the device followed by the
-	// bytes being written.
-	ww := make([]byte, 9, len(w)+9)
-	ww[0] = 0x55 // Match ROM
-	putUint64(ww[1:], d.Addr)
-	ww = append(ww, w...)
-	return d.Bus.Tx(ww, r, WeakPullup)
+	return d.tx(w, r, WeakPullup)
 }
 
 // Duplex always return conn.Half for 1-wire.
@@ -194,24 +260,52 @@ func (d *Dev) Duplex() conn.Duplex {
 	return conn.Half
 }
 
-// TxPower performs a "match ROM" command on the bus to select the device
-// and then transmits and receives the specified bytes. It ends by
-// leaving a strong pull-up on the bus suitable to power devices through
-// an EEPROM write or a temperature conversion.
+// TxPower addresses the device with the ROM command selected by d.ROM and
+// then transmits and receives the specified bytes. It ends by leaving a
+// strong pull-up on the bus suitable to power devices through an EEPROM write
+// or a temperature conversion.
 //
 // It's a wrapper for Dev.Bus.Tx().
 func (d *Dev) TxPower(w, r []byte) error {
-	// Issue ROM match command to select the device followed by the
-	// bytes being written.
-	ww := make([]byte, 9, len(w)+9)
-	ww[0] = 0x55 // Match ROM
-	putUint64(ww[1:], d.Addr)
-	ww = append(ww, w...)
-	return d.Bus.Tx(ww, r, StrongPullup)
+	return d.tx(w, r, StrongPullup)
 }
 
 //
 
+// tx issues the ROM command followed by the bytes being written.
+func (d *Dev) tx(w, r []byte, power Pullup) error {
+	var ww []byte
+	switch d.ROM {
+	case SkipROM:
+		ww = make([]byte, 1, len(w)+1)
+		ww[0] = 0xCC // Skip ROM
+	case OverdriveMatchROM:
+		// Otherwise the device would be left at overdrive speed with no way to
+		// talk to it.
+		if !canOverdrive(d.Bus) {
+			return errors.New("onewire: " + d.Bus.String() + " doesn't support overdrive")
+		}
+		ww = make([]byte, 9, len(w)+9)
+		ww[0] = 0x69 // Overdrive Match ROM
+		putUint64(ww[1:], d.Addr)
+	default:
+		ww = make([]byte, 9, len(w)+9)
+		ww[0] = 0x55 // Match ROM
+		putUint64(ww[1:], d.Addr)
+	}
+	ww = append(ww, w...)
+	return d.Bus.Tx(ww, r, power)
+}
+
+// canOverdrive returns true if the bus implements BusOverdriver.
+func canOverdrive(b Bus) bool {
+	if r, ok := b.(*ResumeBus); ok {
+		b = r.bus
+	}
+	_, ok := b.(BusOverdriver)
+	return ok
+}
+
 // putUint64 is littleEndian.PutUint64().
 //
 // It was extracted to to not depend on encoding/binary, which depends on
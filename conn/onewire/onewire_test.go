@@ -55,7 +55,7 @@ func TestBusError(t *testing.T) {
 }
 
 func TestDevString(t *testing.T) {
-	d := Dev{&fakeBus{}, 12}
+	d := Dev{Bus: &fakeBus{}, Addr: 12}
 	if s := d.String(); s != "fake(0x000000000000000c)" {
 		t.Fatalf("got %s", s)
 	}
@@ -64,7 +64,7 @@ func TestDevString(t *testing.T) {
 func TestDevTx(t *testing.T) {
 	exErr := errors.New("yes")
 	b := &fakeBus{err: exErr, r: []byte{1, 2, 3}}
-	d := Dev{b, 12}
+	d := Dev{Bus: b, Addr: 12}
 	r := make([]byte, 3)
 	w := []byte{3, 4, 5}
 	if err := d.Tx(w, r); exErr != err {
@@ -101,8 +101,99 @@ func TestDevTxPower(t *testing.T) {
 	}
 }
 
+func TestDevTx_ROM(t *testing.T) {
+	b := &overdriveBus{}
+	d := Dev{Bus: b, Addr: 12, ROM: OverdriveMatchROM}
+	if err := d.Tx([]byte{3}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if expected := []byte{0x69, 12, 0, 0, 0, 0, 0, 0, 0, 3}; !bytes.Equal(b.w, expected) || !b.overdrive {
+		t.Fatal(b.w, b.overdrive)
+	}
+	// Skip ROM doesn't send the address.
+	b.w = nil
+	d.ROM = SkipROM
+	if err := d.TxPower([]byte{3}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if expected := []byte{0xcc, 3}; !bytes.Equal(b.w, expected) {
+		t.Fatal(b.w)
+	}
+}
+
+func TestDevTx_OverdriveMatchROM_unsupported(t *testing.T) {
+	b := &fakeBus{}
+	d := Dev{Bus: b, Addr: 12, ROM: OverdriveMatchROM}
+	if err := d.Tx([]byte{3}, nil); err == nil {
+		t.Fatal("bus doesn't support overdrive")
+	}
+	if len(b.w) != 0 {
+		t.Fatal(b.w)
+	}
+	d.Bus = NewResumeBus(b)
+	if err := d.Tx([]byte{3}, nil); err == nil {
+		t.Fatal("bus doesn't support overdrive")
+	}
+	d.Bus = NewResumeBus(&overdriveBus{})
+	if err := d.Tx([]byte{3}, nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestROMCommand_String(t *testing.T) {
+	data := []struct {
+		r        ROMCommand
+		expected string
+	}{
+		{MatchROM, "MatchROM"},
+		{OverdriveMatchROM, "OverdriveMatchROM"},
+		{SkipROM, "SkipROM"},
+		{ROMCommand(10), "ROMCommand(10)"},
+	}
+	for i, line := range data {
+		if s := line.r.String(); s != line.expected {
+			t.Fatalf("#%d: %q != %q", i, s, line.expected)
+		}
+	}
+}
+
+func TestOverdriveSkip(t *testing.T) {
+	b := &overdriveBus{overdrive: true}
+	if err := OverdriveSkip(b); err != nil {
+		t.Fatal(err)
+	}
+	if !b.overdrive || !bytes.Equal(b.w, []byte{0x3c}) {
+		t.Fatal(b.overdrive, b.w)
+	}
+	b.err = errors.New("fail")
+	if err := OverdriveSkip(b); err != b.err {
+		t.Fatal(err)
+	}
+}
+
 //
 
+// overdriveBus implements BusOverdriver.
+type overdriveBus struct {
+	fakeBus
+	overdrive bool
+}
+
+func (o *overdriveBus) Tx(w, r []byte, power Pullup) error {
+	if !o.overdrive && len(w) != 0 && (w[0] == 0x3c || w[0] == 0x69) {
+		o.overdrive = true
+	}
+	return o.fakeBus.Tx(w, r, power)
+}
+
+func (o *overdriveBus) SetOverdrive(on bool) error {
+	if o.err != nil {
+		return o.err
+	}
+	o.overdrive = on
+	return nil
+}
+
 type fakeBus struct {
 	power Pullup
 	err   error
@@ -0,0 +1,96 @@
+// Copyright 2019 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package onewiretest
+
+import (
+	"io"
+	"strconv"
+
+	"periph.io/x/periph/conn/conntest"
+	"periph.io/x/periph/conn/onewire"
+)
+
+// Save writes the recorded operations as a capture file.
+//
+// See conntest.CaptureHeader for the file format. When Devices is not empty,
+// the first line after the header lists them, for example:
+//
+//	{"devices":["a600000a1b2c3d28"]}
+//	{"w":"cc44","pull":true}
+func (r *Record) Save(w io.Writer) error {
+	r.Lock()
+	defer r.Unlock()
+	c, err := conntest.NewCaptureWriter(w, "onewire")
+	if err != nil {
+		return err
+	}
+	if len(r.Devices) != 0 {
+		d := captureDevices{Devices: make([]string, len(r.Devices))}
+		for i, a := range r.Devices {
+			d.Devices[i] = strconv.FormatUint(uint64(a), 16)
+		}
+		if err := c.Write(&d); err != nil {
+			return err
+		}
+	}
+	for i := range r.Ops {
+		op := captureIO{W: r.Ops[i].W, R: r.Ops[i].R, Pull: bool(r.Ops[i].Pull)}
+		if err := c.Write(&op); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Load replaces the operations to play back and the devices with the ones in
+// a capture file written by Record.Save().
+func (p *Playback) Load(r io.Reader) error {
+	c, err := conntest.NewCaptureReader(r, "onewire")
+	if err != nil {
+		return err
+	}
+	var ops []IO
+	var devices []onewire.Address
+	for {
+		var op captureIO
+		if err := c.Read(&op); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return err
+		}
+		if op.Devices != nil {
+			for _, s := range op.Devices {
+				a, err := strconv.ParseUint(s, 16, 64)
+				if err != nil {
+					return err
+				}
+				devices = append(devices, onewire.Address(a))
+			}
+			continue
+		}
+		ops = append(ops, IO{W: op.W, R: op.R, Pull: onewire.Pullup(op.Pull)})
+	}
+	p.Lock()
+	defer p.Unlock()
+	p.Ops = ops
+	p.Devices = devices
+	p.Count = 0
+	return nil
+}
+
+//
+
+type captureDevices struct {
+	Devices []string `json:"devices"`
+}
+
+// captureIO is a line of a capture; either an IO or the devices.
+type captureIO struct {
+	Devices []string     `json:"devices,omitempty"`
+	W       conntest.Hex `json:"w,omitempty"`
+	R       conntest.Hex `json:"r,omitempty"`
+	Pull    bool         `json:"pull,omitempty"`
+}
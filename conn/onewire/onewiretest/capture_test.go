@@ -0,0 +1,75 @@
+// Copyright 2019 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package onewiretest
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"periph.io/x/periph/conn/onewire"
+)
+
+func TestRecord_Save_Playback_Load(t *testing.T) {
+	const addr onewire.Address = 0xa600000a1b2c3d28
+	src := Playback{
+		Ops: []IO{
+			{W: []byte{0xf0}},
+			{W: []byte{0x55, 0x28, 0x3d, 0x2c, 0x1b, 0x0a, 0, 0, 0xa6, 0xbe}, R: []byte{0x50, 0x05}},
+			{W: []byte{0xcc, 0x44}, Pull: onewire.StrongPullup},
+		},
+		Devices: []onewire.Address{addr},
+	}
+	r := Record{Bus: &src}
+	found, err := r.Search(false)
+	if err != nil || !reflect.DeepEqual(found, src.Devices) {
+		t.Fatal(found, err)
+	}
+	d := onewire.Dev{Bus: &r, Addr: addr}
+	if err := d.Tx([]byte{0xbe}, make([]byte, 2)); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Tx([]byte{0xcc, 0x44}, nil, onewire.StrongPullup); err != nil {
+		t.Fatal(err)
+	}
+	buf := bytes.Buffer{}
+	if err := r.Save(&buf); err != nil {
+		t.Fatal(err)
+	}
+	expected := "{\"format\":\"periph-capture\",\"version\":1,\"bus\":\"onewire\"}\n" +
+		"{\"devices\":[\"a600000a1b2c3d28\"]}\n" +
+		"{\"w\":\"f0\"}\n" +
+		"{\"w\":\"55283d2c1b0a0000a6be\",\"r\":\"5005\"}\n" +
+		"{\"w\":\"cc44\",\"pull\":true}\n"
+	if s := buf.String(); s != expected {
+		t.Fatal(s)
+	}
+	p := Playback{}
+	if err := p.Load(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(p.Ops, src.Ops) || !reflect.DeepEqual(p.Devices, src.Devices) {
+		t.Fatal(p.Ops, p.Devices)
+	}
+	if found, err := p.Search(false); err != nil || !reflect.DeepEqual(found, src.Devices) {
+		t.Fatal(found, err)
+	}
+}
+
+func TestPlayback_Load_Err(t *testing.T) {
+	data := []string{
+		"",
+		"{\"format\":\"periph-capture\",\"version\":1,\"bus\":\"i2c\"}\n",
+		"{\"format\":\"periph-capture\",\"version\":1,\"bus\":\"onewire\"}\n{\"devices\":[\"xyz\"]}\n",
+		"{\"format\":\"periph-capture\",\"version\":1,\"bus\":\"onewire\"}\n{\"w\":1}\n",
+	}
+	for i, line := range data {
+		p := Playback{}
+		if err := p.Load(strings.NewReader(line)); err == nil {
+			t.Fatal(i)
+		}
+	}
+}
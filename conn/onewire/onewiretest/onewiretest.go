@@ -28,6 +28,11 @@ type Record struct {
 	sync.Mutex
 	Bus onewire.Bus // Bus can be nil if only writes are being recorded.
 	Ops []IO
+	// Devices are the devices found by Search(), to be used in Playback.
+	Devices []onewire.Address
+	// AlarmDevices are the devices found by Search(true), to be used in
+	// Playback.
+	AlarmDevices []onewire.Address
 }
 
 func (r *Record) String() string {
@@ -68,17 +73,52 @@ func (r *Record) Q() gpio.PinIO {
 	return gpio.INVALID
 }
 
-// Search implements onewire.Bus
+// Search implements onewire.Bus.
+//
+// The search is forwarded to Bus. It is recorded as the search commands
+// issued by onewire.Search(), one per device found, and the devices are added
+// to Devices, or to AlarmDevices for an alarm search, so Playback can replay
+// it.
 func (r *Record) Search(alarmOnly bool) ([]onewire.Address, error) {
-	return nil, nil
+	if r.Bus == nil {
+		return nil, nil
+	}
+	r.Lock()
+	defer r.Unlock()
+	devices, err := r.Bus.Search(alarmOnly)
+	if err != nil {
+		return devices, err
+	}
+	cmd := byte(0xf0)
+	list := &r.Devices
+	if alarmOnly {
+		cmd = 0xec
+		list = &r.AlarmDevices
+	}
+	for _, d := range devices {
+		r.Ops = append(r.Ops, IO{W: []byte{cmd}})
+		found := false
+		for _, e := range *list {
+			if e == d {
+				found = true
+				break
+			}
+		}
+		if !found {
+			*list = append(*list, d)
+		}
+	}
+	return devices, nil
 }
 
 // Playback implements onewire.Bus and plays back a recorded I/O flow.
 //
 // The bus' search function is special-cased. When a Tx operation has
 // 0xf0 in w[0] the search state is reset and subsequent triplet operations
-// respond according to the list of Devices.  In other words, Tx is
-// replayed but the responses to SearchTriplet operations are simulated.
+// respond according to the list of Devices. When it has 0xec, an alarm
+// search, they respond according to the list of AlarmDevices instead. In other
+// words, Tx is replayed but the responses to SearchTriplet operations are
+// simulated.
 //
 // While "replay" type of unit tests are of limited value, they still present
 // an easy way to do basic code coverage.
@@ -92,9 +132,12 @@ type Playback struct {
 	Devices   []onewire.Address // devices that respond to a search operation
 	QPin      gpio.PinIO
 	DontPanic bool
+	// AlarmDevices are the devices that respond to an alarm search operation.
+	AlarmDevices []onewire.Address
 
-	inactive  []bool // Devices that are no longer active in the search
-	searchBit uint   // which bit is being searched next
+	searched  []onewire.Address // Devices or AlarmDevices, for the current search
+	inactive  []bool            // searched devices that are no longer active in the search
+	searchBit uint              // which bit is being searched next
 }
 
 func (p *Playback) String() string {
@@ -128,9 +171,13 @@ func (p *Playback) Tx(w, r []byte, pull onewire.Pullup) error {
 		return errorf(p.DontPanic, "onewiretest: unexpected pullup (count #%d) %s != %s", p.Count, pull, p.Ops[p.Count].Pull)
 	}
 	// Determine whether this starts a search and reset search state.
-	if len(w) > 0 && w[0] == 0xf0 {
+	if len(w) > 0 && (w[0] == 0xf0 || w[0] == 0xec) {
+		p.searched = p.Devices
+		if w[0] == 0xec {
+			p.searched = p.AlarmDevices
+		}
 		p.searchBit = 0
-		p.inactive = make([]bool, len(p.Devices))
+		p.inactive = make([]bool, len(p.searched))
 	}
 	// Concoct response.
 	copy(r, p.Ops[p.Count].R)
@@ -156,15 +203,15 @@ func (p *Playback) SearchTriplet(direction byte) (onewire.TripletResult, error)
 	if p.searchBit > 63 {
 		return tr, errorf(p.DontPanic, "onewiretest: search performs more than 64 triplet operations")
 	}
-	if len(p.inactive) != len(p.Devices) {
+	if len(p.inactive) != len(p.searched) {
 		return tr, errorf(p.DontPanic, "onewiretest: Devices must be initialized before starting search")
 	}
 	// Figure out the devices' response.
-	for i := range p.Devices {
+	for i := range p.searched {
 		if p.inactive[i] {
 			continue
 		}
-		if (p.Devices[i]>>p.searchBit)&1 == 0 {
+		if (p.searched[i]>>p.searchBit)&1 == 0 {
 			tr.GotZero = true
 		} else {
 			tr.GotOne = true
@@ -180,8 +227,8 @@ func (p *Playback) SearchTriplet(direction byte) (onewire.TripletResult, error)
 		tr.Taken = direction
 	}
 	// Inactivate devices in the direction not taken.
-	for i := range p.Devices {
-		if uint8((p.Devices[i]>>p.searchBit)&1) != tr.Taken {
+	for i := range p.searched {
+		if uint8((p.searched[i]>>p.searchBit)&1) != tr.Taken {
 			p.inactive[i] = true
 		}
 	}
@@ -184,6 +184,38 @@ func TestRecord_Playback(t *testing.T) {
 	}
 }
 
+func TestRecord_Search_alarm(t *testing.T) {
+	all := []onewire.Address{withCRC(0x0000000000000128), withCRC(0x0000000000000228)}
+	alarm := all[1:]
+	ops := []IO{
+		{W: []byte{0xec}, Pull: onewire.WeakPullup},
+		{W: []byte{0xf0}, Pull: onewire.WeakPullup},
+		{W: []byte{0xf0}, Pull: onewire.WeakPullup},
+	}
+	r := Record{Bus: &Playback{Ops: ops, Devices: all, AlarmDevices: alarm}}
+	if a, err := r.Search(true); err != nil || len(a) != 1 || a[0] != alarm[0] {
+		t.Fatal(a, err)
+	}
+	if a, err := r.Search(false); err != nil || len(a) != 2 {
+		t.Fatal(a, err)
+	}
+	if len(r.Devices) != 2 || len(r.AlarmDevices) != 1 || r.AlarmDevices[0] != alarm[0] {
+		t.Fatal(r.Devices, r.AlarmDevices)
+	}
+
+	// Replay the recording.
+	p := Playback{Ops: r.Ops, Devices: r.Devices, AlarmDevices: r.AlarmDevices}
+	if a, err := p.Search(true); err != nil || len(a) != 1 || a[0] != alarm[0] {
+		t.Fatal(a, err)
+	}
+	if a, err := p.Search(false); err != nil || len(a) != 2 {
+		t.Fatal(a, err)
+	}
+	if err := p.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
 // TestSearch is the same as ../search_test.go.
 func TestSearch(t *testing.T) {
 	p := Playback{
@@ -239,3 +271,12 @@ match:
 		t.Fatal(err)
 	}
 }
+
+//
+
+// withCRC returns a with the CRC byte fixed up.
+func withCRC(a onewire.Address) onewire.Address {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], uint64(a))
+	return onewire.Address(onewire.CalcCRC(buf[:7]))<<56 | a&0x00ffffffffffffff
+}
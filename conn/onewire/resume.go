@@ -0,0 +1,87 @@
+// Copyright 2019 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package onewire
+
+import (
+	"errors"
+	"sync"
+)
+
+// ResumeBus is a Bus that tracks the device addressed by the last transaction
+// and replaces the Match ROM or Overdrive Match ROM command and the address
+// with a Resume command (0xA5) when the same device is addressed again. This
+// saves 64 bits per transaction, which helps on buses with many devices.
+//
+// The devices forget they were addressed as soon as any other ROM command is
+// sent, so all the transactions on the underlying bus must go through the
+// ResumeBus.
+//
+// It implements BusOverdriver; SetOverdrive() fails when the underlying bus
+// doesn't implement it.
+type ResumeBus struct {
+	bus Bus
+
+	mu        sync.Mutex
+	rom       [9]byte // ROM command and address of the last addressed device
+	addressed bool    // rom is valid
+}
+
+// NewResumeBus returns a ResumeBus wrapping b.
+func NewResumeBus(b Bus) *ResumeBus {
+	return &ResumeBus{bus: b}
+}
+
+func (r *ResumeBus) String() string {
+	return r.bus.String()
+}
+
+// Tx implements Bus.
+func (r *ResumeBus) Tx(w, rd []byte, power Pullup) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var rom [9]byte
+	addressed := false
+	switch {
+	case len(w) >= 9 && (w[0] == 0x55 || w[0] == 0x69):
+		// Match ROM or Overdrive Match ROM.
+		copy(rom[:], w)
+		addressed = true
+		if r.addressed && rom == r.rom {
+			ww := make([]byte, 1, len(w)-8)
+			ww[0] = 0xA5 // Resume
+			w = append(ww, w[9:]...)
+		}
+	case len(w) == 0 || w[0] == 0xA5:
+		// A reset or a Resume doesn't change the device addressed.
+		rom = r.rom
+		addressed = r.addressed
+	}
+	err := r.bus.Tx(w, rd, power)
+	r.rom = rom
+	r.addressed = addressed && err == nil
+	return err
+}
+
+// Search implements Bus.
+func (r *ResumeBus) Search(alarmOnly bool) ([]Address, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.addressed = false
+	return r.bus.Search(alarmOnly)
+}
+
+// SetOverdrive implements BusOverdriver.
+func (r *ResumeBus) SetOverdrive(on bool) error {
+	o, ok := r.bus.(BusOverdriver)
+	if !ok {
+		return errors.New("onewire: " + r.bus.String() + " doesn't support overdrive")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.addressed = false
+	return o.SetOverdrive(on)
+}
+
+var _ BusOverdriver = &ResumeBus{}
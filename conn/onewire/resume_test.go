@@ -0,0 +1,118 @@
+// Copyright 2019 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package onewire
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestResumeBus(t *testing.T) {
+	b := &overdriveBus{}
+	r := NewResumeBus(b)
+	if s := r.String(); s != "fake" {
+		t.Fatal(s)
+	}
+	a := Dev{Bus: r, Addr: 1}
+	c := Dev{Bus: r, Addr: 2}
+	matchA := []byte{0x55, 1, 0, 0, 0, 0, 0, 0, 0, 3}
+	matchC := []byte{0x55, 2, 0, 0, 0, 0, 0, 0, 0, 3}
+	resume := []byte{0xa5, 3}
+	data := []struct {
+		d        *Dev
+		expected []byte
+	}{
+		{&a, matchA},
+		{&a, resume},
+		// Another device was addressed in between.
+		{&c, matchC},
+		{&a, matchA},
+		{&a, resume},
+		{&a, resume},
+	}
+	for i, line := range data {
+		b.w = nil
+		if err := line.d.Tx([]byte{3}, nil); err != nil {
+			t.Fatal(i, err)
+		}
+		if !bytes.Equal(b.w, line.expected) {
+			t.Fatalf("#%d: %#v", i, b.w)
+		}
+	}
+
+	// Any other ROM command deselects the device.
+	for _, w := range [][]byte{{0xcc, 3}, {0x33}, {0x3c}} {
+		if err := r.Tx(w, nil, WeakPullup); err != nil {
+			t.Fatal(err)
+		}
+		b.w = nil
+		if err := a.Tx([]byte{3}, nil); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(b.w, matchA) {
+			t.Fatalf("%#v: %#v", w, b.w)
+		}
+	}
+
+	// A reset alone doesn't.
+	if err := r.Tx(nil, nil, WeakPullup); err != nil {
+		t.Fatal(err)
+	}
+	b.w = nil
+	if err := a.Tx([]byte{3}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(b.w, resume) {
+		t.Fatalf("%#v", b.w)
+	}
+
+	// Neither does a search, a speed change nor a failure.
+	if _, err := r.Search(false); err == nil {
+		t.Fatal("fakeBus doesn't implement Search")
+	}
+	b.w = nil
+	if err := a.Tx([]byte{3}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(b.w, matchA) {
+		t.Fatalf("%#v", b.w)
+	}
+	if err := r.SetOverdrive(false); err != nil {
+		t.Fatal(err)
+	}
+	b.w = nil
+	if err := a.Tx([]byte{3}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(b.w, matchA) {
+		t.Fatalf("%#v", b.w)
+	}
+	b.err = errors.New("fail")
+	if err := a.Tx([]byte{3}, nil); err != b.err {
+		t.Fatal(err)
+	}
+	b.err = nil
+	b.w = nil
+	if err := a.Tx([]byte{3}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(b.w, matchA) {
+		t.Fatalf("%#v", b.w)
+	}
+}
+
+func TestResumeBus_SetOverdrive(t *testing.T) {
+	if err := NewResumeBus(&fakeBus{}).SetOverdrive(true); err == nil {
+		t.Fatal("fakeBus doesn't support overdrive")
+	}
+	b := &overdriveBus{}
+	if err := OverdriveSkip(NewResumeBus(b)); err != nil {
+		t.Fatal(err)
+	}
+	if !b.overdrive {
+		t.Fatal("expected overdrive")
+	}
+}
@@ -18,4 +18,14 @@
 //    µ,u	micro	10⁻⁶  	0.000001
 //    n  	nano 	10⁻⁹  	0.000000001
 //    p  	pico 	10⁻¹² 	0.000000000001
+//
+// Encoding
+//
+// All units implement encoding.TextMarshaler and encoding.TextUnmarshaler, so
+// they are encoded without loss of precision as strings in JSON, e.g.
+// "25.3mA". MarshalText() returns an error for the few values that Set()
+// can't parse back, like the minimum int64 value or a negative Temperature.
+// Wrap a unit or an Env in SIFloat to encode floating point numbers in the SI
+// base unit instead; decoding a number below the resolution of the unit fails
+// instead of rounding it.
 package physic
@@ -5,6 +5,7 @@
 package physic_test
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
 	"log"
@@ -809,6 +810,26 @@ func ExampleRelativeHumidity_float64() {
 	// 80
 }
 
+func ExampleSIFloat() {
+	e := physic.Env{
+		Temperature: 25*physic.Celsius + physic.ZeroCelsius,
+		Pressure:    101325 * physic.Pascal,
+		Humidity:    452 * physic.MilliRH,
+	}
+	b, err := json.Marshal(e)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(string(b))
+	if b, err = json.Marshal(physic.SIFloat{e}); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(string(b))
+	// Output:
+	// {"Temperature":"25°C","Pressure":"101325Pa","Humidity":"45.2%rH"}
+	// {"Temperature":298.15,"Pressure":101325,"Humidity":45.2}
+}
+
 func ExampleSpeed() {
 	fmt.Println(10 * physic.MilliMetrePerSecond)
 	fmt.Println(physic.LightSpeed)
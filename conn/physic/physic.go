@@ -5,12 +5,19 @@
 package physic
 
 import (
+	"encoding/json"
+	"errors"
+	"reflect"
 	"time"
 
 	"periph.io/x/periph/conn"
 )
 
 // Env represents measurements from an environmental sensor.
+//
+// It is encoded in JSON as an object of strings, e.g.
+// {"Temperature":"25.3°C","Pressure":"101.325kPa","Humidity":"45.2%rH"}. Use
+// SIFloat to encode numbers instead.
 type Env struct {
 	Temperature Temperature
 	Pressure    Pressure
@@ -40,3 +47,89 @@ type SenseEnv interface {
 	// or doing oversampling in software. Refer to its datasheet if available.
 	Precision(env *Env)
 }
+
+// SIFloat wraps a physic unit or an Env so it is encoded in JSON as floating
+// point numbers in the SI base unit instead of strings, e.g. 0.0253 instead of
+// "25.3mA". This is the format expected by most time-series databases.
+//
+// Mass is in kilogram, Temperature in Kelvin, Angle in radian and
+// RelativeHumidity in percent. The conversion to float64 may lose precision.
+//
+// The UnmarshalJSON() method of every unit accepts both encodings, so there is
+// no need to wrap the value when decoding.
+type SIFloat struct {
+	V interface{}
+}
+
+// MarshalJSON implements json.Marshaler.
+func (s SIFloat) MarshalJSON() ([]byte, error) {
+	v := s.V
+	if r := reflect.ValueOf(v); r.Kind() == reflect.Ptr {
+		if r.IsNil() {
+			return []byte("null"), nil
+		}
+		v = r.Elem().Interface()
+	} else if v == nil {
+		return []byte("null"), nil
+	}
+	if e, ok := v.(Env); ok {
+		return json.Marshal(struct {
+			Temperature float64
+			Pressure    float64
+			Humidity    float64
+		}{
+			Temperature: float64(e.Temperature) / float64(Kelvin),
+			Pressure:    float64(e.Pressure) / float64(Pascal),
+			Humidity:    float64(e.Humidity) / float64(PercentRH),
+		})
+	}
+	f, ok := toSIFloat(v)
+	if !ok {
+		return nil, errors.New("physic: SIFloat can't encode " + reflect.TypeOf(v).String())
+	}
+	return json.Marshal(f)
+}
+
+//
+
+// toSIFloat returns the value of a physic unit in its SI base unit.
+func toSIFloat(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case Angle:
+		return float64(v) / float64(Radian), true
+	case Distance:
+		return float64(v) / float64(Metre), true
+	case ElectricCurrent:
+		return float64(v) / float64(Ampere), true
+	case ElectricPotential:
+		return float64(v) / float64(Volt), true
+	case ElectricResistance:
+		return float64(v) / float64(Ohm), true
+	case Force:
+		return float64(v) / float64(Newton), true
+	case Frequency:
+		return float64(v) / float64(Hertz), true
+	case Mass:
+		return float64(v) / float64(KiloGram), true
+	case Pressure:
+		return float64(v) / float64(Pascal), true
+	case RelativeHumidity:
+		return float64(v) / float64(PercentRH), true
+	case Speed:
+		return float64(v) / float64(MetrePerSecond), true
+	case Temperature:
+		return float64(v) / float64(Kelvin), true
+	case Power:
+		return float64(v) / float64(Watt), true
+	case Energy:
+		return float64(v) / float64(Joule), true
+	case ElectricalCapacitance:
+		return float64(v) / float64(Farad), true
+	case LuminousIntensity:
+		return float64(v) / float64(Candela), true
+	case LuminousFlux:
+		return float64(v) / float64(Lumen), true
+	default:
+		return 0, false
+	}
+}
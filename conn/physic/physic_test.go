@@ -0,0 +1,84 @@
+// Copyright 2019 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package physic
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestEnv_JSON(t *testing.T) {
+	e := Env{
+		Temperature: ZeroCelsius + 25300*MilliCelsius,
+		Pressure:    101325 * Pascal,
+		Humidity:    45*PercentRH + 2*MilliRH,
+	}
+	b, err := json.Marshal(&e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := string(b); s != `{"Temperature":"25.3°C","Pressure":"101325Pa","Humidity":"45.2%rH"}` {
+		t.Fatal(s)
+	}
+	var got Env
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got != e {
+		t.Fatal(got)
+	}
+}
+
+func TestSIFloat(t *testing.T) {
+	e := Env{
+		Temperature: ZeroCelsius + 25*Celsius,
+		Pressure:    101325 * Pascal,
+		Humidity:    45*PercentRH + 5*MilliRH,
+	}
+	data := []struct {
+		in       interface{}
+		expected string
+	}{
+		{e, `{"Temperature":298.15,"Pressure":101325,"Humidity":45.5}`},
+		{&e, `{"Temperature":298.15,"Pressure":101325,"Humidity":45.5}`},
+		{25300 * MicroAmpere, `0.0253`},
+		{250 * Gram, `0.25`},
+		{RPM, `0.016667`},
+		{470 * PicoFarad, `4.7e-10`},
+		{nil, `null`},
+		{(*Env)(nil), `null`},
+	}
+	for i, line := range data {
+		b, err := json.Marshal(SIFloat{line.in})
+		if err != nil {
+			t.Fatal(i, err)
+		}
+		if s := string(b); s != line.expected {
+			t.Fatalf("#%d: %s != %s", i, s, line.expected)
+		}
+	}
+	if _, err := json.Marshal(SIFloat{42}); err == nil {
+		t.Fatal("int is not a physic unit")
+	}
+}
+
+func TestSIFloat_RoundTrip(t *testing.T) {
+	e := Env{
+		Temperature: ZeroCelsius + 25*Celsius,
+		Pressure:    101325 * Pascal,
+		Humidity:    45*PercentRH + 5*MilliRH,
+	}
+	b, err := json.Marshal(SIFloat{e})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got Env
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got != e {
+		t.Fatal(got)
+	}
+}
@@ -5,7 +5,9 @@
 package physic
 
 import (
+	"encoding/json"
 	"errors"
+	"math"
 	"strconv"
 	"strings"
 	"time"
@@ -134,6 +136,27 @@ func (a *Angle) Set(s string) error {
 	return nil
 }
 
+// MarshalText implements encoding.TextMarshaler.
+//
+// The value is in radian instead of degree, as the conversion to degree is
+// not exact.
+func (a Angle) MarshalText() ([]byte, error) {
+	return marshalText(int64(a), 9, "rad")
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (a *Angle) UnmarshalText(b []byte) error {
+	return a.Set(string(b))
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+//
+// It accepts a string as encoded by MarshalText() or a number in radian as
+// encoded by SIFloat.
+func (a *Angle) UnmarshalJSON(b []byte) error {
+	return unmarshalJSON(b, "rad", 9, a.Set)
+}
+
 // Well known Angle constants.
 const (
 	NanoRadian  Angle = 1
@@ -268,6 +291,27 @@ func (d *Distance) Set(s string) error {
 	return nil
 }
 
+// MarshalText implements encoding.TextMarshaler.
+//
+// Unlike String(), the value is not rounded so it can be parsed back with
+// UnmarshalText().
+func (d Distance) MarshalText() ([]byte, error) {
+	return marshalText(int64(d), 9, "m")
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (d *Distance) UnmarshalText(b []byte) error {
+	return d.Set(string(b))
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+//
+// It accepts a string as encoded by MarshalText() or a number in metre as
+// encoded by SIFloat.
+func (d *Distance) UnmarshalJSON(b []byte) error {
+	return unmarshalJSON(b, "m", 9, d.Set)
+}
+
 // Well known Distance constants.
 const (
 	NanoMetre  Distance = 1
@@ -347,6 +391,27 @@ func (c *ElectricCurrent) Set(s string) error {
 	return nil
 }
 
+// MarshalText implements encoding.TextMarshaler.
+//
+// Unlike String(), the value is not rounded so it can be parsed back with
+// UnmarshalText().
+func (c ElectricCurrent) MarshalText() ([]byte, error) {
+	return marshalText(int64(c), 9, "A")
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (c *ElectricCurrent) UnmarshalText(b []byte) error {
+	return c.Set(string(b))
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+//
+// It accepts a string as encoded by MarshalText() or a number in Ampere as
+// encoded by SIFloat.
+func (c *ElectricCurrent) UnmarshalJSON(b []byte) error {
+	return unmarshalJSON(b, "A", 9, c.Set)
+}
+
 // Well known ElectricCurrent constants.
 const (
 	NanoAmpere  ElectricCurrent = 1
@@ -407,6 +472,27 @@ func (p *ElectricPotential) Set(s string) error {
 	return nil
 }
 
+// MarshalText implements encoding.TextMarshaler.
+//
+// Unlike String(), the value is not rounded so it can be parsed back with
+// UnmarshalText().
+func (p ElectricPotential) MarshalText() ([]byte, error) {
+	return marshalText(int64(p), 9, "V")
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (p *ElectricPotential) UnmarshalText(b []byte) error {
+	return p.Set(string(b))
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+//
+// It accepts a string as encoded by MarshalText() or a number in Volt as
+// encoded by SIFloat.
+func (p *ElectricPotential) UnmarshalJSON(b []byte) error {
+	return unmarshalJSON(b, "V", 9, p.Set)
+}
+
 // Well known ElectricPotential constants.
 const (
 	// Volt is W/A, kg⋅m²/s³/A.
@@ -469,6 +555,27 @@ func (r *ElectricResistance) Set(s string) error {
 	return nil
 }
 
+// MarshalText implements encoding.TextMarshaler.
+//
+// Unlike String(), the value is not rounded so it can be parsed back with
+// UnmarshalText().
+func (r ElectricResistance) MarshalText() ([]byte, error) {
+	return marshalText(int64(r), 9, "Ω")
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (r *ElectricResistance) UnmarshalText(b []byte) error {
+	return r.Set(string(b))
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+//
+// It accepts a string as encoded by MarshalText() or a number in Ohm as
+// encoded by SIFloat.
+func (r *ElectricResistance) UnmarshalJSON(b []byte) error {
+	return unmarshalJSON(b, "Ω", 9, r.Set)
+}
+
 // Well known ElectricResistance constants.
 const (
 	// Ohm is V/A, kg⋅m²/s³/A².
@@ -574,6 +681,27 @@ func (f *Force) Set(s string) error {
 	return nil
 }
 
+// MarshalText implements encoding.TextMarshaler.
+//
+// Unlike String(), the value is not rounded so it can be parsed back with
+// UnmarshalText().
+func (f Force) MarshalText() ([]byte, error) {
+	return marshalText(int64(f), 9, "N")
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (f *Force) UnmarshalText(b []byte) error {
+	return f.Set(string(b))
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+//
+// It accepts a string as encoded by MarshalText() or a number in Newton as
+// encoded by SIFloat.
+func (f *Force) UnmarshalJSON(b []byte) error {
+	return unmarshalJSON(b, "N", 9, f.Set)
+}
+
 // Well known Force constants.
 const (
 	// Newton is kg⋅m/s².
@@ -643,6 +771,27 @@ func (f *Frequency) Set(s string) error {
 	return nil
 }
 
+// MarshalText implements encoding.TextMarshaler.
+//
+// Unlike String(), the value is not rounded so it can be parsed back with
+// UnmarshalText().
+func (f Frequency) MarshalText() ([]byte, error) {
+	return marshalText(int64(f), 6, "Hz")
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (f *Frequency) UnmarshalText(b []byte) error {
+	return f.Set(string(b))
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+//
+// It accepts a string as encoded by MarshalText() or a number in Hertz as
+// encoded by SIFloat.
+func (f *Frequency) UnmarshalJSON(b []byte) error {
+	return unmarshalJSON(b, "Hz", 6, f.Set)
+}
+
 // Period returns the duration of one cycle at this frequency.
 //
 // Frequency above GigaHertz cannot be represented as Duration.
@@ -794,6 +943,27 @@ func (m *Mass) Set(s string) error {
 	return nil
 }
 
+// MarshalText implements encoding.TextMarshaler.
+//
+// Unlike String(), the value is not rounded so it can be parsed back with
+// UnmarshalText().
+func (m Mass) MarshalText() ([]byte, error) {
+	return marshalText(int64(m), 9, "g")
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (m *Mass) UnmarshalText(b []byte) error {
+	return m.Set(string(b))
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+//
+// It accepts a string as encoded by MarshalText() or a number in kilogram as
+// encoded by SIFloat.
+func (m *Mass) UnmarshalJSON(b []byte) error {
+	return unmarshalJSON(b, "kg", 12, m.Set)
+}
+
 // Well known Mass constants.
 const (
 	NanoGram  Mass = 1
@@ -875,6 +1045,27 @@ func (p *Pressure) Set(s string) error {
 	return nil
 }
 
+// MarshalText implements encoding.TextMarshaler.
+//
+// Unlike String(), the value is not rounded so it can be parsed back with
+// UnmarshalText().
+func (p Pressure) MarshalText() ([]byte, error) {
+	return marshalText(int64(p), 9, "Pa")
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (p *Pressure) UnmarshalText(b []byte) error {
+	return p.Set(string(b))
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+//
+// It accepts a string as encoded by MarshalText() or a number in Pascal as
+// encoded by SIFloat.
+func (p *Pressure) UnmarshalJSON(b []byte) error {
+	return unmarshalJSON(b, "Pa", 9, p.Set)
+}
+
 // Well known Pressure constants.
 const (
 	// Pascal is N/m², kg/m/s².
@@ -955,6 +1146,33 @@ func (r *RelativeHumidity) Set(s string) error {
 	return nil
 }
 
+// MarshalText implements encoding.TextMarshaler.
+//
+// Unlike String(), the value is not rounded so it can be parsed back with
+// UnmarshalText().
+func (r RelativeHumidity) MarshalText() ([]byte, error) {
+	switch {
+	case r > maxRelativeHumidity:
+		return nil, maxValueErr(maxRelativeHumidity.String())
+	case r < minRelativeHumidity:
+		return nil, minValueErr(minRelativeHumidity.String())
+	}
+	return marshalText(int64(r), 5, "%rH")
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (r *RelativeHumidity) UnmarshalText(b []byte) error {
+	return r.Set(string(b))
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+//
+// It accepts a string as encoded by MarshalText() or a number in percent as
+// encoded by SIFloat.
+func (r *RelativeHumidity) UnmarshalJSON(b []byte) error {
+	return unmarshalJSON(b, "%rH", 5, r.Set)
+}
+
 // Well known RelativeHumidity constants.
 const (
 	TenthMicroRH RelativeHumidity = 1                 // 0.00001%rH
@@ -1091,6 +1309,27 @@ func (sp *Speed) Set(s string) error {
 	return nil
 }
 
+// MarshalText implements encoding.TextMarshaler.
+//
+// Unlike String(), the value is not rounded so it can be parsed back with
+// UnmarshalText().
+func (sp Speed) MarshalText() ([]byte, error) {
+	return marshalText(int64(sp), 9, "m/s")
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (sp *Speed) UnmarshalText(b []byte) error {
+	return sp.Set(string(b))
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+//
+// It accepts a string as encoded by MarshalText() or a number in m/s as
+// encoded by SIFloat.
+func (sp *Speed) UnmarshalJSON(b []byte) error {
+	return unmarshalJSON(b, "m/s", 9, sp.Set)
+}
+
 // Well known Speed constants.
 const (
 	// MetrePerSecond is m/s.
@@ -1238,6 +1477,33 @@ func (t *Temperature) Set(s string) error {
 	return nil
 }
 
+// MarshalText implements encoding.TextMarshaler.
+//
+// Unlike String(), the value is not rounded so it can be parsed back with
+// UnmarshalText().
+func (t Temperature) MarshalText() ([]byte, error) {
+	if t < 0 {
+		return nil, minValueErr("0K")
+	}
+	if t > maxCelsius {
+		return marshalText(int64(t), 9, "K")
+	}
+	return []byte(fixedAsString(int64(t-ZeroCelsius), 9) + "°C"), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (t *Temperature) UnmarshalText(b []byte) error {
+	return t.Set(string(b))
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+//
+// It accepts a string as encoded by MarshalText() or a number in Kelvin as
+// encoded by SIFloat.
+func (t *Temperature) UnmarshalJSON(b []byte) error {
+	return unmarshalJSON(b, "K", 9, t.Set)
+}
+
 // Celsius returns the temperature as a floating number of °Celsius.
 func (t Temperature) Celsius() float64 {
 	return float64(t-ZeroCelsius) / float64(Celsius)
@@ -1325,6 +1591,27 @@ func (p *Power) Set(s string) error {
 	return nil
 }
 
+// MarshalText implements encoding.TextMarshaler.
+//
+// Unlike String(), the value is not rounded so it can be parsed back with
+// UnmarshalText().
+func (p Power) MarshalText() ([]byte, error) {
+	return marshalText(int64(p), 9, "W")
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (p *Power) UnmarshalText(b []byte) error {
+	return p.Set(string(b))
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+//
+// It accepts a string as encoded by MarshalText() or a number in watts as
+// encoded by SIFloat.
+func (p *Power) UnmarshalJSON(b []byte) error {
+	return unmarshalJSON(b, "W", 9, p.Set)
+}
+
 // Well known Power constants.
 const (
 	// Watt is unit of power J/s, kg⋅m²⋅s⁻³
@@ -1387,6 +1674,27 @@ func (e *Energy) Set(s string) error {
 	return nil
 }
 
+// MarshalText implements encoding.TextMarshaler.
+//
+// Unlike String(), the value is not rounded so it can be parsed back with
+// UnmarshalText().
+func (e Energy) MarshalText() ([]byte, error) {
+	return marshalText(int64(e), 9, "J")
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (e *Energy) UnmarshalText(b []byte) error {
+	return e.Set(string(b))
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+//
+// It accepts a string as encoded by MarshalText() or a number in joules as
+// encoded by SIFloat.
+func (e *Energy) UnmarshalJSON(b []byte) error {
+	return unmarshalJSON(b, "J", 9, e.Set)
+}
+
 // Well known Energy constants.
 const (
 	// Joule is a unit of work. kg⋅m²⋅s⁻²
@@ -1457,6 +1765,27 @@ func (c *ElectricalCapacitance) Set(s string) error {
 	return nil
 }
 
+// MarshalText implements encoding.TextMarshaler.
+//
+// Unlike String(), the value is not rounded so it can be parsed back with
+// UnmarshalText().
+func (c ElectricalCapacitance) MarshalText() ([]byte, error) {
+	return marshalText(int64(c), 12, "F")
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (c *ElectricalCapacitance) UnmarshalText(b []byte) error {
+	return c.Set(string(b))
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+//
+// It accepts a string as encoded by MarshalText() or a number in Farad as
+// encoded by SIFloat.
+func (c *ElectricalCapacitance) UnmarshalJSON(b []byte) error {
+	return unmarshalJSON(b, "F", 12, c.Set)
+}
+
 // Well known ElectricalCapacitance constants.
 const (
 	// Farad is a unit of capacitance. kg⁻¹⋅m⁻²⋅s⁴A²
@@ -1526,6 +1855,27 @@ func (i *LuminousIntensity) Set(s string) error {
 	return nil
 }
 
+// MarshalText implements encoding.TextMarshaler.
+//
+// Unlike String(), the value is not rounded so it can be parsed back with
+// UnmarshalText().
+func (i LuminousIntensity) MarshalText() ([]byte, error) {
+	return marshalText(int64(i), 9, "cd")
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (i *LuminousIntensity) UnmarshalText(b []byte) error {
+	return i.Set(string(b))
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+//
+// It accepts a string as encoded by MarshalText() or a number in candela as
+// encoded by SIFloat.
+func (i *LuminousIntensity) UnmarshalJSON(b []byte) error {
+	return unmarshalJSON(b, "cd", 9, i.Set)
+}
+
 // Well known LuminousIntensity constants.
 const (
 	// Candela is a unit of luminous intensity. cd
@@ -1593,6 +1943,27 @@ func (f *LuminousFlux) Set(s string) error {
 	return nil
 }
 
+// MarshalText implements encoding.TextMarshaler.
+//
+// Unlike String(), the value is not rounded so it can be parsed back with
+// UnmarshalText().
+func (f LuminousFlux) MarshalText() ([]byte, error) {
+	return marshalText(int64(f), 9, "lm")
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (f *LuminousFlux) UnmarshalText(b []byte) error {
+	return f.Set(string(b))
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+//
+// It accepts a string as encoded by MarshalText() or a number in lumen as
+// encoded by SIFloat.
+func (f *LuminousFlux) UnmarshalJSON(b []byte) error {
+	return unmarshalJSON(b, "lm", 9, f.Set)
+}
+
 // Well known LuminousFlux constants.
 const (
 	// Lumen is a unit of luminous flux. cd⋅sr
@@ -1855,6 +2226,63 @@ func picoAsString(v int64) string {
 	return sign + strconv.Itoa(base) + "." + prefixZeros(3, frac) + unit
 }
 
+// fixedAsString returns v divided by 10^digits, without rounding. Trailing
+// zeros of the fractional part are trimmed.
+func fixedAsString(v int64, digits int) string {
+	sign := ""
+	u := uint64(v)
+	if v < 0 {
+		sign = "-"
+		u = uint64(-v)
+	}
+	s := strconv.FormatUint(u, 10)
+	if len(s) <= digits {
+		s = strings.Repeat("0", digits-len(s)+1) + s
+	}
+	i := len(s) - digits
+	if f := strings.TrimRight(s[i:], "0"); f != "" {
+		return sign + s[:i] + "." + f
+	}
+	return sign + s[:i]
+}
+
+// marshalText returns v as a fixed point number with digits decimals followed
+// by unit.
+//
+// math.MinInt64 is rejected since it can't be parsed back.
+func marshalText(v int64, digits int, unit string) ([]byte, error) {
+	if v == math.MinInt64 {
+		return nil, minValueErr(fixedAsString(v+1, digits) + unit)
+	}
+	return []byte(fixedAsString(v, digits) + unit), nil
+}
+
+// unmarshalJSON decodes b, which is either a JSON string or a JSON number, and
+// passes it to set. suffix is the unit of the number and digits the number of
+// decimals it supports; a number with more decimals is rejected instead of
+// being rounded.
+func unmarshalJSON(b []byte, suffix string, digits int, set func(s string) error) error {
+	if len(b) != 0 && b[0] == '"' {
+		var s string
+		if err := json.Unmarshal(b, &s); err != nil {
+			return err
+		}
+		return set(s)
+	}
+	if string(b) == "null" {
+		return nil
+	}
+	f, err := strconv.ParseFloat(string(b), 64)
+	if err != nil {
+		return errors.New("not a JSON string or number")
+	}
+	s := strconv.FormatFloat(f, 'f', -1, 64)
+	if i := strings.IndexByte(s, '.'); i != -1 && len(s)-i-1 > digits {
+		return errors.New("resolution is " + fixedAsString(1, digits) + suffix)
+	}
+	return set(s + suffix)
+}
+
 // Decimal is the representation of decimal number.
 type decimal struct {
 	// base hold the significant digits.
@@ -6,8 +6,12 @@ package physic
 
 import (
 	"bytes"
+	"encoding"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
+	"reflect"
 	"strconv"
 	"testing"
 	"time"
@@ -3658,6 +3662,125 @@ func TestLuminousFlux_RoundTrip(t *testing.T) {
 	}
 }
 
+func TestMarshalText(t *testing.T) {
+	data := []struct {
+		in       encoding.TextMarshaler
+		out      encoding.TextUnmarshaler
+		expected string
+	}{
+		{Angle(0), new(Angle), "0rad"},
+		{123 * Degree, new(Angle), "2.146755039rad"},
+		{-Pi, new(Angle), "-3.141592653rad"},
+		{1234567 * NanoMetre, new(Distance), "0.001234567m"},
+		{-1500 * Metre, new(Distance), "-1500m"},
+		{25300 * MicroAmpere, new(ElectricCurrent), "0.0253A"},
+		{maxElectricPotential, new(ElectricPotential), "9223372036.854775807V"},
+		{minElectricPotential, new(ElectricPotential), "-9223372036.854775807V"},
+		{4700 * Ohm, new(ElectricResistance), "4700Ω"},
+		{Force(1), new(Force), "0.000000001N"},
+		{RPM, new(Frequency), "0.016667Hz"},
+		{1500 * Gram, new(Mass), "1500g"},
+		{101325 * Pascal, new(Pressure), "101325Pa"},
+		{45*PercentRH + 2*MilliRH + 3*TenthMicroRH, new(RelativeHumidity), "45.20003%rH"},
+		{1500 * MilliMetrePerSecond, new(Speed), "1.5m/s"},
+		{ZeroCelsius + 25*Celsius, new(Temperature), "25°C"},
+		{ZeroCelsius - 500*MilliCelsius, new(Temperature), "-0.5°C"},
+		{Temperature(0), new(Temperature), "-273.15°C"},
+		{maxTemperature, new(Temperature), "9223372036.854775807K"},
+		{Power(3000000), new(Power), "0.003W"},
+		{Joule, new(Energy), "1J"},
+		{470 * PicoFarad, new(ElectricalCapacitance), "0.00000000047F"},
+		{Candela, new(LuminousIntensity), "1cd"},
+		{800 * Lumen, new(LuminousFlux), "800lm"},
+	}
+	for i, line := range data {
+		b, err := line.in.MarshalText()
+		if err != nil {
+			t.Fatal(i, err)
+		}
+		if s := string(b); s != line.expected {
+			t.Fatalf("#%d: %s != %s", i, s, line.expected)
+		}
+		if err := line.out.UnmarshalText(b); err != nil {
+			t.Fatal(i, err)
+		}
+		if v := reflect.ValueOf(line.out).Elem().Interface(); v != line.in {
+			t.Fatalf("#%d: %v != %v", i, v, line.in)
+		}
+	}
+}
+
+func TestMarshalText_err(t *testing.T) {
+	data := []encoding.TextMarshaler{
+		ElectricCurrent(math.MinInt64),
+		Distance(math.MinInt64),
+		Frequency(math.MinInt64),
+		Temperature(-1),
+		RelativeHumidity(-1),
+		maxRelativeHumidity + 1,
+	}
+	for i, line := range data {
+		if b, err := line.MarshalText(); err == nil {
+			t.Fatalf("#%d: expected error, got %q", i, b)
+		}
+	}
+}
+
+func TestUnmarshalJSON(t *testing.T) {
+	data := []struct {
+		in       string
+		out      json.Unmarshaler
+		expected interface{}
+	}{
+		{`"25mA"`, new(ElectricCurrent), 25 * MilliAmpere},
+		{`0.0253`, new(ElectricCurrent), 25300 * MicroAmpere},
+		{`1.5`, new(Distance), 1500 * MilliMetre},
+		{`1e3`, new(Frequency), KiloHertz},
+		{`0.25`, new(Mass), 250 * Gram},
+		{`45.2`, new(RelativeHumidity), 45*PercentRH + 2*MilliRH},
+		{`298.15`, new(Temperature), ZeroCelsius + 25*Celsius},
+		{`-1.5`, new(Angle), -1500 * MilliRadian},
+		{`null`, new(Pressure), Pressure(0)},
+		{`1e-9`, new(ElectricCurrent), NanoAmpere},
+		{`0.000001`, new(Frequency), MicroHertz},
+		{`1e-12`, new(Mass), NanoGram},
+		{`4.7e-11`, new(ElectricalCapacitance), 47 * PicoFarad},
+	}
+	for i, line := range data {
+		if err := line.out.UnmarshalJSON([]byte(line.in)); err != nil {
+			t.Fatal(i, err)
+		}
+		if v := reflect.ValueOf(line.out).Elem().Interface(); v != line.expected {
+			t.Fatalf("#%d: %v != %v", i, v, line.expected)
+		}
+	}
+}
+
+func TestUnmarshalJSON_err(t *testing.T) {
+	data := []struct {
+		in  string
+		out json.Unmarshaler
+	}{
+		{`true`, new(ElectricCurrent)},
+		{`"25"`, new(ElectricCurrent)},
+		{`"25mA`, new(ElectricCurrent)},
+		{`"\u00b0C"`, new(Temperature)},
+		{`-1`, new(Temperature)},
+		{`101`, new(RelativeHumidity)},
+		{`1e30`, new(Pressure)},
+		// Below the resolution of the unit.
+		{`1e-12`, new(ElectricCurrent)},
+		{`1.0000000001`, new(Distance)},
+		{`1e-7`, new(Frequency)},
+		{`0.000001`, new(RelativeHumidity)},
+	}
+	for i, line := range data {
+		if err := line.out.UnmarshalJSON([]byte(line.in)); err == nil {
+			t.Fatalf("#%d: expected error", i)
+		}
+	}
+}
+
 // Benchmarks
 
 func BenchmarkDecimal(b *testing.B) {
@@ -0,0 +1,91 @@
+// Copyright 2019 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package spi
+
+import (
+	"strconv"
+	"strings"
+
+	"periph.io/x/periph/conn/physic"
+)
+
+// Caps describes what a SPI port supports.
+//
+// Mode0 to Mode3 are always supported.
+type Caps struct {
+	// Mode is the set of optional flags supported: HalfDuplex, NoCS and
+	// LSBFirst.
+	Mode Mode
+	// CSHigh is true if the controller can drive the CS line active high. It
+	// can be false when unknown.
+	CSHigh bool
+	// MaxSpeed is the maximum clock speed. 0 if unknown.
+	MaxSpeed physic.Frequency
+	// BitsPerWord is the set of supported word sizes; bit n-1 is set when n
+	// bits per word is supported.
+	BitsPerWord uint32
+}
+
+// SupportsBits returns true if words of the specified number of bits are
+// supported.
+func (c *Caps) SupportsBits(bits int) bool {
+	return bits >= 1 && bits <= 32 && c.BitsPerWord&(1<<uint(bits-1)) != 0
+}
+
+// Supports returns true if Connect() can be called with mode and bits.
+func (c *Caps) Supports(mode Mode, bits int) bool {
+	return mode&^(Mode3|c.Mode) == 0 && c.SupportsBits(bits)
+}
+
+func (c *Caps) String() string {
+	var out []string
+	m := c.Mode & (HalfDuplex | NoCS | LSBFirst)
+	if m != 0 {
+		// Trim the "Mode0|" prefix.
+		out = append(out, m.String()[6:])
+	}
+	if c.CSHigh {
+		out = append(out, "CSHigh")
+	}
+	if c.MaxSpeed != 0 {
+		out = append(out, "max "+c.MaxSpeed.String())
+	}
+	if c.BitsPerWord != 0 {
+		out = append(out, "bits "+bitsString(c.BitsPerWord))
+	}
+	return strings.Join(out, ", ")
+}
+
+// Capabilities is implemented by a Port that can report what it supports.
+//
+// It lets a device driver select a mode, word size and speed that works with
+// the port instead of relying on Connect() to fail.
+type Capabilities interface {
+	// Caps returns the capabilities of the port.
+	Caps() (Caps, error)
+}
+
+//
+
+// bitsString formats a set of word sizes, like "4-16,32".
+func bitsString(b uint32) string {
+	var out []string
+	for i := 1; i <= 32; i++ {
+		if b&(1<<uint(i-1)) == 0 {
+			continue
+		}
+		j := i
+		for j < 32 && b&(1<<uint(j)) != 0 {
+			j++
+		}
+		if j == i {
+			out = append(out, strconv.Itoa(i))
+		} else {
+			out = append(out, strconv.Itoa(i)+"-"+strconv.Itoa(j))
+		}
+		i = j
+	}
+	return strings.Join(out, ",")
+}
@@ -6,6 +6,8 @@ package spi
 
 import (
 	"testing"
+
+	"periph.io/x/periph/conn/physic"
 )
 
 func TestMode_String(t *testing.T) {
@@ -22,3 +24,20 @@ func TestMode_String(t *testing.T) {
 		t.Fatal(s)
 	}
 }
+
+func TestCaps(t *testing.T) {
+	c := Caps{Mode: NoCS | LSBFirst, CSHigh: true, MaxSpeed: 10 * physic.MegaHertz, BitsPerWord: 0x8000FF88}
+	if s := c.String(); s != "NoCS|LSBFirst, CSHigh, max 10MHz, bits 4,8-16,32" {
+		t.Fatal(s)
+	}
+	if !c.Supports(Mode3|NoCS, 8) || !c.Supports(Mode0, 32) {
+		t.Fatal("expected supported")
+	}
+	if c.Supports(Mode0|HalfDuplex, 8) || c.Supports(Mode0, 5) || c.SupportsBits(0) || c.SupportsBits(33) {
+		t.Fatal("expected unsupported")
+	}
+	c = Caps{}
+	if s := c.String(); s != "" {
+		t.Fatal(s)
+	}
+}
@@ -0,0 +1,34 @@
+// Copyright 2019 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package spitest
+
+import (
+	"io"
+
+	"periph.io/x/periph/conn/conntest"
+)
+
+// Save writes the recorded operations as a capture file.
+//
+// See conntest.CaptureHeader for the file format.
+func (r *Record) Save(w io.Writer) error {
+	r.Lock()
+	defer r.Unlock()
+	return conntest.SaveOps(w, "spi", r.Ops)
+}
+
+// Load replaces the operations to play back with the ones in a capture file
+// written by Record.Save().
+func (p *Playback) Load(r io.Reader) error {
+	ops, err := conntest.LoadOps(r, "spi")
+	if err != nil {
+		return err
+	}
+	p.Lock()
+	defer p.Unlock()
+	p.Ops = ops
+	p.Count = 0
+	return nil
+}
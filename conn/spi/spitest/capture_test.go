@@ -0,0 +1,44 @@
+// Copyright 2019 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package spitest
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"periph.io/x/periph/conn/conntest"
+	"periph.io/x/periph/conn/physic"
+)
+
+func TestRecord_Save_Playback_Load(t *testing.T) {
+	r := Record{Port: &Playback{Playback: conntest.Playback{Ops: []conntest.IO{{W: []byte{0x80, 0}, R: []byte{0, 0x42}}}}}}
+	c, err := r.Connect(physic.MegaHertz, 0, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Tx([]byte{0x80, 0}, make([]byte, 2)); err != nil {
+		t.Fatal(err)
+	}
+	buf := bytes.Buffer{}
+	if err := r.Save(&buf); err != nil {
+		t.Fatal(err)
+	}
+	expected := "{\"format\":\"periph-capture\",\"version\":1,\"bus\":\"spi\"}\n{\"w\":\"8000\",\"r\":\"0042\"}\n"
+	if s := buf.String(); s != expected {
+		t.Fatal(s)
+	}
+	p := Playback{}
+	if err := p.Load(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(p.Ops, r.Ops) {
+		t.Fatal(p.Ops)
+	}
+	if err := p.Load(strings.NewReader("{\"format\":\"periph-capture\",\"version\":1,\"bus\":\"i2c\"}\n")); err == nil {
+		t.Fatal("wrong bus")
+	}
+}
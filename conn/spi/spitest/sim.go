@@ -0,0 +1,321 @@
+// Copyright 2019 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package spitest
+
+import (
+	"sync"
+
+	"periph.io/x/periph/conn"
+	"periph.io/x/periph/conn/conntest"
+	"periph.io/x/periph/conn/physic"
+	"periph.io/x/periph/conn/spi"
+)
+
+// Frame is the state of the port when a byte is clocked.
+type Frame struct {
+	// Mode, BitsPerWord and Freq are the parameters the connection was
+	// established with. BitsPerWord is overridden by spi.Packet.BitsPerWord
+	// when set.
+	Mode        spi.Mode
+	BitsPerWord int
+	Freq        physic.Frequency
+	// Index is the position of the byte since CS was asserted.
+	Index int
+	// Packet is the index of the spi.Packet in the current transaction, e.g.
+	// since CS was asserted, and Offset is the position of the byte in this
+	// packet. They can be used to detect KeepCS packet boundaries.
+	Packet int
+	Offset int
+}
+
+// Device is a simulated SPI device connected to a Sim.
+type Device interface {
+	// CS is called when the chip select line is asserted (true) or released
+	// (false). It is not called when the connection uses spi.NoCS.
+	CS(asserted bool)
+	// Transfer is called for each byte clocked. w is the byte sent by the
+	// master on MOSI, 0 when the master is only reading. The returned byte is
+	// sent on MISO; it is ignored when the master is only writing.
+	Transfer(f *Frame, w byte) byte
+}
+
+// Fault is an error to inject in a transfer with Sim.Inject().
+type Fault int
+
+const (
+	// FaultCRC flips all the bits of the last byte read in the transfer, as
+	// caused by noise on MISO. A checksum verified by the driver fails.
+	FaultCRC Fault = iota + 1
+	// FaultShortRead stops the transfer after half of the bytes; the device
+	// doesn't see the remaining bytes, the remaining bytes read are left
+	// untouched and the transfer returns an error.
+	FaultShortRead
+	// FaultTimeout fails the transfer without clocking any byte. The error
+	// returned implements Timeout() bool.
+	FaultTimeout
+)
+
+// Sim implements spi.PortCloser by connecting it to a simulated Device.
+//
+// Unlike Playback which expects an exact sequence of transfers, Sim lets the
+// device model react to each byte, so a driver can be tested against the
+// device behavior.
+type Sim struct {
+	Device Device
+	// Features is returned by Caps() and Connect() fails for a mode or word
+	// size that is not supported. If zero, all the modes and 1 to 32 bits per
+	// word are supported.
+	Features spi.Caps
+
+	mu          sync.Mutex
+	initialized bool
+	limit       physic.Frequency
+	f           Frame
+	cs          bool
+	faults      []Fault
+}
+
+func (s *Sim) String() string {
+	return "sim"
+}
+
+// Close implements spi.PortCloser.
+//
+// It releases the CS line if it was kept asserted.
+func (s *Sim) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.release()
+	return nil
+}
+
+// LimitSpeed implements spi.PortCloser.
+func (s *Sim) LimitSpeed(f physic.Frequency) error {
+	if f <= 0 {
+		return conntest.Errorf("spitest: invalid speed %s", f)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.limit = f
+	if s.f.Freq > f {
+		s.f.Freq = f
+	}
+	return nil
+}
+
+// Connect implements spi.PortCloser.
+func (s *Sim) Connect(f physic.Frequency, mode spi.Mode, bits int) (spi.Conn, error) {
+	if f < 0 {
+		return nil, conntest.Errorf("spitest: invalid speed %s", f)
+	}
+	if mode&^(spi.Mode3|spi.HalfDuplex|spi.NoCS|spi.LSBFirst) != 0 {
+		return nil, conntest.Errorf("spitest: invalid mode %s", mode)
+	}
+	if bits < 1 || bits > 32 {
+		return nil, conntest.Errorf("spitest: invalid bits %d", bits)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.Device == nil {
+		return nil, conntest.Errorf("spitest: Device is required")
+	}
+	if s.initialized {
+		return nil, conntest.Errorf("spitest: Connect cannot be called twice")
+	}
+	c := features(s.Features)
+	if !c.Supports(mode, bits) {
+		return nil, conntest.Errorf("spitest: %s with %d bits per word is not supported", mode, bits)
+	}
+	if c.MaxSpeed != 0 && (f == 0 || f > c.MaxSpeed) {
+		f = c.MaxSpeed
+	}
+	s.initialized = true
+	if s.limit != 0 && (f == 0 || f > s.limit) {
+		f = s.limit
+	}
+	s.f = Frame{Mode: mode, BitsPerWord: bits, Freq: f}
+	return &simConn{s}, nil
+}
+
+// Caps implements spi.Capabilities.
+func (s *Sim) Caps() (spi.Caps, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return features(s.Features), nil
+}
+
+// Inject queues a fault to be injected in the next transfer. Each call to
+// Tx() or TxPackets() consumes one queued fault.
+func (s *Sim) Inject(f Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = append(s.faults, f)
+}
+
+// CSAsserted returns true if the chip select line is currently asserted,
+// for example after a packet with KeepCS set.
+func (s *Sim) CSAsserted() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cs
+}
+
+//
+
+type simConn struct {
+	s *Sim
+}
+
+func (c *simConn) String() string {
+	return c.s.String()
+}
+
+func (c *simConn) Duplex() conn.Duplex {
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+	if c.s.f.Mode&spi.HalfDuplex != 0 {
+		return conn.Half
+	}
+	return conn.Full
+}
+
+func (c *simConn) Tx(w, r []byte) error {
+	p := []spi.Packet{{W: w, R: r}}
+	c.s.mu.Lock()
+	half := c.s.f.Mode&spi.HalfDuplex != 0
+	c.s.mu.Unlock()
+	if half && len(w) != 0 && len(r) != 0 {
+		// Same as the sysfs driver: one write then one read.
+		p = []spi.Packet{{W: w, KeepCS: true}, {R: r}}
+	}
+	return c.TxPackets(p)
+}
+
+func (c *simConn) TxPackets(p []spi.Packet) error {
+	return c.s.txPackets(p)
+}
+
+// txPackets clocks the packets through the device.
+func (s *Sim) txPackets(p []spi.Packet) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	total := 0
+	for i := range p {
+		lW := len(p[i].W)
+		lR := len(p[i].R)
+		if lW != lR && lW != 0 && lR != 0 {
+			return conntest.Errorf("spitest: when both w and r are used, they must be the same size; got %d and %d bytes", lW, lR)
+		}
+		if s.f.Mode&spi.HalfDuplex != 0 && lW != 0 && lR != 0 {
+			return conntest.Errorf("spitest: can't read and write in the same packet in half duplex")
+		}
+		total += max(lW, lR)
+	}
+	if total == 0 {
+		return conntest.Errorf("spitest: empty packets")
+	}
+	var fault Fault
+	if len(s.faults) != 0 {
+		fault = s.faults[0]
+		s.faults = s.faults[1:]
+	}
+	if fault == FaultTimeout {
+		s.release()
+		return timeoutError{}
+	}
+	stop := total
+	if fault == FaultShortRead {
+		stop = total / 2
+	}
+	// Last byte read, to be corrupted by FaultCRC.
+	var last *byte
+	done := 0
+	for i := range p {
+		if !s.cs {
+			s.cs = true
+			s.f.Index = 0
+			s.f.Packet = 0
+			if s.f.Mode&spi.NoCS == 0 {
+				s.Device.CS(true)
+			}
+		}
+		bits := s.f.BitsPerWord
+		if p[i].BitsPerWord != 0 {
+			s.f.BitsPerWord = int(p[i].BitsPerWord)
+		}
+		l := max(len(p[i].W), len(p[i].R))
+		for j := 0; j < l; j++ {
+			if done == stop {
+				s.f.BitsPerWord = bits
+				s.release()
+				return conntest.Errorf("spitest: short read; %d bytes transferred out of %d", done, total)
+			}
+			var w byte
+			if j < len(p[i].W) {
+				w = p[i].W[j]
+			}
+			s.f.Offset = j
+			r := s.Device.Transfer(&s.f, w)
+			if j < len(p[i].R) {
+				p[i].R[j] = r
+				last = &p[i].R[j]
+			}
+			s.f.Index++
+			done++
+		}
+		s.f.BitsPerWord = bits
+		if p[i].KeepCS {
+			s.f.Packet++
+		} else {
+			s.release()
+		}
+	}
+	if fault == FaultCRC && last != nil {
+		*last ^= 0xFF
+	}
+	return nil
+}
+
+// release releases the CS line if it is asserted.
+//
+// s.mu must be held.
+func (s *Sim) release() {
+	if s.cs {
+		s.cs = false
+		if s.f.Mode&spi.NoCS == 0 {
+			s.Device.CS(false)
+		}
+	}
+}
+
+// timeoutError is returned on FaultTimeout.
+type timeoutError struct{}
+
+func (timeoutError) Error() string {
+	return "spitest: timeout"
+}
+
+func (timeoutError) Timeout() bool {
+	return true
+}
+
+// features returns c, or all the features if c is zero.
+func features(c spi.Caps) spi.Caps {
+	if c == (spi.Caps{}) {
+		return spi.Caps{Mode: spi.HalfDuplex | spi.NoCS | spi.LSBFirst, CSHigh: true, BitsPerWord: 0xFFFFFFFF}
+	}
+	return c
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+var _ spi.Capabilities = &Sim{}
+var _ spi.PortCloser = &Sim{}
+var _ spi.Conn = &simConn{}
@@ -0,0 +1,261 @@
+// Copyright 2019 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package spitest
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"periph.io/x/periph/conn"
+	"periph.io/x/periph/conn/physic"
+	"periph.io/x/periph/conn/spi"
+)
+
+func TestSim(t *testing.T) {
+	d := &regDev{}
+	d.regs[0x10] = 0xAB
+	d.regs[0x11] = 0xCD
+	s := Sim{Device: d}
+	if s.String() != "sim" {
+		t.Fatal(s.String())
+	}
+	if err := s.LimitSpeed(physic.MegaHertz); err != nil {
+		t.Fatal(err)
+	}
+	c, err := s.Connect(10*physic.MegaHertz, spi.Mode3, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Connect(physic.MegaHertz, spi.Mode3, 8); err == nil {
+		t.Fatal("Connect twice")
+	}
+	if c.String() != "sim" || c.Duplex() != conn.Full {
+		t.Fatal(c.String(), c.Duplex())
+	}
+
+	// Full duplex read of two registers.
+	r := make([]byte, 3)
+	if err := c.Tx([]byte{0x90, 0, 0}, r); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(r, []byte{0x00, 0xAB, 0xCD}) {
+		t.Fatal(r)
+	}
+	if d.freq != physic.MegaHertz || d.mode != spi.Mode3 {
+		t.Fatal(d.freq, d.mode)
+	}
+
+	// Write then read while keeping CS asserted.
+	p := []spi.Packet{{W: []byte{0x20}, KeepCS: true}, {W: []byte{0x12, 0x34}, BitsPerWord: 16}}
+	if err := c.TxPackets(p); err != nil {
+		t.Fatal(err)
+	}
+	if d.regs[0x20] != 0x12 || d.regs[0x21] != 0x34 {
+		t.Fatal(d.regs[0x20:0x22])
+	}
+	expected := []string{"cs", "0/0/0 8", "1/0/1 8", "2/0/2 8", "release", "cs", "0/0/0 8", "1/1/0 16", "2/1/1 16", "release"}
+	if !reflect.DeepEqual(d.log, expected) {
+		t.Fatal(d.log)
+	}
+
+	// CS stays asserted across calls.
+	if err := c.TxPackets([]spi.Packet{{W: []byte{0xA0}, KeepCS: true}}); err != nil {
+		t.Fatal(err)
+	}
+	if !s.CSAsserted() {
+		t.Fatal("expected CS asserted")
+	}
+	r = make([]byte, 1)
+	if err := c.TxPackets([]spi.Packet{{R: r}}); err != nil {
+		t.Fatal(err)
+	}
+	if r[0] != 0x12 || s.CSAsserted() {
+		t.Fatal(r, s.CSAsserted())
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSim_HalfDuplex(t *testing.T) {
+	d := &regDev{}
+	d.regs[0x01] = 0x42
+	s := Sim{Device: d}
+	c, err := s.Connect(physic.MegaHertz, spi.Mode0|spi.HalfDuplex|spi.NoCS, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Duplex() != conn.Half {
+		t.Fatal(c.Duplex())
+	}
+	r := make([]byte, 1)
+	// Tx() is split in a write packet and a read packet; Frame.Index still
+	// counts from the start of the transaction even without CS.
+	if err := c.Tx([]byte{0x81}, r); err != nil {
+		t.Fatal(err)
+	}
+	if r[0] != 0x42 {
+		t.Fatal(r)
+	}
+	if len(d.log) != 2 {
+		t.Fatal(d.log)
+	}
+	if err := c.TxPackets([]spi.Packet{{W: []byte{0x81}, R: r}}); err == nil {
+		t.Fatal("half duplex")
+	}
+}
+
+func TestSim_Fault(t *testing.T) {
+	d := &regDev{}
+	d.regs[0x00] = 0x55
+	s := Sim{Device: d}
+	c, err := s.Connect(physic.MegaHertz, spi.Mode0, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Inject(FaultCRC)
+	s.Inject(FaultShortRead)
+	s.Inject(FaultTimeout)
+
+	r := make([]byte, 2)
+	if err := c.Tx([]byte{0x80, 0}, r); err != nil {
+		t.Fatal(err)
+	}
+	if r[1] != 0xAA {
+		t.Fatal(r)
+	}
+
+	r = []byte{0xEE, 0xEE, 0xEE, 0xEE}
+	if err := c.Tx([]byte{0x80, 0, 0, 0}, r); err == nil {
+		t.Fatal("short read")
+	}
+	if !bytes.Equal(r, []byte{0x00, 0x55, 0xEE, 0xEE}) {
+		t.Fatal(r)
+	}
+	if s.CSAsserted() {
+		t.Fatal("CS must be released")
+	}
+
+	d.log = nil
+	err = c.Tx([]byte{0x80, 0}, r[:2])
+	if e, ok := err.(interface{ Timeout() bool }); !ok || !e.Timeout() {
+		t.Fatal(err)
+	}
+	if len(d.log) != 0 {
+		t.Fatal(d.log)
+	}
+
+	// No more faults.
+	if err := c.Tx([]byte{0x80, 0}, r[:2]); err != nil {
+		t.Fatal(err)
+	}
+	if r[1] != 0x55 {
+		t.Fatal(r)
+	}
+}
+
+func TestSim_Caps(t *testing.T) {
+	s := Sim{Device: &regDev{}}
+	c, err := s.Caps()
+	if err != nil || !c.Supports(spi.Mode3|spi.HalfDuplex|spi.NoCS|spi.LSBFirst, 32) {
+		t.Fatal(c, err)
+	}
+	s.Features = spi.Caps{MaxSpeed: physic.MegaHertz, BitsPerWord: 1 << 7}
+	if c, err = s.Caps(); err != nil || c != s.Features {
+		t.Fatal(c, err)
+	}
+	if _, err := s.Connect(10*physic.MegaHertz, spi.Mode0|spi.LSBFirst, 8); err == nil {
+		t.Fatal("LSBFirst is not supported")
+	}
+	if _, err := s.Connect(10*physic.MegaHertz, spi.Mode0, 9); err == nil {
+		t.Fatal("9 bits is not supported")
+	}
+	c2, err := s.Connect(10*physic.MegaHertz, spi.Mode0, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c2.Tx([]byte{0x80}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if d := s.Device.(*regDev); d.freq != physic.MegaHertz {
+		t.Fatal(d.freq)
+	}
+}
+
+func TestSim_Err(t *testing.T) {
+	s := Sim{}
+	if _, err := s.Connect(physic.MegaHertz, spi.Mode0, 8); err == nil {
+		t.Fatal("no device")
+	}
+	if err := s.LimitSpeed(0); err == nil {
+		t.Fatal("invalid speed")
+	}
+	s.Device = &regDev{}
+	if _, err := s.Connect(-1, spi.Mode0, 8); err == nil {
+		t.Fatal("invalid speed")
+	}
+	if _, err := s.Connect(physic.MegaHertz, spi.Mode(0x100), 8); err == nil {
+		t.Fatal("invalid mode")
+	}
+	if _, err := s.Connect(physic.MegaHertz, spi.Mode0, 0); err == nil {
+		t.Fatal("invalid bits")
+	}
+	c, err := s.Connect(physic.MegaHertz, spi.Mode0, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Tx(nil, nil); err == nil {
+		t.Fatal("empty")
+	}
+	if err := c.Tx([]byte{1}, make([]byte, 2)); err == nil {
+		t.Fatal("size mismatch")
+	}
+}
+
+//
+
+// regDev is a device with 128 registers. The first byte selects the register
+// with bit 7 set for a read, the following bytes access consecutive
+// registers.
+type regDev struct {
+	regs [128]byte
+	log  []string
+	freq physic.Frequency
+	mode spi.Mode
+	ptr  byte
+	read bool
+}
+
+func (r *regDev) CS(asserted bool) {
+	if asserted {
+		r.log = append(r.log, "cs")
+	} else {
+		r.log = append(r.log, "release")
+	}
+}
+
+func (r *regDev) Transfer(f *Frame, w byte) byte {
+	r.log = append(r.log, fmtFrame(f))
+	r.freq = f.Freq
+	r.mode = f.Mode
+	if f.Index == 0 {
+		r.ptr = w & 0x7F
+		r.read = w&0x80 != 0
+		return 0
+	}
+	p := r.ptr
+	r.ptr = (r.ptr + 1) & 0x7F
+	if r.read {
+		return r.regs[p]
+	}
+	r.regs[p] = w
+	return 0
+}
+
+func fmtFrame(f *Frame) string {
+	return fmt.Sprintf("%d/%d/%d %d", f.Index, f.Packet, f.Offset, f.BitsPerWord)
+}
@@ -123,6 +123,17 @@ func (r *Record) Connect(f physic.Frequency, mode spi.Mode, bits int) (spi.Conn,
 	return &recordConn{r, nil}, nil
 }
 
+// Caps implements spi.Capabilities.
+//
+// It returns the capabilities of Port, or an error if Port doesn't report
+// them.
+func (r *Record) Caps() (spi.Caps, error) {
+	if c, ok := r.Port.(spi.Capabilities); ok {
+		return c.Caps()
+	}
+	return spi.Caps{}, conntest.Errorf("spitest: capabilities are not reported")
+}
+
 // CLK implements spi.Pins.
 func (r *Record) CLK() gpio.PinOut {
 	if p, ok := r.Port.(spi.Pins); ok {
@@ -235,10 +246,13 @@ func (r *recordConn) CS() gpio.PinOut {
 // an easy way to do basic code coverage.
 type Playback struct {
 	conntest.Playback
-	CLKPin      gpio.PinIO
-	MOSIPin     gpio.PinIO
-	MISOPin     gpio.PinIO
-	CSPin       gpio.PinIO
+	CLKPin  gpio.PinIO
+	MOSIPin gpio.PinIO
+	MISOPin gpio.PinIO
+	CSPin   gpio.PinIO
+	// Features is returned by Caps(). If zero, all the modes and 1 to 32 bits
+	// per word are reported as supported.
+	Features    spi.Caps
 	Initialized bool
 }
 
@@ -265,6 +279,13 @@ func (p *Playback) Connect(f physic.Frequency, mode spi.Mode, bits int) (spi.Con
 	return &playbackConn{p}, nil
 }
 
+// Caps implements spi.Capabilities.
+func (p *Playback) Caps() (spi.Caps, error) {
+	p.Lock()
+	defer p.Unlock()
+	return features(p.Features), nil
+}
+
 // CLK implements spi.Pins.
 func (p *Playback) CLK() gpio.PinOut {
 	return p.CLKPin
@@ -374,5 +395,7 @@ var _ spi.PortCloser = &RecordRaw{}
 var _ spi.PortCloser = &Record{}
 var _ spi.PortCloser = &Playback{}
 var _ spi.PortCloser = &Log{}
+var _ spi.Capabilities = &Record{}
+var _ spi.Capabilities = &Playback{}
 var _ spi.Pins = &Record{}
 var _ spi.Pins = &Playback{}
@@ -289,6 +289,23 @@ func TestRecord_Fail(t *testing.T) {
 	}
 }
 
+func TestRecord_Playback_Caps(t *testing.T) {
+	r := Record{}
+	if _, err := r.Caps(); err == nil {
+		t.Fatal("no port")
+	}
+	p := &Playback{Features: spi.Caps{Mode: spi.NoCS, BitsPerWord: 1 << 7}}
+	r.Port = p
+	c, err := r.Caps()
+	if err != nil || c != p.Features {
+		t.Fatal(c, err)
+	}
+	p.Features = spi.Caps{}
+	if c, err = p.Caps(); err != nil || !c.Supports(spi.Mode0|spi.HalfDuplex, 16) {
+		t.Fatal(c, err)
+	}
+}
+
 func TestLog_Playback(t *testing.T) {
 	r := Log{
 		PortCloser: &Playback{
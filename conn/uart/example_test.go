@@ -8,8 +8,8 @@ import (
 	"fmt"
 	"log"
 
-	"periph.io/x/periph/experimental/conn/uart"
-	"periph.io/x/periph/experimental/conn/uart/uartreg"
+	"periph.io/x/periph/conn/uart"
+	"periph.io/x/periph/conn/uart/uartreg"
 	"periph.io/x/periph/host"
 )
 
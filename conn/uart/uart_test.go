@@ -0,0 +1,24 @@
+// Copyright 2019 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package uart
+
+import "testing"
+
+func TestFlow_String(t *testing.T) {
+	data := []struct {
+		f        Flow
+		expected string
+	}{
+		{NoFlow, "None"},
+		{RTSCTS, "RTS/CTS"},
+		{MakeXOnXOffFlow('a', 'b'), "XOn(a)/XOff(b)"},
+		{Flow(1), "Flow(1)"},
+	}
+	for i, line := range data {
+		if s := line.f.String(); s != line.expected {
+			t.Fatalf("#%d: %q != %q", i, s, line.expected)
+		}
+	}
+}
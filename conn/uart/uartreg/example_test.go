@@ -11,8 +11,8 @@ import (
 	"strings"
 
 	"periph.io/x/periph/conn/physic"
-	"periph.io/x/periph/experimental/conn/uart"
-	"periph.io/x/periph/experimental/conn/uart/uartreg"
+	"periph.io/x/periph/conn/uart"
+	"periph.io/x/periph/conn/uart/uartreg"
 	"periph.io/x/periph/host"
 )
 
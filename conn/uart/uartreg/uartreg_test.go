@@ -12,7 +12,7 @@ import (
 	"periph.io/x/periph/conn"
 	"periph.io/x/periph/conn/gpio"
 	"periph.io/x/periph/conn/physic"
-	"periph.io/x/periph/experimental/conn/uart"
+	"periph.io/x/periph/conn/uart"
 )
 
 func TestOpen(t *testing.T) {
@@ -0,0 +1,342 @@
+// Copyright 2019 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+// Package uarttest is meant to be used to test drivers over a fake UART port.
+package uarttest
+
+import (
+	"log"
+	"sync"
+
+	"periph.io/x/periph/conn"
+	"periph.io/x/periph/conn/conntest"
+	"periph.io/x/periph/conn/gpio"
+	"periph.io/x/periph/conn/physic"
+	"periph.io/x/periph/conn/uart"
+)
+
+// Record implements uart.PortCloser that records everything written to it.
+//
+// This can then be used to feed to Playback to do "replay" based unit tests.
+type Record struct {
+	sync.Mutex
+	Port        uart.PortCloser // Port can be nil if only writes are being recorded.
+	Ops         []conntest.IO
+	Initialized bool
+}
+
+func (r *Record) String() string {
+	return "record"
+}
+
+// Close implements uart.PortCloser.
+func (r *Record) Close() error {
+	if r.Port != nil {
+		return r.Port.Close()
+	}
+	return nil
+}
+
+// LimitSpeed implements uart.PortCloser.
+func (r *Record) LimitSpeed(f physic.Frequency) error {
+	if r.Port != nil {
+		return r.Port.LimitSpeed(f)
+	}
+	return nil
+}
+
+// Connect implements uart.PortCloser.
+func (r *Record) Connect(f physic.Frequency, stopBit uart.Stop, parity uart.Parity, flow uart.Flow, bits int) (conn.Conn, error) {
+	r.Lock()
+	defer r.Unlock()
+	if r.Initialized {
+		return nil, conntest.Errorf("uarttest: Connect cannot be called twice")
+	}
+	r.Initialized = true
+	if r.Port != nil {
+		c, err := r.Port.Connect(f, stopBit, parity, flow, bits)
+		if err != nil {
+			return nil, err
+		}
+		return &recordConn{r, c}, nil
+	}
+	return &recordConn{r, nil}, nil
+}
+
+// RX implements uart.Pins.
+func (r *Record) RX() gpio.PinIn {
+	if p, ok := r.Port.(uart.Pins); ok {
+		return p.RX()
+	}
+	return gpio.INVALID
+}
+
+// TX implements uart.Pins.
+func (r *Record) TX() gpio.PinOut {
+	if p, ok := r.Port.(uart.Pins); ok {
+		return p.TX()
+	}
+	return gpio.INVALID
+}
+
+// RTS implements uart.Pins.
+func (r *Record) RTS() gpio.PinOut {
+	if p, ok := r.Port.(uart.Pins); ok {
+		return p.RTS()
+	}
+	return gpio.INVALID
+}
+
+// CTS implements uart.Pins.
+func (r *Record) CTS() gpio.PinIn {
+	if p, ok := r.Port.(uart.Pins); ok {
+		return p.CTS()
+	}
+	return gpio.INVALID
+}
+
+func (r *Record) txInternal(c conn.Conn, w, read []byte) error {
+	io := conntest.IO{}
+	if len(w) != 0 {
+		io.W = make([]byte, len(w))
+		copy(io.W, w)
+	}
+	r.Lock()
+	defer r.Unlock()
+	if r.Port == nil {
+		if len(read) != 0 {
+			return conntest.Errorf("uarttest: read unsupported when no port is connected")
+		}
+	} else {
+		if err := c.Tx(w, read); err != nil {
+			return err
+		}
+	}
+	if len(read) != 0 {
+		io.R = make([]byte, len(read))
+		copy(io.R, read)
+	}
+	r.Ops = append(r.Ops, io)
+	return nil
+}
+
+//
+
+type recordConn struct {
+	r *Record
+	c conn.Conn
+}
+
+func (r *recordConn) String() string {
+	return r.r.String()
+}
+
+func (r *recordConn) Duplex() conn.Duplex {
+	if r.c != nil {
+		return r.c.Duplex()
+	}
+	return conn.DuplexUnknown
+}
+
+func (r *recordConn) Tx(w, read []byte) error {
+	return r.r.txInternal(r.c, w, read)
+}
+
+// Read implements io.Reader.
+//
+// It fills b entirely, as a Tx() call with only a read buffer.
+func (r *recordConn) Read(b []byte) (int, error) {
+	if err := r.Tx(nil, b); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// Write implements io.Writer.
+func (r *recordConn) Write(b []byte) (int, error) {
+	if err := r.Tx(b, nil); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// RX implements uart.Pins.
+func (r *recordConn) RX() gpio.PinIn {
+	return r.r.RX()
+}
+
+// TX implements uart.Pins.
+func (r *recordConn) TX() gpio.PinOut {
+	return r.r.TX()
+}
+
+// RTS implements uart.Pins.
+func (r *recordConn) RTS() gpio.PinOut {
+	return r.r.RTS()
+}
+
+// CTS implements uart.Pins.
+func (r *recordConn) CTS() gpio.PinIn {
+	return r.r.CTS()
+}
+
+//
+
+// Playback implements uart.PortCloser and plays back a recorded I/O flow.
+//
+// While "replay" type of unit tests are of limited value, they still present
+// an easy way to do basic code coverage.
+//
+// The connection returned by Connect() also implements io.Reader and
+// io.Writer; each Read() or Write() call is matched against one IO in Ops.
+type Playback struct {
+	conntest.Playback
+	RXPin       gpio.PinIO
+	TXPin       gpio.PinIO
+	RTSPin      gpio.PinIO
+	CTSPin      gpio.PinIO
+	Initialized bool
+}
+
+// Close implements uart.PortCloser.
+//
+// Close() verifies that all the expected Ops have been consumed.
+func (p *Playback) Close() error {
+	return p.Playback.Close()
+}
+
+// LimitSpeed implements uart.PortCloser.
+func (p *Playback) LimitSpeed(f physic.Frequency) error {
+	return nil
+}
+
+// Connect implements uart.PortCloser.
+func (p *Playback) Connect(f physic.Frequency, stopBit uart.Stop, parity uart.Parity, flow uart.Flow, bits int) (conn.Conn, error) {
+	p.Lock()
+	defer p.Unlock()
+	if p.Initialized {
+		return nil, conntest.Errorf("uarttest: Connect cannot be called twice")
+	}
+	p.Initialized = true
+	return &playbackConn{p}, nil
+}
+
+// RX implements uart.Pins.
+func (p *Playback) RX() gpio.PinIn {
+	return p.RXPin
+}
+
+// TX implements uart.Pins.
+func (p *Playback) TX() gpio.PinOut {
+	return p.TXPin
+}
+
+// RTS implements uart.Pins.
+func (p *Playback) RTS() gpio.PinOut {
+	return p.RTSPin
+}
+
+// CTS implements uart.Pins.
+func (p *Playback) CTS() gpio.PinIn {
+	return p.CTSPin
+}
+
+type playbackConn struct {
+	p *Playback
+}
+
+func (p *playbackConn) String() string {
+	return p.p.String()
+}
+
+func (p *playbackConn) Duplex() conn.Duplex {
+	return p.p.Duplex()
+}
+
+func (p *playbackConn) Tx(w, r []byte) error {
+	return p.p.Tx(w, r)
+}
+
+func (p *playbackConn) Read(b []byte) (int, error) {
+	if err := p.p.Tx(nil, b); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+func (p *playbackConn) Write(b []byte) (int, error) {
+	if err := p.p.Tx(b, nil); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+func (p *playbackConn) RX() gpio.PinIn {
+	return p.p.RX()
+}
+
+func (p *playbackConn) TX() gpio.PinOut {
+	return p.p.TX()
+}
+
+func (p *playbackConn) RTS() gpio.PinOut {
+	return p.p.RTS()
+}
+
+func (p *playbackConn) CTS() gpio.PinIn {
+	return p.p.CTS()
+}
+
+//
+
+// Log logs all operations done on an uart.PortCloser.
+type Log struct {
+	uart.PortCloser
+}
+
+// Close implements uart.PortCloser.
+func (l *Log) Close() error {
+	err := l.PortCloser.Close()
+	log.Printf("%s.Close() = %v", l.PortCloser, err)
+	return err
+}
+
+// LimitSpeed implements uart.PortCloser.
+func (l *Log) LimitSpeed(f physic.Frequency) error {
+	err := l.PortCloser.LimitSpeed(f)
+	log.Printf("%s.LimitSpeed(%s) = %v", l.PortCloser, f, err)
+	return err
+}
+
+// Connect implements uart.PortCloser.
+func (l *Log) Connect(f physic.Frequency, stopBit uart.Stop, parity uart.Parity, flow uart.Flow, bits int) (conn.Conn, error) {
+	c, err := l.PortCloser.Connect(f, stopBit, parity, flow, bits)
+	log.Printf("%s.Connect(%s, %d, %c, %s, %d) = %v", l.PortCloser, f, stopBit, parity, flow, bits, err)
+	return &LogConn{c}, err
+}
+
+//
+
+// LogConn logs all operations done on an uart connection.
+type LogConn struct {
+	conn.Conn
+}
+
+// Tx implements conn.Conn.
+func (l *LogConn) Tx(w, r []byte) error {
+	err := l.Conn.Tx(w, r)
+	log.Printf("%s.Tx(%#v, %#v) = %v", l.Conn, w, r, err)
+	return err
+}
+
+//
+
+var _ uart.PortCloser = &Record{}
+var _ uart.PortCloser = &Playback{}
+var _ uart.PortCloser = &Log{}
+var _ uart.Pins = &Record{}
+var _ uart.Pins = &Playback{}
+var _ conn.Conn = &recordConn{}
+var _ conn.Conn = &playbackConn{}
+var _ conn.Conn = &LogConn{}
@@ -0,0 +1,263 @@
+// Copyright 2019 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package uarttest
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"log"
+	"testing"
+
+	"periph.io/x/periph/conn"
+	"periph.io/x/periph/conn/conntest"
+	"periph.io/x/periph/conn/gpio"
+	"periph.io/x/periph/conn/gpio/gpiotest"
+	"periph.io/x/periph/conn/physic"
+	"periph.io/x/periph/conn/uart"
+)
+
+func TestRecord_empty(t *testing.T) {
+	r := Record{}
+	if s := r.String(); s != "record" {
+		t.Fatal(s)
+	}
+	if err := r.LimitSpeed(-100); err != nil {
+		t.Fatal(err)
+	}
+	c, err := r.Connect(0, uart.One, uart.NoParity, uart.NoFlow, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Connect(0, uart.One, uart.NoParity, uart.NoFlow, 8); err == nil {
+		t.Fatal("Can't call Connect twice")
+	}
+	if v := c.String(); v != "record" {
+		t.Fatal(v)
+	}
+	if v := c.Duplex(); v != conn.DuplexUnknown {
+		t.Fatal(v)
+	}
+	if c.Tx(nil, []byte{'a'}) == nil {
+		t.Fatal("Port is nil")
+	}
+	if _, err := c.(io.Writer).Write([]byte{'a', 'b'}); err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Ops) != 1 || !bytes.Equal(r.Ops[0].W, []byte{'a', 'b'}) {
+		t.Fatal(r.Ops)
+	}
+	p := c.(uart.Pins)
+	if s := p.RX(); s != gpio.INVALID {
+		t.Fatal(s)
+	}
+	if s := p.TX(); s != gpio.INVALID {
+		t.Fatal(s)
+	}
+	if s := p.RTS(); s != gpio.INVALID {
+		t.Fatal(s)
+	}
+	if s := p.CTS(); s != gpio.INVALID {
+		t.Fatal(s)
+	}
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPlayback(t *testing.T) {
+	p := Playback{
+		RXPin:  &gpiotest.Pin{N: "RX"},
+		TXPin:  &gpiotest.Pin{N: "TX"},
+		RTSPin: &gpiotest.Pin{N: "RTS"},
+		CTSPin: &gpiotest.Pin{N: "CTS"},
+	}
+	if s := p.String(); s != "playback" {
+		t.Fatal(s)
+	}
+	if err := p.LimitSpeed(-100); err != nil {
+		t.Fatal(err)
+	}
+	c, err := p.Connect(9600*physic.Hertz, uart.One, uart.NoParity, uart.RTSCTS, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Connect(9600*physic.Hertz, uart.One, uart.NoParity, uart.RTSCTS, 8); err == nil {
+		t.Fatal("Can't call Connect twice")
+	}
+	pins := c.(uart.Pins)
+	if n := pins.RX().Name(); n != "RX" {
+		t.Fatal(n)
+	}
+	if n := pins.TX().Name(); n != "TX" {
+		t.Fatal(n)
+	}
+	if n := pins.RTS().Name(); n != "RTS" {
+		t.Fatal(n)
+	}
+	if n := pins.CTS().Name(); n != "CTS" {
+		t.Fatal(n)
+	}
+	if err := p.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPlayback_ReadWrite(t *testing.T) {
+	p := Playback{
+		Playback: conntest.Playback{
+			Ops: []conntest.IO{{W: []byte("ping")}, {R: []byte("pong")}},
+		},
+	}
+	c, err := p.Connect(9600*physic.Hertz, uart.One, uart.NoParity, uart.NoFlow, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rw := c.(io.ReadWriter)
+	if n, err := rw.Write([]byte("ping")); n != 4 || err != nil {
+		t.Fatal(n, err)
+	}
+	b := make([]byte, 4)
+	if n, err := rw.Read(b); n != 4 || err != nil || string(b) != "pong" {
+		t.Fatal(n, err, string(b))
+	}
+	if err := p.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPlayback_Tx_err(t *testing.T) {
+	p := Playback{
+		Playback: conntest.Playback{
+			Ops:       []conntest.IO{{W: []byte{10}, R: []byte{12}}},
+			DontPanic: true,
+		},
+	}
+	c, err := p.Connect(0, uart.One, uart.NoParity, uart.NoFlow, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Tx(nil, nil) == nil {
+		t.Fatal("missing read and write")
+	}
+	if p.Close() == nil {
+		t.Fatal("Ops is not empty")
+	}
+	if _, err := c.(io.Reader).Read(make([]byte, 2)); err == nil {
+		t.Fatal("invalid read size")
+	}
+}
+
+func TestRecord_Playback(t *testing.T) {
+	r := Record{
+		Port: &Playback{
+			Playback: conntest.Playback{
+				Ops:       []conntest.IO{{W: []byte{10}, R: []byte{12}}},
+				D:         conn.Full,
+				DontPanic: true,
+			},
+			RXPin:  &gpiotest.Pin{N: "RX"},
+			TXPin:  &gpiotest.Pin{N: "TX"},
+			RTSPin: &gpiotest.Pin{N: "RTS"},
+			CTSPin: &gpiotest.Pin{N: "CTS"},
+		},
+	}
+	if err := r.LimitSpeed(-100); err != nil {
+		t.Fatal(err)
+	}
+	c, err := r.Connect(0, uart.One, uart.NoParity, uart.NoFlow, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := c.Duplex(); d != conn.Full {
+		t.Fatal(d)
+	}
+	p := c.(uart.Pins)
+	if n := p.RX().Name(); n != "RX" {
+		t.Fatal(n)
+	}
+	if n := p.TX().Name(); n != "TX" {
+		t.Fatal(n)
+	}
+	if n := p.RTS().Name(); n != "RTS" {
+		t.Fatal(n)
+	}
+	if n := p.CTS().Name(); n != "CTS" {
+		t.Fatal(n)
+	}
+	v := [1]byte{}
+	if err := c.Tx([]byte{10}, v[:]); err != nil {
+		t.Fatal(err)
+	}
+	if v[0] != 12 {
+		t.Fatalf("expected 12, got %v", v)
+	}
+	if len(r.Ops) != 1 || r.Ops[0].R[0] != 12 {
+		t.Fatal(r.Ops)
+	}
+	if c.Tx([]byte{10}, v[:]) == nil {
+		t.Fatal("Playback.Ops is empty")
+	}
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+type connectFail struct {
+	Playback
+}
+
+func (c *connectFail) Connect(f physic.Frequency, stopBit uart.Stop, parity uart.Parity, flow uart.Flow, bits int) (conn.Conn, error) {
+	return nil, errors.New("foo")
+}
+
+func TestRecord_Fail(t *testing.T) {
+	r := Record{Port: &connectFail{}}
+	if _, err := r.Connect(0, uart.One, uart.NoParity, uart.NoFlow, 8); err == nil || err.Error() != "foo" {
+		t.Fatal("should have failed")
+	}
+}
+
+func TestLog_Playback(t *testing.T) {
+	r := Log{
+		PortCloser: &Playback{
+			Playback: conntest.Playback{
+				Ops:       []conntest.IO{{W: []byte{10}, R: []byte{12}}},
+				D:         conn.Full,
+				DontPanic: true,
+			},
+		},
+	}
+	if err := r.LimitSpeed(-100); err != nil {
+		t.Fatal(err)
+	}
+	c, err := r.Connect(0, uart.One, uart.NoParity, uart.NoFlow, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := c.Duplex(); d != conn.Full {
+		t.Fatal(d)
+	}
+	v := [1]byte{}
+	if err := c.Tx([]byte{10}, v[:]); err != nil {
+		t.Fatal(err)
+	}
+	if v[0] != 12 {
+		t.Fatalf("expected 12, got %v", v)
+	}
+	if c.Tx([]byte{10}, v[:]) == nil {
+		t.Fatal("Playback.Ops is empty")
+	}
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+//
+
+func init() {
+	log.SetOutput(ioutil.Discard)
+}
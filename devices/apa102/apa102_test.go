@@ -904,6 +904,35 @@ func TestHalt(t *testing.T) {
 	}
 }
 
+func TestHalt_sim(t *testing.T) {
+	strip := &stripSim{leds: make([][4]byte, 4)}
+	s := spitest.Sim{Device: strip}
+	o := DefaultOpts
+	o.NumPixels = 4
+	d, err := New(&s, &o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.Write([]byte{0xFF, 0, 0, 0, 0xFF, 0, 0, 0, 0xFF, 0xFF, 0xFF, 0xFF}); err != nil {
+		t.Fatal(err)
+	}
+	if strip.leds[0][3] == 0 || strip.leds[1][2] == 0 || strip.leds[2][1] == 0 {
+		t.Fatal(strip.leds)
+	}
+	if err := d.Halt(); err != nil {
+		t.Fatal(err)
+	}
+	for i, l := range strip.leds {
+		if l[1] != 0 || l[2] != 0 || l[3] != 0 {
+			t.Fatal(i, l)
+		}
+	}
+	s.Inject(spitest.FaultTimeout)
+	if err := d.Halt(); err == nil {
+		t.Fatal("timeout")
+	}
+}
+
 func TestInit(t *testing.T) {
 	// Catch the "maxB == maxG" line.
 	l := lut{}
@@ -1108,3 +1137,37 @@ func equalUint16(a, b []uint16) bool {
 	}
 	return true
 }
+
+// stripSim simulates a strip of APA102 LEDs: a start frame of 32 zero bits,
+// then 4 bytes per LED: 0xE0|brightness, blue, green, red.
+type stripSim struct {
+	leds  [][4]byte
+	zeros int
+	pos   int
+}
+
+func (s *stripSim) CS(asserted bool) {
+	// The APA102 has no CS line.
+}
+
+func (s *stripSim) Transfer(f *spitest.Frame, w byte) byte {
+	if f.Index == 0 {
+		s.zeros = 0
+		s.pos = -1
+	}
+	if s.pos < 0 {
+		if w == 0 {
+			if s.zeros++; s.zeros == 4 {
+				s.pos = 0
+			}
+		} else {
+			s.zeros = 0
+		}
+		return 0
+	}
+	if i := s.pos / 4; i < len(s.leds) {
+		s.leds[i][s.pos%4] = w
+	}
+	s.pos++
+	return 0
+}
@@ -11,6 +11,9 @@
 // as long as the bus driver can provide sufficient power using an active
 // pull-up.
 //
+// Use Poller to read all the sensors on a bus; it starts a single conversion
+// for all of them and tracks the sensors added and removed.
+//
 // The DS18B20 alarm functionality and reading/writing the 2 alarm bytes in
 // the EEPROM are not supported. The DS18S20 is also not supported.
 //
@@ -6,6 +6,8 @@ package ds18b20
 
 import (
 	"errors"
+	"log"
+	"sync"
 	"time"
 
 	"periph.io/x/periph/conn"
@@ -74,40 +76,82 @@ func New(o onewire.Bus, addr onewire.Address, resolutionBits int) (*Dev, error)
 	return d, nil
 }
 
+// Open returns an object that communicates over 1-wire to the DS18B20 sensor
+// with the specified 64-bit address, keeping the resolution currently
+// configured in the device.
+//
+// Unlike New, it never writes the configuration, so the alarm thresholds are
+// preserved and the EEPROM is not written to. It is meant to be used when
+// enumerating the devices on a bus.
+func Open(o onewire.Bus, addr onewire.Address) (*Dev, error) {
+	d := &Dev{onewire: onewire.Dev{Bus: o, Addr: addr}}
+	spad, err := d.readScratchpad()
+	if err != nil {
+		return nil, err
+	}
+	// Bits 5 and 6 of the configuration register, datasheet p.9.
+	d.resolution = int(spad[4]>>5&3) + 9
+	return d, nil
+}
+
 // Dev is a handle to a Dallas Semi / Maxim DS18B20 temperature sensor on a
 // 1-wire bus.
 type Dev struct {
 	onewire    onewire.Dev // device on 1-wire bus
 	resolution int         // resolution in bits (9..12)
+
+	mu   sync.Mutex
+	stop chan struct{}
+	wg   sync.WaitGroup
 }
 
 func (d *Dev) String() string {
 	return "DS18B20{" + d.onewire.String() + "}"
 }
 
-// Halt implements conn.Resource.
+// Halt stops the continuous sensing started with SenseContinuous(), if any.
 func (d *Dev) Halt() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.haltLocked()
 	return nil
 }
 
 // Sense implements physic.SenseEnv.
 func (d *Dev) Sense(e *physic.Env) error {
-	if err := d.onewire.TxPower([]byte{0x44}, nil); err != nil {
-		return err
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.stop != nil {
+		return errors.New("ds18b20: already sensing continuously")
 	}
-	conversionSleep(d.resolution)
-	t, err := d.LastTemp()
-	if err != nil {
-		return err
-	}
-	e.Temperature = t
-	return nil
+	return d.sense(e)
 }
 
 // SenseContinuous implements physic.SenseEnv.
-func (d *Dev) SenseContinuous(time.Duration) (<-chan physic.Env, error) {
-	// TODO(maruel): Manually poll in a loop via time.NewTicker.
-	return nil, errors.New("ds18b20: not implemented")
+//
+// A conversion is started at each interval, so interval should be longer than
+// the conversion time at the device resolution. The application must call
+// Halt() to stop the sensing and close the channel.
+//
+// To read many sensors on the same bus, use a Poller instead, which does a
+// single conversion for all the sensors.
+func (d *Dev) SenseContinuous(interval time.Duration) (<-chan physic.Env, error) {
+	if interval <= 0 {
+		return nil, errors.New("ds18b20: invalid interval")
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.haltLocked()
+	sensing := make(chan physic.Env)
+	stop := make(chan struct{})
+	d.stop = stop
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		defer close(sensing)
+		d.sensingContinuous(interval, sensing, stop)
+	}()
+	return sensing, nil
 }
 
 // Precision implements physic.SenseEnv.
@@ -145,6 +189,60 @@ func (d *Dev) LastTemp() (physic.Temperature, error) {
 
 //
 
+func (d *Dev) sense(e *physic.Env) error {
+	if err := d.onewire.TxPower([]byte{0x44}, nil); err != nil {
+		return err
+	}
+	conversionSleep(d.resolution)
+	t, err := d.LastTemp()
+	if err != nil {
+		return err
+	}
+	e.Temperature = t
+	return nil
+}
+
+func (d *Dev) sensingContinuous(interval time.Duration, sensing chan<- physic.Env, stop <-chan struct{}) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		// Do one initial sensing right away.
+		e := physic.Env{}
+		d.mu.Lock()
+		err := d.sense(&e)
+		d.mu.Unlock()
+		if err != nil {
+			log.Printf("%s: failed to sense: %v", d, err)
+			return
+		}
+		select {
+		case sensing <- e:
+		case <-stop:
+			return
+		}
+		select {
+		case <-stop:
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// haltLocked stops the continuous sensing.
+//
+// d.mu must be held.
+func (d *Dev) haltLocked() {
+	if d.stop == nil {
+		return
+	}
+	close(d.stop)
+	d.stop = nil
+	// The goroutine needs d.mu to complete a measurement.
+	d.mu.Unlock()
+	d.wg.Wait()
+	d.mu.Lock()
+}
+
 // busError implements error and onewire.BusError.
 type busError string
 
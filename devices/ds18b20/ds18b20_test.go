@@ -84,6 +84,55 @@ func TestSense(t *testing.T) {
 	}
 }
 
+func TestOpen(t *testing.T) {
+	ops := []onewiretest.IO{
+		// Match ROM + Read Scratchpad; 11 bits resolution.
+		{
+			W: []uint8{0x55, 0x28, 0xac, 0x41, 0xe, 0x7, 0x0, 0x0, 0x74, 0xbe},
+			R: []uint8{0xe0, 0x1, 0x4b, 0x46, 0x5f, 0xff, 0x10, 0x10, 0x57},
+		},
+		// Match ROM + Convert
+		{
+			W:    []uint8{0x55, 0x28, 0xac, 0x41, 0xe, 0x7, 0x0, 0x0, 0x74, 0x44},
+			Pull: true,
+		},
+		// Match ROM + Read Scratchpad (read temp)
+		{
+			W: []uint8{0x55, 0x28, 0xac, 0x41, 0xe, 0x7, 0x0, 0x0, 0x74, 0xbe},
+			R: []uint8{0xe0, 0x1, 0x4b, 0x46, 0x5f, 0xff, 0x10, 0x10, 0x57},
+		},
+	}
+	var addr onewire.Address = 0x740000070e41ac28
+	bus := onewiretest.Playback{Ops: ops}
+	dev, err := Open(&bus, addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sleeps []time.Duration
+	sleep = func(d time.Duration) { sleeps = append(sleeps, d) }
+	defer func() { sleep = func(time.Duration) {} }()
+	e := physic.Env{}
+	if err := dev.Sense(&e); err != nil {
+		t.Fatal(err)
+	}
+	if expected := 30*physic.Celsius + physic.ZeroCelsius; e.Temperature != expected {
+		t.Fatal(e.Temperature)
+	}
+	if !reflect.DeepEqual(sleeps, []time.Duration{376 * time.Millisecond}) {
+		t.Fatal(sleeps)
+	}
+	if err := bus.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOpen_fail_read(t *testing.T) {
+	bus := &onewiretest.Playback{DontPanic: true}
+	if d, err := Open(bus, 0x740000070e41ac28); d != nil || err == nil {
+		t.Fatal("expected error")
+	}
+}
+
 // TestConvertAll tests a temperature conversion on all ds18b20 using
 // recorded bus transactions.
 func TestConvertAll(t *testing.T) {
@@ -0,0 +1,253 @@
+// Copyright 2019 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package ds18b20
+
+import (
+	"errors"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"periph.io/x/periph/conn/onewire"
+	"periph.io/x/periph/conn/physic"
+)
+
+// Family is the 1-wire family code of the DS18B20 and MAX31820.
+const Family = 0x28
+
+// PollerOpts contains the options for NewPoller.
+type PollerOpts struct {
+	// Resolution is the resolution in bits, in the range 9..12, set on every
+	// sensor found.
+	Resolution int
+	// Retries is the number of times the scratchpad of a sensor is read again
+	// when the read fails, for example because of a CRC error.
+	Retries int
+}
+
+// DefaultPollerOpts is the recommended default options.
+var DefaultPollerOpts = PollerOpts{
+	Resolution: 10,
+	Retries:    2,
+}
+
+// Reading is the measurement of one sensor in a polling cycle.
+type Reading struct {
+	Addr onewire.Address
+	Env  physic.Env
+	// Err is set when the sensor could not be read during this cycle.
+	Err error
+	// Removed is set when the sensor is not found on the bus anymore. It is
+	// the last Reading for this address until the sensor is found again.
+	Removed bool
+}
+
+// NewPoller returns a Poller that reads all the DS18B20 sensors on the bus.
+func NewPoller(b onewire.Bus, opts *PollerOpts) (*Poller, error) {
+	if opts.Resolution < 9 || opts.Resolution > 12 {
+		return nil, errors.New("ds18b20: invalid resolution")
+	}
+	if opts.Retries < 0 {
+		return nil, errors.New("ds18b20: invalid retries")
+	}
+	return &Poller{bus: b, opts: *opts, devs: map[onewire.Address]*Dev{}}, nil
+}
+
+// Poller reads all the DS18B20 sensors on a 1-wire bus.
+//
+// Each cycle searches the bus to detect the sensors added or removed, starts
+// the conversion on all the sensors at once with ConvertAll() then reads each
+// sensor. A cycle takes the conversion time plus a few milliseconds per sensor.
+type Poller struct {
+	bus  onewire.Bus
+	opts PollerOpts
+
+	mu   sync.Mutex
+	devs map[onewire.Address]*Dev
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+func (p *Poller) String() string {
+	return "DS18B20Poller{" + p.bus.String() + "}"
+}
+
+// Devices returns the addresses of the sensors found, in increasing order.
+func (p *Poller) Devices() []onewire.Address {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.addrs()
+}
+
+// Poll runs one cycle and returns the readings, in increasing order of
+// address, followed by the sensors removed.
+//
+// An error is returned when the conversion couldn't be started; errors
+// specific to a sensor are returned in its Reading.
+func (p *Poller) Poll() ([]Reading, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.stop != nil {
+		return nil, errors.New("ds18b20: already polling continuously")
+	}
+	return p.poll()
+}
+
+// PollContinuous runs a cycle at each interval and sends the readings on the
+// returned channel.
+//
+// Failed cycles are logged and retried at the next interval. The application
+// must call Halt() to stop the polling and close the channel.
+func (p *Poller) PollContinuous(interval time.Duration) (<-chan Reading, error) {
+	if interval <= 0 {
+		return nil, errors.New("ds18b20: invalid interval")
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.haltLocked()
+	readings := make(chan Reading)
+	stop := make(chan struct{})
+	p.stop = stop
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		defer close(readings)
+		p.pollContinuous(interval, readings, stop)
+	}()
+	return readings, nil
+}
+
+// Halt stops the polling started with PollContinuous(), if any.
+func (p *Poller) Halt() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.haltLocked()
+	return nil
+}
+
+//
+
+// poll runs one cycle.
+//
+// p.mu must be held.
+func (p *Poller) poll() ([]Reading, error) {
+	var out []Reading
+	found, err := p.bus.Search(false)
+	complete := err == nil
+	if e, ok := err.(onewire.NoDevicesError); ok && e.NoDevices() {
+		complete = true
+	}
+	if !complete {
+		// Keep the known sensors, the search stopped before finding them.
+		log.Printf("%s: search failed: %v", p, err)
+	}
+	present := map[onewire.Address]bool{}
+	for _, a := range found {
+		if a&0xFF != Family {
+			continue
+		}
+		present[a] = true
+		if p.devs[a] != nil {
+			continue
+		}
+		d, err := New(p.bus, a, p.opts.Resolution)
+		if err != nil {
+			// Try again at the next cycle.
+			out = append(out, Reading{Addr: a, Err: err})
+			continue
+		}
+		p.devs[a] = d
+	}
+	var removed []Reading
+	if complete {
+		for _, a := range p.addrs() {
+			if !present[a] {
+				delete(p.devs, a)
+				removed = append(removed, Reading{Addr: a, Removed: true})
+			}
+		}
+	}
+	if len(p.devs) != 0 {
+		if err := ConvertAll(p.bus, p.opts.Resolution); err != nil {
+			return append(out, removed...), err
+		}
+	}
+	for _, a := range p.addrs() {
+		r := Reading{Addr: a}
+		for i := 0; i <= p.opts.Retries; i++ {
+			if r.Env.Temperature, r.Err = p.devs[a].LastTemp(); r.Err == nil {
+				break
+			}
+		}
+		out = append(out, r)
+	}
+	sort.Sort(byAddr(out))
+	return append(out, removed...), nil
+}
+
+func (p *Poller) pollContinuous(interval time.Duration, readings chan<- Reading, stop <-chan struct{}) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		p.mu.Lock()
+		out, err := p.poll()
+		p.mu.Unlock()
+		if err != nil {
+			log.Printf("%s: failed to poll: %v", p, err)
+		}
+		for _, r := range out {
+			select {
+			case readings <- r:
+			case <-stop:
+				return
+			}
+		}
+		select {
+		case <-stop:
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// haltLocked stops the continuous polling.
+//
+// p.mu must be held.
+func (p *Poller) haltLocked() {
+	if p.stop == nil {
+		return
+	}
+	close(p.stop)
+	p.stop = nil
+	// The goroutine needs p.mu to complete a cycle.
+	p.mu.Unlock()
+	p.wg.Wait()
+	p.mu.Lock()
+}
+
+// addrs returns the addresses of the known sensors, sorted.
+//
+// p.mu must be held.
+func (p *Poller) addrs() []onewire.Address {
+	out := make(addresses, 0, len(p.devs))
+	for a := range p.devs {
+		out = append(out, a)
+	}
+	sort.Sort(out)
+	return out
+}
+
+type addresses []onewire.Address
+
+func (a addresses) Len() int           { return len(a) }
+func (a addresses) Less(i, j int) bool { return a[i] < a[j] }
+func (a addresses) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+
+type byAddr []Reading
+
+func (r byAddr) Len() int           { return len(r) }
+func (r byAddr) Less(i, j int) bool { return r[i].Addr < r[j].Addr }
+func (r byAddr) Swap(i, j int)      { r[i], r[j] = r[j], r[i] }
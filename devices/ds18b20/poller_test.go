@@ -0,0 +1,306 @@
+// Copyright 2019 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package ds18b20
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"periph.io/x/periph/conn/onewire"
+	"periph.io/x/periph/conn/physic"
+)
+
+func TestPoller(t *testing.T) {
+	b := &sensorBus{sensors: map[onewire.Address]*sensor{
+		0x0100000000000128: {temp: 20 * 16},
+		0x0200000000000228: {temp: -5 * 16},
+		// Not a DS18B20.
+		0x0300000000000310: {},
+	}}
+	p, err := NewPoller(b, &DefaultPollerOpts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := p.String(); s != "DS18B20Poller{sensors}" {
+		t.Fatal(s)
+	}
+	r, err := p.Poll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Reading{
+		{Addr: 0x0100000000000128, Env: physic.Env{Temperature: 20*physic.Kelvin + physic.ZeroCelsius}},
+		{Addr: 0x0200000000000228, Env: physic.Env{Temperature: -5*physic.Kelvin + physic.ZeroCelsius}},
+	}
+	if !reflect.DeepEqual(r, expected) {
+		t.Fatal(r)
+	}
+	if b.converts != 1 {
+		t.Fatal(b.converts)
+	}
+	// The resolution was set once.
+	if b.sensors[0x0100000000000128].config != 1<<5|0x1f {
+		t.Fatal(b.sensors[0x0100000000000128].config)
+	}
+
+	// A sensor is removed, another one added and one has a CRC error.
+	delete(b.sensors, 0x0200000000000228)
+	b.sensors[0x0000000000000428] = &sensor{temp: 1}
+	b.sensors[0x0100000000000128].corrupt = 2
+	r, err = p.Poll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = []Reading{
+		{Addr: 0x0000000000000428, Env: physic.Env{Temperature: physic.Kelvin/16 + physic.ZeroCelsius}},
+		{Addr: 0x0100000000000128, Env: physic.Env{Temperature: 20*physic.Kelvin + physic.ZeroCelsius}},
+		{Addr: 0x0200000000000228, Removed: true},
+	}
+	if !reflect.DeepEqual(r, expected) {
+		t.Fatal(r)
+	}
+	if a := p.Devices(); !reflect.DeepEqual(a, []onewire.Address{0x0000000000000428, 0x0100000000000128}) {
+		t.Fatal(a)
+	}
+
+	// Too many CRC errors.
+	b.sensors[0x0100000000000128].corrupt = 3
+	if r, err = p.Poll(); err != nil {
+		t.Fatal(err)
+	}
+	if len(r) != 2 || r[1].Err == nil {
+		t.Fatal(r)
+	}
+
+	// Search failure doesn't remove the sensors.
+	b.searchErr = errors.New("search failed")
+	if r, err = p.Poll(); err != nil {
+		t.Fatal(err)
+	}
+	if len(r) != 2 || r[0].Err != nil || r[1].Err != nil {
+		t.Fatal(r)
+	}
+	b.searchErr = nil
+
+	// Conversion failure.
+	b.txErr = errors.New("convert failed")
+	if _, err = p.Poll(); err == nil {
+		t.Fatal("expected failure")
+	}
+}
+
+func TestPoller_PollContinuous(t *testing.T) {
+	b := &sensorBus{sensors: map[onewire.Address]*sensor{0x0100000000000128: {temp: 16}}}
+	p, err := NewPoller(b, &DefaultPollerOpts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.PollContinuous(0); err == nil {
+		t.Fatal("invalid interval")
+	}
+	c, err := p.PollContinuous(time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if r := <-c; r.Addr != 0x0100000000000128 || r.Env.Temperature != physic.Kelvin+physic.ZeroCelsius {
+			t.Fatal(r)
+		}
+	}
+	if _, err := p.Poll(); err == nil {
+		t.Fatal("already polling")
+	}
+	if err := p.Halt(); err != nil {
+		t.Fatal(err)
+	}
+	for range c {
+	}
+}
+
+func TestPoller_PollContinuous_halt(t *testing.T) {
+	b := &sensorBus{sensors: map[onewire.Address]*sensor{0x0100000000000128: {temp: 16}}}
+	p, err := NewPoller(b, &DefaultPollerOpts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Halt right away without reading from the channel.
+	c, err := p.PollContinuous(time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Halt(); err != nil {
+		t.Fatal(err)
+	}
+	for range c {
+	}
+}
+
+func TestNewPoller_Err(t *testing.T) {
+	if _, err := NewPoller(&sensorBus{}, &PollerOpts{Resolution: 8}); err == nil {
+		t.Fatal("invalid resolution")
+	}
+	if _, err := NewPoller(&sensorBus{}, &PollerOpts{Resolution: 9, Retries: -1}); err == nil {
+		t.Fatal("invalid retries")
+	}
+}
+
+func TestSenseContinuous(t *testing.T) {
+	b := &sensorBus{sensors: map[onewire.Address]*sensor{0x0100000000000128: {temp: 32}}}
+	d, err := New(b, 0x0100000000000128, 9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.SenseContinuous(0); err == nil {
+		t.Fatal("invalid interval")
+	}
+	c, err := d.SenseContinuous(time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if e := <-c; e.Temperature != 2*physic.Kelvin+physic.ZeroCelsius {
+			t.Fatal(e)
+		}
+	}
+	if err := d.Sense(&physic.Env{}); err == nil {
+		t.Fatal("already sensing")
+	}
+	if err := d.Halt(); err != nil {
+		t.Fatal(err)
+	}
+	for range c {
+	}
+	e := physic.Env{}
+	if err := d.Sense(&e); err != nil {
+		t.Fatal(err)
+	}
+
+	// The channel is closed on failure.
+	c, err = d.SenseContinuous(time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.mu.Lock()
+	b.txErr = errors.New("failure")
+	b.mu.Unlock()
+	for range c {
+	}
+	if err := d.Halt(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSenseContinuous_halt(t *testing.T) {
+	b := &sensorBus{sensors: map[onewire.Address]*sensor{0x0100000000000128: {temp: 32}}}
+	d, err := New(b, 0x0100000000000128, 9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Halt right away without reading from the channel.
+	c, err := d.SenseContinuous(time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Halt(); err != nil {
+		t.Fatal(err)
+	}
+	for range c {
+	}
+}
+
+//
+
+// sensor is a simulated DS18B20.
+type sensor struct {
+	temp    int16 // in 1/16 °C
+	config  byte
+	reading int16 // temperature of the last conversion
+	corrupt int   // number of scratchpad reads to return with a bad CRC
+}
+
+// sensorBus is a onewire.Bus with simulated DS18B20 sensors.
+type sensorBus struct {
+	mu        sync.Mutex
+	sensors   map[onewire.Address]*sensor
+	converts  int
+	searchErr error
+	txErr     error
+}
+
+func (b *sensorBus) String() string {
+	return "sensors"
+}
+
+func (b *sensorBus) Tx(w, r []byte, power onewire.Pullup) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.txErr != nil {
+		return b.txErr
+	}
+	var targets []*sensor
+	switch {
+	case len(w) >= 2 && w[0] == 0xcc:
+		for _, s := range b.sensors {
+			targets = append(targets, s)
+		}
+		w = w[1:]
+	case len(w) >= 10 && w[0] == 0x55:
+		var a onewire.Address
+		for i := 8; i > 0; i-- {
+			a = a<<8 | onewire.Address(w[i])
+		}
+		if s := b.sensors[a]; s != nil {
+			targets = append(targets, s)
+		}
+		w = w[9:]
+	default:
+		return errors.New("unexpected command")
+	}
+	for i := range r {
+		r[i] = 0xff
+	}
+	if w[0] == 0x44 {
+		b.converts++
+	}
+	for _, s := range targets {
+		switch w[0] {
+		case 0x44:
+			if power != onewire.StrongPullup {
+				return errors.New("expected strong pull-up")
+			}
+			s.reading = s.temp
+		case 0xbe:
+			spad := []byte{byte(s.reading), byte(s.reading >> 8), 0, 0, s.config, 0xff, 0x0c, 0x10}
+			spad = append(spad, onewire.CalcCRC(spad))
+			if s.corrupt != 0 {
+				s.corrupt--
+				spad[8] ^= 0xff
+			}
+			copy(r, spad)
+		case 0x4e:
+			s.config = w[3]
+		case 0x48:
+		default:
+			return errors.New("unexpected function command")
+		}
+	}
+	return nil
+}
+
+func (b *sensorBus) Search(alarmOnly bool) ([]onewire.Address, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.searchErr != nil {
+		return nil, b.searchErr
+	}
+	var out []onewire.Address
+	for a := range b.sensors {
+		out = append(out, a)
+	}
+	return out, nil
+}
@@ -86,8 +86,8 @@ type Dev struct {
 	i2c        conn.Conn     // i2c device handle for the ds248x
 	isDS2483   bool          // true: ds2483, false: ds2482-100
 	confReg    byte          // value written to configuration register
-	tReset     time.Duration // time to perform a 1-wire reset
-	tSlot      time.Duration // time to perform a 1-bit 1-wire read/write
+	tReset     time.Duration // time to perform a 1-wire reset at standard speed
+	tSlot      time.Duration // time to perform a 1-bit 1-wire read/write at standard speed
 	err        error         // persistent error, device will no longer operate
 }
 
@@ -109,6 +109,9 @@ func (d *Dev) Halt() error {
 //
 // A strong pull-up is typically required to power temperature conversion or
 // EEPROM writes.
+//
+// The port switches to overdrive speed after an Overdrive Skip ROM or
+// Overdrive Match ROM command, as required by onewire.BusOverdriver.
 func (d *Dev) Tx(w, r []byte, power onewire.Pullup) error {
 	d.Lock()
 	defer d.Unlock()
@@ -127,7 +130,12 @@ func (d *Dev) Tx(w, r []byte, power onewire.Pullup) error {
 			d.i2cTx([]byte{cmdWriteConfig, d.confReg&0xbf | 0x4}, nil)
 		}
 		d.i2cTx([]byte{cmd1WWrite, b}, nil)
-		d.waitIdle(7 * d.tSlot)
+		d.waitIdle(7 * d.slot())
+		if i == 0 && (b == 0x3c || b == 0x69) {
+			// Overdrive Skip ROM or Overdrive Match ROM; the devices are now at
+			// overdrive speed.
+			d.setOverdrive(true)
+		}
 	}
 
 	// Read bytes from one-wire bus.
@@ -137,7 +145,7 @@ func (d *Dev) Tx(w, r []byte, power onewire.Pullup) error {
 			d.i2cTx([]byte{cmdWriteConfig, d.confReg&0xbf | 0x4}, nil)
 		}
 		d.i2cTx([]byte{cmd1WRead}, r[i:i+1])
-		d.waitIdle(7 * d.tSlot)
+		d.waitIdle(7 * d.slot())
 		d.i2cTx([]byte{cmdSetReadPtr, regRDR}, r[i:i+1])
 	}
 
@@ -154,6 +162,17 @@ func (d *Dev) Search(alarmOnly bool) ([]onewire.Address, error) {
 	return onewire.Search(d, alarmOnly)
 }
 
+// SetOverdrive implements onewire.BusOverdriver.
+//
+// It selects the overdrive speed of the 1-wire port when on is true, or the
+// standard speed otherwise.
+func (d *Dev) SetOverdrive(on bool) error {
+	d.Lock()
+	defer d.Unlock()
+	d.setOverdrive(on)
+	return d.err
+}
+
 // SearchTriplet performs a single bit search triplet command on the bus, waits
 // for it to complete and returs the outcome.
 //
@@ -184,7 +203,11 @@ func (d *Dev) reset() (bool, error) {
 	d.i2cTx([]byte{cmd1WReset}, nil)
 
 	// Wait for reset to complete.
-	status := d.waitIdle(d.tReset)
+	tReset := d.tReset
+	if d.confReg&0x08 != 0 {
+		tReset /= 8
+	}
+	status := d.waitIdle(tReset)
 	if d.err != nil {
 		return false, d.err
 	}
@@ -195,6 +218,29 @@ func (d *Dev) reset() (bool, error) {
 	return (status & 2) != 0, nil
 }
 
+// setOverdrive sets the 1WS bit of the configuration register.
+//
+// The upper nibble of the register is the complement of the lower nibble.
+func (d *Dev) setOverdrive(on bool) {
+	c := d.confReg&^0x80 | 0x08
+	if !on {
+		c = d.confReg&^0x08 | 0x80
+	}
+	if c != d.confReg {
+		d.confReg = c
+		d.i2cTx([]byte{cmdWriteConfig, d.confReg}, nil)
+	}
+}
+
+// slot returns the duration of a time slot at the current speed.
+func (d *Dev) slot() time.Duration {
+	if d.confReg&0x08 != 0 {
+		// Overdrive is about 8 times faster.
+		return d.tSlot / 8
+	}
+	return d.tSlot
+}
+
 // i2cTx is a helper function to call i2c.Tx and handle the error by persisting
 // it.
 func (d *Dev) i2cTx(w, r []byte) {
@@ -313,6 +359,7 @@ func (e busError) BusError() bool { return true }
 var sleep = time.Sleep
 
 var _ conn.Resource = &Dev{}
+var _ onewire.BusOverdriver = &Dev{}
 
 const (
 	cmdReset       = 0xf0 // reset ds248x
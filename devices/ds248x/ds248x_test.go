@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"periph.io/x/periph/conn/i2c/i2ctest"
+	"periph.io/x/periph/conn/onewire"
 )
 
 func TestNew(t *testing.T) {
@@ -54,6 +55,54 @@ func TestNew_opts(t *testing.T) {
 	}
 }
 
+func TestOverdrive(t *testing.T) {
+	bus := i2ctest.Playback{
+		Ops: []i2ctest.IO{
+			{Addr: 0x18, W: []byte{0xf0}},
+			{Addr: 0x18, W: []byte{0xe1, 0xf0}, R: []byte{0x18}},
+			{Addr: 0x18, W: []byte{0xd2, 0xe1}, R: []byte{0x1}},
+			{Addr: 0x18, W: []byte{0xe1, 0xb4}},
+			{Addr: 0x18, W: []byte{0xc3, 0x6, 0x26, 0x46, 0x66, 0x86}},
+			// Overdrive Skip ROM at standard speed.
+			{Addr: 0x18, W: []byte{0xb4}},
+			{Addr: 0x18, R: []byte{0x2}},
+			{Addr: 0x18, W: []byte{0xa5, 0x3c}},
+			{Addr: 0x18, R: []byte{0x0}},
+			// 1WS is set.
+			{Addr: 0x18, W: []byte{0xd2, 0x69}},
+			// Skip ROM at overdrive speed.
+			{Addr: 0x18, W: []byte{0xb4}},
+			{Addr: 0x18, R: []byte{0x2}},
+			{Addr: 0x18, W: []byte{0xa5, 0xcc}},
+			{Addr: 0x18, R: []byte{0x0}},
+			// SetOverdrive(false).
+			{Addr: 0x18, W: []byte{0xd2, 0xe1}},
+		},
+	}
+	d, err := New(&bus, 0x18, &DefaultOpts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := onewire.OverdriveSkip(d); err != nil {
+		t.Fatal(err)
+	}
+	if s := d.slot(); s != DefaultOpts.Write0Low/8+DefaultOpts.Write0Recovery/8 {
+		t.Fatal(s)
+	}
+	if err := d.Tx([]byte{0xcc}, nil, onewire.WeakPullup); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.SetOverdrive(false); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.SetOverdrive(false); err != nil {
+		t.Fatal(err)
+	}
+	if err := bus.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func init() {
 	sleep = func(time.Duration) {}
 }
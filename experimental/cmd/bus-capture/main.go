@@ -0,0 +1,209 @@
+// Copyright 2019 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+// bus-capture runs a driver on a real bus while recording all the bus traffic
+// and saves it as a capture file.
+//
+// The capture can be loaded in unit tests with i2ctest.Playback.Load(),
+// spitest.Playback.Load() or onewiretest.Playback.Load() to build regression
+// tests from captures done in the field.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+
+	"periph.io/x/periph/conn/i2c"
+	"periph.io/x/periph/conn/i2c/i2creg"
+	"periph.io/x/periph/conn/i2c/i2ctest"
+	"periph.io/x/periph/conn/onewire"
+	"periph.io/x/periph/conn/onewire/onewirereg"
+	"periph.io/x/periph/conn/onewire/onewiretest"
+	"periph.io/x/periph/conn/physic"
+	"periph.io/x/periph/conn/spi"
+	"periph.io/x/periph/conn/spi/spireg"
+	"periph.io/x/periph/conn/spi/spitest"
+	"periph.io/x/periph/devices/bmxx80"
+	"periph.io/x/periph/devices/ds18b20"
+	"periph.io/x/periph/host"
+)
+
+// i2cDrivers are the drivers that can be run on an I²C bus.
+var i2cDrivers = map[string]func(b i2c.Bus, addr uint16, n int) error{
+	"bmxx80": func(b i2c.Bus, addr uint16, n int) error {
+		d, err := bmxx80.NewI2C(b, addr, &bmxx80.DefaultOpts)
+		if err != nil {
+			return err
+		}
+		return senseN(d, n)
+	},
+}
+
+// spiDrivers are the drivers that can be run on a SPI port.
+var spiDrivers = map[string]func(p spi.Port, n int) error{
+	"bmxx80": func(p spi.Port, n int) error {
+		d, err := bmxx80.NewSPI(p, &bmxx80.DefaultOpts)
+		if err != nil {
+			return err
+		}
+		return senseN(d, n)
+	},
+}
+
+// onewireDrivers are the drivers that can be run on a 1-wire bus.
+var onewireDrivers = map[string]func(b onewire.Bus, n int) error{
+	"ds18b20": func(b onewire.Bus, n int) error {
+		addrs, err := b.Search(false)
+		if err != nil {
+			return err
+		}
+		var devs []*ds18b20.Dev
+		for _, a := range addrs {
+			if a&0xFF != ds18b20.Family {
+				continue
+			}
+			// Keep the configuration of the sensors as found in the field.
+			d, err := ds18b20.Open(b, a)
+			if err != nil {
+				return err
+			}
+			devs = append(devs, d)
+		}
+		if len(devs) == 0 {
+			return errors.New("no DS18B20 found")
+		}
+		for i := 0; i < n; i++ {
+			// Wait for the longest conversion, since the resolutions may differ.
+			if err := ds18b20.ConvertAll(b, 12); err != nil {
+				return err
+			}
+			for _, d := range devs {
+				t, err := d.LastTemp()
+				if err != nil {
+					return err
+				}
+				log.Printf("%s: %s", d, t)
+			}
+		}
+		return nil
+	},
+}
+
+func senseN(d physic.SenseEnv, n int) error {
+	for i := 0; i < n; i++ {
+		var e physic.Env
+		if err := d.Sense(&e); err != nil {
+			return err
+		}
+		log.Printf("%s: %s %s %s", d, e.Temperature, e.Pressure, e.Humidity)
+	}
+	return d.Halt()
+}
+
+// saver is implemented by i2ctest.Record, spitest.Record and
+// onewiretest.Record.
+type saver interface {
+	Save(w io.Writer) error
+}
+
+func record(bus, name, drv string, addr uint16, n int) (saver, error) {
+	switch bus {
+	case "i2c":
+		f := i2cDrivers[drv]
+		if f == nil {
+			return nil, fmt.Errorf("unknown I²C driver %q", drv)
+		}
+		b, err := i2creg.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		defer b.Close()
+		r := &i2ctest.Record{Bus: b}
+		return r, f(r, addr, n)
+	case "spi":
+		f := spiDrivers[drv]
+		if f == nil {
+			return nil, fmt.Errorf("unknown SPI driver %q", drv)
+		}
+		p, err := spireg.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		r := &spitest.Record{Port: p}
+		// Record.Close() closes the port.
+		defer r.Close()
+		return r, f(r, n)
+	case "onewire":
+		f := onewireDrivers[drv]
+		if f == nil {
+			return nil, fmt.Errorf("unknown 1-wire driver %q", drv)
+		}
+		b, err := onewirereg.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		defer b.Close()
+		r := &onewiretest.Record{Bus: b}
+		return r, f(r, n)
+	default:
+		return nil, fmt.Errorf("unknown bus %q; supported: i2c, onewire, spi", bus)
+	}
+}
+
+func mainImpl() error {
+	bus := flag.String("bus", "i2c", "type of bus: i2c, onewire or spi")
+	name := flag.String("b", "", "name of the bus to open (default, uses the first one found)")
+	drv := flag.String("d", "", "driver to run: bmxx80 (i2c, spi), ds18b20 (onewire)")
+	var addr i2c.Addr = 0x76
+	flag.Var(&addr, "a", "I²C device address")
+	n := flag.Int("n", 1, "number of measurements")
+	out := flag.String("o", "", "capture file to write (default: stdout)")
+	verbose := flag.Bool("v", false, "verbose mode")
+	flag.Parse()
+	if !*verbose {
+		log.SetOutput(ioutil.Discard)
+	}
+	log.SetFlags(log.Lmicroseconds)
+	if flag.NArg() != 0 {
+		return errors.New("unexpected argument, try -help")
+	}
+	if *drv == "" {
+		return errors.New("-d is required, try -help")
+	}
+
+	if _, err := host.Init(); err != nil {
+		return err
+	}
+	r, err := record(*bus, *name, *drv, uint16(addr), *n)
+	if r == nil {
+		return err
+	}
+	// Save what was recorded even if the driver failed, it is useful to
+	// reproduce the failure.
+	w := io.Writer(os.Stdout)
+	if *out != "" {
+		f, err2 := os.Create(*out)
+		if err2 != nil {
+			return err2
+		}
+		defer f.Close()
+		w = f
+	}
+	if err2 := r.Save(w); err == nil {
+		err = err2
+	}
+	return err
+}
+
+func main() {
+	if err := mainImpl(); err != nil {
+		fmt.Fprintf(os.Stderr, "bus-capture: %s.\n", err)
+		os.Exit(1)
+	}
+}
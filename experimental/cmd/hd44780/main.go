@@ -55,14 +55,18 @@ func mainFunc() error {
 		return fmt.Errorf("strobe pin %s can not be found", *ePin)
 	}
 
-	var dataPins [4]gpio.PinOut
+	var dataPins [4]gpio.PinIO
 	for i, pinName := range pinsStr {
 		if dataPins[i] = gpioreg.ByName(pinName); dataPins[i] == nil {
 			return fmt.Errorf("data pin %s can not be found", pinName)
 		}
 	}
 
-	dev, err := hd44780.New(dataPins[:], rsPinReg, ePinReg)
+	g, err := gpio.NewGroup(dataPins[:]...)
+	if err != nil {
+		return err
+	}
+	dev, err := hd44780.NewGroup(g, rsPinReg, ePinReg)
 	if err != nil {
 		return err
 	}
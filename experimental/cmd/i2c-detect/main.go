@@ -0,0 +1,109 @@
+// Copyright 2019 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+// i2c-detect scans an I²C bus and prints the devices found, similar to
+// i2cdetect.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+
+	"periph.io/x/periph/conn/i2c/i2creg"
+	"periph.io/x/periph/experimental/conn/i2c/i2cutil"
+	"periph.io/x/periph/host"
+)
+
+// printGrid prints the devices found as a table of 16 addresses per row.
+func printGrid(w io.Writer, opts *i2cutil.ScanOpts, devs []i2cutil.Device) {
+	found := map[uint16]bool{}
+	for _, d := range devs {
+		found[d.Addr] = true
+	}
+	fmt.Fprint(w, "    ")
+	for i := 0; i < 16; i++ {
+		fmt.Fprintf(w, "  %x", i)
+	}
+	for addr := uint16(0); addr < 0x80; addr++ {
+		if addr%16 == 0 {
+			fmt.Fprintf(w, "\n%02x:", addr)
+		}
+		switch {
+		case addr < opts.First || addr > opts.Last:
+			fmt.Fprint(w, "   ")
+		case found[addr]:
+			fmt.Fprintf(w, " %02x", addr)
+		default:
+			fmt.Fprint(w, " --")
+		}
+	}
+	fmt.Fprint(w, "\n")
+}
+
+func mainImpl() error {
+	busName := flag.String("b", "", "I²C bus to use")
+	quick := flag.Bool("q", false, "probe with SMBus quick write; may corrupt some EEPROMs")
+	read := flag.Bool("r", false, "probe with a byte read; may lock up write-only devices")
+	all := flag.Bool("a", false, "scan all addresses, including the reserved ones")
+	id := flag.Bool("id", false, "read the devices found to identify them; may have side effects on unknown devices")
+	verbose := flag.Bool("v", false, "verbose mode")
+	flag.Parse()
+	if !*verbose {
+		log.SetOutput(ioutil.Discard)
+	}
+	log.SetFlags(log.Lmicroseconds)
+	if flag.NArg() != 0 {
+		return errors.New("unexpected argument, try -help")
+	}
+	if *quick && *read {
+		return errors.New("use only one of -q or -r")
+	}
+
+	if _, err := host.Init(); err != nil {
+		return err
+	}
+	bus, err := i2creg.Open(*busName)
+	if err != nil {
+		return err
+	}
+	defer bus.Close()
+
+	opts := i2cutil.DefaultScanOpts
+	opts.Identify = *id
+	if *quick {
+		opts.Probe = i2cutil.ProbeQuickWrite
+	} else if *read {
+		opts.Probe = i2cutil.ProbeReadByte
+	}
+	if *all {
+		opts.First = 0
+		opts.Last = 0x7F
+	}
+	log.Printf("Scanning %s with %s", bus, opts.Probe)
+	devs, err := i2cutil.Scan(bus, &opts)
+	if err != nil {
+		return err
+	}
+	printGrid(os.Stdout, &opts, devs)
+	if *id {
+		for _, d := range devs {
+			if len(d.Parts) != 0 || len(d.Candidates) != 0 {
+				fmt.Printf("%s\n", &d)
+			}
+		}
+	}
+	return nil
+}
+
+func main() {
+	if err := mainImpl(); err != nil {
+		fmt.Fprintf(os.Stderr, "i2c-detect: %s.\n", err)
+		os.Exit(1)
+	}
+}
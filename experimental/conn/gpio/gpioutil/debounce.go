@@ -5,6 +5,8 @@
 package gpioutil
 
 import (
+	"context"
+	"sync"
 	"time"
 
 	"periph.io/x/periph/conn/gpio"
@@ -22,6 +24,9 @@ type debounced struct {
 	debounce time.Duration
 
 	// Mutable.
+	mu    sync.Mutex
+	level gpio.Level // last level reported by WaitForEdgeContext()
+	until time.Time  // end of the debounce period of the last edge reported
 }
 
 // Debounce returns a debounced gpio.PinIO from a gpio.PinIO source. Only the
@@ -47,12 +52,17 @@ func Debounce(p gpio.PinIO, denoise, debounce time.Duration, edge gpio.Edge) (gp
 		denoise:  denoise,
 		debounce: debounce,
 		// Mutable.
+		level: p.Read(),
 	}, nil
 }
 
 // In implements gpio.PinIO.
 func (d *debounced) In(pull gpio.Pull, edge gpio.Edge) error {
 	err := d.PinIO.In(pull, gpio.BothEdges)
+	d.mu.Lock()
+	d.level = d.PinIO.Read()
+	d.until = time.Time{}
+	d.mu.Unlock()
 	return err
 }
 
@@ -73,6 +83,57 @@ func (d *debounced) WaitForEdge(timeout time.Duration) bool {
 	return true
 }
 
+// WaitForEdgeContext implements gpio.EdgeWaiter.
+//
+// An edge is reported once the level stayed steady for denoise, and the edges
+// happening for debounce after an edge reported are ignored. The time
+// returned is when the underlying gpio.PinIO last changed.
+func (d *debounced) WaitForEdgeContext(ctx context.Context) (gpio.Edge, time.Time, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	t, err := d.discardEdges(ctx)
+	if err != nil {
+		return gpio.NoEdge, time.Time{}, err
+	}
+	if t.Before(d.until) {
+		// A change during the debounce period is reported at the end of it.
+		t = d.until
+	}
+	l := d.PinIO.Read()
+	if l != d.level && t.IsZero() {
+		// The level changed since Debounce() or In() was called.
+		t = now()
+	}
+	for l == d.level {
+		_, t2, err := gpio.WaitForEdgeContext(ctx, d.PinIO)
+		if err != nil {
+			return gpio.NoEdge, time.Time{}, err
+		}
+		t = t2
+		// Wait for the level to be steady; each edge restarts the wait.
+		for d.denoise != 0 {
+			cctx, cancel := context.WithTimeout(ctx, d.denoise)
+			_, t2, err = gpio.WaitForEdgeContext(cctx, d.PinIO)
+			cancel()
+			if err != nil {
+				if err = ctx.Err(); err != nil {
+					return gpio.NoEdge, time.Time{}, err
+				}
+				break
+			}
+			t = t2
+		}
+		// When the level went back to the one last reported, it was noise.
+		l = d.PinIO.Read()
+	}
+	d.level = l
+	d.until = t.Add(d.debounce)
+	if l == gpio.High {
+		return gpio.RisingEdge, t, nil
+	}
+	return gpio.FallingEdge, t, nil
+}
+
 // Halt implements gpio.PinIO.
 func (d *debounced) Halt() error {
 	return nil
@@ -86,5 +147,28 @@ func (d *debounced) Real() gpio.PinIO {
 	return d.PinIO
 }
 
+//
+
+// discardEdges ignores the edges until the end of the debounce period of the
+// last edge reported. It returns the time of the last edge ignored, if any.
+//
+// d.mu must be held.
+func (d *debounced) discardEdges(ctx context.Context) (time.Time, error) {
+	var last time.Time
+	if d.debounce == 0 || d.until.IsZero() {
+		return last, nil
+	}
+	cctx, cancel := context.WithDeadline(ctx, d.until)
+	defer cancel()
+	for {
+		_, t, err := gpio.WaitForEdgeContext(cctx, d.PinIO)
+		if err != nil {
+			return last, ctx.Err()
+		}
+		last = t
+	}
+}
+
 var now = time.Now
 var _ gpio.PinIO = &debounced{}
+var _ gpio.EdgeWaiter = &debounced{}
@@ -5,6 +5,7 @@
 package gpioutil
 
 import (
+	"context"
 	"testing"
 	"time"
 
@@ -104,6 +105,83 @@ func TestDebounce_WaitForEdge_Timeout(t *testing.T) {
 	}
 }
 
+func TestDebounce_WaitForEdgeContext(t *testing.T) {
+	offsets := []time.Duration{}
+	defer mocktime(t, offsets)()
+	f := gpiotest.Pin{EdgesChan: make(chan gpio.Level, 1)}
+	p, err := Debounce(&f, 10*time.Millisecond, 0, gpio.BothEdges)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.EdgesChan <- gpio.High
+	if e, _, err := gpio.WaitForEdgeContext(context.Background(), p); err != nil || e != gpio.RisingEdge {
+		t.Fatal(e, err)
+	}
+}
+
+func TestDebounce_WaitForEdgeContext_Denoise(t *testing.T) {
+	defer mocktime(t, nil)()
+	f := gpiotest.Pin{EdgesChan: make(chan gpio.Level, 3)}
+	p, err := Debounce(&f, 10*time.Millisecond, 0, gpio.BothEdges)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The bounces are reported as a single edge.
+	f.EdgesChan <- gpio.High
+	f.EdgesChan <- gpio.Low
+	f.EdgesChan <- gpio.High
+	if e, _, err := gpio.WaitForEdgeContext(context.Background(), p); err != nil || e != gpio.RisingEdge {
+		t.Fatal(e, err)
+	}
+	// A glitch back to the same level is not reported.
+	f.EdgesChan <- gpio.Low
+	f.EdgesChan <- gpio.High
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if e, _, err := gpio.WaitForEdgeContext(ctx, p); err != context.DeadlineExceeded {
+		t.Fatal(e, err)
+	}
+}
+
+func TestDebounce_WaitForEdgeContext_Debounce(t *testing.T) {
+	defer mocktime(t, nil)()
+	f := gpiotest.Pin{EdgesChan: make(chan gpio.Level, 2)}
+	p, err := Debounce(&f, 0, 50*time.Millisecond, gpio.BothEdges)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.EdgesChan <- gpio.High
+	if e, _, err := gpio.WaitForEdgeContext(context.Background(), p); err != nil || e != gpio.RisingEdge {
+		t.Fatal(e, err)
+	}
+	// The edges right after are ignored.
+	f.EdgesChan <- gpio.Low
+	f.EdgesChan <- gpio.High
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if e, _, err := gpio.WaitForEdgeContext(ctx, p); err != context.DeadlineExceeded {
+		t.Fatal(e, err)
+	}
+	f.EdgesChan <- gpio.Low
+	e, t1, err := gpio.WaitForEdgeContext(context.Background(), p)
+	if err != nil || e != gpio.FallingEdge {
+		t.Fatal(e, err)
+	}
+	// The level changed during the debounce period; it is reported at the end
+	// of it.
+	f.EdgesChan <- gpio.High
+	e, t2, err := gpio.WaitForEdgeContext(context.Background(), p)
+	if err != nil || e != gpio.RisingEdge || t2.Sub(t1) != 50*time.Millisecond {
+		t.Fatal(e, t2.Sub(t1), err)
+	}
+	// The context is checked during the debounce period.
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	if _, _, err := gpio.WaitForEdgeContext(ctx, p); err != context.Canceled {
+		t.Fatal(err)
+	}
+}
+
 func TestDebounce_RealPin(t *testing.T) {
 	defer mocktime(t, []time.Duration{})()
 	f := gpiotest.Pin{EdgesChan: make(chan gpio.Level)}
@@ -55,6 +55,23 @@ func ExamplePollEdge() {
 	}
 }
 
+func ExampleSoftPWM() {
+	p := gpioreg.ByName("GPIO6")
+	if p == nil {
+		log.Fatal("please open another GPIO")
+	}
+	// Dim a LED at 25% with software PWM if the pin doesn't support hardware
+	// PWM.
+	l := gpioutil.SoftPWM(p)
+	if err := l.PWM(gpio.DutyMax/4, 200*physic.Hertz); err != nil {
+		log.Fatal(err)
+	}
+	time.Sleep(10 * time.Second)
+	if err := l.Halt(); err != nil {
+		log.Fatal(err)
+	}
+}
+
 func Example() {
 	// Complete solution:
 	// - Fallback to software polling if the GPIO doesn't support hardware edge
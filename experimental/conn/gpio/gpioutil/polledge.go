@@ -5,6 +5,8 @@
 package gpioutil
 
 import (
+	"context"
+	"errors"
 	"time"
 
 	"periph.io/x/periph/conn/gpio"
@@ -47,23 +49,73 @@ func (p *pollEdge) In(pull gpio.Pull, edge gpio.Edge) error {
 
 // WaitForEdge implements gpio.PinIO.
 func (p *pollEdge) WaitForEdge(timeout time.Duration) bool {
-	select {
-	case <-p.die:
-	default:
-	}
-	defer func() {
-		select {
-		case <-p.die:
-		default:
-		}
-	}()
-	curr := p.PinIO.Read()
+	p.drain()
+	defer p.drain()
 	// -1 means to wait indefinitely.
 	if timeout >= 0 {
 		defer time.AfterFunc(timeout, func() {
 			p.die <- struct{}{}
 		}).Stop()
 	}
+	_, ok := p.poll(nil)
+	return ok
+}
+
+// WaitForEdgeContext implements gpio.EdgeWaiter.
+//
+// The time returned is when the change was polled.
+func (p *pollEdge) WaitForEdgeContext(ctx context.Context) (gpio.Edge, time.Time, error) {
+	p.drain()
+	defer p.drain()
+	n, ok := p.poll(ctx.Done())
+	if !ok {
+		if err := ctx.Err(); err != nil {
+			return gpio.NoEdge, time.Time{}, err
+		}
+		return gpio.NoEdge, time.Time{}, errors.New("gpioutil: halted")
+	}
+	if n == gpio.High {
+		return gpio.RisingEdge, time.Now(), nil
+	}
+	return gpio.FallingEdge, time.Now(), nil
+}
+
+// Halt implements gpio.PinIO.
+//
+// It unblocks any WaitForEdge loop.
+func (p *pollEdge) Halt() error {
+	select {
+	// If a WaitForEdge was pending, it will be unblocked.
+	case p.die <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Real implements gpio.RealPin.
+func (p *pollEdge) Real() gpio.PinIO {
+	if r, ok := p.PinIO.(gpio.RealPin); ok {
+		return r.Real()
+	}
+	return p.PinIO
+}
+
+//
+
+// drain discards a pending unblock request.
+func (p *pollEdge) drain() {
+	select {
+	case <-p.die:
+	default:
+	}
+}
+
+// poll polls the pin until an edge as configured with In() is detected, then
+// returns the new level.
+//
+// It returns false when Halt() is called or when done is closed.
+func (p *pollEdge) poll(done <-chan struct{}) (gpio.Level, bool) {
+	curr := p.PinIO.Read()
 	// Sadly it's not possible to stop then restart a ticker, so we can't cache
 	// it in the object.
 	t := time.NewTicker(p.period)
@@ -76,42 +128,25 @@ func (p *pollEdge) WaitForEdge(timeout time.Duration) bool {
 				switch p.edge {
 				case gpio.RisingEdge:
 					if n == gpio.High {
-						return true
+						return n, true
 					}
 					curr = n
 				case gpio.FallingEdge:
 					if n == gpio.Low {
-						return true
+						return n, true
 					}
 					curr = n
 				case gpio.BothEdges:
-					return true
+					return n, true
 				}
 			}
 		case <-p.die:
-			return false
+			return curr, false
+		case <-done:
+			return curr, false
 		}
 	}
 }
 
-// Halt implements gpio.PinIO.
-//
-// It unblocks any WaitForEdge loop.
-func (p *pollEdge) Halt() error {
-	select {
-	// If a WaitForEdge was pending, it will be unblocked.
-	case p.die <- struct{}{}:
-	default:
-	}
-	return nil
-}
-
-// Real implements gpio.RealPin.
-func (p *pollEdge) Real() gpio.PinIO {
-	if r, ok := p.PinIO.(gpio.RealPin); ok {
-		return r.Real()
-	}
-	return p.PinIO
-}
-
 var _ gpio.PinIO = &pollEdge{}
+var _ gpio.EdgeWaiter = &pollEdge{}
@@ -5,6 +5,7 @@
 package gpioutil
 
 import (
+	"context"
 	"sync"
 	"testing"
 	"time"
@@ -97,6 +98,47 @@ func TestPollEdge_BothEdges(t *testing.T) {
 	}
 }
 
+func TestPollEdge_WaitForEdgeContext(t *testing.T) {
+	f := pinLevels{levels: []gpio.Level{gpio.High, gpio.Low}}
+	p := PollEdge(&f, physic.KiloHertz)
+	if err := p.In(gpio.PullNoChange, gpio.BothEdges); err != nil {
+		t.Fatal(err)
+	}
+	e, when, err := gpio.WaitForEdgeContext(context.Background(), p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e != gpio.FallingEdge || when.IsZero() {
+		t.Fatal(e, when)
+	}
+}
+
+func TestPollEdge_WaitForEdgeContext_Cancel(t *testing.T) {
+	p := PollEdge(&gpiotest.Pin{}, physic.KiloHertz)
+	if err := p.In(gpio.PullNoChange, gpio.BothEdges); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, _, err := gpio.WaitForEdgeContext(ctx, p); err != context.DeadlineExceeded {
+		t.Fatal(err)
+	}
+}
+
+func TestPollEdge_WaitForEdgeContext_Halt(t *testing.T) {
+	f := pinWait{wait: make(chan struct{})}
+	p := PollEdge(&f, physic.Hertz)
+	go func() {
+		<-f.wait
+		if err := p.Halt(); err != nil {
+			t.Error(err)
+		}
+	}()
+	if _, _, err := gpio.WaitForEdgeContext(context.Background(), p); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
 func TestPollEdge_RealPin(t *testing.T) {
 	f := gpiotest.Pin{}
 	p := PollEdge(&f, physic.Hertz)
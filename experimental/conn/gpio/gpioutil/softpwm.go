@@ -0,0 +1,340 @@
+// Copyright 2019 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package gpioutil
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"periph.io/x/periph/conn/gpio"
+	"periph.io/x/periph/conn/gpio/gpiostream"
+	"periph.io/x/periph/conn/physic"
+)
+
+// SoftPWMMaxFreq is the maximum frequency supported by the software scheduler
+// used by SoftPWM().
+//
+// Higher frequencies would make the duty cycle meaningless due to the
+// scheduling jitter.
+const SoftPWMMaxFreq = physic.KiloHertz
+
+// SoftPWM returns a gpio.PinOut which implements PWM() even if p doesn't.
+//
+// On each PWM() call, the hardware PWM of p is tried first. If p doesn't
+// support it and implements gpiostream.PinOut, for example via DMA, the
+// waveform is streamed continuously from a goroutine. Otherwise, a scheduler
+// goroutine shared by all the pins returned by SoftPWM() toggles the pin with
+// Out().
+//
+// The scheduler sleeps until shortly before each edge then busy waits, so the
+// jitter is bounded by the time it takes to call Out(), as long as the
+// process is scheduled. When it falls behind by more than one period, it
+// skips the missed periods instead of catching up with a burst of pulses.
+//
+// If streaming fails above SoftPWMMaxFreq, where the scheduler can't take
+// over, the waveform stops and the error is returned by the next PWM() or
+// Halt() call.
+//
+// Using 0 as frequency selects 100Hz, which is suitable to dim a LED or drive
+// a fan.
+func SoftPWM(p gpio.PinOut) gpio.PinOut {
+	return &softPWM{PinOut: p}
+}
+
+// softPWM is a gpio.PinOut where PWM is done in software when necessary.
+type softPWM struct {
+	// Immutable.
+	gpio.PinOut
+
+	// Mutable.
+	mu sync.Mutex
+	// stop stops the PWM started in software and returns the error that
+	// stopped it early, if any; it is nil when none is running.
+	stop func() error
+
+	// Owned by sched.
+	level  gpio.Level    // current level
+	high   time.Duration // time spent High in each period
+	period time.Duration
+	start  time.Time // start of the current period
+	next   time.Time // next edge
+}
+
+// Out implements gpio.PinOut.
+//
+// It stops the PWM done in software, if any.
+func (s *softPWM) Out(l gpio.Level) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.halt()
+	return s.PinOut.Out(l)
+}
+
+// PWM implements gpio.PinOut.
+func (s *softPWM) PWM(duty gpio.Duty, f physic.Frequency) error {
+	if !duty.Valid() {
+		return errors.New("gpioutil: invalid duty cycle " + duty.String())
+	}
+	if f < 0 {
+		return errors.New("gpioutil: invalid frequency " + f.String())
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.halt(); err != nil {
+		return err
+	}
+	if duty == 0 {
+		return s.PinOut.Out(gpio.Low)
+	}
+	if duty == gpio.DutyMax {
+		return s.PinOut.Out(gpio.High)
+	}
+	if s.PinOut.PWM(duty, f) == nil {
+		return nil
+	}
+	if f == 0 {
+		f = 100 * physic.Hertz
+	}
+	if p, ok := s.PinOut.(gpiostream.PinOut); ok && f <= maxStreamFreq {
+		s.stream(p, duty, f)
+		return nil
+	}
+	if f > SoftPWMMaxFreq {
+		return errors.New("gpioutil: frequency " + f.String() + " is too high for software PWM")
+	}
+	if err := s.PinOut.Out(gpio.Low); err != nil {
+		return err
+	}
+	s.schedule(duty, f)
+	return nil
+}
+
+// Halt implements gpio.PinOut.
+//
+// It stops the PWM done in software, if any.
+func (s *softPWM) Halt() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	err := s.halt()
+	if err2 := s.PinOut.Halt(); err == nil {
+		err = err2
+	}
+	return err
+}
+
+//
+
+// halt stops the PWM done in software and returns the error that stopped it
+// early, if any.
+//
+// s.mu must be held.
+func (s *softPWM) halt() error {
+	if s.stop == nil {
+		return nil
+	}
+	err := s.stop()
+	s.stop = nil
+	return err
+}
+
+// schedule adds the pin to the shared scheduler.
+//
+// s.mu must be held.
+func (s *softPWM) schedule(duty gpio.Duty, f physic.Frequency) {
+	period := f.Period()
+	high := time.Duration(int64(period) * int64(duty) / int64(gpio.DutyMax))
+	sched.add(s, high, period)
+	s.stop = func() error {
+		sched.remove(s)
+		return nil
+	}
+}
+
+// stream streams the waveform to p from a goroutine.
+//
+// Each StreamOut() call covers at least streamDuration. If StreamOut() fails,
+// the pin is handed over to the shared scheduler, or the error is returned by
+// s.stop() if the frequency is too high for it.
+//
+// s.mu must be held.
+func (s *softPWM) stream(p gpiostream.PinOut, duty gpio.Duty, f physic.Frequency) {
+	b := pwmBits(duty, f)
+	die := make(chan struct{})
+	done := make(chan struct{})
+	var failed error // written before done is closed
+	go func() {
+		defer close(done)
+		for {
+			select {
+			case <-die:
+				return
+			default:
+			}
+			if err := p.StreamOut(b); err != nil {
+				if f <= SoftPWMMaxFreq {
+					high := time.Duration(int64(f.Period()) * int64(duty) / int64(gpio.DutyMax))
+					sched.add(s, high, f.Period())
+				} else {
+					failed = errors.New("gpioutil: streaming PWM failed: " + err.Error())
+				}
+				return
+			}
+		}
+	}()
+	s.stop = func() error {
+		close(die)
+		<-done
+		sched.remove(s)
+		return failed
+	}
+}
+
+// maxStreamFreq is the maximum frequency for the gpiostream fallback, to
+// bound the memory used by each stream.
+const maxStreamFreq = 100 * physic.KiloHertz
+
+// streamDuration is the minimum duration of each stream sent by the
+// gpiostream fallback.
+const streamDuration = 100 * time.Millisecond
+
+// streamResolution is the number of samples per period in the gpiostream
+// fallback. It must be a multiple of 8.
+const streamResolution = 64
+
+// pwmBits returns a BitStream containing whole periods of the waveform.
+func pwmBits(duty gpio.Duty, f physic.Frequency) *gpiostream.BitStream {
+	periods := int(streamDuration/f.Period()) + 1
+	high := int((int64(duty)*streamResolution + int64(gpio.DutyMax)/2) / int64(gpio.DutyMax))
+	b := &gpiostream.BitStream{
+		Bits: make([]byte, periods*streamResolution/8),
+		Freq: f * streamResolution,
+		LSBF: true,
+	}
+	for i := 0; i < periods; i++ {
+		for j := 0; j < high; j++ {
+			n := i*streamResolution + j
+			b.Bits[n/8] |= 1 << uint(n%8)
+		}
+	}
+	return b
+}
+
+// spinDuration is the time the scheduler busy waits before each edge, to
+// compensate for the timer resolution.
+const spinDuration = 100 * time.Microsecond
+
+// scheduler toggles the pins running a software PWM from a single goroutine.
+type scheduler struct {
+	mu      sync.Mutex
+	pins    []*softPWM
+	running bool
+	wake    chan struct{}
+}
+
+var sched = scheduler{wake: make(chan struct{}, 1)}
+
+func (c *scheduler) add(s *softPWM, high, period time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	s.high = high
+	s.period = period
+	s.start = now
+	s.next = now
+	s.level = gpio.Low
+	c.pins = append(c.pins, s)
+	if !c.running {
+		c.running = true
+		go c.run()
+	}
+	select {
+	case c.wake <- struct{}{}:
+	default:
+	}
+}
+
+// remove removes s from the scheduler. Once it returns, s is not toggled
+// anymore.
+func (c *scheduler) remove(s *softPWM) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, p := range c.pins {
+		if p == s {
+			copy(c.pins[i:], c.pins[i+1:])
+			c.pins[len(c.pins)-1] = nil
+			c.pins = c.pins[:len(c.pins)-1]
+			return
+		}
+	}
+}
+
+func (c *scheduler) run() {
+	t := time.NewTimer(time.Hour)
+	defer t.Stop()
+	for {
+		c.mu.Lock()
+		if len(c.pins) == 0 {
+			c.running = false
+			c.mu.Unlock()
+			return
+		}
+		next := c.pins[0].next
+		for _, p := range c.pins[1:] {
+			if p.next.Before(next) {
+				next = p.next
+			}
+		}
+		c.mu.Unlock()
+
+		if d := next.Sub(time.Now()) - spinDuration; d > 0 {
+			if !t.Stop() {
+				select {
+				case <-t.C:
+				default:
+				}
+			}
+			t.Reset(d)
+			select {
+			case <-t.C:
+			case <-c.wake:
+				// A pin was added; recalculate the next edge.
+				continue
+			}
+		}
+		for time.Now().Before(next) {
+		}
+
+		c.mu.Lock()
+		now := time.Now()
+		for _, p := range c.pins {
+			if !p.next.After(now) {
+				p.toggle(now)
+			}
+		}
+		c.mu.Unlock()
+	}
+}
+
+// toggle changes the level of the pin and calculates the next edge.
+//
+// sched.mu must be held.
+func (s *softPWM) toggle(now time.Time) {
+	if s.level == gpio.High {
+		s.level = gpio.Low
+		s.next = s.start.Add(s.period)
+	} else {
+		if now.Sub(s.start) >= s.period {
+			// Skip the missed periods.
+			s.start = s.start.Add(now.Sub(s.start) / s.period * s.period)
+		}
+		s.level = gpio.High
+		s.next = s.start.Add(s.high)
+	}
+	// Ignore errors; there's no one to report them to.
+	_ = s.PinOut.Out(s.level)
+}
+
+var _ gpio.PinOut = &softPWM{}
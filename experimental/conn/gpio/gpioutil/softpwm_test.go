@@ -0,0 +1,236 @@
+// Copyright 2019 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package gpioutil
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"periph.io/x/periph/conn/gpio"
+	"periph.io/x/periph/conn/gpio/gpiostream"
+	"periph.io/x/periph/conn/gpio/gpiotest"
+	"periph.io/x/periph/conn/physic"
+)
+
+func TestSoftPWM_Hardware(t *testing.T) {
+	f := gpiotest.Pin{}
+	p := SoftPWM(&f)
+	if err := p.PWM(gpio.DutyHalf, physic.KiloHertz); err != nil {
+		t.Fatal(err)
+	}
+	if f.D != gpio.DutyHalf || f.F != physic.KiloHertz {
+		t.Fatal(f.D, f.F)
+	}
+}
+
+func TestSoftPWM_Err(t *testing.T) {
+	p := SoftPWM(&pinNoPWM{})
+	if p.PWM(-1, physic.Hertz) == nil {
+		t.Fatal("invalid duty")
+	}
+	if p.PWM(gpio.DutyHalf, -physic.Hertz) == nil {
+		t.Fatal("invalid frequency")
+	}
+	if p.PWM(gpio.DutyHalf, 2*SoftPWMMaxFreq) == nil {
+		t.Fatal("frequency too high")
+	}
+}
+
+func TestSoftPWM_Extremes(t *testing.T) {
+	f := pinNoPWM{}
+	p := SoftPWM(&f)
+	if err := p.PWM(gpio.DutyMax, physic.Hertz); err != nil {
+		t.Fatal(err)
+	}
+	if l := f.Read(); l != gpio.High {
+		t.Fatal(l)
+	}
+	if err := p.PWM(0, physic.Hertz); err != nil {
+		t.Fatal(err)
+	}
+	if l := f.Read(); l != gpio.Low {
+		t.Fatal(l)
+	}
+}
+
+func TestSoftPWM_Scheduler(t *testing.T) {
+	f := pinNoPWM{}
+	p := SoftPWM(&f)
+	if err := p.PWM(gpio.DutyHalf, 100*physic.Hertz); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if err := p.Out(gpio.Low); err != nil {
+		t.Fatal(err)
+	}
+	n := f.count()
+	// Expect around 20 edges; be lenient with loaded machines.
+	if n < 4 {
+		t.Fatalf("expected more edges; got %d", n)
+	}
+	time.Sleep(30 * time.Millisecond)
+	if n2 := f.count(); n2 != n {
+		t.Fatalf("scheduler kept toggling the pin: %d != %d", n2, n)
+	}
+	if err := p.Halt(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSoftPWM_Toggle(t *testing.T) {
+	f := pinNoPWM{}
+	start := time.Unix(10, 0)
+	s := &softPWM{PinOut: &f, high: 2 * time.Millisecond, period: 10 * time.Millisecond, start: start, next: start}
+	s.toggle(start)
+	if s.level != gpio.High || !s.next.Equal(start.Add(2*time.Millisecond)) {
+		t.Fatal(s.level, s.next)
+	}
+	s.toggle(s.next)
+	if s.level != gpio.Low || !s.next.Equal(start.Add(10*time.Millisecond)) {
+		t.Fatal(s.level, s.next)
+	}
+	// 3.5 periods late; the missed periods are skipped.
+	s.toggle(start.Add(45 * time.Millisecond))
+	if s.level != gpio.High || !s.start.Equal(start.Add(40*time.Millisecond)) || !s.next.Equal(start.Add(42*time.Millisecond)) {
+		t.Fatal(s.level, s.start, s.next)
+	}
+}
+
+func TestSoftPWM_Stream(t *testing.T) {
+	f := pinStream{streamed: make(chan *gpiostream.BitStream, 1)}
+	p := SoftPWM(&f)
+	if err := p.PWM(gpio.DutyMax/4, physic.KiloHertz); err != nil {
+		t.Fatal(err)
+	}
+	b := <-f.streamed
+	if b.Freq != 64*physic.KiloHertz || len(b.Bits) != 101*8 {
+		t.Fatal(b.Freq, len(b.Bits))
+	}
+	if err := p.Out(gpio.Low); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSoftPWM_Stream_Fallback(t *testing.T) {
+	f := pinStream{err: errors.New("no DMA")}
+	p := SoftPWM(&f)
+	if err := p.PWM(gpio.DutyHalf, 100*physic.Hertz); err != nil {
+		t.Fatal(err)
+	}
+	for start := time.Now(); f.count() < 2; {
+		if time.Since(start) > 10*time.Second {
+			t.Fatal("scheduler didn't take over")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if err := p.Halt(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSoftPWM_Stream_Err(t *testing.T) {
+	f := pinStream{err: errors.New("no DMA")}
+	p := SoftPWM(&f)
+	// Too fast for the scheduler to take over.
+	if err := p.PWM(gpio.DutyHalf, 10*physic.KiloHertz); err != nil {
+		t.Fatal(err)
+	}
+	for start := time.Now(); f.streamCalls() == 0; {
+		if time.Since(start) > 10*time.Second {
+			t.Fatal("StreamOut() wasn't called")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if err := p.Halt(); err == nil {
+		t.Fatal("streaming failure should be reported")
+	}
+	if err := p.Halt(); err != nil {
+		t.Fatal(err)
+	}
+	if f.count() != 0 {
+		t.Fatal(f.count())
+	}
+}
+
+func TestPWMBits(t *testing.T) {
+	b := pwmBits(gpio.DutyMax/4, 20*physic.Hertz)
+	// 100ms at 20Hz is 2 periods, plus one.
+	if len(b.Bits) != 3*8 {
+		t.Fatal(len(b.Bits))
+	}
+	if !b.LSBF || b.Freq != 20*64*physic.Hertz {
+		t.Fatal(b.LSBF, b.Freq)
+	}
+	// 16 bits high out of 64.
+	for i := 0; i < 3; i++ {
+		for j, v := range b.Bits[i*8 : (i+1)*8] {
+			expected := byte(0)
+			if j < 2 {
+				expected = 0xFF
+			}
+			if v != expected {
+				t.Fatal(i, j, v)
+			}
+		}
+	}
+}
+
+//
+
+// pinNoPWM is a pin that doesn't support hardware PWM and counts the calls
+// to Out().
+type pinNoPWM struct {
+	gpiotest.Pin
+	mu  sync.Mutex
+	out int
+}
+
+func (p *pinNoPWM) Out(l gpio.Level) error {
+	p.mu.Lock()
+	p.out++
+	p.mu.Unlock()
+	return p.Pin.Out(l)
+}
+
+func (p *pinNoPWM) PWM(duty gpio.Duty, f physic.Frequency) error {
+	return errors.New("not supported")
+}
+
+func (p *pinNoPWM) count() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.out
+}
+
+// pinStream is a pin that supports gpiostream.PinOut.
+type pinStream struct {
+	pinNoPWM
+	err      error
+	streamed chan *gpiostream.BitStream
+	calls    int
+}
+
+func (p *pinStream) StreamOut(s gpiostream.Stream) error {
+	p.mu.Lock()
+	p.calls++
+	p.mu.Unlock()
+	if p.err != nil {
+		return p.err
+	}
+	select {
+	case p.streamed <- s.(*gpiostream.BitStream):
+	default:
+	}
+	time.Sleep(time.Millisecond)
+	return nil
+}
+
+func (p *pinStream) streamCalls() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.calls
+}
@@ -0,0 +1,6 @@
+// Copyright 2019 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+// Package i2cutil includes utilities to discover the devices on an I²C bus.
+package i2cutil
@@ -0,0 +1,171 @@
+// Copyright 2019 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package i2cutil
+
+import (
+	"errors"
+	"strconv"
+
+	"periph.io/x/periph/conn/i2c"
+	"periph.io/x/periph/conn/i2c/smbus"
+)
+
+// Probe is the method used to detect if a device acknowledges its address.
+type Probe int
+
+// Valid Probe values.
+const (
+	// ProbeAuto uses ProbeReadByte for the address ranges 0x30-0x37 and
+	// 0x50-0x5F, where EEPROMs may be corrupted by a quick write, and
+	// ProbeQuickWrite elsewhere. This is what i2cdetect does.
+	//
+	// ProbeReadByte is used everywhere if the bus doesn't support
+	// ProbeQuickWrite.
+	ProbeAuto Probe = iota
+	// ProbeQuickWrite sends the address with the R/W bit cleared, without
+	// data. It can corrupt the content of some EEPROMs, like the AT24RF08.
+	//
+	// It requires the bus to implement smbus.Bus or i2c.MsgBus.
+	ProbeQuickWrite
+	// ProbeReadByte reads one byte. It can lock up write-only devices, which
+	// keep SDA low.
+	ProbeReadByte
+)
+
+const probeName = "ProbeAutoProbeQuickWriteProbeReadByte"
+
+var probeIndex = [...]uint8{0, 9, 24, 37}
+
+func (p Probe) String() string {
+	if p < 0 || int(p) >= len(probeIndex)-1 {
+		return "Probe(" + strconv.Itoa(int(p)) + ")"
+	}
+	return probeName[probeIndex[p]:probeIndex[p+1]]
+}
+
+// ScanOpts configures Scan().
+type ScanOpts struct {
+	// Probe is the method used to detect the devices.
+	Probe Probe
+	// First and Last are the range of 7 bits addresses to scan, inclusive.
+	First, Last uint16
+	// Skip lists the addresses that must not be probed, for example because
+	// a device is known to misbehave when probed.
+	Skip []uint16
+	// Identify reads the responding devices to match them against
+	// Signatures. It may have side effects on devices that are not listed in
+	// Signatures.
+	Identify bool
+}
+
+// DefaultScanOpts scans the addresses not reserved by the I²C
+// specification, the same as i2cdetect.
+var DefaultScanOpts = ScanOpts{First: 0x08, Last: 0x77}
+
+// Device is a device found by Scan().
+type Device struct {
+	Addr uint16
+	// Parts lists the parts whose signature matched. It is only set when
+	// ScanOpts.Identify is true.
+	Parts []string
+	// Candidates lists the parts known to use this address but that have no
+	// signature to confirm it. It is only set when ScanOpts.Identify is true
+	// and Parts is empty.
+	Candidates []string
+}
+
+func (d *Device) String() string {
+	s := i2c.Addr(d.Addr).String()
+	for i, p := range d.Parts {
+		if i == 0 {
+			s += ": "
+		} else {
+			s += ", "
+		}
+		s += p
+	}
+	for i, p := range d.Candidates {
+		if i == 0 {
+			s += ": "
+		} else {
+			s += ", "
+		}
+		s += p + "?"
+	}
+	return s
+}
+
+// Scan probes each address of b as specified by opts and returns the devices
+// that acknowledged their address.
+//
+// Uses DefaultScanOpts if opts is nil.
+func Scan(b i2c.Bus, opts *ScanOpts) ([]Device, error) {
+	if opts == nil {
+		opts = &DefaultScanOpts
+	}
+	if opts.First > opts.Last || opts.Last > 0x7F {
+		return nil, errors.New("i2cutil: invalid address range")
+	}
+	quick := canQuickWrite(b)
+	if opts.Probe == ProbeQuickWrite && !quick {
+		return nil, errors.New("i2cutil: " + b.String() + " doesn't support quick write")
+	}
+	var out []Device
+	for addr := opts.First; addr <= opts.Last; addr++ {
+		if contains(opts.Skip, addr) {
+			continue
+		}
+		p := opts.Probe
+		if p == ProbeAuto {
+			p = ProbeQuickWrite
+			if !quick || (addr >= 0x30 && addr <= 0x37) || (addr >= 0x50 && addr <= 0x5F) {
+				p = ProbeReadByte
+			}
+		}
+		if !probe(b, addr, p) {
+			continue
+		}
+		d := Device{Addr: addr}
+		if opts.Identify {
+			if d.Parts = Identify(b, addr, Signatures); len(d.Parts) == 0 {
+				d.Candidates = Candidates(addr, Signatures)
+			}
+		}
+		out = append(out, d)
+	}
+	return out, nil
+}
+
+//
+
+// canQuickWrite returns true if b can send an address without data.
+func canQuickWrite(b i2c.Bus) bool {
+	if s, ok := b.(smbus.Bus); ok && s.SMBusSupported(smbus.QuickWrite, false) {
+		return true
+	}
+	_, ok := b.(i2c.MsgBus)
+	return ok
+}
+
+// probe returns true if a device acknowledged addr.
+func probe(b i2c.Bus, addr uint16, p Probe) bool {
+	if p == ProbeReadByte {
+		var r [1]byte
+		return b.Tx(addr, nil, r[:]) == nil
+	}
+	if s, ok := b.(smbus.Bus); ok && s.SMBusSupported(smbus.QuickWrite, false) {
+		return (&smbus.Dev{Bus: b, Addr: addr}).Quick(false) == nil
+	}
+	return i2c.TxMsgs(b, []i2c.Msg{{Addr: addr}}) == nil
+}
+
+func contains(l []uint16, v uint16) bool {
+	for _, x := range l {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
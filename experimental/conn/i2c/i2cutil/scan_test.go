@@ -0,0 +1,197 @@
+// Copyright 2019 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package i2cutil
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"periph.io/x/periph/conn/i2c"
+	"periph.io/x/periph/conn/physic"
+)
+
+func TestProbe_String(t *testing.T) {
+	if s := ProbeQuickWrite.String(); s != "ProbeQuickWrite" {
+		t.Fatal(s)
+	}
+	if s := Probe(10).String(); s != "Probe(10)" {
+		t.Fatal(s)
+	}
+}
+
+func TestScan(t *testing.T) {
+	b := newFakeBus()
+	d, err := Scan(b, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(d, []Device{{Addr: 0x18}, {Addr: 0x3C}, {Addr: 0x50}, {Addr: 0x76}}) {
+		t.Fatal(d)
+	}
+	// Doesn't support quick write, so everything is read.
+	if len(b.quick) != 0 || len(b.read) != 0x77-0x08+1 {
+		t.Fatal(b.quick, b.read)
+	}
+}
+
+func TestScan_Auto(t *testing.T) {
+	b := &fakeMsgBus{fakeBus: *newFakeBus()}
+	opts := ScanOpts{First: 0x10, Last: 0x5F, Skip: []uint16{0x3C}, Identify: true}
+	d, err := Scan(b, &opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Device{
+		{Addr: 0x18, Parts: []string{"mcp9808"}},
+		{Addr: 0x50},
+	}
+	if !reflect.DeepEqual(d, expected) {
+		t.Fatal(d)
+	}
+	// EEPROMs ranges are read.
+	if len(b.read) != 8+16 {
+		t.Fatal(b.read)
+	}
+	if len(b.quick) != 0x5F-0x10+1-8-16-1 {
+		t.Fatal(b.quick)
+	}
+}
+
+func TestScan_Identify(t *testing.T) {
+	b := newFakeBus()
+	d, err := Scan(b, &ScanOpts{Probe: ProbeReadByte, First: 0x08, Last: 0x77, Identify: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Device{
+		{Addr: 0x18, Parts: []string{"mcp9808"}},
+		{Addr: 0x3C, Candidates: []string{"ssd1306"}},
+		{Addr: 0x50},
+		{Addr: 0x76, Parts: []string{"bme280"}},
+	}
+	if !reflect.DeepEqual(d, expected) {
+		t.Fatal(d)
+	}
+	if s := d[1].String(); s != "0x3c: ssd1306?" {
+		t.Fatal(s)
+	}
+	if s := d[3].String(); s != "0x76: bme280" {
+		t.Fatal(s)
+	}
+}
+
+func TestScan_Err(t *testing.T) {
+	b := newFakeBus()
+	if _, err := Scan(b, &ScanOpts{First: 0x10, Last: 0x08}); err == nil {
+		t.Fatal("invalid range")
+	}
+	if _, err := Scan(b, &ScanOpts{First: 0x10, Last: 0x80}); err == nil {
+		t.Fatal("invalid range")
+	}
+	if _, err := Scan(b, &ScanOpts{Probe: ProbeQuickWrite, First: 0x08, Last: 0x77}); err == nil {
+		t.Fatal("quick write is not supported")
+	}
+}
+
+func TestSignatures(t *testing.T) {
+	names := map[string]bool{}
+	for _, s := range Signatures {
+		if names[s.Name] {
+			t.Fatalf("duplicate %s", s.Name)
+		}
+		names[s.Name] = true
+		if len(s.Addrs) == 0 {
+			t.Fatalf("%s: no address", s.Name)
+		}
+		for _, a := range s.Addrs {
+			if a < 0x08 || a > 0x77 {
+				t.Fatalf("%s: invalid address %#x", s.Name, a)
+			}
+		}
+		if len(s.Mask) > len(s.Value) {
+			t.Fatalf("%s: invalid mask", s.Name)
+		}
+	}
+}
+
+func TestIdentify_Mask(t *testing.T) {
+	b := newFakeBus()
+	sigs := []Signature{
+		{Name: "foo", Addrs: []uint16{0x18}, Reg: 0x07, Value: []byte{0x04, 0x00}, Mask: []byte{0xFF, 0x00}},
+		{Name: "bar", Addrs: []uint16{0x18}, Reg: 0x07, Value: []byte{0x04, 0x01}},
+		{Name: "baz", Addrs: []uint16{0x18}, Reg: 0x42, Value: []byte{0x04}},
+	}
+	if p := Identify(b, 0x18, sigs); !reflect.DeepEqual(p, []string{"foo"}) {
+		t.Fatal(p)
+	}
+	if p := Candidates(0x18, sigs); !reflect.DeepEqual(p, []string{"foo", "bar", "baz"}) {
+		t.Fatal(p)
+	}
+}
+
+//
+
+// fakeBus has an mcp9808 at 0x18, an ssd1306 at 0x3C, an EEPROM at 0x50 and
+// a bme280 at 0x76.
+type fakeBus struct {
+	devs  map[uint16]map[byte][]byte
+	read  []uint16
+	quick []uint16
+}
+
+func newFakeBus() *fakeBus {
+	return &fakeBus{
+		devs: map[uint16]map[byte][]byte{
+			0x18: {0x06: {0x00, 0x54}, 0x07: {0x04, 0x03}},
+			0x3C: {},
+			0x50: {},
+			0x76: {0xD0: {0x60}},
+		},
+	}
+}
+
+func (f *fakeBus) String() string {
+	return "fake"
+}
+
+func (f *fakeBus) Tx(addr uint16, w, r []byte) error {
+	if len(w) == 0 {
+		f.read = append(f.read, addr)
+	}
+	regs, ok := f.devs[addr]
+	if !ok {
+		return errors.New("NACK")
+	}
+	if len(w) == 0 {
+		return nil
+	}
+	v, ok := regs[w[0]]
+	if !ok {
+		return errors.New("NACK")
+	}
+	copy(r, v)
+	return nil
+}
+
+func (f *fakeBus) SetSpeed(freq physic.Frequency) error {
+	return nil
+}
+
+// fakeMsgBus supports quick write via TxMsgs.
+type fakeMsgBus struct {
+	fakeBus
+}
+
+func (f *fakeMsgBus) TxMsgs(msgs []i2c.Msg) error {
+	if len(msgs) != 1 || len(msgs[0].Buf) != 0 || msgs[0].Flags != 0 {
+		return errors.New("unexpected")
+	}
+	f.quick = append(f.quick, msgs[0].Addr)
+	if _, ok := f.devs[msgs[0].Addr]; !ok {
+		return errors.New("NACK")
+	}
+	return nil
+}
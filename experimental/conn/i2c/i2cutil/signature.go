@@ -0,0 +1,119 @@
+// Copyright 2019 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package i2cutil
+
+import (
+	"bytes"
+
+	"periph.io/x/periph/conn/i2c"
+)
+
+// Signature identifies a part by the addresses it can use and optionally the
+// content of one of its registers.
+type Signature struct {
+	// Name is the name of the part.
+	Name string
+	// Addrs lists the addresses the part can use.
+	Addrs []uint16
+	// Reg is the register to read.
+	Reg byte
+	// Value is the expected content of Reg. Its length determines the number
+	// of bytes read. If it is empty, the signature only matches on the
+	// address and it is not possible to confirm the part is present.
+	Value []byte
+	// Mask, if set, is applied to the bytes read before comparing with Value.
+	Mask []byte
+}
+
+// Signatures lists the parts supported by the drivers in periph.
+//
+// Only parts with an identification register, or a register with a well
+// known value, have a Value.
+var Signatures = []Signature{
+	// devices/bmxx80
+	{Name: "bmp180", Addrs: []uint16{0x77}, Reg: 0xD0, Value: []byte{0x55}},
+	{Name: "bmp280", Addrs: []uint16{0x76, 0x77}, Reg: 0xD0, Value: []byte{0x58}},
+	{Name: "bme280", Addrs: []uint16{0x76, 0x77}, Reg: 0xD0, Value: []byte{0x60}},
+	// devices/cap1xxx; product ID.
+	{Name: "cap1105", Addrs: capAddrs, Reg: 0xFD, Value: []byte{0x56}},
+	{Name: "cap1106", Addrs: capAddrs, Reg: 0xFD, Value: []byte{0x55}},
+	{Name: "cap1126", Addrs: capAddrs, Reg: 0xFD, Value: []byte{0x53}},
+	{Name: "cap1128", Addrs: capAddrs, Reg: 0xFD, Value: []byte{0x52}},
+	{Name: "cap1133", Addrs: capAddrs, Reg: 0xFD, Value: []byte{0x54}},
+	{Name: "cap1166", Addrs: capAddrs, Reg: 0xFD, Value: []byte{0x51}},
+	{Name: "cap1188", Addrs: capAddrs, Reg: 0xFD, Value: []byte{0x50}},
+	// devices/ds248x
+	{Name: "ds2482", Addrs: []uint16{0x18, 0x19, 0x1A, 0x1B}},
+	// devices/lepton; CCI.
+	{Name: "lepton", Addrs: []uint16{0x2A}},
+	// devices/ssd1306
+	{Name: "ssd1306", Addrs: []uint16{0x3C, 0x3D}},
+	// experimental/devices/ads1x15
+	{Name: "ads1x15", Addrs: []uint16{0x48, 0x49, 0x4A, 0x4B}},
+	// experimental/devices/as7262
+	{Name: "as7262", Addrs: []uint16{0x49}},
+	// experimental/devices/bh1750
+	{Name: "bh1750", Addrs: []uint16{0x23, 0x5C}},
+	// experimental/devices/ccs811; hardware ID.
+	{Name: "ccs811", Addrs: []uint16{0x5A, 0x5B}, Reg: 0x20, Value: []byte{0x81}},
+	// experimental/devices/ht16k33
+	{Name: "ht16k33", Addrs: pcaAddrs},
+	// experimental/devices/ina219; configuration register after reset.
+	{Name: "ina219", Addrs: []uint16{0x40, 0x41, 0x42, 0x43, 0x44, 0x45, 0x46, 0x47, 0x48, 0x49, 0x4A, 0x4B, 0x4C, 0x4D, 0x4E, 0x4F}, Reg: 0x00, Value: []byte{0x39, 0x9F}},
+	// experimental/devices/mcp9808; manufacturer ID.
+	{Name: "mcp9808", Addrs: []uint16{0x18, 0x19, 0x1A, 0x1B, 0x1C, 0x1D, 0x1E, 0x1F}, Reg: 0x06, Value: []byte{0x00, 0x54}},
+	// experimental/devices/mpu9250; WHO_AM_I.
+	{Name: "mpu9250", Addrs: []uint16{0x68, 0x69}, Reg: 0x75, Value: []byte{0x71}},
+	// experimental/devices/pca9548
+	{Name: "pca9548", Addrs: pcaAddrs},
+	// experimental/devices/pca9685
+	{Name: "pca9685", Addrs: []uint16{0x40}},
+	// experimental/devices/sn3218
+	{Name: "sn3218", Addrs: []uint16{0x54}},
+}
+
+// Identify returns the name of the parts in sigs with a register signature
+// that matches the device at addr.
+//
+// Reading errors are ignored, since the device may not have the register.
+func Identify(b i2c.Bus, addr uint16, sigs []Signature) []string {
+	var out []string
+	for i := range sigs {
+		s := &sigs[i]
+		if len(s.Value) == 0 || !contains(s.Addrs, addr) {
+			continue
+		}
+		r := make([]byte, len(s.Value))
+		if b.Tx(addr, []byte{s.Reg}, r) != nil {
+			continue
+		}
+		for j := range s.Mask {
+			if j < len(r) {
+				r[j] &= s.Mask[j]
+			}
+		}
+		if bytes.Equal(r, s.Value) {
+			out = append(out, s.Name)
+		}
+	}
+	return out
+}
+
+// Candidates returns the name of the parts in sigs that can use addr.
+func Candidates(addr uint16, sigs []Signature) []string {
+	var out []string
+	for i := range sigs {
+		if contains(sigs[i].Addrs, addr) {
+			out = append(out, sigs[i].Name)
+		}
+	}
+	return out
+}
+
+//
+
+var capAddrs = []uint16{0x28, 0x29, 0x2A, 0x2B, 0x2C}
+
+var pcaAddrs = []uint16{0x70, 0x71, 0x72, 0x73, 0x74, 0x75, 0x76, 0x77}
@@ -24,6 +24,10 @@ import (
 // SkipAddr can be used to skip the address from being sent.
 const SkipAddr uint16 = 0xFFFF
 
+// DefaultStretchTimeout is the default maximum time a device can stretch the
+// clock. It is the minimum timeout of the SMBus specification.
+const DefaultStretchTimeout = 25 * time.Millisecond
+
 // New returns an object that communicates I²C over two pins.
 //
 // BUG(maruel): It is close to working but not yet, the signal is incorrect
@@ -50,19 +54,21 @@ func New(clk gpio.PinIO, data gpio.PinIO, f physic.Frequency) (*I2C, error) {
 		return nil, err
 	}
 	i := &I2C{
-		scl:       clk,
-		sda:       data,
-		halfCycle: f.Period() / 2,
+		scl:            clk,
+		sda:            data,
+		halfCycle:      f.Period() / 2,
+		stretchTimeout: DefaultStretchTimeout,
 	}
 	return i, nil
 }
 
 // I2C represents an I²C master implemented as bit-banging on 2 GPIO pins.
 type I2C struct {
-	mu        sync.Mutex
-	scl       gpio.PinIO // Clock line
-	sda       gpio.PinIO // Data line
-	halfCycle time.Duration
+	mu             sync.Mutex
+	scl            gpio.PinIO // Clock line
+	sda            gpio.PinIO // Data line
+	halfCycle      time.Duration
+	stretchTimeout time.Duration
 }
 
 func (i *I2C) String() string {
@@ -76,6 +82,35 @@ func (i *I2C) Close() error {
 
 // Tx implements i2c.Bus.
 func (i *I2C) Tx(addr uint16, w, r []byte) error {
+	var msgs [2]i2c.Msg
+	m := msgs[:0]
+	if len(w) != 0 || len(r) == 0 {
+		m = append(m, i2c.Msg{Addr: addr, Buf: w})
+	}
+	if len(r) != 0 {
+		f := i2c.MsgRead
+		if addr == SkipAddr && len(m) != 0 {
+			// Raw protocol: clock the read right after the write, in one frame.
+			f |= i2c.MsgNoStart
+		}
+		m = append(m, i2c.Msg{Addr: addr, Flags: f, Buf: r})
+	}
+	return i.TxMsgs(m)
+}
+
+// TxMsgs implements i2c.MsgBus.
+//
+// All the flags are supported. The special address SkipAddr skips sending the
+// address for the message.
+func (i *I2C) TxMsgs(msgs []i2c.Msg) error {
+	if len(msgs) == 0 {
+		return nil
+	}
+	for j := range msgs {
+		if msgs[j].Addr != SkipAddr && !i2c.Addr(msgs[j].Addr).Valid() {
+			return errors.New("bitbang-i2c: invalid address")
+		}
+	}
 	i.mu.Lock()
 	defer i.mu.Unlock()
 	runtime.LockOSThread()
@@ -84,39 +119,36 @@ func (i *I2C) Tx(addr uint16, w, r []byte) error {
 
 	i.start()
 	defer i.stop()
-	if addr != SkipAddr {
-		if addr > 0xFF {
-			// Page 15, section 3.1.11 10-bit addressing
-			// TODO(maruel): Implement if desired; prefix 0b11110xx.
-			return errors.New("bitbang-i2c: invalid address")
-		}
-		// Page 13, section 3.1.10 The slave address and R/W bit
-		addr <<= 1
-		if len(r) == 0 {
-			addr |= 1
-		}
-		ack, err := i.writeByte(byte(addr))
-		if err != nil {
-			return err
+	for j := range msgs {
+		m := &msgs[j]
+		if j != 0 {
+			if msgs[j-1].Flags&i2c.MsgStop != 0 {
+				i.stop()
+				i.start()
+			} else if m.Flags&i2c.MsgNoStart == 0 {
+				i.restart()
+			}
 		}
-		if !ack {
-			return errors.New("bitbang-i2c: got NACK")
+		if m.Flags&i2c.MsgNoStart == 0 && m.Addr != SkipAddr {
+			if err := i.writeAddr(m.Addr, m.Flags); err != nil {
+				return err
+			}
 		}
-	}
-	for _, b := range w {
-		ack, err := i.writeByte(b)
-		if err != nil {
-			return err
-		}
-		if !ack {
-			return errors.New("bitbang-i2c: got NACK")
-		}
-	}
-	for x := range r {
-		var err error
-		r[x], err = i.readByte()
-		if err != nil {
-			return err
+		if m.Flags&i2c.MsgRead != 0 {
+			for x := range m.Buf {
+				// The last byte is not acknowledged to tell the device to stop.
+				ack := x != len(m.Buf)-1 && m.Flags&i2c.MsgNoReadACK == 0
+				var err error
+				if m.Buf[x], err = i.readByte(ack); err != nil {
+					return err
+				}
+			}
+		} else {
+			for _, b := range m.Buf {
+				if err := i.write(b, m.Flags); err != nil {
+					return err
+				}
+			}
 		}
 	}
 	return nil
@@ -130,6 +162,32 @@ func (i *I2C) SetSpeed(f physic.Frequency) error {
 	return nil
 }
 
+// SetStretchTimeout sets the maximum time a device can hold SCL low to stretch
+// the clock before the transaction is aborted.
+//
+// 0 disables the timeout. The default is DefaultStretchTimeout.
+func (i *I2C) SetStretchTimeout(d time.Duration) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.stretchTimeout = d
+}
+
+// Recover implements i2c.Recoverer.
+//
+// The bus is clocked at 100kHz.
+func (i *I2C) Recover() error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if err := i2c.RecoverPins(i.scl, i.sda, 100*physic.KiloHertz); err != nil {
+		return fmt.Errorf("bitbang-i2c: %v", err)
+	}
+	// Idle high like New() does.
+	if err := i.scl.Out(gpio.High); err != nil {
+		return err
+	}
+	return i.sda.Out(gpio.High)
+}
+
 // SCL implements i2c.Pins.
 func (i *I2C) SCL() gpio.PinIO {
 	return i.scl
@@ -163,6 +221,8 @@ func (i *I2C) start() {
 func (i *I2C) stop() {
 	// Page 9, section 3.1.4 START and STOP conditions
 	_ = i.scl.Out(gpio.Low)
+	// SDA may have been released to NACK the last byte read.
+	_ = i.sda.Out(gpio.Low)
 	i.sleepHalfCycle()
 	_ = i.scl.Out(gpio.High)
 	i.sleepHalfCycle()
@@ -172,6 +232,63 @@ func (i *I2C) stop() {
 	i.sleepHalfCycle()
 }
 
+// restart sends a repeated START condition.
+//
+// Expects SCL low. Ends with SDA and SCL low.
+//
+// Lasts 3/2 cycle.
+func (i *I2C) restart() {
+	// Page 9, section 3.1.4 START and STOP conditions
+	_ = i.sda.Out(gpio.High)
+	i.sleepHalfCycle()
+	_ = i.scl.Out(gpio.High)
+	i.sleepHalfCycle()
+	i.start()
+}
+
+// writeAddr sends the address and the R/W bit.
+func (i *I2C) writeAddr(addr uint16, flags i2c.MsgFlags) error {
+	read := (flags&i2c.MsgRead != 0) != (flags&i2c.MsgRevDirAddr != 0)
+	a := i2c.Addr(addr)
+	if !a.IsTenBit() && flags&i2c.MsgTenBit == 0 {
+		// Page 13, section 3.1.10 The slave address and R/W bit
+		b := byte(a.Value() << 1)
+		if read {
+			b |= 1
+		}
+		return i.write(b, flags)
+	}
+	// Page 15, section 3.1.11 10-bit addressing
+	// The first byte is 0b11110xx0 with the 2 MSB of the address, followed by
+	// the 8 LSB. To read, a repeated START is sent followed by the first byte
+	// with the R/W bit set.
+	v := a.Value()
+	hi := byte(0xF0 | (v>>7)&0x06)
+	if err := i.write(hi, flags); err != nil {
+		return err
+	}
+	if err := i.write(byte(v), flags); err != nil {
+		return err
+	}
+	if !read {
+		return nil
+	}
+	i.restart()
+	return i.write(hi|1, flags)
+}
+
+// write writes a byte and checks the ACK unless flags has MsgIgnoreNAK.
+func (i *I2C) write(b byte, flags i2c.MsgFlags) error {
+	ack, err := i.writeByte(b)
+	if err != nil {
+		return err
+	}
+	if !ack && flags&i2c.MsgIgnoreNAK == 0 {
+		return errors.New("bitbang-i2c: got NACK")
+	}
+	return nil
+}
+
 // writeByte writes 8 bits then waits for ACK.
 //
 // Expects SDA and SCL low.
@@ -188,25 +305,22 @@ func (i *I2C) writeByte(b byte) (bool, error) {
 		_ = i.sda.Out(b&byte(1<<byte(7-x)) != 0)
 		i.sleepHalfCycle()
 		// Let the device read SDA.
-		// TODO(maruel): Support clock stretching, the device may keep the line low.
-		_ = i.scl.Out(gpio.High)
+		if err := i.sclHigh(); err != nil {
+			return false, err
+		}
 		i.sleepHalfCycle()
 		_ = i.scl.Out(gpio.Low)
 	}
 	// Page 10, section 3.1.6 ACK and NACK
 	// 9th clock is ACK.
 	i.sleepHalfCycle()
-	// SCL was already set as pull-up. PullNoChange
-	if err := i.scl.In(gpio.PullUp, gpio.NoEdge); err != nil {
-		return false, err
-	}
+	// Release SDA before SCL, otherwise it may be seen as a STOP.
 	// SDA was already set as pull-up.
 	if err := i.sda.In(gpio.PullUp, gpio.NoEdge); err != nil {
 		return false, err
 	}
-	// Implement clock stretching, the device may keep the line low.
-	for i.scl.Read() == gpio.Low {
-		i.sleepHalfCycle()
+	if err := i.sclHigh(); err != nil {
+		return false, err
 	}
 	// ACK == Low.
 	ack := i.sda.Read() == gpio.Low
@@ -219,40 +333,62 @@ func (i *I2C) writeByte(b byte) (bool, error) {
 	return ack, nil
 }
 
-// readByte reads 8 bits and an ACK.
+// readByte reads 8 bits and sends an ACK, or a NACK if ack is false.
 //
 // Expects SDA and SCL low.
 //
-// Ends with SDA low and SCL high.
+// Ends with SCL low.
 //
 // Lasts 9 cycles.
-func (i *I2C) readByte() (byte, error) {
+func (i *I2C) readByte(ack bool) (byte, error) {
 	var b byte
 	if err := i.sda.In(gpio.PullUp, gpio.NoEdge); err != nil {
 		return b, err
 	}
 	for x := 0; x < 8; x++ {
 		i.sleepHalfCycle()
-		// TODO(maruel): Support clock stretching, the device may keep the line low.
-		_ = i.scl.Out(gpio.High)
+		if err := i.sclHigh(); err != nil {
+			return 0, err
+		}
 		i.sleepHalfCycle()
 		if i.sda.Read() == gpio.High {
 			b |= byte(1) << byte(7-x)
 		}
 		_ = i.scl.Out(gpio.Low)
 	}
-	if err := i.sda.Out(gpio.Low); err != nil {
+	// ACK == Low.
+	if err := i.sda.Out(gpio.Level(!ack)); err != nil {
 		return 0, err
 	}
 	i.sleepHalfCycle()
-	_ = i.scl.Out(gpio.High)
+	if err := i.sclHigh(); err != nil {
+		return 0, err
+	}
 	i.sleepHalfCycle()
+	_ = i.scl.Out(gpio.Low)
 	return b, nil
 }
 
+// sclHigh releases SCL and waits for the device to release it too, as it may
+// stretch the clock.
+func (i *I2C) sclHigh() error {
+	// SCL was already set as pull-up.
+	if err := i.scl.In(gpio.PullUp, gpio.NoEdge); err != nil {
+		return err
+	}
+	for start := time.Now(); i.scl.Read() == gpio.Low; i.sleepHalfCycle() {
+		if i.stretchTimeout != 0 && time.Since(start) > i.stretchTimeout {
+			return errors.New("bitbang-i2c: clock stretching timeout")
+		}
+	}
+	return nil
+}
+
 // sleep does a busy loop to act as fast as possible.
 func (i *I2C) sleepHalfCycle() {
 	cpu.Nanospin(i.halfCycle)
 }
 
 var _ i2c.Bus = &I2C{}
+var _ i2c.MsgBus = &I2C{}
+var _ i2c.Recoverer = &I2C{}
@@ -0,0 +1,397 @@
+// Copyright 2019 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package bitbang
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"periph.io/x/periph/conn/gpio"
+	"periph.io/x/periph/conn/gpio/gpiotest"
+	"periph.io/x/periph/conn/i2c"
+	"periph.io/x/periph/conn/physic"
+)
+
+func TestI2C_Tx(t *testing.T) {
+	s, b := newI2CSim(t, 0x50)
+	s.data = []byte{0xAB, 0xCD}
+	r := make([]byte, 2)
+	if err := b.Tx(0x50, []byte{0x10}, r); err != nil {
+		t.Fatal(err)
+	}
+	if r[0] != 0xAB || r[1] != 0xCD {
+		t.Fatal(r)
+	}
+	expected := []string{"start", "addr 0x50 W", "write 0x10", "start", "addr 0x50 R", "read 0xab ack", "read 0xcd nack", "stop"}
+	if !reflect.DeepEqual(s.log, expected) {
+		t.Fatal(s.log)
+	}
+	if err := b.Tx(0x51, []byte{0x10}, nil); err == nil {
+		t.Fatal("expected NACK")
+	}
+	if err := b.Tx(0x80, nil, nil); err == nil {
+		t.Fatal("invalid address")
+	}
+	if err := b.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestI2C_TxMsgs(t *testing.T) {
+	s, b := newI2CSim(t, 0x50)
+	// EEPROM page write from two buffers.
+	msgs := []i2c.Msg{
+		{Addr: 0x50, Buf: []byte{0x00, 0x20}},
+		{Addr: 0x50, Flags: i2c.MsgNoStart, Buf: []byte{1, 2}},
+	}
+	if err := i2c.TxMsgs(b, msgs); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"start", "addr 0x50 W", "write 0x00", "write 0x20", "write 0x01", "write 0x02", "stop"}
+	if !reflect.DeepEqual(s.log, expected) {
+		t.Fatal(s.log)
+	}
+	s.log = nil
+	msgs = []i2c.Msg{
+		{Addr: 0x51, Flags: i2c.MsgIgnoreNAK | i2c.MsgStop},
+		{Addr: 0x50, Flags: i2c.MsgRead | i2c.MsgNoReadACK, Buf: make([]byte, 1)},
+	}
+	if err := b.TxMsgs(msgs); err != nil {
+		t.Fatal(err)
+	}
+	expected = []string{"start", "stop", "start", "addr 0x50 R", "read 0xff nack", "stop"}
+	if !reflect.DeepEqual(s.log, expected) {
+		t.Fatal(s.log)
+	}
+	if err := b.TxMsgs(nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestI2C_TenBit(t *testing.T) {
+	s, b := newI2CSim(t, 0x150)
+	s.data = []byte{0xAB}
+	r := make([]byte, 1)
+	if err := b.Tx(0x150, []byte{0x10}, r); err != nil {
+		t.Fatal(err)
+	}
+	if r[0] != 0xAB {
+		t.Fatal(r)
+	}
+	expected := []string{"start", "addr 0x150 W", "write 0x10", "start", "addr 0x150 W", "start", "addr 0x150 R", "read 0xab nack", "stop"}
+	if !reflect.DeepEqual(s.log, expected) {
+		t.Fatal(s.log)
+	}
+	// The 7 bits address 0x50 is a different device.
+	s, b = newI2CSim(t, i2c.TenBit|0x50)
+	if err := b.Tx(0x50, []byte{0x10}, nil); err == nil {
+		t.Fatal("expected NACK")
+	}
+	s.log = nil
+	if err := b.TxMsgs([]i2c.Msg{{Addr: 0x50, Flags: i2c.MsgTenBit, Buf: []byte{0x10}}}); err != nil {
+		t.Fatal(err)
+	}
+	expected = []string{"start", "addr 0x50/10 W", "write 0x10", "stop"}
+	if !reflect.DeepEqual(s.log, expected) {
+		t.Fatal(s.log)
+	}
+	if err := b.Tx(i2c.TenBit|0x400, nil, nil); err == nil {
+		t.Fatal("invalid address")
+	}
+}
+
+func TestI2C_TxMsgs_SkipAddr(t *testing.T) {
+	s, b := newI2CSim(t, 0x50)
+	s.skipAddr = true
+	if err := b.TxMsgs([]i2c.Msg{{Addr: SkipAddr, Buf: []byte{0x42}}}); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"start", "write 0x42", "stop"}
+	if !reflect.DeepEqual(s.log, expected) {
+		t.Fatal(s.log)
+	}
+}
+
+func TestI2C_Tx_SkipAddr(t *testing.T) {
+	s, b := newI2CSim(t, 0x50)
+	s.skipAddr = true
+	// The read is clocked right after the write, without a repeated START. The
+	// simulated device keeps receiving, so it logs the bits read as a write.
+	r := make([]byte, 1)
+	if err := b.Tx(SkipAddr, []byte{0x42}, r); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"start", "write 0x42", "write 0xff", "stop"}
+	if !reflect.DeepEqual(s.log, expected) {
+		t.Fatal(s.log)
+	}
+}
+
+func TestI2C_Stretch(t *testing.T) {
+	s, b := newI2CSim(t, 0x50)
+	s.stretch = true
+	b.SetStretchTimeout(time.Millisecond)
+	if err := b.Tx(0x50, []byte{0x10}, nil); err == nil {
+		t.Fatal("expected clock stretching timeout")
+	}
+	s.stretch = false
+	if err := b.Tx(0x50, []byte{0x10}, nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestI2C_Recover(t *testing.T) {
+	s, b := newI2CSim(t, 0x50)
+	// The device was interrupted while sending the 4th bit of 0x00.
+	s.state = simRead
+	s.tx = true
+	s.n = 3
+	s.drive = gpio.Low
+	if err := b.Recover(); err != nil {
+		t.Fatal(err)
+	}
+	// The device sees the missing ACK and stops sending.
+	if !reflect.DeepEqual(s.log, []string{"read 0x00 nack", "stop"}) {
+		t.Fatal(s.log)
+	}
+	s.log = nil
+	if err := b.Tx(0x50, []byte{0x10}, nil); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"start", "addr 0x50 W", "write 0x10", "stop"}
+	if !reflect.DeepEqual(s.log, expected) {
+		t.Fatal(s.log)
+	}
+}
+
+//
+
+// i2cSim simulates an I²C device at the wire level, connected to simulated
+// SCL and SDA open-drain lines.
+type i2cSim struct {
+	addr     uint16
+	skipAddr bool     // the device accepts data right after START
+	data     []byte   // bytes returned on read
+	stretch  bool     // the device holds SCL low
+	log      []string // decoded bus activity
+
+	mu       sync.Mutex
+	scl      gpio.Level // master SCL
+	sda      gpio.Level // master SDA
+	drive    gpio.Level // device SDA; Low when pulling the line down
+	state    int
+	n        int  // SCL rising edges in the current 9 bits frame
+	shift    byte // byte being received or sent
+	tx       bool // the device is sending
+	read     bool // the address was sent with the R/W bit set
+	selected bool // selected by a 10 bits address write
+	ackBit   gpio.Level
+}
+
+const (
+	simIdle = iota
+	simAddr
+	simAddr2 // second byte of a 10 bits address
+	simWrite
+	simRead
+)
+
+func newI2CSim(t *testing.T, addr uint16) (*i2cSim, *I2C) {
+	s := &i2cSim{addr: addr, scl: gpio.High, sda: gpio.High, drive: gpio.High}
+	b, err := New(&simPin{Pin: gpiotest.Pin{N: "SCL"}, s: s, clk: true}, &simPin{Pin: gpiotest.Pin{N: "SDA"}, s: s}, 100*physic.KiloHertz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.halfCycle = 0
+	return s, b
+}
+
+// wired returns the level of SDA.
+//
+// s.mu must be held.
+func (s *i2cSim) wired() gpio.Level {
+	return s.sda && s.drive
+}
+
+func (s *i2cSim) set(clk bool, l gpio.Level) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if clk {
+		if s.scl == l {
+			return
+		}
+		s.scl = l
+		if l {
+			s.rise()
+		} else {
+			s.fall()
+		}
+		return
+	}
+	before := s.wired()
+	s.sda = l
+	if s.scl && before != s.wired() {
+		if before {
+			s.log = append(s.log, "start")
+			s.state = simAddr
+			if s.skipAddr {
+				s.state = simWrite
+			}
+			s.n = 0
+			s.tx = false
+			s.drive = gpio.High
+		} else {
+			s.log = append(s.log, "stop")
+			s.state = simIdle
+			s.selected = false
+			s.drive = gpio.High
+		}
+	}
+}
+
+func (s *i2cSim) rise() {
+	if s.state == simIdle {
+		return
+	}
+	s.n++
+	if s.n <= 8 && !s.tx {
+		s.shift <<= 1
+		if s.wired() {
+			s.shift |= 1
+		}
+	}
+	if s.n == 9 && s.tx {
+		s.ackBit = s.wired()
+	}
+}
+
+func (s *i2cSim) fall() {
+	if s.state == simIdle {
+		return
+	}
+	switch {
+	case s.n == 8 && !s.tx:
+		// Byte received; ACK it if it is for this device.
+		if !s.received(s.shift) {
+			s.state = simIdle
+			return
+		}
+		s.drive = gpio.Low
+	case s.n == 8 && s.tx:
+		// Release SDA for the master to ACK.
+		s.drive = gpio.High
+	case s.n == 9:
+		s.drive = gpio.High
+		s.n = 0
+		if s.tx {
+			ack := "ack"
+			if s.ackBit {
+				ack = "nack"
+			}
+			s.log = append(s.log, fmt.Sprintf("read 0x%02x %s", s.shift, ack))
+			if s.ackBit {
+				s.tx = false
+				s.state = simIdle
+				return
+			}
+		} else if s.state == simAddr && s.read {
+			s.state = simRead
+			s.tx = true
+		} else {
+			if s.state == simAddr {
+				s.state = simWrite
+			}
+			return
+		}
+		s.shift = 0xFF
+		if len(s.data) != 0 {
+			s.shift = s.data[0]
+			s.data = s.data[1:]
+		}
+		s.drive = gpio.Level(s.shift&0x80 != 0)
+	case s.tx && s.n < 8:
+		s.drive = gpio.Level(s.shift&(0x80>>uint(s.n)) != 0)
+	}
+}
+
+// received processes a byte received by the device and returns true if it
+// is acknowledged.
+func (s *i2cSim) received(b byte) bool {
+	a := i2c.Addr(s.addr)
+	switch s.state {
+	case simAddr:
+		if !a.IsTenBit() {
+			if uint16(b>>1) != a.Value() {
+				return false
+			}
+			s.read = b&1 != 0
+		} else {
+			if b&0xF8 != 0xF0 || uint16(b>>1)&3 != a.Value()>>8 {
+				return false
+			}
+			if b&1 == 0 {
+				s.state = simAddr2
+				return true
+			}
+			// Reading requires the device to be selected with a write first.
+			if !s.selected {
+				return false
+			}
+			s.read = true
+		}
+		rw := "W"
+		if s.read {
+			rw = "R"
+		}
+		s.log = append(s.log, fmt.Sprintf("addr %s %s", a, rw))
+	case simAddr2:
+		if b != byte(a.Value()) {
+			return false
+		}
+		s.selected = true
+		s.read = false
+		s.state = simWrite
+		s.log = append(s.log, fmt.Sprintf("addr %s W", a))
+	default:
+		s.log = append(s.log, fmt.Sprintf("write 0x%02x", b))
+	}
+	return true
+}
+
+// simPin is SCL or SDA of an i2cSim.
+type simPin struct {
+	gpiotest.Pin
+	s   *i2cSim
+	clk bool
+}
+
+func (p *simPin) In(pull gpio.Pull, edge gpio.Edge) error {
+	if err := p.Pin.In(pull, edge); err != nil {
+		return err
+	}
+	// Released; the line is pulled up.
+	p.s.set(p.clk, gpio.High)
+	return nil
+}
+
+func (p *simPin) Read() gpio.Level {
+	p.s.mu.Lock()
+	defer p.s.mu.Unlock()
+	if p.clk {
+		return p.s.scl && gpio.Level(!p.s.stretch)
+	}
+	return p.s.wired()
+}
+
+func (p *simPin) Out(l gpio.Level) error {
+	if err := p.Pin.Out(l); err != nil {
+		return err
+	}
+	p.s.set(p.clk, l)
+	return nil
+}
@@ -0,0 +1,178 @@
+// Copyright 2019 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+// Specification
+//
+// IEEE 1149.1 is not freely available; the signals are summarized at
+// https://en.wikipedia.org/wiki/JTAG#Electrical_characteristics
+
+package bitbang
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"periph.io/x/periph/conn/gpio"
+	"periph.io/x/periph/conn/jtag"
+	"periph.io/x/periph/conn/physic"
+	"periph.io/x/periph/host/cpu"
+)
+
+// NewJTAG returns a jtag.Conn that shifts bits over 4 or 5 pins.
+//
+// trst can be nil. When specified, it is kept high, that is inactive.
+//
+// f is the maximum TCK frequency; use 0 to go as fast as possible.
+func NewJTAG(tck, tms, tdi gpio.PinOut, tdo gpio.PinIn, trst gpio.PinOut, f physic.Frequency) (*JTAG, error) {
+	if f < 0 {
+		return nil, errors.New("bitbang-jtag: invalid frequency")
+	}
+	j := &JTAG{tck: tck, tms: tms, tdi: tdi, tdo: tdo, trst: trst}
+	if f != 0 {
+		j.halfCycle = f.Period() / 2
+	}
+	// TCK idles low.
+	if err := tck.Out(gpio.Low); err != nil {
+		return nil, fmt.Errorf("bitbang-jtag: failed to idle TCK: %v", err)
+	}
+	if err := tms.Out(gpio.High); err != nil {
+		return nil, fmt.Errorf("bitbang-jtag: failed to initialize TMS: %v", err)
+	}
+	if err := tdi.Out(gpio.High); err != nil {
+		return nil, fmt.Errorf("bitbang-jtag: failed to initialize TDI: %v", err)
+	}
+	if err := tdo.In(gpio.PullUp, gpio.NoEdge); err != nil {
+		return nil, fmt.Errorf("bitbang-jtag: failed to initialize TDO: %v", err)
+	}
+	if trst != nil {
+		if err := trst.Out(gpio.High); err != nil {
+			return nil, fmt.Errorf("bitbang-jtag: failed to initialize TRST: %v", err)
+		}
+	}
+	return j, nil
+}
+
+// JTAG represents a JTAG adapter implemented as bit-banging on 4 or 5 GPIO
+// pins.
+type JTAG struct {
+	// Immutable.
+	tck  gpio.PinOut
+	tms  gpio.PinOut
+	tdi  gpio.PinOut
+	tdo  gpio.PinIn
+	trst gpio.PinOut
+
+	mu        sync.Mutex
+	halfCycle time.Duration
+}
+
+func (j *JTAG) String() string {
+	return fmt.Sprintf("bitbang/jtag(%s, %s, %s, %s)", j.tck, j.tms, j.tdi, j.tdo)
+}
+
+// Close implements io.Closer.
+func (j *JTAG) Close() error {
+	return nil
+}
+
+// Shift implements jtag.Conn.
+//
+// TMS and TDI are changed while TCK is low and TDO is sampled right before
+// the TCK rising edge, since the target changes TDO on the falling edge.
+func (j *JTAG) Shift(bits int, tms, tdi, tdo []byte) error {
+	n := (bits + 7) / 8
+	if bits < 0 || len(tms) < n || (tdi != nil && len(tdi) < n) || (tdo != nil && len(tdo) < n) {
+		return errors.New("bitbang-jtag: buffers too short")
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	for i := 0; i < bits; i++ {
+		mask := byte(1) << uint(i%8)
+		if err := j.tms.Out(tms[i/8]&mask != 0); err != nil {
+			return fmt.Errorf("bitbang-jtag: failed to set TMS: %v", err)
+		}
+		if err := j.tdi.Out(tdi != nil && tdi[i/8]&mask != 0); err != nil {
+			return fmt.Errorf("bitbang-jtag: failed to set TDI: %v", err)
+		}
+		j.sleepHalfCycle()
+		if tdo != nil {
+			if j.tdo.Read() {
+				tdo[i/8] |= mask
+			} else {
+				tdo[i/8] &^= mask
+			}
+		}
+		if err := j.tck.Out(gpio.High); err != nil {
+			return fmt.Errorf("bitbang-jtag: failed to assert TCK: %v", err)
+		}
+		j.sleepHalfCycle()
+		if err := j.tck.Out(gpio.Low); err != nil {
+			return fmt.Errorf("bitbang-jtag: failed to idle TCK: %v", err)
+		}
+	}
+	return nil
+}
+
+// Reset resets the TAP controllers asynchronously by pulsing TRST low.
+//
+// It returns an error if no TRST pin was specified; use jtag.TAP.Reset()
+// instead.
+func (j *JTAG) Reset() error {
+	if j.trst == nil {
+		return errors.New("bitbang-jtag: no TRST pin")
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if err := j.trst.Out(gpio.Low); err != nil {
+		return fmt.Errorf("bitbang-jtag: failed to assert TRST: %v", err)
+	}
+	// The minimum pulse width is not defined by the standard; be generous.
+	cpu.Nanospin(time.Microsecond + 2*j.halfCycle)
+	if err := j.trst.Out(gpio.High); err != nil {
+		return fmt.Errorf("bitbang-jtag: failed to deassert TRST: %v", err)
+	}
+	return nil
+}
+
+// TCK implements jtag.Pins.
+func (j *JTAG) TCK() gpio.PinOut {
+	return j.tck
+}
+
+// TMS implements jtag.Pins.
+func (j *JTAG) TMS() gpio.PinOut {
+	return j.tms
+}
+
+// TDI implements jtag.Pins.
+func (j *JTAG) TDI() gpio.PinOut {
+	return j.tdi
+}
+
+// TDO implements jtag.Pins.
+func (j *JTAG) TDO() gpio.PinIn {
+	return j.tdo
+}
+
+// TRST implements jtag.Pins.
+//
+// It returns gpio.INVALID when no reset pin was specified.
+func (j *JTAG) TRST() gpio.PinOut {
+	if j.trst == nil {
+		return gpio.INVALID
+	}
+	return j.trst
+}
+
+//
+
+// sleepHalfCycle does a busy loop to act as fast as possible.
+func (j *JTAG) sleepHalfCycle() {
+	cpu.Nanospin(j.halfCycle)
+}
+
+var _ jtag.Conn = &JTAG{}
+var _ jtag.Pins = &JTAG{}
@@ -0,0 +1,105 @@
+// Copyright 2019 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package bitbang
+
+import (
+	"reflect"
+	"testing"
+
+	"periph.io/x/periph/conn/gpio"
+	"periph.io/x/periph/conn/gpio/gpiotest"
+	"periph.io/x/periph/conn/jtag"
+	"periph.io/x/periph/conn/jtag/jtagtest"
+)
+
+func TestJTAG(t *testing.T) {
+	c := &jtagtest.Chain{
+		Devices: []*jtagtest.Device{
+			{IRLen: 4, IDCode: 0x0BA00477, IDCodeInstr: 0xE},
+			{IRLen: 5, IDCode: 0x06413041, IDCodeInstr: 0x1},
+		},
+	}
+	tms := &gpiotest.Pin{N: "TMS"}
+	tdi := &gpiotest.Pin{N: "TDI"}
+	tck := &tckPin{Pin: gpiotest.Pin{N: "TCK"}, c: c, tms: tms, tdi: tdi}
+	tdo := &tdoPin{Pin: gpiotest.Pin{N: "TDO"}, c: c}
+	trst := &gpiotest.Pin{N: "TRST"}
+	j, err := NewJTAG(tck, tms, tdi, tdo, trst, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := j.String(); s != "bitbang/jtag(TCK(0), TMS(0), TDI(0), TDO(0))" {
+		t.Fatal(s)
+	}
+	if trst.L != gpio.High {
+		t.Fatal("TRST must be inactive")
+	}
+	ids, err := jtag.ScanIDCodes(jtag.NewTAP(j), 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(ids, []jtag.IDCode{0x06413041, 0x0BA00477}) {
+		t.Fatal(ids)
+	}
+	if tck.L != gpio.Low {
+		t.Fatal("TCK must idle low")
+	}
+	if err := j.Reset(); err != nil {
+		t.Fatal(err)
+	}
+	if j.TCK() != tck || j.TMS() != tms || j.TDI() != tdi || j.TDO() != tdo || j.TRST() != trst {
+		t.Fatal("unexpected pins")
+	}
+	if j.Shift(9, []byte{0}, nil, nil) == nil {
+		t.Fatal("buffer too short")
+	}
+	if err := j.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestJTAG_err(t *testing.T) {
+	p := &gpiotest.Pin{}
+	if _, err := NewJTAG(p, p, p, p, nil, -1); err == nil {
+		t.Fatal("invalid frequency")
+	}
+	j, err := NewJTAG(p, p, p, p, nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if j.Reset() == nil {
+		t.Fatal("no TRST")
+	}
+	if j.TRST() != gpio.INVALID {
+		t.Fatal(j.TRST())
+	}
+}
+
+//
+
+// tckPin clocks the simulated chain on the rising edge.
+type tckPin struct {
+	gpiotest.Pin
+	c   *jtagtest.Chain
+	tms *gpiotest.Pin
+	tdi *gpiotest.Pin
+}
+
+func (p *tckPin) Out(l gpio.Level) error {
+	if l && !p.L {
+		p.c.Clock(p.tms.L, p.tdi.L)
+	}
+	return p.Pin.Out(l)
+}
+
+// tdoPin reads TDO from the simulated chain.
+type tdoPin struct {
+	gpiotest.Pin
+	c *jtagtest.Chain
+}
+
+func (p *tdoPin) Read() gpio.Level {
+	return p.c.TDO()
+}
@@ -0,0 +1,362 @@
+// Copyright 2019 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+// Specification
+//
+// https://www.maximintegrated.com/en/app-notes/index.mvp/id/126
+// https://www.maximintegrated.com/en/app-notes/index.mvp/id/187
+
+package bitbang
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+
+	"periph.io/x/periph/conn/gpio"
+	"periph.io/x/periph/conn/onewire"
+	"periph.io/x/periph/host/cpu"
+)
+
+// OneWireOpts contains the options for NewOneWire.
+type OneWireOpts struct {
+	// SPU is the pin controlling the strong pull-up, typically the gate of a
+	// P-channel MOSFET between VCC and the bus. If nil, the strong pull-up is
+	// done by driving the data pin high.
+	SPU gpio.PinOut
+	// SPUActive is the level on SPU that enables the strong pull-up. The zero
+	// value, gpio.Low, is the one for a P-channel MOSFET.
+	SPUActive gpio.Level
+}
+
+// NewOneWire returns a 1-wire bus master bit-banged on q.
+//
+// q must be usable as an open-drain pin: the bus is pulled low with Out(Low)
+// and released with In(), relying on an external pull-up resistor, usually
+// 4.7kΩ, to pull it high.
+//
+// Slots are timed by busy looping; the result depends on the process not
+// being preempted in the middle of a slot, so it is less reliable than a
+// DS248x or the kernel w1 driver on a loaded host.
+func NewOneWire(q gpio.PinIO, opts *OneWireOpts) (*OneWire, error) {
+	o := &OneWire{q: q, t: &standardTiming, sleep: cpu.Nanospin}
+	if opts != nil {
+		o.spu = opts.SPU
+		o.spuActive = opts.SPUActive
+	}
+	if err := q.In(gpio.PullUp, gpio.NoEdge); err != nil {
+		return nil, fmt.Errorf("bitbang-onewire: %v", err)
+	}
+	if err := o.strongPullup(false); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// OneWire represents a 1-wire master implemented as bit-banging on a GPIO
+// pin.
+type OneWire struct {
+	q         gpio.PinIO
+	spu       gpio.PinOut
+	spuActive gpio.Level
+
+	mu    sync.Mutex
+	t     *oneWireTiming
+	spuOn bool
+	sleep func(time.Duration)
+}
+
+func (o *OneWire) String() string {
+	return fmt.Sprintf("bitbang/onewire(%s)", o.q)
+}
+
+// Close implements onewire.BusCloser.
+//
+// It turns off the strong pull-up if it is enabled.
+func (o *OneWire) Close() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.strongPullup(false)
+}
+
+// Tx implements onewire.Bus.
+//
+// With onewire.StrongPullup, the strong pull-up is enabled right after the
+// last bit and stays enabled until the next operation on the bus.
+//
+// When the first byte of w is an Overdrive Skip ROM or Overdrive Match ROM
+// command, the overdrive timings are selected right after it is sent.
+func (o *OneWire) Tx(w, r []byte, power onewire.Pullup) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	if err := o.reset(); err != nil {
+		return err
+	}
+	for i, b := range w {
+		if err := o.writeByte(b); err != nil {
+			return err
+		}
+		if i == 0 && (b == 0x3C || b == 0x69) {
+			o.t = &overdriveTiming
+		}
+	}
+	for i := range r {
+		b, err := o.readByte()
+		if err != nil {
+			return err
+		}
+		r[i] = b
+	}
+	if power == onewire.StrongPullup {
+		return o.strongPullup(true)
+	}
+	return nil
+}
+
+// Search implements onewire.Bus.
+func (o *OneWire) Search(alarmOnly bool) ([]onewire.Address, error) {
+	return onewire.Search(o, alarmOnly)
+}
+
+// SearchTriplet implements onewire.BusSearcher.
+//
+// It reads the bit and its complement then writes the direction taken.
+func (o *OneWire) SearchTriplet(direction byte) (onewire.TripletResult, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	tr := onewire.TripletResult{}
+	b, err := o.readBit()
+	if err != nil {
+		return tr, err
+	}
+	c, err := o.readBit()
+	if err != nil {
+		return tr, err
+	}
+	tr.GotZero = !b
+	tr.GotOne = !c
+	switch {
+	case tr.GotZero && tr.GotOne:
+		tr.Taken = direction & 1
+	case tr.GotZero:
+		tr.Taken = 0
+	default:
+		tr.Taken = 1
+	}
+	return tr, o.writeBit(tr.Taken == 1)
+}
+
+// SetOverdrive implements onewire.BusOverdriver.
+//
+// It selects the overdrive timings when on is true, or the standard timings
+// otherwise. The devices must be switched to overdrive beforehand with an
+// Overdrive Skip ROM or Overdrive Match ROM command at standard speed, which
+// Tx does automatically. A reset at standard speed switches all the devices
+// back to standard speed.
+func (o *OneWire) SetOverdrive(on bool) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if on {
+		o.t = &overdriveTiming
+	} else {
+		o.t = &standardTiming
+	}
+	return nil
+}
+
+// Q implements onewire.Pins.
+func (o *OneWire) Q() gpio.PinIO {
+	return o.q
+}
+
+//
+
+// oneWireTiming are the delays of Maxim's AN126 "1-Wire Communication
+// Through Software", table 2.
+type oneWireTiming struct {
+	a, b, c, d, e, f, g, h, i, j time.Duration
+}
+
+var standardTiming = oneWireTiming{
+	a: 6 * time.Microsecond,
+	b: 64 * time.Microsecond,
+	c: 60 * time.Microsecond,
+	d: 10 * time.Microsecond,
+	e: 9 * time.Microsecond,
+	f: 55 * time.Microsecond,
+	g: 0,
+	h: 480 * time.Microsecond,
+	i: 70 * time.Microsecond,
+	j: 410 * time.Microsecond,
+}
+
+var overdriveTiming = oneWireTiming{
+	a: 1000 * time.Nanosecond,
+	b: 7500 * time.Nanosecond,
+	c: 7500 * time.Nanosecond,
+	d: 2500 * time.Nanosecond,
+	e: 1000 * time.Nanosecond,
+	f: 7000 * time.Nanosecond,
+	g: 2500 * time.Nanosecond,
+	h: 70 * time.Microsecond,
+	i: 8500 * time.Nanosecond,
+	j: 40 * time.Microsecond,
+}
+
+// reset sends a reset pulse and returns an error if no device answered with
+// a presence pulse.
+//
+// o.mu must be held.
+func (o *OneWire) reset() error {
+	if err := o.strongPullup(false); err != nil {
+		return err
+	}
+	o.sleep(o.t.g)
+	if o.q.Read() == gpio.Low {
+		return shortedBusError("bitbang-onewire: bus is shorted")
+	}
+	if err := o.low(); err != nil {
+		return err
+	}
+	o.sleep(o.t.h)
+	if err := o.release(); err != nil {
+		return err
+	}
+	o.sleep(o.t.i)
+	present := o.q.Read() == gpio.Low
+	o.sleep(o.t.j)
+	if !present {
+		return noDevicesError("bitbang-onewire: no device present")
+	}
+	return nil
+}
+
+// writeBit sends one bit.
+//
+// o.mu must be held.
+func (o *OneWire) writeBit(bit bool) error {
+	low, high := o.t.c, o.t.d
+	if bit {
+		low, high = o.t.a, o.t.b
+	}
+	if err := o.low(); err != nil {
+		return err
+	}
+	o.sleep(low)
+	if err := o.release(); err != nil {
+		return err
+	}
+	o.sleep(high)
+	return nil
+}
+
+// readBit samples one bit sent by the devices.
+//
+// o.mu must be held.
+func (o *OneWire) readBit() (bool, error) {
+	if err := o.low(); err != nil {
+		return false, err
+	}
+	o.sleep(o.t.a)
+	if err := o.release(); err != nil {
+		return false, err
+	}
+	o.sleep(o.t.e)
+	bit := o.q.Read() == gpio.High
+	o.sleep(o.t.f)
+	return bit, nil
+}
+
+// writeByte sends a byte, least significant bit first.
+func (o *OneWire) writeByte(b byte) error {
+	for i := uint(0); i < 8; i++ {
+		if err := o.writeBit(b&(1<<i) != 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readByte reads a byte, least significant bit first.
+func (o *OneWire) readByte() (byte, error) {
+	var b byte
+	for i := uint(0); i < 8; i++ {
+		bit, err := o.readBit()
+		if err != nil {
+			return 0, err
+		}
+		if bit {
+			b |= 1 << i
+		}
+	}
+	return b, nil
+}
+
+func (o *OneWire) low() error {
+	if err := o.q.Out(gpio.Low); err != nil {
+		return fmt.Errorf("bitbang-onewire: failed to pull the bus low: %v", err)
+	}
+	return nil
+}
+
+func (o *OneWire) release() error {
+	if err := o.q.In(gpio.PullNoChange, gpio.NoEdge); err != nil {
+		return fmt.Errorf("bitbang-onewire: failed to release the bus: %v", err)
+	}
+	return nil
+}
+
+// strongPullup enables or disables the strong pull-up.
+//
+// o.mu must be held.
+func (o *OneWire) strongPullup(on bool) error {
+	if o.spu == nil {
+		if on {
+			if err := o.q.Out(gpio.High); err != nil {
+				return fmt.Errorf("bitbang-onewire: failed to enable strong pull-up: %v", err)
+			}
+		} else if o.spuOn {
+			if err := o.release(); err != nil {
+				return err
+			}
+		}
+		o.spuOn = on
+		return nil
+	}
+	l := o.spuActive
+	if !on {
+		l = !l
+	}
+	if err := o.spu.Out(l); err != nil {
+		return fmt.Errorf("bitbang-onewire: failed to set strong pull-up: %v", err)
+	}
+	o.spuOn = on
+	return nil
+}
+
+// noDevicesError implements error, onewire.NoDevicesError and
+// onewire.BusError.
+type noDevicesError string
+
+func (e noDevicesError) Error() string   { return string(e) }
+func (e noDevicesError) NoDevices() bool { return true }
+func (e noDevicesError) BusError() bool  { return true }
+
+// shortedBusError implements error, onewire.ShortedBusError and
+// onewire.BusError.
+type shortedBusError string
+
+func (e shortedBusError) Error() string   { return string(e) }
+func (e shortedBusError) IsShorted() bool { return true }
+func (e shortedBusError) BusError() bool  { return true }
+
+var _ onewire.BusCloser = &OneWire{}
+var _ onewire.BusOverdriver = &OneWire{}
+var _ onewire.BusSearcher = &OneWire{}
+var _ onewire.Pins = &OneWire{}
@@ -0,0 +1,443 @@
+// Copyright 2019 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package bitbang
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+
+	"periph.io/x/periph/conn/gpio"
+	"periph.io/x/periph/conn/gpio/gpiotest"
+	"periph.io/x/periph/conn/onewire"
+)
+
+func TestOneWire_Tx(t *testing.T) {
+	d := newOWDev(0x28, 1, []byte{0x50, 0x05, 0x4b, 0x46, 0x7f, 0xff, 0x0c, 0x10, 0x1c})
+	s, o := newOneWireSim(t, nil, d)
+	if o.String() != "bitbang/onewire(Q(0))" || o.Q() == nil {
+		t.Fatal(o.String())
+	}
+	r := make([]byte, 9)
+	if err := o.Tx([]byte{0xCC, 0xBE}, r, onewire.WeakPullup); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(r, d.data) {
+		t.Fatalf("%x", r)
+	}
+	// Match ROM.
+	dev := onewire.Dev{Bus: o, Addr: d.addr}
+	r = make([]byte, 2)
+	if err := dev.Tx([]byte{0xBE}, r); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(r, d.data[:2]) {
+		t.Fatalf("%x", r)
+	}
+	if expected := []byte{0xBE, 0xBE}; !bytes.Equal(d.written, expected) {
+		t.Fatalf("%x", d.written)
+	}
+	// Another device is not selected.
+	dev.Addr = newOWDev(0x28, 2, nil).addr
+	r[0], r[1] = 0, 0
+	if err := dev.Tx([]byte{0xBE}, r); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(r, []byte{0xFF, 0xFF}) {
+		t.Fatalf("%x", r)
+	}
+	if s.now == 0 {
+		t.Fatal("the clock must advance")
+	}
+	if err := o.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOneWire_Search(t *testing.T) {
+	devs := []*owDev{newOWDev(0x28, 0x123456, nil), newOWDev(0x28, 0x123457, nil), newOWDev(0x10, 0xABCDEF, nil)}
+	devs[1].alarm = true
+	_, o := newOneWireSim(t, nil, devs...)
+	addrs, err := o.Search(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(addrs) != len(devs) {
+		t.Fatalf("%x", addrs)
+	}
+	found := map[onewire.Address]bool{}
+	for _, a := range addrs {
+		found[a] = true
+	}
+	for _, d := range devs {
+		if !found[d.addr] {
+			t.Fatalf("%x not found in %x", d.addr, addrs)
+		}
+	}
+	addrs, err = o.Search(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(addrs, []onewire.Address{devs[1].addr}) {
+		t.Fatalf("%x", addrs)
+	}
+}
+
+func TestOneWire_StrongPullup(t *testing.T) {
+	s, o := newOneWireSim(t, nil, newOWDev(0x28, 1, nil))
+	if err := o.Tx([]byte{0xCC, 0x44}, nil, onewire.StrongPullup); err != nil {
+		t.Fatal(err)
+	}
+	if !s.high {
+		t.Fatal("expected Q driven high")
+	}
+	if err := o.Tx([]byte{0xCC}, nil, onewire.WeakPullup); err != nil {
+		t.Fatal(err)
+	}
+	if s.high {
+		t.Fatal("expected Q released")
+	}
+
+	spu := &gpiotest.Pin{N: "SPU"}
+	s, o = newOneWireSim(t, &OneWireOpts{SPU: spu}, newOWDev(0x28, 1, nil))
+	if spu.Read() != gpio.High {
+		t.Fatal("SPU must be disabled")
+	}
+	if err := o.Tx([]byte{0xCC, 0x44}, nil, onewire.StrongPullup); err != nil {
+		t.Fatal(err)
+	}
+	if spu.Read() != gpio.Low || s.high {
+		t.Fatal("SPU must be enabled")
+	}
+	if err := o.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if spu.Read() != gpio.High {
+		t.Fatal("SPU must be disabled")
+	}
+}
+
+func TestOneWire_Overdrive(t *testing.T) {
+	d := newOWDev(0x28, 1, []byte{0x12, 0x34})
+	s, o := newOneWireSim(t, nil, d)
+	// Overdrive Skip ROM at standard speed.
+	if err := o.Tx([]byte{0x3C}, nil, onewire.WeakPullup); err != nil {
+		t.Fatal(err)
+	}
+	if !d.overdrive {
+		t.Fatal("expected overdrive")
+	}
+	if o.t != &overdriveTiming {
+		t.Fatal("expected overdrive timings")
+	}
+	if err := o.SetOverdrive(true); err != nil {
+		t.Fatal(err)
+	}
+	start := s.now
+	r := make([]byte, 2)
+	if err := o.Tx([]byte{0xCC, 0xBE}, r, onewire.WeakPullup); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(r, d.data) {
+		t.Fatalf("%x", r)
+	}
+	if !d.overdrive {
+		t.Fatal("expected overdrive")
+	}
+	if e := s.now - start; e > 500*time.Microsecond {
+		t.Fatal("overdrive is too slow", e)
+	}
+	// A standard speed reset returns to standard speed.
+	if err := o.SetOverdrive(false); err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Tx([]byte{0xCC, 0xBE}, r, onewire.WeakPullup); err != nil {
+		t.Fatal(err)
+	}
+	if d.overdrive || !bytes.Equal(r, d.data) {
+		t.Fatalf("%x", r)
+	}
+}
+
+func TestOneWire_Err(t *testing.T) {
+	s, o := newOneWireSim(t, nil)
+	err := o.Tx([]byte{0xCC}, nil, onewire.WeakPullup)
+	if e, ok := err.(onewire.NoDevicesError); !ok || !e.NoDevices() {
+		t.Fatal(err)
+	}
+	if _, err := o.Search(false); err == nil {
+		t.Fatal("no device")
+	}
+	s.shorted = true
+	err = o.Tx([]byte{0xCC}, nil, onewire.WeakPullup)
+	if e, ok := err.(onewire.ShortedBusError); !ok || !e.IsShorted() {
+		t.Fatal(err)
+	}
+	if e, ok := err.(onewire.BusError); !ok || !e.BusError() {
+		t.Fatal(err)
+	}
+}
+
+//
+
+// oneWireSim simulates a 1-wire bus at the wire level with a virtual clock.
+// The delays of the master advance the clock, and the devices decode the
+// slots from the duration of the low pulses, like real devices do.
+type oneWireSim struct {
+	devs    []*owDev
+	shorted bool // Q is shorted to ground
+
+	now       time.Duration
+	masterLow bool          // the master pulls the bus low
+	high      bool          // the master drives the bus high
+	fell      time.Duration // when the master pulled the bus low
+	pullFrom  time.Duration // the devices pull the bus low in [pullFrom, pullTo)
+	pullTo    time.Duration
+}
+
+func newOneWireSim(t *testing.T, opts *OneWireOpts, devs ...*owDev) (*oneWireSim, *OneWire) {
+	s := &oneWireSim{devs: devs}
+	o, err := NewOneWire(&oneWirePin{Pin: gpiotest.Pin{N: "Q"}, s: s}, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o.sleep = s.advance
+	return s, o
+}
+
+func (s *oneWireSim) advance(d time.Duration) {
+	s.now += d
+}
+
+func (s *oneWireSim) read() gpio.Level {
+	if s.shorted || s.masterLow || (s.now >= s.pullFrom && s.now < s.pullTo) {
+		return gpio.Low
+	}
+	return gpio.High
+}
+
+// low is called when the master pulls the bus low.
+func (s *oneWireSim) low() {
+	s.high = false
+	if s.masterLow {
+		return
+	}
+	s.masterLow = true
+	s.fell = s.now
+	// The devices sending a 0 hold the bus low.
+	for _, d := range s.devs {
+		if bit, ok := d.sending(); ok && !bit {
+			s.pullFrom = s.now
+			s.pullTo = s.now + d.timing(30*time.Microsecond, 3*time.Microsecond)
+		}
+	}
+}
+
+// release is called when the master releases the bus.
+func (s *oneWireSim) release() {
+	s.high = false
+	if !s.masterLow {
+		return
+	}
+	s.masterLow = false
+	l := s.now - s.fell
+	for _, d := range s.devs {
+		if l >= 400*time.Microsecond || (d.overdrive && l >= 48*time.Microsecond) {
+			if l >= 400*time.Microsecond {
+				d.overdrive = false
+			}
+			d.reset()
+			// Presence pulse.
+			s.pullFrom = s.now + d.timing(30*time.Microsecond, 3*time.Microsecond)
+			s.pullTo = s.pullFrom + d.timing(120*time.Microsecond, 10*time.Microsecond)
+			continue
+		}
+		d.slot(l < d.timing(15*time.Microsecond, 2*time.Microsecond))
+	}
+}
+
+// oneWirePin is the Q pin of a oneWireSim.
+type oneWirePin struct {
+	gpiotest.Pin
+	s *oneWireSim
+}
+
+func (p *oneWirePin) In(pull gpio.Pull, edge gpio.Edge) error {
+	if err := p.Pin.In(pull, edge); err != nil {
+		return err
+	}
+	p.s.release()
+	return nil
+}
+
+func (p *oneWirePin) Read() gpio.Level {
+	return p.s.read()
+}
+
+func (p *oneWirePin) Out(l gpio.Level) error {
+	if err := p.Pin.Out(l); err != nil {
+		return err
+	}
+	if l == gpio.Low {
+		p.s.low()
+	} else {
+		p.s.release()
+		p.s.high = true
+	}
+	return nil
+}
+
+const (
+	owIdle   = iota // waiting for a reset
+	owROM           // receiving a ROM command
+	owMatch         // receiving the address to match
+	owSearch        // search ROM
+	owFunc          // selected, receiving function commands
+)
+
+// owDev simulates a 1-wire device with a scratchpad.
+type owDev struct {
+	addr      onewire.Address
+	data      []byte // scratchpad returned by Read Scratchpad (0xBE)
+	alarm     bool
+	overdrive bool
+	written   []byte // function commands received
+
+	state int
+	in    uint64 // bits being received
+	n     int    // number of bits received or sent in the current state
+	out   []bool // bits to send
+}
+
+func newOWDev(family byte, serial uint64, data []byte) *owDev {
+	var b [8]byte
+	b[0] = family
+	for i := 1; i < 7; i++ {
+		b[i] = byte(serial >> uint(8*(i-1)))
+	}
+	b[7] = onewire.CalcCRC(b[:7])
+	var a uint64
+	for i := 7; i >= 0; i-- {
+		a = a<<8 | uint64(b[i])
+	}
+	return &owDev{addr: onewire.Address(a), data: data}
+}
+
+func (d *owDev) timing(standard, overdrive time.Duration) time.Duration {
+	if d.overdrive {
+		return overdrive
+	}
+	return standard
+}
+
+func (d *owDev) reset() {
+	d.state = owROM
+	d.in = 0
+	d.n = 0
+	d.out = nil
+}
+
+// sending returns the bit the device sends in the next slot, if any.
+func (d *owDev) sending() (bool, bool) {
+	switch {
+	case d.state == owIdle:
+		return false, false
+	case len(d.out) != 0:
+		return d.out[0], true
+	case d.state == owSearch && d.n%3 != 2:
+		bit := uint64(d.addr)&(1<<uint(d.n/3)) != 0
+		return bit == (d.n%3 == 0), true
+	}
+	return false, false
+}
+
+// slot is called at the end of a slot with the bit written by the master.
+func (d *owDev) slot(bit bool) {
+	if _, ok := d.sending(); ok {
+		if len(d.out) != 0 {
+			d.out = d.out[1:]
+		} else {
+			d.n++
+		}
+		return
+	}
+	switch d.state {
+	case owROM:
+		if !d.receive(bit, 8) {
+			return
+		}
+		switch byte(d.in) {
+		case 0x33: // Read ROM
+			d.send(uint64(d.addr), 64)
+			d.state = owFunc
+		case 0xCC: // Skip ROM
+			d.state = owFunc
+		case 0x3C: // Overdrive Skip ROM
+			d.overdrive = true
+			d.state = owFunc
+		case 0x55: // Match ROM
+			d.state = owMatch
+		case 0xF0: // Search ROM
+			d.state = owSearch
+		case 0xEC: // Alarm Search
+			d.state = owIdle
+			if d.alarm {
+				d.state = owSearch
+			}
+		default:
+			d.state = owIdle
+		}
+		d.in = 0
+		d.n = 0
+	case owMatch:
+		if !d.receive(bit, 64) {
+			return
+		}
+		d.state = owIdle
+		if onewire.Address(d.in) == d.addr {
+			d.state = owFunc
+		}
+		d.in = 0
+		d.n = 0
+	case owSearch:
+		if bit != (uint64(d.addr)&(1<<uint(d.n/3)) != 0) {
+			d.state = owIdle
+			return
+		}
+		if d.n++; d.n == 3*64 {
+			d.state = owFunc
+			d.n = 0
+		}
+	case owFunc:
+		if !d.receive(bit, 8) {
+			return
+		}
+		d.written = append(d.written, byte(d.in))
+		if byte(d.in) == 0xBE {
+			for _, b := range d.data {
+				d.send(uint64(b), 8)
+			}
+		}
+		d.in = 0
+		d.n = 0
+	}
+}
+
+// receive accumulates a bit and returns true once n bits were received.
+func (d *owDev) receive(bit bool, n int) bool {
+	if bit {
+		d.in |= 1 << uint(d.n)
+	}
+	d.n++
+	return d.n == n
+}
+
+// send queues n bits to send, least significant bit first.
+func (d *owDev) send(v uint64, n int) {
+	for i := 0; i < n; i++ {
+		d.out = append(d.out, v&(1<<uint(i)) != 0)
+	}
+}
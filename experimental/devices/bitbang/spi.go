@@ -106,6 +106,14 @@ func (s *SPI) LimitSpeed(f physic.Frequency) error {
 	return nil
 }
 
+// Caps implements spi.Capabilities.
+//
+// The maximum speed depends on the CPU and the GPIO driver so it is not
+// reported.
+func (s *SPI) Caps() (spi.Caps, error) {
+	return spi.Caps{Mode: spi.NoCS, BitsPerWord: 1 << 7}, nil
+}
+
 // CLK implements spi.Pins.
 func (s *SPI) CLK() gpio.PinOut {
 	return s.spiConn.sck
@@ -296,4 +304,5 @@ func (s *spiConn) initializePins() error {
 }
 
 var _ spi.Conn = &spiConn{}
+var _ spi.Capabilities = &SPI{}
 var _ spi.PortCloser = &SPI{}
@@ -0,0 +1,15 @@
+// Copyright 2019 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+// Package ds1990 reads Maxim DS1990A serial number iButtons over a 1-wire
+// bus.
+//
+// A DS1990A has no memory besides its 64 bits ROM, so the identification is
+// the ROM itself. Read() reads the ROM of an iButton touching a reader probe
+// without a search, while Dev checks for the presence of a known iButton.
+//
+// Datasheet
+//
+// https://datasheets.maximintegrated.com/en/ds/DS1990A.pdf
+package ds1990
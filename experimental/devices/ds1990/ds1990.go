@@ -0,0 +1,97 @@
+// Copyright 2019 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package ds1990
+
+import (
+	"errors"
+
+	"periph.io/x/periph/conn"
+	"periph.io/x/periph/conn/onewire"
+)
+
+// Family is the 1-wire family code of the DS1990A.
+const Family = 0x01
+
+// Read returns the address of the iButton on the bus.
+//
+// It uses a Read ROM command, which is only valid when a single device is on
+// the bus, as is the case for an iButton reader probe. An error implementing
+// onewire.NoDevicesError is returned when no iButton is touching the probe,
+// assuming the bus driver detects presence pulses.
+func Read(b onewire.Bus) (onewire.Address, error) {
+	var r [8]byte
+	if err := b.Tx([]byte{0x33}, r[:], onewire.WeakPullup); err != nil {
+		return 0, err
+	}
+	if !onewire.CheckCRC(r[:]) {
+		return 0, busError("ds1990: incorrect ROM CRC; is there more than one device?")
+	}
+	var a onewire.Address
+	for i := 7; i >= 0; i-- {
+		a = a<<8 | onewire.Address(r[i])
+	}
+	// A shorted probe reads as all zeros, which has a valid CRC.
+	if a&0xFF != Family {
+		return 0, busError("ds1990: not a DS1990A")
+	}
+	return a, nil
+}
+
+// New returns a handle to the DS1990A with the specified 64-bit address.
+//
+// No I/O is done.
+func New(b onewire.Bus, addr onewire.Address) (*Dev, error) {
+	if addr&0xFF != Family {
+		return nil, errors.New("ds1990: invalid family code")
+	}
+	return &Dev{onewire: onewire.Dev{Bus: b, Addr: addr}}, nil
+}
+
+// Dev is a handle to a Maxim DS1990A iButton on a 1-wire bus.
+type Dev struct {
+	onewire onewire.Dev
+}
+
+func (d *Dev) String() string {
+	return "DS1990{" + d.onewire.String() + "}"
+}
+
+// Halt implements conn.Resource.
+func (d *Dev) Halt() error {
+	return nil
+}
+
+// Serial returns the 48 bits serial number of the iButton, as engraved on
+// its case.
+func (d *Dev) Serial() uint64 {
+	return uint64(d.onewire.Addr>>8) & 0xFFFFFFFFFFFF
+}
+
+// Present returns true if the iButton is currently on the bus.
+func (d *Dev) Present() (bool, error) {
+	addrs, err := d.onewire.Bus.Search(false)
+	if err != nil {
+		if e, ok := err.(onewire.NoDevicesError); ok && e.NoDevices() {
+			return false, nil
+		}
+		return false, err
+	}
+	for _, a := range addrs {
+		if a == d.onewire.Addr {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+//
+
+// busError implements error and onewire.BusError.
+type busError string
+
+func (e busError) Error() string  { return string(e) }
+func (e busError) BusError() bool { return true }
+
+var _ conn.Resource = &Dev{}
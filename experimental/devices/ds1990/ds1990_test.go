@@ -0,0 +1,98 @@
+// Copyright 2019 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package ds1990
+
+import (
+	"fmt"
+	"testing"
+
+	"periph.io/x/periph/conn/onewire"
+	"periph.io/x/periph/conn/onewire/onewiretest"
+)
+
+func TestRead(t *testing.T) {
+	a := address(Family, 0x0000123456)
+	bus := &onewiretest.Playback{Ops: []onewiretest.IO{{W: []byte{0x33}, R: bytes(a)}}}
+	if got, err := Read(bus); err != nil || got != a {
+		t.Fatal(got, err)
+	}
+	if err := bus.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRead_err(t *testing.T) {
+	// Incorrect CRC, typically two devices answering at the same time.
+	r := bytes(address(Family, 0x0000123456))
+	r[7]++
+	bus := &onewiretest.Playback{Ops: []onewiretest.IO{{W: []byte{0x33}, R: r}}}
+	if _, err := Read(bus); err == nil {
+		t.Fatal("incorrect CRC")
+	}
+	// Shorted probe.
+	bus = &onewiretest.Playback{Ops: []onewiretest.IO{{W: []byte{0x33}, R: make([]byte, 8)}}}
+	if _, err := Read(bus); err == nil {
+		t.Fatal("not a DS1990A")
+	} else if _, ok := err.(onewire.BusError); !ok {
+		t.Fatal("expected a BusError")
+	}
+	// Failed Tx.
+	bus = &onewiretest.Playback{DontPanic: true}
+	if _, err := Read(bus); err == nil {
+		t.Fatal("expected failure")
+	}
+}
+
+func TestDev(t *testing.T) {
+	a := address(Family, 0x0000123456)
+	other := address(0x28, 0x0000654321)
+	bus := &onewiretest.Playback{
+		Ops:     []onewiretest.IO{{W: []byte{0xf0}}, {W: []byte{0xf0}}},
+		Devices: []onewire.Address{a},
+	}
+	d, err := New(bus, a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := d.String(); s != fmt.Sprintf("DS1990{playback(0x%016x)}", uint64(a)) {
+		t.Fatal(s)
+	}
+	if s := d.Serial(); s != 0x123456 {
+		t.Fatalf("%#x", s)
+	}
+	if ok, err := d.Present(); !ok || err != nil {
+		t.Fatal(ok, err)
+	}
+	bus.Devices = []onewire.Address{other}
+	if ok, err := d.Present(); ok || err != nil {
+		t.Fatal(ok, err)
+	}
+	if err := d.Halt(); err != nil {
+		t.Fatal(err)
+	}
+	if err := bus.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := New(bus, other); err == nil {
+		t.Fatal("invalid family code")
+	}
+}
+
+//
+
+// address returns the address with a valid CRC.
+func address(family byte, serial uint64) onewire.Address {
+	a := onewire.Address(serial<<8 | uint64(family))
+	b := bytes(a)
+	return a | onewire.Address(onewire.CalcCRC(b[:7]))<<56
+}
+
+func bytes(a onewire.Address) []byte {
+	b := make([]byte, 8)
+	for i := range b {
+		b[i] = byte(a >> uint(8*i))
+	}
+	return b
+}
@@ -0,0 +1,17 @@
+// Copyright 2019 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+// Package ds2408 controls a Maxim DS2408 8-channel addressable switch over a
+// 1-wire bus.
+//
+// The eight open drain PIO pins are exposed as gpio.PinIO. An external pull-up
+// resistor is required for a pin to read High. The activity latches, which
+// record the level changes on each pin, can be read and reset.
+//
+// The conditional search and the RSTZ pin configuration are not supported.
+//
+// Datasheet
+//
+// https://datasheets.maximintegrated.com/en/ds/DS2408.pdf
+package ds2408
@@ -0,0 +1,298 @@
+// Copyright 2019 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package ds2408
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"periph.io/x/periph/conn"
+	"periph.io/x/periph/conn/gpio"
+	"periph.io/x/periph/conn/onewire"
+	"periph.io/x/periph/conn/physic"
+	"periph.io/x/periph/conn/pin"
+)
+
+// Family is the 1-wire family code of the DS2408.
+const Family = 0x29
+
+// New returns a handle to the DS2408 with the specified 64-bit address.
+//
+// The current state of the output latches is read from the device and kept
+// as is.
+func New(b onewire.Bus, addr onewire.Address) (*Dev, error) {
+	if addr&0xFF != Family {
+		return nil, errors.New("ds2408: invalid family code")
+	}
+	d := &Dev{onewire: onewire.Dev{Bus: b, Addr: addr}}
+	r, err := d.registers()
+	if err != nil {
+		return nil, err
+	}
+	d.latch = r[regOutputLatch]
+	for i := range d.Pins {
+		d.Pins[i] = &pio{d: d, n: i}
+	}
+	return d, nil
+}
+
+// Dev is a handle to a Maxim DS2408 on a 1-wire bus.
+type Dev struct {
+	// Pins are P0 to P7, in this order.
+	Pins [8]gpio.PinIO
+
+	onewire onewire.Dev
+
+	mu    sync.Mutex
+	latch byte    // output latches; P0 is bit 0
+	out   [8]bool // pins set with Out()
+}
+
+func (d *Dev) String() string {
+	return "DS2408{" + d.onewire.String() + "}"
+}
+
+// Halt implements conn.Resource.
+func (d *Dev) Halt() error {
+	return nil
+}
+
+// Read returns the level of the pins; P0 is bit 0.
+func (d *Dev) Read() (byte, error) {
+	r, err := d.registers()
+	if err != nil {
+		return 0, err
+	}
+	return r[regLogicState], nil
+}
+
+// Write sets the output latches; P0 is bit 0.
+//
+// A bit at 0 turns on the output transistor, pulling the pin low. A bit at 1
+// turns it off, letting the pin be pulled high by the external pull-up
+// resistor or driven by another device.
+func (d *Dev) Write(latch byte) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.write(latch)
+}
+
+// Activity returns the activity latches; P0 is bit 0.
+//
+// A bit is set when a level change was detected on the pin since the last
+// call to ResetActivity().
+func (d *Dev) Activity() (byte, error) {
+	r, err := d.registers()
+	if err != nil {
+		return 0, err
+	}
+	return r[regActivityLatch], nil
+}
+
+// ResetActivity clears the activity latches.
+func (d *Dev) ResetActivity() error {
+	var r [1]byte
+	if err := d.onewire.Tx([]byte{0xC3}, r[:]); err != nil {
+		return err
+	}
+	if r[0] != 0xAA {
+		return busError("ds2408: reset of the activity latches was not confirmed")
+	}
+	return nil
+}
+
+//
+
+// Offsets of the registers returned by registers(), starting at address
+// 0x88.
+const (
+	regLogicState    = 0
+	regOutputLatch   = 1
+	regActivityLatch = 2
+)
+
+// registers reads the registers at addresses 0x88 to 0x8F and checks the
+// CRC.
+func (d *Dev) registers() ([]byte, error) {
+	// Read PIO Registers, followed by the target address and, in the answer,
+	// the registers up to 0x8F then the CRC of the whole transaction.
+	w := []byte{0xF0, 0x88, 0x00}
+	var r [10]byte
+	if err := d.onewire.Tx(w, r[:]); err != nil {
+		return nil, err
+	}
+	if !onewire.CheckCRC16(append(w, r[:]...)) {
+		return nil, busError("ds2408: incorrect CRC")
+	}
+	return r[:8], nil
+}
+
+// write sets the output latches.
+//
+// d.mu must be held.
+func (d *Dev) write(latch byte) error {
+	var r [2]byte
+	if err := d.onewire.Tx([]byte{0x5A, latch, ^latch}, r[:]); err != nil {
+		return err
+	}
+	if r[0] != 0xAA {
+		return busError("ds2408: write was not confirmed")
+	}
+	d.latch = latch
+	return nil
+}
+
+// pio is a PIO pin of a DS2408.
+type pio struct {
+	d *Dev
+	n int
+}
+
+func (p *pio) String() string {
+	return p.d.String() + "." + p.Name()
+}
+
+// Halt implements conn.Resource.
+func (p *pio) Halt() error {
+	return nil
+}
+
+// Name implements pin.Pin.
+func (p *pio) Name() string {
+	return "P" + strconv.Itoa(p.n)
+}
+
+// Number implements pin.Pin.
+func (p *pio) Number() int {
+	return p.n
+}
+
+// Function implements pin.Pin.
+func (p *pio) Function() string {
+	return string(p.Func())
+}
+
+// Func implements pin.PinFunc.
+func (p *pio) Func() pin.Func {
+	p.d.mu.Lock()
+	out := p.d.out[p.n]
+	p.d.mu.Unlock()
+	if out {
+		if p.Read() {
+			return gpio.OUT_HIGH
+		}
+		return gpio.OUT_LOW
+	}
+	if p.Read() {
+		return gpio.IN_HIGH
+	}
+	return gpio.IN_LOW
+}
+
+// SupportedFuncs implements pin.PinFunc.
+func (p *pio) SupportedFuncs() []pin.Func {
+	return []pin.Func{gpio.IN, gpio.OUT}
+}
+
+// SetFunc implements pin.PinFunc.
+func (p *pio) SetFunc(f pin.Func) error {
+	switch f {
+	case gpio.IN:
+		return p.In(gpio.PullNoChange, gpio.NoEdge)
+	case gpio.OUT_HIGH:
+		return p.Out(gpio.High)
+	case gpio.OUT, gpio.OUT_LOW:
+		return p.Out(gpio.Low)
+	default:
+		return p.wrap(errors.New("unsupported function"))
+	}
+}
+
+// In implements gpio.PinIn.
+//
+// It turns off the output transistor.
+func (p *pio) In(pull gpio.Pull, edge gpio.Edge) error {
+	if pull != gpio.PullNoChange && pull != gpio.Float {
+		return p.wrap(errors.New("doesn't support pull-up/pull-down"))
+	}
+	if edge != gpio.NoEdge {
+		return p.wrap(errors.New("doesn't support edge detection"))
+	}
+	p.d.mu.Lock()
+	defer p.d.mu.Unlock()
+	if err := p.d.write(p.d.latch | 1<<uint(p.n)); err != nil {
+		return err
+	}
+	p.d.out[p.n] = false
+	return nil
+}
+
+// Read implements gpio.PinIn.
+//
+// It returns Low if the device cannot be read.
+func (p *pio) Read() gpio.Level {
+	v, err := p.d.Read()
+	if err != nil {
+		return gpio.Low
+	}
+	return gpio.Level(v>>uint(p.n)&1 != 0)
+}
+
+// WaitForEdge implements gpio.PinIn.
+//
+// It always returns false since edge detection is not supported. Use
+// Dev.Activity() to poll for level changes instead.
+func (p *pio) WaitForEdge(timeout time.Duration) bool {
+	return false
+}
+
+// Pull implements gpio.PinIn.
+func (p *pio) Pull() gpio.Pull {
+	return gpio.Float
+}
+
+// DefaultPull implements gpio.PinIn.
+func (p *pio) DefaultPull() gpio.Pull {
+	return gpio.Float
+}
+
+// Out implements gpio.PinOut.
+//
+// High turns off the output transistor, so the pin is only high if an
+// external pull-up resistor is connected.
+func (p *pio) Out(l gpio.Level) error {
+	p.d.mu.Lock()
+	defer p.d.mu.Unlock()
+	latch := p.d.latch &^ (1 << uint(p.n))
+	if l {
+		latch |= 1 << uint(p.n)
+	}
+	if err := p.d.write(latch); err != nil {
+		return err
+	}
+	p.d.out[p.n] = true
+	return nil
+}
+
+// PWM implements gpio.PinOut.
+func (p *pio) PWM(gpio.Duty, physic.Frequency) error {
+	return p.wrap(errors.New("not supported"))
+}
+
+func (p *pio) wrap(err error) error {
+	return errors.New("ds2408: " + p.Name() + ": " + err.Error())
+}
+
+// busError implements error and onewire.BusError.
+type busError string
+
+func (e busError) Error() string  { return string(e) }
+func (e busError) BusError() bool { return true }
+
+var _ conn.Resource = &Dev{}
+var _ gpio.PinIO = &pio{}
+var _ pin.PinFunc = &pio{}
@@ -0,0 +1,159 @@
+// Copyright 2019 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package ds2408
+
+import (
+	"testing"
+
+	"periph.io/x/periph/conn/gpio"
+	"periph.io/x/periph/conn/onewire"
+	"periph.io/x/periph/conn/onewire/onewiretest"
+)
+
+const addr onewire.Address = 0x7b00000012345629
+
+func matchROM(w ...byte) []byte {
+	return append([]byte{0x55, 0x29, 0x56, 0x34, 0x12, 0x00, 0x00, 0x00, 0x7b}, w...)
+}
+
+// readRegs returns the Read PIO Registers transaction returning the logic
+// state, output latch and activity latch registers.
+func readRegs(state, latch, activity byte) onewiretest.IO {
+	r := []byte{state, latch, activity, 0x00, 0x00, 0x88, 0xff, 0xff}
+	crc := ^onewire.CalcCRC16(append([]byte{0xf0, 0x88, 0x00}, r...))
+	return onewiretest.IO{W: matchROM(0xf0, 0x88, 0x00), R: append(r, byte(crc), byte(crc>>8))}
+}
+
+func TestDev(t *testing.T) {
+	bus := &onewiretest.Playback{
+		Ops: []onewiretest.IO{
+			// New.
+			readRegs(0x0f, 0x0f, 0x00),
+			// Read.
+			readRegs(0x0f, 0x0f, 0x00),
+			// P7.Out(High).
+			{W: matchROM(0x5a, 0x8f, 0x70), R: []byte{0xaa, 0x8f}},
+			// P0.Out(Low).
+			{W: matchROM(0x5a, 0x8e, 0x71), R: []byte{0xaa, 0x8e}},
+			// P0.Read().
+			readRegs(0x8e, 0x8e, 0x01),
+			// Activity.
+			readRegs(0x8e, 0x8e, 0x01),
+			// ResetActivity.
+			{W: matchROM(0xc3), R: []byte{0xaa}},
+			// P0.In().
+			{W: matchROM(0x5a, 0x8f, 0x70), R: []byte{0xaa, 0x8f}},
+			// P0.Func().
+			readRegs(0x8f, 0x8f, 0x00),
+			// P7.Func().
+			readRegs(0x8f, 0x8f, 0x00),
+		},
+	}
+	d, err := New(bus, addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := d.String(); s != "DS2408{playback(0x7b00000012345629)}" {
+		t.Fatal(s)
+	}
+	if v, err := d.Read(); err != nil || v != 0x0f {
+		t.Fatal(v, err)
+	}
+	p0, p7 := d.Pins[0], d.Pins[7]
+	if s := p7.String(); s != "DS2408{playback(0x7b00000012345629)}.P7" {
+		t.Fatal(s)
+	}
+	if n := p7.Number(); n != 7 {
+		t.Fatal(n)
+	}
+	if err := p7.Out(gpio.High); err != nil {
+		t.Fatal(err)
+	}
+	if err := p0.Out(gpio.Low); err != nil {
+		t.Fatal(err)
+	}
+	if l := p0.Read(); l != gpio.Low {
+		t.Fatal(l)
+	}
+	if v, err := d.Activity(); err != nil || v != 0x01 {
+		t.Fatal(v, err)
+	}
+	if err := d.ResetActivity(); err != nil {
+		t.Fatal(err)
+	}
+	if err := p0.In(gpio.PullNoChange, gpio.NoEdge); err != nil {
+		t.Fatal(err)
+	}
+	if f := p0.Function(); f != string(gpio.IN_HIGH) {
+		t.Fatal(f)
+	}
+	if f := p7.(*pio).Func(); f != gpio.OUT_HIGH {
+		t.Fatal(f)
+	}
+	if err := d.Halt(); err != nil {
+		t.Fatal(err)
+	}
+	if err := bus.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDev_pin_err(t *testing.T) {
+	bus := &onewiretest.Playback{Ops: []onewiretest.IO{readRegs(0xff, 0xff, 0x00)}}
+	d, err := New(bus, addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := d.Pins[3]
+	if err := p.In(gpio.PullDown, gpio.NoEdge); err == nil {
+		t.Fatal("pull-down is not supported")
+	}
+	if err := p.In(gpio.Float, gpio.BothEdges); err == nil {
+		t.Fatal("edge detection is not supported")
+	}
+	if p.WaitForEdge(-1) {
+		t.Fatal("edge detection is not supported")
+	}
+	if err := p.PWM(gpio.DutyHalf, 0); err == nil {
+		t.Fatal("PWM is not supported")
+	}
+	if err := p.(*pio).SetFunc(gpio.CLK); err == nil {
+		t.Fatal("CLK is not supported")
+	}
+}
+
+func TestNew_err(t *testing.T) {
+	if _, err := New(&onewiretest.Playback{}, 0x7b0000001234563a); err == nil {
+		t.Fatal("invalid family code")
+	}
+	io := readRegs(0xff, 0xff, 0x00)
+	io.R[9]++
+	bus := &onewiretest.Playback{Ops: []onewiretest.IO{io}}
+	if _, err := New(bus, addr); err == nil {
+		t.Fatal("invalid CRC")
+	} else if _, ok := err.(onewire.BusError); !ok {
+		t.Fatal("expected a BusError")
+	}
+}
+
+func TestDev_not_confirmed(t *testing.T) {
+	bus := &onewiretest.Playback{
+		Ops: []onewiretest.IO{
+			readRegs(0xff, 0xff, 0x00),
+			{W: matchROM(0x5a, 0x00, 0xff), R: []byte{0xff, 0xff}},
+			{W: matchROM(0xc3), R: []byte{0xff}},
+		},
+	}
+	d, err := New(bus, addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Write(0); err == nil {
+		t.Fatal("write was not confirmed")
+	}
+	if err := d.ResetActivity(); err == nil {
+		t.Fatal("reset was not confirmed")
+	}
+}
@@ -0,0 +1,14 @@
+// Copyright 2019 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+// Package ds2413 controls a Maxim DS2413 dual channel addressable switch over
+// a 1-wire bus.
+//
+// The two open drain PIO pins are exposed as gpio.PinIO. An external pull-up
+// resistor is required for a pin to read High.
+//
+// Datasheet
+//
+// https://datasheets.maximintegrated.com/en/ds/DS2413.pdf
+package ds2413
@@ -0,0 +1,266 @@
+// Copyright 2019 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package ds2413
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"periph.io/x/periph/conn"
+	"periph.io/x/periph/conn/gpio"
+	"periph.io/x/periph/conn/onewire"
+	"periph.io/x/periph/conn/physic"
+	"periph.io/x/periph/conn/pin"
+)
+
+// Family is the 1-wire family code of the DS2413.
+const Family = 0x3A
+
+// New returns a handle to the DS2413 with the specified 64-bit address.
+//
+// The current state of the output latches is read from the device and kept
+// as is.
+func New(b onewire.Bus, addr onewire.Address) (*Dev, error) {
+	if addr&0xFF != Family {
+		return nil, errors.New("ds2413: invalid family code")
+	}
+	d := &Dev{onewire: onewire.Dev{Bus: b, Addr: addr}}
+	s, err := d.status()
+	if err != nil {
+		return nil, err
+	}
+	d.latch = s >> 1 & 1
+	d.latch |= s >> 2 & 2
+	for i := range d.Pins {
+		d.Pins[i] = &pio{d: d, n: i}
+	}
+	return d, nil
+}
+
+// Dev is a handle to a Maxim DS2413 on a 1-wire bus.
+type Dev struct {
+	// Pins are PIOA and PIOB, in this order.
+	Pins [2]gpio.PinIO
+
+	onewire onewire.Dev
+
+	mu    sync.Mutex
+	latch byte    // output latches; PIOA is bit 0
+	out   [2]bool // pins set with Out()
+}
+
+func (d *Dev) String() string {
+	return "DS2413{" + d.onewire.String() + "}"
+}
+
+// Halt implements conn.Resource.
+func (d *Dev) Halt() error {
+	return nil
+}
+
+// Read returns the level of the pins; PIOA is bit 0 and PIOB is bit 1.
+func (d *Dev) Read() (byte, error) {
+	s, err := d.status()
+	if err != nil {
+		return 0, err
+	}
+	return s&1 | s>>1&2, nil
+}
+
+// Write sets the output latches; PIOA is bit 0 and PIOB is bit 1.
+//
+// A bit at 0 turns on the output transistor, pulling the pin low. A bit at 1
+// turns it off, letting the pin be pulled high by the external pull-up
+// resistor or driven by another device.
+func (d *Dev) Write(latch byte) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.write(latch)
+}
+
+//
+
+// status reads the PIO status byte and checks it.
+//
+// Bits 0 and 2 are the pin levels of PIOA and PIOB, bits 1 and 3 are their
+// output latches.
+func (d *Dev) status() (byte, error) {
+	var r [1]byte
+	if err := d.onewire.Tx([]byte{0xF5}, r[:]); err != nil {
+		return 0, err
+	}
+	// The upper nibble is the complement of the lower one.
+	if r[0]>>4 != ^r[0]&0xF {
+		return 0, busError("ds2413: invalid status byte")
+	}
+	return r[0], nil
+}
+
+// write sets the output latches.
+//
+// d.mu must be held.
+func (d *Dev) write(latch byte) error {
+	v := 0xFC | latch&3
+	var r [2]byte
+	if err := d.onewire.Tx([]byte{0x5A, v, ^v}, r[:]); err != nil {
+		return err
+	}
+	if r[0] != 0xAA {
+		return busError("ds2413: write was not confirmed")
+	}
+	d.latch = latch & 3
+	return nil
+}
+
+// pio is a PIO pin of a DS2413.
+type pio struct {
+	d *Dev
+	n int
+}
+
+func (p *pio) String() string {
+	return p.d.String() + "." + p.Name()
+}
+
+// Halt implements conn.Resource.
+func (p *pio) Halt() error {
+	return nil
+}
+
+// Name implements pin.Pin.
+func (p *pio) Name() string {
+	return [...]string{"PIOA", "PIOB"}[p.n]
+}
+
+// Number implements pin.Pin.
+func (p *pio) Number() int {
+	return p.n
+}
+
+// Function implements pin.Pin.
+func (p *pio) Function() string {
+	return string(p.Func())
+}
+
+// Func implements pin.PinFunc.
+func (p *pio) Func() pin.Func {
+	p.d.mu.Lock()
+	out := p.d.out[p.n]
+	p.d.mu.Unlock()
+	if out {
+		if p.Read() {
+			return gpio.OUT_HIGH
+		}
+		return gpio.OUT_LOW
+	}
+	if p.Read() {
+		return gpio.IN_HIGH
+	}
+	return gpio.IN_LOW
+}
+
+// SupportedFuncs implements pin.PinFunc.
+func (p *pio) SupportedFuncs() []pin.Func {
+	return []pin.Func{gpio.IN, gpio.OUT}
+}
+
+// SetFunc implements pin.PinFunc.
+func (p *pio) SetFunc(f pin.Func) error {
+	switch f {
+	case gpio.IN:
+		return p.In(gpio.PullNoChange, gpio.NoEdge)
+	case gpio.OUT_HIGH:
+		return p.Out(gpio.High)
+	case gpio.OUT, gpio.OUT_LOW:
+		return p.Out(gpio.Low)
+	default:
+		return p.wrap(errors.New("unsupported function"))
+	}
+}
+
+// In implements gpio.PinIn.
+//
+// It turns off the output transistor.
+func (p *pio) In(pull gpio.Pull, edge gpio.Edge) error {
+	if pull != gpio.PullNoChange && pull != gpio.Float {
+		return p.wrap(errors.New("doesn't support pull-up/pull-down"))
+	}
+	if edge != gpio.NoEdge {
+		return p.wrap(errors.New("doesn't support edge detection"))
+	}
+	p.d.mu.Lock()
+	defer p.d.mu.Unlock()
+	if err := p.d.write(p.d.latch | 1<<uint(p.n)); err != nil {
+		return err
+	}
+	p.d.out[p.n] = false
+	return nil
+}
+
+// Read implements gpio.PinIn.
+//
+// It returns Low if the device cannot be read.
+func (p *pio) Read() gpio.Level {
+	v, err := p.d.Read()
+	if err != nil {
+		return gpio.Low
+	}
+	return gpio.Level(v>>uint(p.n)&1 != 0)
+}
+
+// WaitForEdge implements gpio.PinIn.
+//
+// It always returns false since edge detection is not supported.
+func (p *pio) WaitForEdge(timeout time.Duration) bool {
+	return false
+}
+
+// Pull implements gpio.PinIn.
+func (p *pio) Pull() gpio.Pull {
+	return gpio.Float
+}
+
+// DefaultPull implements gpio.PinIn.
+func (p *pio) DefaultPull() gpio.Pull {
+	return gpio.Float
+}
+
+// Out implements gpio.PinOut.
+//
+// High turns off the output transistor, so the pin is only high if an
+// external pull-up resistor is connected.
+func (p *pio) Out(l gpio.Level) error {
+	p.d.mu.Lock()
+	defer p.d.mu.Unlock()
+	latch := p.d.latch &^ (1 << uint(p.n))
+	if l {
+		latch |= 1 << uint(p.n)
+	}
+	if err := p.d.write(latch); err != nil {
+		return err
+	}
+	p.d.out[p.n] = true
+	return nil
+}
+
+// PWM implements gpio.PinOut.
+func (p *pio) PWM(gpio.Duty, physic.Frequency) error {
+	return p.wrap(errors.New("not supported"))
+}
+
+func (p *pio) wrap(err error) error {
+	return errors.New("ds2413: " + p.Name() + ": " + err.Error())
+}
+
+// busError implements error and onewire.BusError.
+type busError string
+
+func (e busError) Error() string  { return string(e) }
+func (e busError) BusError() bool { return true }
+
+var _ conn.Resource = &Dev{}
+var _ gpio.PinIO = &pio{}
+var _ pin.PinFunc = &pio{}
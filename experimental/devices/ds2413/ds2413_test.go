@@ -0,0 +1,138 @@
+// Copyright 2019 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package ds2413
+
+import (
+	"testing"
+
+	"periph.io/x/periph/conn/gpio"
+	"periph.io/x/periph/conn/onewire"
+	"periph.io/x/periph/conn/onewire/onewiretest"
+)
+
+const addr onewire.Address = 0x7b0000001234563a
+
+func matchROM(w ...byte) []byte {
+	return append([]byte{0x55, 0x3a, 0x56, 0x34, 0x12, 0x00, 0x00, 0x00, 0x7b}, w...)
+}
+
+func TestDev(t *testing.T) {
+	bus := &onewiretest.Playback{
+		Ops: []onewiretest.IO{
+			// New: PIOA is released and high, PIOB is pulled low.
+			{W: matchROM(0xf5), R: []byte{0xc3}},
+			// Read.
+			{W: matchROM(0xf5), R: []byte{0xc3}},
+			// PIOB.Out(High).
+			{W: matchROM(0x5a, 0xff, 0x00), R: []byte{0xaa, 0x0f}},
+			// PIOA.Out(Low).
+			{W: matchROM(0x5a, 0xfe, 0x01), R: []byte{0xaa, 0x3c}},
+			// PIOA.Read().
+			{W: matchROM(0xf5), R: []byte{0x3c}},
+			// PIOA.Func().
+			{W: matchROM(0xf5), R: []byte{0x3c}},
+			// PIOA.In().
+			{W: matchROM(0x5a, 0xff, 0x00), R: []byte{0xaa, 0x0f}},
+			// PIOA.Func().
+			{W: matchROM(0xf5), R: []byte{0x0f}},
+		},
+	}
+	d, err := New(bus, addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := d.String(); s != "DS2413{playback(0x7b0000001234563a)}" {
+		t.Fatal(s)
+	}
+	if v, err := d.Read(); err != nil || v != 1 {
+		t.Fatal(v, err)
+	}
+	a, b := d.Pins[0], d.Pins[1]
+	if s := b.String(); s != "DS2413{playback(0x7b0000001234563a)}.PIOB" {
+		t.Fatal(s)
+	}
+	if n := b.Number(); n != 1 {
+		t.Fatal(n)
+	}
+	if err := b.Out(gpio.High); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Out(gpio.Low); err != nil {
+		t.Fatal(err)
+	}
+	if l := a.Read(); l != gpio.Low {
+		t.Fatal(l)
+	}
+	if f := a.(*pio).Func(); f != gpio.OUT_LOW {
+		t.Fatal(f)
+	}
+	if err := a.In(gpio.PullNoChange, gpio.NoEdge); err != nil {
+		t.Fatal(err)
+	}
+	if f := a.Function(); f != string(gpio.IN_HIGH) {
+		t.Fatal(f)
+	}
+	if err := d.Halt(); err != nil {
+		t.Fatal(err)
+	}
+	if err := bus.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDev_pin_err(t *testing.T) {
+	bus := &onewiretest.Playback{Ops: []onewiretest.IO{{W: matchROM(0xf5), R: []byte{0x0f}}}}
+	d, err := New(bus, addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := d.Pins[0]
+	if err := p.In(gpio.PullUp, gpio.NoEdge); err == nil {
+		t.Fatal("pull-up is not supported")
+	}
+	if err := p.In(gpio.Float, gpio.RisingEdge); err == nil {
+		t.Fatal("edge detection is not supported")
+	}
+	if p.WaitForEdge(-1) {
+		t.Fatal("edge detection is not supported")
+	}
+	if err := p.PWM(gpio.DutyHalf, 0); err == nil {
+		t.Fatal("PWM is not supported")
+	}
+	if err := p.(*pio).SetFunc(gpio.CLK); err == nil {
+		t.Fatal("CLK is not supported")
+	}
+	if p.Pull() != gpio.Float || p.DefaultPull() != gpio.Float {
+		t.Fatal("expected Float")
+	}
+}
+
+func TestNew_err(t *testing.T) {
+	if _, err := New(&onewiretest.Playback{}, 0x7b00000012345628); err == nil {
+		t.Fatal("invalid family code")
+	}
+	bus := &onewiretest.Playback{Ops: []onewiretest.IO{{W: matchROM(0xf5), R: []byte{0xff}}}}
+	if _, err := New(bus, addr); err == nil {
+		t.Fatal("invalid status byte")
+	} else if _, ok := err.(onewire.BusError); !ok {
+		t.Fatal("expected a BusError")
+	}
+}
+
+func TestWrite_err(t *testing.T) {
+	bus := &onewiretest.Playback{
+		Ops: []onewiretest.IO{
+			{W: matchROM(0xf5), R: []byte{0x0f}},
+			{W: matchROM(0x5a, 0xfc, 0x03), R: []byte{0xff, 0xff}},
+		},
+	}
+	d, err := New(bus, addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Write(0); err == nil {
+		t.Fatal("write was not confirmed")
+	}
+}
@@ -0,0 +1,20 @@
+// Copyright 2019 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+// Package ds2431 controls the Maxim DS2431 1024 bits and DS28EC20 20480 bits
+// 1-wire EEPROMs.
+//
+// The data memory is exposed as an io.ReaderAt and an io.WriterAt. Writes go
+// through the scratchpad of the device, one row of 8 bytes (DS2431) or 32
+// bytes (DS28EC20) at a time, and are verified before being copied to the
+// EEPROM.
+//
+// The memory protection and the register pages are not supported.
+//
+// Datasheets
+//
+// https://datasheets.maximintegrated.com/en/ds/DS2431.pdf
+//
+// https://datasheets.maximintegrated.com/en/ds/DS28EC20.pdf
+package ds2431
@@ -0,0 +1,200 @@
+// Copyright 2019 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package ds2431
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"periph.io/x/periph/conn"
+	"periph.io/x/periph/conn/onewire"
+)
+
+// Family codes of the supported devices.
+const (
+	FamilyDS2431   = 0x2D
+	FamilyDS28EC20 = 0x43
+)
+
+// New returns a handle to the EEPROM with the specified 64-bit address.
+//
+// The device type is determined by the family code of the address.
+func New(b onewire.Bus, addr onewire.Address) (*Dev, error) {
+	d := &Dev{onewire: onewire.Dev{Bus: b, Addr: addr}}
+	switch addr & 0xFF {
+	case FamilyDS2431:
+		d.name = "DS2431"
+		d.size = 128
+		d.row = 8
+	case FamilyDS28EC20:
+		d.name = "DS28EC20"
+		d.size = 2560
+		d.row = 32
+	default:
+		return nil, errors.New("ds2431: invalid family code")
+	}
+	return d, nil
+}
+
+// Dev is a handle to a DS2431 or DS28EC20 EEPROM on a 1-wire bus.
+type Dev struct {
+	onewire onewire.Dev
+	name    string
+	size    int64 // size of the data memory in bytes
+	row     int   // size of the scratchpad in bytes
+
+	mu sync.Mutex
+}
+
+func (d *Dev) String() string {
+	return d.name + "{" + d.onewire.String() + "}"
+}
+
+// Halt implements conn.Resource.
+func (d *Dev) Halt() error {
+	return nil
+}
+
+// Size returns the size of the data memory in bytes.
+func (d *Dev) Size() int64 {
+	return d.size
+}
+
+// ReadAt implements io.ReaderAt.
+//
+// It returns io.EOF when reading past the end of the data memory.
+func (d *Dev) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errors.New("ds2431: invalid offset")
+	}
+	if off >= d.size {
+		return 0, io.EOF
+	}
+	n := len(p)
+	if int64(n) > d.size-off {
+		n = int(d.size - off)
+	}
+	if n != 0 {
+		d.mu.Lock()
+		err := d.onewire.Tx([]byte{0xF0, byte(off), byte(off >> 8)}, p[:n])
+		d.mu.Unlock()
+		if err != nil {
+			return 0, err
+		}
+	}
+	if n != len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// WriteAt implements io.WriterAt.
+//
+// The memory is written one row at a time. The rows partially covered by p
+// are read first so their other bytes are preserved. Each row write takes
+// about 10ms.
+func (d *Dev) WriteAt(p []byte, off int64) (int, error) {
+	if off < 0 || off+int64(len(p)) > d.size {
+		return 0, errors.New("ds2431: write out of range")
+	}
+	row := int64(d.row)
+	buf := make([]byte, d.row)
+	n := 0
+	for n != len(p) {
+		a := off + int64(n)
+		start := a - a%row
+		c := d.row - int(a-start)
+		if c > len(p)-n {
+			c = len(p) - n
+		}
+		if c != d.row {
+			// Preserve the bytes not overwritten.
+			if _, err := d.ReadAt(buf, start); err != nil {
+				return n, err
+			}
+		}
+		copy(buf[a-start:], p[n:n+c])
+		if err := d.writeRow(uint16(start), buf); err != nil {
+			return n, err
+		}
+		n += c
+	}
+	return n, nil
+}
+
+//
+
+// writeRow writes a full row through the scratchpad.
+func (d *Dev) writeRow(addr uint16, data []byte) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	ta1, ta2 := byte(addr), byte(addr>>8)
+
+	// Write Scratchpad; the device answers with the CRC of the command when
+	// the end of the scratchpad is reached.
+	w := append([]byte{0x0F, ta1, ta2}, data...)
+	var crc [2]byte
+	if err := d.onewire.Tx(w, crc[:]); err != nil {
+		return err
+	}
+	if !onewire.CheckCRC16(append(w, crc[:]...)) {
+		return busError("ds2431: incorrect CRC writing scratchpad")
+	}
+
+	// Read Scratchpad to verify the data and get the authorization pattern.
+	es, err := d.readScratchpad(addr, data)
+	if err != nil {
+		return err
+	}
+	if es&0x3F != byte(d.row-1) {
+		return busError("ds2431: incomplete scratchpad write")
+	}
+
+	// Copy Scratchpad; the EEPROM is programmed while the bus is held high.
+	if err := d.onewire.TxPower([]byte{0x55, ta1, ta2, es}, nil); err != nil {
+		return err
+	}
+	sleep(10 * time.Millisecond)
+
+	// The AA flag is set when the copy succeeded.
+	if es, err = d.readScratchpad(addr, data); err != nil {
+		return err
+	}
+	if es&0x80 == 0 {
+		return errors.New("ds2431: copy failed; is the memory write protected?")
+	}
+	return nil
+}
+
+// readScratchpad reads the scratchpad, checks the CRC and that it contains
+// data for addr then returns the E/S byte.
+func (d *Dev) readScratchpad(addr uint16, data []byte) (byte, error) {
+	r := make([]byte, 3+d.row+2)
+	if err := d.onewire.Tx([]byte{0xAA}, r); err != nil {
+		return 0, err
+	}
+	if !onewire.CheckCRC16(append([]byte{0xAA}, r...)) {
+		return 0, busError("ds2431: incorrect CRC reading scratchpad")
+	}
+	if r[0] != byte(addr) || r[1] != byte(addr>>8) || !bytes.Equal(r[3:3+d.row], data) {
+		return 0, busError("ds2431: scratchpad content mismatch")
+	}
+	return r[2], nil
+}
+
+// busError implements error and onewire.BusError.
+type busError string
+
+func (e busError) Error() string  { return string(e) }
+func (e busError) BusError() bool { return true }
+
+var sleep = time.Sleep
+
+var _ conn.Resource = &Dev{}
+var _ io.ReaderAt = &Dev{}
+var _ io.WriterAt = &Dev{}
@@ -0,0 +1,186 @@
+// Copyright 2019 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package ds2431
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	"periph.io/x/periph/conn/onewire"
+	"periph.io/x/periph/conn/onewire/onewiretest"
+)
+
+func TestReadAt(t *testing.T) {
+	const addr onewire.Address = 0x7b0000001234562d
+	bus := &onewiretest.Playback{
+		Ops: []onewiretest.IO{
+			{W: matchROM(addr, 0xf0, 0x10, 0x00), R: []byte{1, 2, 3, 4}},
+			{W: matchROM(addr, 0xf0, 0x7e, 0x00), R: []byte{5, 6}},
+		},
+	}
+	d, err := New(bus, addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := d.String(); s != "DS2431{playback(0x7b0000001234562d)}" {
+		t.Fatal(s)
+	}
+	if s := d.Size(); s != 128 {
+		t.Fatal(s)
+	}
+	b := make([]byte, 4)
+	if n, err := d.ReadAt(b, 16); n != 4 || err != nil || !bytes.Equal(b, []byte{1, 2, 3, 4}) {
+		t.Fatal(n, err, b)
+	}
+	if n, err := d.ReadAt(b, 126); n != 2 || err != io.EOF || !bytes.Equal(b[:2], []byte{5, 6}) {
+		t.Fatal(n, err, b)
+	}
+	if n, err := d.ReadAt(b, 128); n != 0 || err != io.EOF {
+		t.Fatal(n, err)
+	}
+	if _, err := d.ReadAt(b, -1); err == nil {
+		t.Fatal("invalid offset")
+	}
+	if err := d.Halt(); err != nil {
+		t.Fatal(err)
+	}
+	if err := bus.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWriteAt(t *testing.T) {
+	const addr onewire.Address = 0x7b0000001234562d
+	empty := bytes.Repeat([]byte{0xff}, 8)
+	var ops []onewiretest.IO
+	ops = append(ops, onewiretest.IO{W: matchROM(addr, 0xf0, 0x00, 0x00), R: empty})
+	ops = append(ops, writeRow(addr, 0x0000, []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 1, 2}, 0x80)...)
+	ops = append(ops, onewiretest.IO{W: matchROM(addr, 0xf0, 0x08, 0x00), R: empty})
+	ops = append(ops, writeRow(addr, 0x0008, []byte{3, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, 0x80)...)
+	bus := &onewiretest.Playback{Ops: ops}
+	var sleeps []time.Duration
+	sleep = func(d time.Duration) { sleeps = append(sleeps, d) }
+	defer func() { sleep = time.Sleep }()
+
+	d, err := New(bus, addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, err := d.WriteAt([]byte{1, 2, 3}, 6); n != 3 || err != nil {
+		t.Fatal(n, err)
+	}
+	if len(sleeps) != 2 || sleeps[0] != 10*time.Millisecond {
+		t.Fatal(sleeps)
+	}
+	if err := bus.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWriteAt_DS28EC20(t *testing.T) {
+	const addr onewire.Address = 0x7b00000012345643
+	data := make([]byte, 32)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	bus := &onewiretest.Playback{Ops: writeRow(addr, 0x0980, data, 0x80)}
+	sleep = func(time.Duration) {}
+	defer func() { sleep = time.Sleep }()
+
+	d, err := New(bus, addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := d.String(); s != "DS28EC20{playback(0x7b00000012345643)}" {
+		t.Fatal(s)
+	}
+	if s := d.Size(); s != 2560 {
+		t.Fatal(s)
+	}
+	if n, err := d.WriteAt(data, 0x980); n != 32 || err != nil {
+		t.Fatal(n, err)
+	}
+	if err := bus.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWriteAt_err(t *testing.T) {
+	const addr onewire.Address = 0x7b0000001234562d
+	data := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+	sleep = func(time.Duration) {}
+	defer func() { sleep = time.Sleep }()
+
+	d, err := New(&onewiretest.Playback{}, addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.WriteAt(data, 124); err == nil {
+		t.Fatal("out of range")
+	}
+	if _, err := d.WriteAt(data, -1); err == nil {
+		t.Fatal("out of range")
+	}
+
+	// The memory is write protected; the AA flag is not set.
+	bus := &onewiretest.Playback{Ops: writeRow(addr, 0x0000, data, 0)}
+	if d, err = New(bus, addr); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := d.WriteAt(data, 0); n != 0 || err == nil {
+		t.Fatal("copy failed")
+	}
+
+	// CRC error while writing the scratchpad.
+	ops := writeRow(addr, 0x0000, data, 0x80)
+	ops[0].R[0]++
+	bus = &onewiretest.Playback{Ops: ops[:1]}
+	if d, err = New(bus, addr); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.WriteAt(data, 0); err == nil {
+		t.Fatal("incorrect CRC")
+	} else if _, ok := err.(onewire.BusError); !ok {
+		t.Fatal("expected a BusError")
+	}
+}
+
+func TestNew_err(t *testing.T) {
+	if _, err := New(&onewiretest.Playback{}, 0x7b00000012345628); err == nil {
+		t.Fatal("invalid family code")
+	}
+}
+
+//
+
+func matchROM(addr onewire.Address, w ...byte) []byte {
+	b := []byte{0x55}
+	for i := uint(0); i < 64; i += 8 {
+		b = append(b, byte(addr>>i))
+	}
+	return append(b, w...)
+}
+
+// writeRow returns the transactions to write a row; aa is the AA flag
+// returned after the copy.
+func writeRow(addr onewire.Address, a uint16, data []byte, aa byte) []onewiretest.IO {
+	w := append([]byte{0x0f, byte(a), byte(a >> 8)}, data...)
+	crc := ^onewire.CalcCRC16(w)
+	es := byte(len(data) - 1)
+	return []onewiretest.IO{
+		{W: matchROM(addr, w...), R: []byte{byte(crc), byte(crc >> 8)}},
+		{W: matchROM(addr, 0xaa), R: scratchpad(a, es, data)},
+		{W: matchROM(addr, 0x55, byte(a), byte(a>>8), es), Pull: onewire.StrongPullup},
+		{W: matchROM(addr, 0xaa), R: scratchpad(a, es|aa, data)},
+	}
+}
+
+func scratchpad(a uint16, es byte, data []byte) []byte {
+	r := append([]byte{byte(a), byte(a >> 8), es}, data...)
+	crc := ^onewire.CalcCRC16(append([]byte{0xaa}, r...))
+	return append(r, byte(crc), byte(crc>>8))
+}
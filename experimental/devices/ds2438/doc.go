@@ -0,0 +1,17 @@
+// Copyright 2019 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+// Package ds2438 controls a Maxim DS2438 smart battery monitor over a 1-wire
+// bus.
+//
+// The device measures its temperature, its supply voltage (VDD), the voltage
+// on its VAD input and, with a sense resistor, the battery current.
+//
+// The current accumulators, the elapsed time meter and the EEPROM pages are
+// not supported.
+//
+// Datasheet
+//
+// https://datasheets.maximintegrated.com/en/ds/DS2438.pdf
+package ds2438
@@ -0,0 +1,186 @@
+// Copyright 2019 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package ds2438
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"periph.io/x/periph/conn"
+	"periph.io/x/periph/conn/onewire"
+	"periph.io/x/periph/conn/physic"
+)
+
+// Family is the 1-wire family code of the DS2438.
+const Family = 0x26
+
+// Opts holds the configuration options.
+type Opts struct {
+	// SenseResistor is the resistor between the VSENS+ and VSENS- pins. When
+	// 0, the current is not measured.
+	SenseResistor physic.ElectricResistance
+}
+
+// New returns a handle to the DS2438 with the specified 64-bit address.
+//
+// The configuration stored in the device, including the current accumulator
+// settings, is kept as is. The only change done is to enable the current A/D
+// converter when opts.SenseResistor is set and it is disabled.
+func New(b onewire.Bus, addr onewire.Address, opts *Opts) (*Dev, error) {
+	if addr&0xFF != Family {
+		return nil, errors.New("ds2438: invalid family code")
+	}
+	if opts.SenseResistor < 0 {
+		return nil, errors.New("ds2438: invalid sense resistor")
+	}
+	d := &Dev{onewire: onewire.Dev{Bus: b, Addr: addr}, sense: opts.SenseResistor}
+	page, err := d.readPage0()
+	if err != nil {
+		return nil, err
+	}
+	// Ignore the read only status bits.
+	d.config = page[0] & (cfgIAD | cfgCA | cfgEE | cfgAD)
+	if d.sense != 0 && d.config&cfgIAD == 0 {
+		d.config |= cfgIAD
+		if err := d.writeConfig(d.config); err != nil {
+			return nil, err
+		}
+	}
+	return d, nil
+}
+
+// Dev is a handle to a Maxim DS2438 on a 1-wire bus.
+type Dev struct {
+	onewire onewire.Dev
+	sense   physic.ElectricResistance
+
+	mu     sync.Mutex
+	config byte
+}
+
+func (d *Dev) String() string {
+	return "DS2438{" + d.onewire.String() + "}"
+}
+
+// Halt implements conn.Resource.
+func (d *Dev) Halt() error {
+	return nil
+}
+
+// Sense does a temperature conversion and a voltage conversion on both VDD
+// and VAD then returns the measurements.
+//
+// It takes about 30ms. The current is the last one measured by the
+// device, which measures it continuously when a sense resistor is set.
+func (d *Dev) Sense() (Measurement, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var m Measurement
+	if err := d.onewire.Tx([]byte{0x44}, nil); err != nil {
+		return m, err
+	}
+	sleep(conversionTime)
+	for _, vdd := range []bool{true, false} {
+		cfg := d.config &^ cfgAD
+		if vdd {
+			cfg |= cfgAD
+		}
+		if err := d.writeConfig(cfg); err != nil {
+			return m, err
+		}
+		if err := d.onewire.Tx([]byte{0xB4}, nil); err != nil {
+			return m, err
+		}
+		sleep(conversionTime)
+		page, err := d.readPage0()
+		if err != nil {
+			return m, err
+		}
+		// Bits 0 to 9, with a LSB of 10mV.
+		v := physic.ElectricPotential(uint16(page[4]&3)<<8|uint16(page[3])) * 10 * physic.MilliVolt
+		if vdd {
+			m.VDD = v
+			continue
+		}
+		m.VAD = v
+		// 13 bits signed left aligned, with a LSB of 0.03125°C.
+		t := int16(uint16(page[2])<<8 | uint16(page[1]))
+		m.Temperature = physic.Temperature(t)*physic.Kelvin/256 + physic.ZeroCelsius
+		if d.sense != 0 {
+			// 10 bits signed, I = value / (4096 * Rsens).
+			i := int16(uint16(page[6])<<8 | uint16(page[5]))
+			m.Current = physic.ElectricCurrent(int64(i) * int64(physic.Ampere) / 4096 * int64(physic.Ohm) / int64(d.sense))
+		}
+	}
+	return m, nil
+}
+
+// Measurement is the result of Dev.Sense().
+type Measurement struct {
+	Temperature physic.Temperature
+	// VDD is the supply voltage.
+	VDD physic.ElectricPotential
+	// VAD is the voltage on the VAD input.
+	VAD physic.ElectricPotential
+	// Current is the current through the sense resistor, negative when the
+	// battery is discharging.
+	Current physic.ElectricCurrent
+}
+
+func (m Measurement) String() string {
+	return fmt.Sprintf("%s, VDD: %s, VAD: %s, Current: %s", m.Temperature, m.VDD, m.VAD, m.Current)
+}
+
+//
+
+// Bits of the status/configuration register, page 0 byte 0.
+const (
+	cfgIAD byte = 1 << 0 // current A/D and ICA enabled
+	cfgCA  byte = 1 << 1 // current accumulator configuration
+	cfgEE  byte = 1 << 2 // current accumulator shadow selector
+	cfgAD  byte = 1 << 3 // voltage A/D input select: 1 is VDD, 0 is VAD
+)
+
+// conversionTime is the maximum duration of a temperature or a voltage
+// conversion.
+const conversionTime = 10 * time.Millisecond
+
+// readPage0 recalls the page 0 into the scratchpad then reads it and checks
+// the CRC.
+func (d *Dev) readPage0() ([]byte, error) {
+	if err := d.onewire.Tx([]byte{0xB8, 0x00}, nil); err != nil {
+		return nil, err
+	}
+	var spad [9]byte
+	if err := d.onewire.Tx([]byte{0xBE, 0x00}, spad[:]); err != nil {
+		return nil, err
+	}
+	if !onewire.CheckCRC(spad[:]) {
+		return nil, busError("ds2438: incorrect scratchpad CRC")
+	}
+	return spad[:8], nil
+}
+
+// writeConfig writes the status/configuration register.
+func (d *Dev) writeConfig(cfg byte) error {
+	if err := d.onewire.Tx([]byte{0x4E, 0x00, cfg}, nil); err != nil {
+		return err
+	}
+	// Copy the scratchpad to the page 0 registers.
+	return d.onewire.Tx([]byte{0x48, 0x00}, nil)
+}
+
+// busError implements error and onewire.BusError.
+type busError string
+
+func (e busError) Error() string  { return string(e) }
+func (e busError) BusError() bool { return true }
+
+var sleep = time.Sleep
+
+var _ conn.Resource = &Dev{}
+var _ fmt.Stringer = Measurement{}
@@ -0,0 +1,177 @@
+// Copyright 2019 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package ds2438
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"periph.io/x/periph/conn/onewire"
+	"periph.io/x/periph/conn/onewire/onewiretest"
+	"periph.io/x/periph/conn/physic"
+)
+
+const addr onewire.Address = 0x7b00000012345626
+
+func matchROM(w ...byte) []byte {
+	return append([]byte{0x55, 0x26, 0x56, 0x34, 0x12, 0x00, 0x00, 0x00, 0x7b}, w...)
+}
+
+// readPage0 returns the transactions to read the page 0 with the specified
+// register values.
+func readPage0(cfg byte, temp int16, volt uint16, cur int16) []onewiretest.IO {
+	r := []byte{cfg, byte(temp), byte(temp >> 8), byte(volt), byte(volt >> 8), byte(cur), byte(cur >> 8), 0}
+	r = append(r, onewire.CalcCRC(r))
+	return []onewiretest.IO{
+		{W: matchROM(0xb8, 0x00)},
+		{W: matchROM(0xbe, 0x00), R: r},
+	}
+}
+
+func writeConfig(cfg byte) []onewiretest.IO {
+	return []onewiretest.IO{
+		{W: matchROM(0x4e, 0x00, cfg)},
+		{W: matchROM(0x48, 0x00)},
+	}
+}
+
+func TestSense(t *testing.T) {
+	var ops []onewiretest.IO
+	// New.
+	ops = append(ops, readPage0(0x08, 0, 0, 0)...)
+	ops = append(ops, writeConfig(0x09)...)
+	// Sense.
+	ops = append(ops, onewiretest.IO{W: matchROM(0x44)})
+	ops = append(ops, writeConfig(0x09)...)
+	ops = append(ops, onewiretest.IO{W: matchROM(0xb4)})
+	ops = append(ops, readPage0(0x09, 0, 500, 0)...)
+	ops = append(ops, writeConfig(0x01)...)
+	ops = append(ops, onewiretest.IO{W: matchROM(0xb4)})
+	ops = append(ops, readPage0(0x01, 25*256+128, 123, -41)...)
+	bus := &onewiretest.Playback{Ops: ops}
+
+	var sleeps []time.Duration
+	sleep = func(d time.Duration) { sleeps = append(sleeps, d) }
+	defer func() { sleep = time.Sleep }()
+
+	d, err := New(bus, addr, &Opts{SenseResistor: 25 * physic.MilliOhm})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := d.String(); s != "DS2438{playback(0x7b00000012345626)}" {
+		t.Fatal(s)
+	}
+	m, err := d.Sense()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Measurement{
+		Temperature: 25500*physic.MilliKelvin + physic.ZeroCelsius,
+		VDD:         5 * physic.Volt,
+		VAD:         1230 * physic.MilliVolt,
+		Current:     -400390600 * physic.NanoAmpere,
+	}
+	if m != expected {
+		t.Fatal(m)
+	}
+	if s := m.String(); s != "25.500°C, VDD: 5V, VAD: 1.230V, Current: -400.391mA" {
+		t.Fatal(s)
+	}
+	if !reflect.DeepEqual(sleeps, []time.Duration{10 * time.Millisecond, 10 * time.Millisecond, 10 * time.Millisecond}) {
+		t.Fatal(sleeps)
+	}
+	if err := d.Halt(); err != nil {
+		t.Fatal(err)
+	}
+	if err := bus.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNew_keep_config(t *testing.T) {
+	sleep = func(time.Duration) {}
+	defer func() { sleep = time.Sleep }()
+	// The current accumulator settings and the busy flags are left alone.
+	var ops []onewiretest.IO
+	ops = append(ops, readPage0(0x77, 0, 0, 0)...)
+	ops = append(ops, onewiretest.IO{W: matchROM(0x44)})
+	ops = append(ops, writeConfig(0x0f)...)
+	bus := &onewiretest.Playback{Ops: ops, DontPanic: true}
+	d, err := New(bus, addr, &Opts{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Stop after the first configuration write.
+	if _, err := d.Sense(); err == nil {
+		t.Fatal("expected playback error")
+	}
+	if err := bus.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// IAD is already set, nothing to write.
+	bus = &onewiretest.Playback{Ops: readPage0(0x01, 0, 0, 0)}
+	if _, err := New(bus, addr, &Opts{SenseResistor: physic.Ohm}); err != nil {
+		t.Fatal(err)
+	}
+	if err := bus.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSense_no_current(t *testing.T) {
+	var ops []onewiretest.IO
+	// New; the configuration is already correct.
+	ops = append(ops, readPage0(0x00, 0, 0, 0)...)
+	// Sense.
+	ops = append(ops, onewiretest.IO{W: matchROM(0x44)})
+	ops = append(ops, writeConfig(0x08)...)
+	ops = append(ops, onewiretest.IO{W: matchROM(0xb4)})
+	ops = append(ops, readPage0(0x08, 0, 330, 0)...)
+	ops = append(ops, writeConfig(0x00)...)
+	ops = append(ops, onewiretest.IO{W: matchROM(0xb4)})
+	ops = append(ops, readPage0(0x00, -10*256-64, 1023, 12)...)
+	bus := &onewiretest.Playback{Ops: ops}
+	sleep = func(time.Duration) {}
+	defer func() { sleep = time.Sleep }()
+
+	d, err := New(bus, addr, &Opts{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := d.Sense()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Measurement{
+		Temperature: -10250*physic.MilliKelvin + physic.ZeroCelsius,
+		VDD:         3300 * physic.MilliVolt,
+		VAD:         10230 * physic.MilliVolt,
+	}
+	if m != expected {
+		t.Fatal(m)
+	}
+	if err := bus.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNew_err(t *testing.T) {
+	if _, err := New(&onewiretest.Playback{}, 0x7b00000012345628, &Opts{}); err == nil {
+		t.Fatal("invalid family code")
+	}
+	if _, err := New(&onewiretest.Playback{}, addr, &Opts{SenseResistor: -1}); err == nil {
+		t.Fatal("invalid sense resistor")
+	}
+	ops := readPage0(0x00, 0, 0, 0)
+	ops[1].R[8]++
+	bus := &onewiretest.Playback{Ops: ops}
+	if _, err := New(bus, addr, &Opts{}); err == nil {
+		t.Fatal("invalid CRC")
+	} else if _, ok := err.(onewire.BusError); !ok {
+		t.Fatal("expected a BusError")
+	}
+}
@@ -22,8 +22,10 @@ const lineTwo = 0x40
 
 // Dev is the 4-bit addressing device for HD-44780
 type Dev struct {
-	// data pins
+	// data pins, written one at a time; nil when data is used
 	dataPins []gpio.PinOut
+	// data pins, written as a single operation
+	data gpio.Group
 
 	// register select pin
 	rsPin gpio.PinOut
@@ -51,6 +53,28 @@ func New(data []gpio.PinOut, rs, e gpio.PinOut) (*Dev, error) {
 	return dev, nil
 }
 
+// NewGroup creates and initializes the LCD device
+//	data - group of the data pins DB4 to DB7, in this order
+//	rs - rs pin
+//	e - strobe pin
+//
+// The data pins are written as a single operation, so they change at the same
+// time when the GPIO driver supports it.
+func NewGroup(data gpio.Group, rs, e gpio.PinOut) (*Dev, error) {
+	if n := len(data.Pins()); n != 4 {
+		return nil, fmt.Errorf("expected 4 data pins, passed %d", n)
+	}
+	dev := &Dev{
+		data:      data,
+		enablePin: e,
+		rsPin:     rs,
+	}
+	if err := dev.Reset(); err != nil {
+		return nil, err
+	}
+	return dev, nil
+}
+
 // Reset resets the HC-44780 chipset, clears the screen buffer and moves cursor to the
 // home of screen (line 0, column 0).
 func (r *Dev) Reset() error {
@@ -153,15 +177,20 @@ func (r *Dev) bulkSendData(seq [][]uint, f func(_data uint8) error) error {
 }
 
 func (r *Dev) clearBits() error {
-	for _, v := range r.dataPins {
-		if err := v.Out(gpio.Low); err != nil {
-			return err
-		}
-	}
-	return nil
+	return r.writeData(0)
 }
 
 func (r *Dev) write4Bits(data uint8) error {
+	if err := r.writeData(data); err != nil {
+		return err
+	}
+	return r.strobe()
+}
+
+func (r *Dev) writeData(data uint8) error {
+	if r.data != nil {
+		return r.data.Out(uint64(data), 0xF)
+	}
 	for i, v := range r.dataPins {
 		if data&(1<<uint(i)) > 0 {
 			if err := v.Out(gpio.High); err != nil {
@@ -173,7 +202,7 @@ func (r *Dev) write4Bits(data uint8) error {
 			}
 		}
 	}
-	return r.strobe()
+	return nil
 }
 
 func (r *Dev) sendInstruction() error {
@@ -137,6 +137,54 @@ func TestSense(t *testing.T) {
 	}
 }
 
+func TestSense_regMap(t *testing.T) {
+	// Simulate the device instead of replaying exact transactions.
+	m := &i2ctest.RegMap{
+		Regs: map[byte]*i2ctest.Reg{
+			configuration:    {Width: 2, Value: 0x0100},
+			temperature:      {Width: 2, ReadOnly: 0xffff, Value: 0x0190},
+			resolutionConfig: {ReadOnly: 0xfc},
+		},
+	}
+	bus := i2ctest.Sim{}
+	if _, err := bus.Listen(0x18, m); err != nil {
+		t.Fatal(err)
+	}
+	d, err := New(&bus, &DefaultOpts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := m.Get(resolutionConfig); v != 0x03 {
+		t.Fatalf("resolution 0x%x", v)
+	}
+	if v := m.Get(configuration); v != 0 {
+		t.Fatalf("configuration 0x%x", v)
+	}
+	e := physic.Env{}
+	if err := d.Sense(&e); err != nil {
+		t.Fatal(err)
+	}
+	if want := physic.ZeroCelsius + 25*physic.Kelvin; e.Temperature != want {
+		t.Fatalf("%s != %s", e.Temperature, want)
+	}
+	m.Set(temperature, 0x1ffc)
+	if err := d.Sense(&e); err != nil {
+		t.Fatal(err)
+	}
+	if want := physic.ZeroCelsius - 250*physic.MilliKelvin; e.Temperature != want {
+		t.Fatalf("%s != %s", e.Temperature, want)
+	}
+	if err := d.Halt(); err != nil {
+		t.Fatal(err)
+	}
+	if v := m.Get(configuration); v != 0x0100 {
+		t.Fatalf("configuration 0x%x", v)
+	}
+	if err := m.Err(); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestSenseContinuous(t *testing.T) {
 	tests := []struct {
 		name     string
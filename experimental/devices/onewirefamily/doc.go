@@ -0,0 +1,12 @@
+// Copyright 2019 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+// Package onewirefamily instantiates the driver matching the family code of
+// the devices found on a 1-wire bus.
+//
+// The family code is the lowest byte of a device address. The drivers in
+// periph for the DS1990A, DS18B20, DS2408, DS2413, DS2431, DS2438 and
+// DS28EC20 are registered by default; other drivers can be added with
+// Register().
+package onewirefamily
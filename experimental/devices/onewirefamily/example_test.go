@@ -0,0 +1,52 @@
+// Copyright 2019 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package onewirefamily_test
+
+import (
+	"fmt"
+	"log"
+
+	"periph.io/x/periph/conn/onewire/onewirereg"
+	"periph.io/x/periph/conn/physic"
+	"periph.io/x/periph/experimental/devices/ds2413"
+	"periph.io/x/periph/experimental/devices/onewirefamily"
+	"periph.io/x/periph/host"
+)
+
+func ExampleScan() {
+	// Make sure periph is initialized.
+	if _, err := host.Init(); err != nil {
+		log.Fatal(err)
+	}
+
+	// Use onewirereg 1-wire bus registry to find the first available 1-wire
+	// bus.
+	b, err := onewirereg.Open("")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer b.Close()
+
+	devs, err := onewirefamily.Scan(b)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, d := range devs {
+		switch dev := d.Dev.(type) {
+		case physic.SenseEnv:
+			e := physic.Env{}
+			if err := dev.Sense(&e); err != nil {
+				log.Fatal(err)
+			}
+			fmt.Printf("%s: %s\n", dev, e.Temperature)
+		case *ds2413.Dev:
+			fmt.Printf("%s: %s %s\n", dev, dev.Pins[0].Read(), dev.Pins[1].Read())
+		case nil:
+			fmt.Printf("%#016x: %s %v\n", uint64(d.Addr), d.Name, d.Err)
+		default:
+			fmt.Printf("%s\n", dev)
+		}
+	}
+}
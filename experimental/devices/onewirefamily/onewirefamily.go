@@ -0,0 +1,163 @@
+// Copyright 2019 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package onewirefamily
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+
+	"periph.io/x/periph/conn"
+	"periph.io/x/periph/conn/onewire"
+	"periph.io/x/periph/devices/ds18b20"
+	"periph.io/x/periph/experimental/devices/ds1990"
+	"periph.io/x/periph/experimental/devices/ds2408"
+	"periph.io/x/periph/experimental/devices/ds2413"
+	"periph.io/x/periph/experimental/devices/ds2431"
+	"periph.io/x/periph/experimental/devices/ds2438"
+)
+
+// Opener returns a handle to the device with the specified address.
+//
+// It is provided by the device driver.
+type Opener func(b onewire.Bus, addr onewire.Address) (conn.Resource, error)
+
+// Device is a device found by Scan().
+type Device struct {
+	Addr onewire.Address
+	// Name is the name the driver was registered with; it is empty when no
+	// driver is registered for the family code.
+	Name string
+	// Dev is the handle returned by the driver, or nil if no driver is
+	// registered for the family code or if Err is set.
+	Dev conn.Resource
+	// Err is the error returned by the driver.
+	Err error
+}
+
+// Scan searches the bus and opens the driver for each device found.
+//
+// The devices are returned in the order found by the search. It is not an
+// error for a device to have no registered driver, see Device.
+func Scan(b onewire.Bus) ([]Device, error) {
+	addrs, err := b.Search(false)
+	if err != nil {
+		if e, ok := err.(onewire.NoDevicesError); ok && e.NoDevices() {
+			return nil, nil
+		}
+		return nil, err
+	}
+	out := make([]Device, 0, len(addrs))
+	for _, a := range addrs {
+		d := Device{Addr: a}
+		if r := lookup(byte(a)); r != nil {
+			d.Name = r.name
+			d.Dev, d.Err = r.open(b, a)
+		}
+		out = append(out, d)
+	}
+	return out, nil
+}
+
+// Open returns a handle to the device with the specified address, using the
+// driver registered for its family code.
+func Open(b onewire.Bus, addr onewire.Address) (conn.Resource, error) {
+	r := lookup(byte(addr))
+	if r == nil {
+		return nil, errors.New("onewirefamily: no driver for family code 0x" + strconv.FormatUint(uint64(addr&0xFF), 16))
+	}
+	return r.open(b, addr)
+}
+
+// Name returns the name of the driver registered for the family code, or an
+// empty string if none.
+func Name(family byte) string {
+	if r := lookup(family); r != nil {
+		return r.name
+	}
+	return ""
+}
+
+// Register registers a driver for a family code.
+//
+// name is the device name, e.g. "DS18B20".
+func Register(family byte, name string, o Opener) error {
+	if len(name) == 0 {
+		return errors.New("onewirefamily: can't register a driver with no name")
+	}
+	if o == nil {
+		return errors.New("onewirefamily: can't register driver " + strconv.Quote(name) + " with nil Opener")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if r, ok := byFamily[family]; ok {
+		return errors.New("onewirefamily: can't register driver " + strconv.Quote(name) + "; family code 0x" + strconv.FormatUint(uint64(family), 16) + " is already registered by " + strconv.Quote(r.name))
+	}
+	byFamily[family] = &ref{name: name, o: o}
+	return nil
+}
+
+// Unregister removes the driver registered for a family code.
+//
+// It can be used to replace one of the drivers registered by default.
+func Unregister(family byte) error {
+	mu.Lock()
+	defer mu.Unlock()
+	if _, ok := byFamily[family]; !ok {
+		return errors.New("onewirefamily: can't unregister unknown family code 0x" + strconv.FormatUint(uint64(family), 16))
+	}
+	delete(byFamily, family)
+	return nil
+}
+
+//
+
+type ref struct {
+	name string
+	o    Opener
+}
+
+// open calls the Opener, making sure a nil conn.Resource is returned on
+// failure instead of a typed nil pointer.
+func (r *ref) open(b onewire.Bus, a onewire.Address) (conn.Resource, error) {
+	d, err := r.o(b, a)
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+var (
+	mu       sync.Mutex
+	byFamily = map[byte]*ref{
+		ds1990.Family: {"DS1990", func(b onewire.Bus, a onewire.Address) (conn.Resource, error) {
+			return ds1990.New(b, a)
+		}},
+		ds18b20.Family: {"DS18B20", func(b onewire.Bus, a onewire.Address) (conn.Resource, error) {
+			return ds18b20.Open(b, a)
+		}},
+		ds2408.Family: {"DS2408", func(b onewire.Bus, a onewire.Address) (conn.Resource, error) {
+			return ds2408.New(b, a)
+		}},
+		ds2413.Family: {"DS2413", func(b onewire.Bus, a onewire.Address) (conn.Resource, error) {
+			return ds2413.New(b, a)
+		}},
+		ds2431.FamilyDS2431:   {"DS2431", openDS2431},
+		ds2431.FamilyDS28EC20: {"DS28EC20", openDS2431},
+		ds2438.Family: {"DS2438", func(b onewire.Bus, a onewire.Address) (conn.Resource, error) {
+			return ds2438.New(b, a, &ds2438.Opts{})
+		}},
+	}
+)
+
+func openDS2431(b onewire.Bus, a onewire.Address) (conn.Resource, error) {
+	return ds2431.New(b, a)
+}
+
+func lookup(family byte) *ref {
+	mu.Lock()
+	defer mu.Unlock()
+	return byFamily[family]
+}
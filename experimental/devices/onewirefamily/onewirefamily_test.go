@@ -0,0 +1,143 @@
+// Copyright 2019 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package onewirefamily
+
+import (
+	"errors"
+	"testing"
+
+	"periph.io/x/periph/conn"
+	"periph.io/x/periph/conn/onewire"
+	"periph.io/x/periph/conn/onewire/onewiretest"
+	"periph.io/x/periph/experimental/devices/ds1990"
+)
+
+func TestScan(t *testing.T) {
+	if err := Register(0x7e, "Fake", openFake); err != nil {
+		t.Fatal(err)
+	}
+	defer Unregister(0x7e)
+
+	button := address(0x01, 0x123456)
+	fake := address(0x7e, 0x1)
+	unknown := address(0x05, 0x2)
+	broken := address(0x3a, 0x3)
+	bus := &onewiretest.Playback{
+		// One search command per device found. The DS2413 fails to initialize.
+		Ops:       []onewiretest.IO{{W: []byte{0xf0}}, {W: []byte{0xf0}}, {W: []byte{0xf0}}, {W: []byte{0xf0}}},
+		Devices:   []onewire.Address{button, fake, unknown, broken},
+		DontPanic: true,
+	}
+	devs, err := Scan(bus)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(devs) != 4 {
+		t.Fatal(devs)
+	}
+	for _, d := range devs {
+		switch d.Addr {
+		case button:
+			if _, ok := d.Dev.(*ds1990.Dev); !ok || d.Name != "DS1990" || d.Err != nil {
+				t.Fatal(d)
+			}
+		case fake:
+			if f, ok := d.Dev.(*fakeDev); !ok || f.addr != fake || d.Name != "Fake" || d.Err != nil {
+				t.Fatal(d)
+			}
+		case unknown:
+			if d.Dev != nil || d.Name != "" || d.Err != nil {
+				t.Fatal(d)
+			}
+		case broken:
+			if d.Dev != nil || d.Name != "DS2413" || d.Err == nil {
+				t.Fatal(d)
+			}
+		default:
+			t.Fatal(d)
+		}
+	}
+}
+
+func TestScan_err(t *testing.T) {
+	if devs, err := Scan(&searchBus{err: noDevicesError("none")}); devs != nil || err != nil {
+		t.Fatal(devs, err)
+	}
+	if _, err := Scan(&searchBus{err: errors.New("failed")}); err == nil {
+		t.Fatal("expected failure")
+	}
+}
+
+func TestOpen(t *testing.T) {
+	d, err := Open(&onewiretest.Playback{}, address(0x01, 0x123456))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := d.(*ds1990.Dev); !ok {
+		t.Fatal(d)
+	}
+	if _, err := Open(&onewiretest.Playback{}, address(0x05, 0x2)); err == nil {
+		t.Fatal("unknown family code")
+	}
+	if d, err := Open(&onewiretest.Playback{DontPanic: true}, address(0x3a, 0x3)); d != nil || err == nil {
+		t.Fatal(d, err)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	if s := Name(0x28); s != "DS18B20" {
+		t.Fatal(s)
+	}
+	if s := Name(0x05); s != "" {
+		t.Fatal(s)
+	}
+	if err := Register(0x28, "Other", openFake); err == nil {
+		t.Fatal("already registered")
+	}
+	if err := Register(0x7e, "", openFake); err == nil {
+		t.Fatal("no name")
+	}
+	if err := Register(0x7e, "Fake", nil); err == nil {
+		t.Fatal("no Opener")
+	}
+	if err := Unregister(0x7e); err == nil {
+		t.Fatal("not registered")
+	}
+}
+
+//
+
+type fakeDev struct {
+	conn.Resource
+	addr onewire.Address
+}
+
+func openFake(b onewire.Bus, a onewire.Address) (conn.Resource, error) {
+	return &fakeDev{addr: a}, nil
+}
+
+type searchBus struct {
+	onewiretest.Playback
+	err error
+}
+
+func (s *searchBus) Search(alarmOnly bool) ([]onewire.Address, error) {
+	return nil, s.err
+}
+
+type noDevicesError string
+
+func (e noDevicesError) Error() string   { return string(e) }
+func (e noDevicesError) NoDevices() bool { return true }
+
+// address returns the address with a valid CRC.
+func address(family byte, serial uint64) onewire.Address {
+	a := onewire.Address(serial<<8 | uint64(family))
+	var b [7]byte
+	for i := range b {
+		b[i] = byte(a >> uint(8*i))
+	}
+	return a | onewire.Address(onewire.CalcCRC(b[:]))<<56
+}
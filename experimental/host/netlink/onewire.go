@@ -25,6 +25,10 @@ import (
 // write operations. Hence this driver does not support this feature either. The
 // pull-up argument passed to Tx() is ignored. Devices may need to be powered
 // externally to work with this driver.
+//
+// NOTE: the Linux 1-wire netlink API does not support changing the bus speed.
+// Hence OneWire does not implement onewire.BusOverdriver. onewire.ResumeBus
+// and onewire.SkipROM work since they only change the bytes written.
 func New(masterID uint32) (*OneWire, error) {
 	if isLinux {
 		return newOneWire(masterID)
@@ -11,52 +11,88 @@ package serial
 import (
 	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
+	"strings"
 	"sync"
 	"syscall"
+	"time"
 
 	"periph.io/x/periph"
 	"periph.io/x/periph/conn"
 	"periph.io/x/periph/conn/gpio"
 	"periph.io/x/periph/conn/gpio/gpioreg"
 	"periph.io/x/periph/conn/physic"
-	"periph.io/x/periph/experimental/conn/uart"
+	"periph.io/x/periph/conn/uart"
+	"periph.io/x/periph/conn/uart/uartreg"
 )
 
-// Enumerate returns the available serial buses as exposed by the OS.
+// Enumerate returns the names of the serial ports exposed by the OS, like
+// "ttyAMA0", "ttyS0", "ttyUSB0" or "ttyACM0".
 //
-// TODO(maruel): Port number are likely not useful, we need port names.
-func Enumerate() ([]int, error) {
-	var out []int
-	if !isWindows {
-		// Do not use "/sys/class/tty/ttyS0/" as these are all owned by root.
-		prefix := "/dev/ttyS"
-		items, err := filepath.Glob(prefix + "*")
+// The ports are returned in this order: ports provided by the CPU first, then
+// USB serial adapters. 8250/16550 ports without hardware behind them are
+// skipped.
+func Enumerate() ([]string, error) {
+	var out []string
+	if isWindows {
+		return out, nil
+	}
+	for _, prefix := range devPrefixes {
+		items, err := filepath.Glob("/dev/" + prefix + "*")
 		if err != nil {
 			return nil, err
 		}
-		out = make([]int, 0, len(items))
+		var numbers []int
 		for _, item := range items {
-			i, err := strconv.Atoi(item[len(prefix):])
+			i, err := strconv.Atoi(item[len("/dev/"+prefix):])
 			if err != nil {
 				continue
 			}
-			out = append(out, i)
+			numbers = append(numbers, i)
+		}
+		sort.Ints(numbers)
+		for _, i := range numbers {
+			name := prefix + strconv.Itoa(i)
+			if prefix == "ttyS" && !hasUART(name) {
+				continue
+			}
+			out = append(out, name)
 		}
 	}
 	return out, nil
 }
 
-func newPortDevFs(portNumber int) (*Port, error) {
-	// Use the devfs path for now.
-	name := fmt.Sprintf("ttyS%d", portNumber)
-	f, err := os.OpenFile("/dev/"+name, os.O_RDWR|syscall.O_NOCTTY, os.ModeExclusive)
+// Open opens a serial port by its name, like "ttyS0", or by its path, like
+// "/dev/ttyUSB0".
+//
+// Using a path is useful for ports not returned by Enumerate(), like pseudo
+// terminals.
+//
+// On Linux, the port is opened in exclusive mode: other processes fail to
+// open it with EBUSY until it is closed, unless they run as root.
+func Open(name string) (*Port, error) {
+	path := name
+	if !strings.HasPrefix(path, "/") {
+		path = "/dev/" + name
+	}
+	name = filepath.Base(path)
+	f, err := os.OpenFile(path, os.O_RDWR|syscall.O_NOCTTY, 0)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("sysfs-uart: %v", err)
+	}
+	// Fd() puts the file descriptor in blocking mode, so the read timeout is
+	// handled by the terminal driver.
+	_ = f.Fd()
+	p := &Port{serialConn{name: name, f: f, portNumber: portNumber(name)}}
+	if err := p.conn.lockExclusive(); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("sysfs-uart: %v", err)
 	}
-	p := &Port{serialConn{name: name, f: f, portNumber: portNumber}}
 	return p, nil
 }
 
@@ -67,6 +103,11 @@ type Port struct {
 
 // Close implements uart.PortCloser.
 func (p *Port) Close() error {
+	p.conn.mu.Lock()
+	defer p.conn.mu.Unlock()
+	if p.conn.f == nil {
+		return errors.New("sysfs-uart: already closed")
+	}
 	err := p.conn.f.Close()
 	p.conn.f = nil
 	return err
@@ -89,14 +130,23 @@ func (p *Port) Connect(f physic.Frequency, stopBit uart.Stop, parity uart.Parity
 		return nil, fmt.Errorf("sysfs-uart: invalid bits %d; must be between 5 and 8", bits)
 	}
 
-	// Find the closest value in acceptedBauds.
-	baud := uint32(f / physic.Hertz)
-	//var op uint32
-	for _, line := range acceptedBauds {
-		if line[0] > baud {
-			break
+	switch stopBit {
+	case uart.One, uart.Two:
+	case uart.OneHalf:
+		if bits != 5 {
+			return nil, errors.New("sysfs-uart: 1.5 stop bits is only supported with 5 bits")
 		}
-		//op = line[1]
+	default:
+		return nil, fmt.Errorf("sysfs-uart: invalid stop bit %d", stopBit)
+	}
+	switch parity {
+	case uart.NoParity, uart.Odd, uart.Even, uart.Mark, uart.Space:
+	default:
+		return nil, fmt.Errorf("sysfs-uart: invalid parity %q", byte(parity))
+	}
+	// The lower 16 bits of XOnXOff flow are the xon and xoff characters.
+	if flow != uart.NoFlow && flow != uart.RTSCTS && flow&^0xFFFF != uart.XOnXOff {
+		return nil, fmt.Errorf("sysfs-uart: invalid flow %s", flow)
 	}
 
 	p.conn.mu.Lock()
@@ -107,6 +157,22 @@ func (p *Port) Connect(f physic.Frequency, stopBit uart.Stop, parity uart.Parity
 	if p.conn.connected {
 		return nil, errors.New("sysfs-uart: already connected")
 	}
+	if p.conn.freqPort != 0 && p.conn.freqPort < f {
+		f = p.conn.freqPort
+	}
+	cfg := config{
+		baud:    uint32((f + physic.Hertz/2) / physic.Hertz),
+		stop:    stopBit,
+		parity:  parity,
+		flow:    flow,
+		bits:    uint8(bits),
+		timeout: p.conn.timeout,
+	}
+	if err := p.conn.configure(&cfg); err != nil {
+		return nil, fmt.Errorf("sysfs-uart: %v", err)
+	}
+	p.conn.cfg = cfg
+	p.conn.connected = true
 	p.conn.freqConn = f
 	p.conn.bitsPerWord = uint8(bits)
 	if flow != uart.RTSCTS {
@@ -115,9 +181,6 @@ func (p *Port) Connect(f physic.Frequency, stopBit uart.Stop, parity uart.Parity
 		p.conn.cts = gpio.INVALID
 		p.conn.muPins.Unlock()
 	}
-
-	// TODO(maruel): ioctl with flags and op.
-
 	return &p.conn, nil
 }
 
@@ -135,6 +198,38 @@ func (p *Port) LimitSpeed(f physic.Frequency) error {
 	return nil
 }
 
+// SetReadTimeout sets the maximum duration a read waits for data.
+//
+// When no data is received within d, Read() returns 0 bytes and ErrTimeout.
+// Use 0 to block until at least one byte is received, which is the default.
+//
+// The terminal driver has a resolution of 100ms and a maximum of 25.5s. d is
+// rounded up to the next 100ms.
+func (p *Port) SetReadTimeout(d time.Duration) error {
+	if d < 0 || d > maxReadTimeout {
+		return fmt.Errorf("sysfs-uart: invalid read timeout %s; must be between 0 and %s", d, maxReadTimeout)
+	}
+	p.conn.mu.Lock()
+	defer p.conn.mu.Unlock()
+	p.conn.timeout = d
+	if p.conn.connected {
+		if p.conn.f == nil {
+			return errors.New("sysfs-uart: already closed")
+		}
+		cfg := p.conn.cfg
+		cfg.timeout = d
+		if err := p.conn.configure(&cfg); err != nil {
+			return fmt.Errorf("sysfs-uart: %v", err)
+		}
+		p.conn.cfg = cfg
+	}
+	return nil
+}
+
+// ErrTimeout is returned by Read() and Tx() when no data was received within
+// the timeout specified with SetReadTimeout().
+var ErrTimeout = errors.New("sysfs-uart: read timeout")
+
 // RX implements uart.Pins.
 func (p *Port) RX() gpio.PinIn {
 	return p.conn.RX()
@@ -158,14 +253,16 @@ func (p *Port) CTS() gpio.PinIn {
 type serialConn struct {
 	// Immutable
 	name       string
-	f          *os.File
 	portNumber int
 
 	mu          sync.Mutex
+	f           *os.File         // nil once closed
 	freqPort    physic.Frequency // Frequency specified at LimitSpeed()
 	freqConn    physic.Frequency // Frequency specified at Connect()
 	bitsPerWord uint8
 	connected   bool
+	cfg         config        // Configuration applied at Connect()
+	timeout     time.Duration // Read timeout; 0 means blocking
 
 	// Use a separate lock for the pins, so that they can be queried while a
 	// transaction is happening.
@@ -187,29 +284,65 @@ func (s *serialConn) Duplex() conn.Duplex {
 }
 
 // Read implements io.Reader.
+//
+// It returns as soon as at least one byte is available.
 func (s *serialConn) Read(b []byte) (int, error) {
-	return s.f.Read(b)
+	f, err := s.file()
+	if err != nil {
+		return 0, err
+	}
+	n, err := f.Read(b)
+	if n == 0 && err == io.EOF && len(b) != 0 && s.hasTimeout() {
+		// The terminal driver returns 0 bytes when the read timeout expires.
+		err = ErrTimeout
+	}
+	return n, err
 }
 
 // Write implements io.Writer.
 func (s *serialConn) Write(b []byte) (int, error) {
-	return s.f.Write(b)
+	f, err := s.file()
+	if err != nil {
+		return 0, err
+	}
+	return f.Write(b)
 }
 
 // Tx implements conn.Conn.
 func (s *serialConn) Tx(w, r []byte) error {
 	if len(w) != 0 {
-		if _, err := s.f.Write(w); err != nil {
+		if _, err := s.Write(w); err != nil {
 			return err
 		}
 	}
-	if len(r) != 0 {
-		_, err := s.f.Read(r)
-		return err
+	for len(r) != 0 {
+		n, err := s.Read(r)
+		if err != nil {
+			return err
+		}
+		r = r[n:]
 	}
 	return nil
 }
 
+// file returns the file to do I/O with.
+//
+// s.mu is not held during the I/O, so Close() can interrupt a blocking read.
+func (s *serialConn) file() (*os.File, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.f == nil {
+		return nil, errors.New("sysfs-uart: already closed")
+	}
+	return s.f, nil
+}
+
+func (s *serialConn) hasTimeout() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cfg.timeout != 0
+}
+
 // RX implements uart.Pins.
 func (s *serialConn) RX() gpio.PinIn {
 	s.initPins()
@@ -259,6 +392,48 @@ func (s *serialConn) initPins() {
 
 //
 
+// devPrefixes is the device names to look for, in order.
+var devPrefixes = []string{"ttyAMA", "ttyS", "ttyUSB", "ttyACM"}
+
+// maxReadTimeout is the longest VTIME supported by termios.
+const maxReadTimeout = 25500 * time.Millisecond
+
+// config is the port configuration applied to the terminal driver.
+type config struct {
+	baud    uint32
+	stop    uart.Stop
+	parity  uart.Parity
+	flow    uart.Flow
+	bits    uint8
+	timeout time.Duration
+}
+
+// hasUART returns false if the ttyS port is a placeholder without hardware.
+//
+// The kernel creates a fixed number of ttyS devices, whether there is a UART
+// or not. The port type is readable by all users, contrary to the device.
+func hasUART(name string) bool {
+	b, err := ioutil.ReadFile("/sys/class/tty/" + name + "/type")
+	if err != nil {
+		// Can't tell, assume there's a UART.
+		return true
+	}
+	return strings.TrimSpace(string(b)) != "0"
+}
+
+// portNumber returns the number of the CPU UART for the device name or -1 for
+// ports not provided by the CPU, like USB serial adapters.
+func portNumber(name string) int {
+	for _, prefix := range []string{"ttyAMA", "ttyS"} {
+		if strings.HasPrefix(name, prefix) {
+			if i, err := strconv.Atoi(name[len(prefix):]); err == nil {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
 // driverSerial implements periph.Driver.
 type driverSerial struct {
 }
@@ -275,12 +450,39 @@ func (d *driverSerial) After() []string {
 	return nil
 }
 
+// Init registers the serial ports in uartreg.
+//
+// The ports provided by the CPU are registered with their number, so
+// uartreg.Open("0") works. When both ttyAMA0 and ttyS0 exist, ttyAMA0 gets the
+// number.
 func (d *driverSerial) Init() (bool, error) {
+	names, err := Enumerate()
+	if err != nil {
+		return true, err
+	}
+	if len(names) == 0 {
+		return false, errors.New("no serial port found")
+	}
+	used := map[int]bool{}
+	for _, name := range names {
+		number := portNumber(name)
+		if used[number] {
+			number = -1
+		} else if number != -1 {
+			used[number] = true
+		}
+		n := name
+		if err := uartreg.Register(name, []string{"/dev/" + name}, number, func() (uart.PortCloser, error) { return Open(n) }); err != nil {
+			return true, err
+		}
+	}
 	return true, nil
 }
 
 func init() {
-	periph.MustRegister(&drv)
+	if !isWindows {
+		periph.MustRegister(&drv)
+	}
 }
 
 var drv driverSerial
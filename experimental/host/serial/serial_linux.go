@@ -0,0 +1,145 @@
+// Copyright 2019 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package serial
+
+import (
+	"errors"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"periph.io/x/periph/conn/uart"
+	"periph.io/x/periph/host/fs"
+)
+
+// configure applies the configuration to the terminal.
+//
+// It uses termios2, which supports arbitrary baud rates via BOTHER. The
+// terminal is put in raw mode.
+//
+// mu must be held.
+func (s *serialConn) configure(c *config) error {
+	var t termios2
+	if err := s.ioctl(tcGets2, &t); err != nil {
+		return err
+	}
+	if err := c.toTermios(&t); err != nil {
+		return err
+	}
+	return s.ioctl(tcSets2, &t)
+}
+
+// lockExclusive puts the terminal in exclusive mode with TIOCEXCL, so further
+// open() calls fail, except for root.
+func (s *serialConn) lockExclusive() error {
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, s.f.Fd(), syscall.TIOCEXCL, 0); errno != 0 {
+		return syscall.Errno(errno)
+	}
+	return nil
+}
+
+func (s *serialConn) ioctl(op uint, t *termios2) error {
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, s.f.Fd(), uintptr(op), uintptr(unsafe.Pointer(t))); errno != 0 {
+		return syscall.Errno(errno)
+	}
+	return nil
+}
+
+// toTermios updates t with the configuration.
+func (c *config) toTermios(t *termios2) error {
+	// Raw mode, as done by cfmakeraw().
+	t.iflag &^= syscall.IGNBRK | syscall.BRKINT | syscall.PARMRK | syscall.ISTRIP | syscall.INLCR | syscall.IGNCR | syscall.ICRNL | syscall.IXON | syscall.IXOFF | syscall.IXANY | syscall.INPCK | syscall.IGNPAR
+	t.oflag &^= syscall.OPOST
+	t.lflag &^= syscall.ECHO | syscall.ECHONL | syscall.ICANON | syscall.ISIG | syscall.IEXTEN
+	t.cflag &^= syscall.CSIZE | syscall.PARENB | syscall.PARODD | cmspar | syscall.CSTOPB | crtscts | cbaud
+	t.cflag |= syscall.CREAD | syscall.CLOCAL
+
+	// Speed.
+	t.cflag |= bother
+	t.ispeed = c.baud
+	t.ospeed = c.baud
+
+	// Data bits.
+	switch c.bits {
+	case 5:
+		t.cflag |= syscall.CS5
+	case 6:
+		t.cflag |= syscall.CS6
+	case 7:
+		t.cflag |= syscall.CS7
+	case 8:
+		t.cflag |= syscall.CS8
+	default:
+		return errors.New("invalid bits")
+	}
+
+	// Stop bits. With 5 bits, CSTOPB means 1.5 stop bits.
+	if c.stop != uart.One {
+		t.cflag |= syscall.CSTOPB
+	}
+
+	// Parity. Parity errors are not reported in-band; the byte is read as-is.
+	switch c.parity {
+	case uart.NoParity:
+	case uart.Odd:
+		t.cflag |= syscall.PARENB | syscall.PARODD
+	case uart.Even:
+		t.cflag |= syscall.PARENB
+	case uart.Mark:
+		t.cflag |= syscall.PARENB | syscall.PARODD | cmspar
+	case uart.Space:
+		t.cflag |= syscall.PARENB | cmspar
+	}
+
+	// Flow control.
+	switch {
+	case c.flow == uart.RTSCTS:
+		t.cflag |= crtscts
+	case c.flow&^0xFFFF == uart.XOnXOff:
+		t.iflag |= syscall.IXON | syscall.IXOFF
+		xon, xoff := byte(c.flow>>8), byte(c.flow)
+		if xon == 0 && xoff == 0 {
+			// Use the usual DC1 and DC3.
+			xon, xoff = 0x11, 0x13
+		}
+		t.cc[syscall.VSTART] = xon
+		t.cc[syscall.VSTOP] = xoff
+	}
+
+	// Read timeout.
+	if c.timeout == 0 {
+		t.cc[syscall.VMIN] = 1
+		t.cc[syscall.VTIME] = 0
+	} else {
+		t.cc[syscall.VMIN] = 0
+		t.cc[syscall.VTIME] = uint8((c.timeout + 100*time.Millisecond - 1) / (100 * time.Millisecond))
+	}
+	return nil
+}
+
+// termios2 is struct termios2 as defined in asm-generic/termbits.h.
+type termios2 struct {
+	iflag  uint32
+	oflag  uint32
+	cflag  uint32
+	lflag  uint32
+	line   uint8
+	cc     [19]uint8
+	ispeed uint32
+	ospeed uint32
+}
+
+// Flags in asm-generic/termbits.h not defined in package syscall.
+const (
+	cbaud   = 0x100F     // CBAUD
+	bother  = 0x1000     // BOTHER; use ispeed and ospeed as the baud rate
+	cmspar  = 0x40000000 // CMSPAR; mark or space parity
+	crtscts = 0x80000000 // CRTSCTS
+)
+
+var (
+	tcGets2 = fs.IOR('T', 0x2A, uint(unsafe.Sizeof(termios2{}))) // TCGETS2
+	tcSets2 = fs.IOW('T', 0x2B, uint(unsafe.Sizeof(termios2{}))) // TCSETS2
+)
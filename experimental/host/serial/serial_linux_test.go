@@ -0,0 +1,240 @@
+// Copyright 2019 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package serial
+
+import (
+	"io"
+	"os"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+	"unsafe"
+
+	"periph.io/x/periph/conn/physic"
+	"periph.io/x/periph/conn/uart"
+)
+
+func TestPort_pty(t *testing.T) {
+	master, p := openPTY(t)
+	defer master.Close()
+	defer p.Close()
+	if err := p.LimitSpeed(57600 * physic.Hertz); err != nil {
+		t.Fatal(err)
+	}
+	c, err := p.Connect(115200*physic.Hertz, uart.Two, uart.Even, uart.RTSCTS, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tio termios2
+	if err := p.conn.ioctl(tcGets2, &tio); err != nil {
+		t.Fatal(err)
+	}
+	if tio.cflag&cbaud != bother || tio.ospeed != 57600 || tio.ispeed != 57600 {
+		t.Fatalf("unexpected speed: %#x %d %d", tio.cflag&cbaud, tio.ispeed, tio.ospeed)
+	}
+	// Pseudo terminals always use 8 bits without parity, the data bits, stop bits
+	// and parity are verified in TestConfig_toTermios.
+	if tio.lflag&syscall.ICANON != 0 {
+		t.Fatal("expected raw mode")
+	}
+	if _, err := p.Connect(115200*physic.Hertz, uart.One, uart.NoParity, uart.NoFlow, 8); err == nil {
+		t.Fatal("already connected")
+	}
+
+	// Port to master.
+	if err := c.Tx([]byte("hello"), nil); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 5)
+	if n, err := master.Read(buf); err != nil || string(buf[:n]) != "hello" {
+		t.Fatal(n, err, string(buf[:n]))
+	}
+
+	// Master to port; Tx() waits for all the bytes.
+	go func() {
+		_, _ = master.Write([]byte("wo"))
+		time.Sleep(10 * time.Millisecond)
+		_, _ = master.Write([]byte("rld"))
+	}()
+	if err := c.Tx(nil, buf); err != nil || string(buf) != "world" {
+		t.Fatal(err, string(buf))
+	}
+
+	// Read timeout.
+	if err := p.SetReadTimeout(150 * time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.conn.ioctl(tcGets2, &tio); err != nil {
+		t.Fatal(err)
+	}
+	if tio.cc[syscall.VMIN] != 0 || tio.cc[syscall.VTIME] != 2 {
+		t.Fatal(tio.cc[syscall.VMIN], tio.cc[syscall.VTIME])
+	}
+	start := time.Now()
+	if n, err := c.(*serialConn).Read(buf); n != 0 || err != ErrTimeout {
+		t.Fatal(n, err)
+	}
+	if d := time.Since(start); d < 100*time.Millisecond {
+		t.Fatal(d)
+	}
+	if err := p.SetReadTimeout(time.Minute); err == nil {
+		t.Fatal("timeout too long")
+	}
+}
+
+func TestOpen_exclusive(t *testing.T) {
+	master, p := openPTY(t)
+	defer master.Close()
+	defer p.Close()
+	// TIOCGEXCL is _IOR('T', 0x40, int); it is missing from package syscall.
+	const tiocgexcl = 0x80045440
+	var excl int32
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, p.conn.f.Fd(), tiocgexcl, uintptr(unsafe.Pointer(&excl))); errno != 0 {
+		t.Skip(errno)
+	}
+	if excl == 0 {
+		t.Fatal("expected the terminal to be in exclusive mode")
+	}
+}
+
+func TestPort_XOnXOff(t *testing.T) {
+	master, p := openPTY(t)
+	defer master.Close()
+	defer p.Close()
+	if _, err := p.Connect(9600*physic.Hertz, uart.One, uart.Mark, uart.MakeXOnXOffFlow('a', 'b'), 8); err != nil {
+		t.Fatal(err)
+	}
+	var tio termios2
+	if err := p.conn.ioctl(tcGets2, &tio); err != nil {
+		t.Fatal(err)
+	}
+	if tio.iflag&(syscall.IXON|syscall.IXOFF) != syscall.IXON|syscall.IXOFF {
+		t.Fatal("expected XOn/XOff")
+	}
+	if tio.cc[syscall.VSTART] != 'a' || tio.cc[syscall.VSTOP] != 'b' {
+		t.Fatal(tio.cc[syscall.VSTART], tio.cc[syscall.VSTOP])
+	}
+	if tio.cflag&crtscts != 0 {
+		t.Fatal("unexpected RTS/CTS")
+	}
+}
+
+func TestConfig_toTermios(t *testing.T) {
+	data := []struct {
+		c     config
+		cflag uint32
+	}{
+		{config{bits: 8, stop: uart.One, parity: uart.NoParity}, syscall.CS8},
+		{config{bits: 7, stop: uart.Two, parity: uart.Even}, syscall.CS7 | syscall.CSTOPB | syscall.PARENB},
+		{config{bits: 6, stop: uart.One, parity: uart.Odd}, syscall.CS6 | syscall.PARENB | syscall.PARODD},
+		{config{bits: 5, stop: uart.OneHalf, parity: uart.Space}, syscall.CS5 | syscall.CSTOPB | syscall.PARENB | cmspar},
+		{config{bits: 8, stop: uart.One, parity: uart.Mark}, syscall.CS8 | syscall.PARENB | syscall.PARODD | cmspar},
+		{config{bits: 8, stop: uart.One, parity: uart.NoParity, flow: uart.RTSCTS}, syscall.CS8 | crtscts},
+	}
+	const mask = syscall.CSIZE | syscall.CSTOPB | syscall.PARENB | syscall.PARODD | cmspar | crtscts
+	for i, line := range data {
+		// Start with all the flags set to make sure they are cleared.
+		tio := termios2{cflag: 0xFFFFFFFF, lflag: 0xFFFFFFFF}
+		line.c.baud = 1234
+		if err := line.c.toTermios(&tio); err != nil {
+			t.Fatalf("#%d: %v", i, err)
+		}
+		if v := tio.cflag & mask; v != line.cflag {
+			t.Fatalf("#%d: cflag %#x != %#x", i, v, line.cflag)
+		}
+		if tio.cflag&cbaud != bother || tio.ispeed != 1234 || tio.ospeed != 1234 {
+			t.Fatalf("#%d: invalid speed", i)
+		}
+		if tio.lflag&(syscall.ICANON|syscall.ECHO) != 0 {
+			t.Fatalf("#%d: expected raw mode", i)
+		}
+	}
+	c := config{bits: 9}
+	if c.toTermios(&termios2{}) == nil {
+		t.Fatal("invalid bits")
+	}
+}
+
+func TestPort_Connect_Err(t *testing.T) {
+	master, p := openPTY(t)
+	defer master.Close()
+	defer p.Close()
+	if _, err := p.Connect(9600*physic.Hertz, uart.OneHalf, uart.NoParity, uart.NoFlow, 8); err == nil {
+		t.Fatal("1.5 stop bits requires 5 bits")
+	}
+	if _, err := p.Connect(9600*physic.Hertz, uart.One, uart.Parity('X'), uart.NoFlow, 8); err == nil {
+		t.Fatal("invalid parity")
+	}
+	if _, err := p.Connect(9600*physic.Hertz, uart.One, uart.NoParity, uart.Flow(1), 8); err == nil {
+		t.Fatal("invalid flow")
+	}
+	if _, err := p.Connect(9600*physic.Hertz, uart.One, uart.NoParity, uart.XOnXOff|uart.RTSCTS, 8); err == nil {
+		t.Fatal("XOnXOff and RTSCTS can't be combined")
+	}
+	if _, err := p.Connect(9600*physic.Hertz, uart.One, uart.NoParity, uart.XOnXOff|0x1000000, 8); err == nil {
+		t.Fatal("unknown flow bits")
+	}
+	if _, err := p.Connect(9600*physic.Hertz, uart.One, uart.NoParity, uart.NoFlow, 9); err == nil {
+		t.Fatal("invalid bits")
+	}
+}
+
+func TestPort_Close(t *testing.T) {
+	master, p := openPTY(t)
+	defer master.Close()
+	c, err := p.Connect(9600*physic.Hertz, uart.One, uart.NoParity, uart.NoFlow, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Close(); err == nil {
+		t.Fatal("already closed")
+	}
+	if err := c.Tx([]byte{1}, nil); err == nil {
+		t.Fatal("already closed")
+	}
+	if _, err := c.(io.Reader).Read(make([]byte, 1)); err == nil {
+		t.Fatal("already closed")
+	}
+	if err := p.SetReadTimeout(time.Second); err == nil {
+		t.Fatal("already closed")
+	}
+}
+
+func TestTermios2Size(t *testing.T) {
+	if s := unsafe.Sizeof(termios2{}); s != 44 {
+		t.Fatal(s)
+	}
+}
+
+//
+
+// openPTY opens a pseudo terminal pair and returns the master and the slave
+// opened as a Port.
+func openPTY(t *testing.T) (*os.File, *Port) {
+	master, err := os.OpenFile("/dev/ptmx", os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		t.Skip(err)
+	}
+	var unlock int32
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, master.Fd(), syscall.TIOCSPTLCK, uintptr(unsafe.Pointer(&unlock))); errno != 0 {
+		master.Close()
+		t.Skip(errno)
+	}
+	var n uint32
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, master.Fd(), syscall.TIOCGPTN, uintptr(unsafe.Pointer(&n))); errno != 0 {
+		master.Close()
+		t.Skip(errno)
+	}
+	p, err := Open("/dev/pts/" + strconv.Itoa(int(n)))
+	if err != nil {
+		master.Close()
+		t.Skip(err)
+	}
+	return master, p
+}
@@ -0,0 +1,17 @@
+// Copyright 2019 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+// +build !linux
+
+package serial
+
+import "errors"
+
+func (s *serialConn) configure(c *config) error {
+	return errors.New("configuring the port is not supported on this OS")
+}
+
+func (s *serialConn) lockExclusive() error {
+	return nil
+}
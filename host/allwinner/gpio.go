@@ -8,6 +8,7 @@
 package allwinner
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
@@ -323,6 +324,17 @@ func (p *Pin) WaitForEdge(timeout time.Duration) bool {
 	return false
 }
 
+// WaitForEdgeContext implements gpio.EdgeWaiter.
+//
+// Edge detection is done by the sysfs driver, so the same restrictions as
+// WaitForEdge() apply.
+func (p *Pin) WaitForEdgeContext(ctx context.Context) (gpio.Edge, time.Time, error) {
+	if p.sysfsPin != nil {
+		return p.sysfsPin.WaitForEdgeContext(ctx)
+	}
+	return gpio.NoEdge, time.Time{}, p.wrap(errors.New("edge detection is not supported"))
+}
+
 // Pull implements gpio.PinIn.
 func (p *Pin) Pull() gpio.Pull {
 	if drvGPIO.gpioMemory == nil || !p.available {
@@ -1062,4 +1074,5 @@ var drvGPIO driverGPIO
 var _ gpio.PinIO = &Pin{}
 var _ gpio.PinIn = &Pin{}
 var _ gpio.PinOut = &Pin{}
+var _ gpio.EdgeWaiter = &Pin{}
 var _ pin.PinFunc = &Pin{}
@@ -5,6 +5,7 @@
 package allwinner
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
@@ -274,6 +275,17 @@ func (p *PinPL) WaitForEdge(timeout time.Duration) bool {
 	return false
 }
 
+// WaitForEdgeContext implements gpio.EdgeWaiter.
+//
+// Edge detection is done by the sysfs driver, so the same restrictions as
+// WaitForEdge() apply.
+func (p *PinPL) WaitForEdgeContext(ctx context.Context) (gpio.Edge, time.Time, error) {
+	if p.sysfsPin != nil {
+		return p.sysfsPin.WaitForEdgeContext(ctx)
+	}
+	return gpio.NoEdge, time.Time{}, p.wrap(errors.New("edge detection is not supported"))
+}
+
 // Pull implements gpio.PinIn.
 func (p *PinPL) Pull() gpio.Pull {
 	if drvGPIOPL.gpioMemoryPL == nil {
@@ -560,4 +572,5 @@ var drvGPIOPL driverGPIOPL
 var _ gpio.PinIO = &PinPL{}
 var _ gpio.PinIn = &PinPL{}
 var _ gpio.PinOut = &PinPL{}
+var _ gpio.EdgeWaiter = &PinPL{}
 var _ pin.PinFunc = &PinPL{}
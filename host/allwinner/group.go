@@ -0,0 +1,131 @@
+// Copyright 2019 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package allwinner
+
+import (
+	"strings"
+
+	"periph.io/x/periph/conn/gpio"
+)
+
+// Group implements gpio.PinGrouper.
+//
+// It returns a gpio.Group that reads the pins with a single register read and
+// writes them with a single register write, so all the pins change at the
+// same time.
+//
+// All the pins must be in the same port, for example PB0 to PB23, and the
+// memory mapped GPIO registers must be accessible. Returns nil otherwise.
+func (p *Pin) Group(pins []gpio.PinIO) gpio.Group {
+	if drvGPIO.gpioMemory == nil {
+		return nil
+	}
+	g := &group{pins: pins, real: make([]*Pin, len(pins)), port: p.group}
+	for i, pin := range pins {
+		for {
+			r, ok := pin.(gpio.RealPin)
+			if !ok {
+				break
+			}
+			pin = r.Real()
+		}
+		a, ok := pin.(*Pin)
+		if !ok || !a.available || a.group != g.port {
+			return nil
+		}
+		g.real[i] = a
+	}
+	return g
+}
+
+//
+
+// group implements gpio.Group for pins in the same port.
+type group struct {
+	pins []gpio.PinIO
+	real []*Pin
+	port uint8
+}
+
+func (g *group) String() string {
+	names := make([]string, len(g.pins))
+	for i, p := range g.pins {
+		names[i] = p.String()
+	}
+	return "Group(" + strings.Join(names, ", ") + ")"
+}
+
+// Halt implements conn.Resource.
+func (g *group) Halt() error {
+	var err error
+	for _, p := range g.real {
+		if err2 := p.Halt(); err == nil {
+			err = err2
+		}
+	}
+	return err
+}
+
+// Pins implements gpio.Group.
+func (g *group) Pins() []gpio.PinIO {
+	return g.pins
+}
+
+// In implements gpio.Group.
+func (g *group) In(pull gpio.Pull) error {
+	for _, p := range g.real {
+		if err := p.In(pull, gpio.NoEdge); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Read implements gpio.Group.
+//
+// All the pins are sampled at the same time.
+func (g *group) Read(mask uint64) uint64 {
+	d := drvGPIO.gpioMemory.groups[g.port].data
+	var out uint64
+	for i, p := range g.real {
+		if mask&(1<<uint(i)) != 0 && d&(1<<p.offset) != 0 {
+			out |= 1 << uint(i)
+		}
+	}
+	return out
+}
+
+// Out implements gpio.Group.
+//
+// The pins already set as output are changed with a single register write.
+func (g *group) Out(bits, mask uint64) error {
+	var set, clear uint32
+	for i, p := range g.real {
+		if mask&(1<<uint(i)) == 0 {
+			continue
+		}
+		l := gpio.Level(bits&(1<<uint(i)) != 0)
+		if p.function() != out {
+			// First use; this also disables edge detection.
+			if err := p.Out(l); err != nil {
+				return err
+			}
+			continue
+		}
+		if l {
+			set |= 1 << p.offset
+		} else {
+			clear |= 1 << p.offset
+		}
+	}
+	if set|clear != 0 {
+		d := &drvGPIO.gpioMemory.groups[g.port].data
+		*d = (*d &^ clear) | set
+	}
+	return nil
+}
+
+var _ gpio.Group = &group{}
+var _ gpio.PinGrouper = &Pin{}
@@ -272,6 +272,32 @@ func (c *clock) set(f physic.Frequency, maxWaitCycles uint32) (physic.Frequency,
 	return actual, div2, c.setRaw(ctl, div)
 }
 
+// setFrac sets the clock to the closest frequency to f that can be generated,
+// using the fractional divisor if no integer divisor is found.
+//
+// This is needed for audio bit clocks, which are rarely an integer fraction
+// of the clock sources. The fractional divisor is used with MASH noise
+// shaping, which adds jitter but keeps the average frequency accurate.
+//
+// Returns the actual frequency.
+func (c *clock) setFrac(f physic.Frequency) (physic.Frequency, error) {
+	if f < physic.Hertz || f > 25*physic.MegaHertz {
+		return 0, fmt.Errorf("bcm283x-clock: desired frequency %s is out of range", f)
+	}
+	if div, _ := findDivisorExact(clk19dot2MHz, f, 1); div != 0 {
+		return f, c.setRaw(clockSrc19dot2MHz, div)
+	}
+	if div, _ := findDivisorExact(clk500MHz, f, 1); div != 0 {
+		return f, c.setRaw(clockSrcPLLD, div)
+	}
+	// 12.12 fixed point, rounded to the closest value.
+	d := (clk500MHz<<clockDiviShift + f/2) / f
+	if i := d >> clockDiviShift; i < 2 || i > clockDiviMax {
+		return 0, fmt.Errorf("bcm283x-clock: desired frequency %s can't be generated", f)
+	}
+	return (clk500MHz << clockDiviShift) / d, c.setDiv(clockSrcPLLD|clockMash1, clockDiv(d))
+}
+
 // setRaw sets the clock speed with the clock source and the divisor.
 func (c *clock) setRaw(ctl clockCtl, div uint32) error {
 	if div < 1 || div > clockDiviMax {
@@ -280,13 +306,17 @@ func (c *clock) setRaw(ctl clockCtl, div uint32) error {
 	if ctl != clockSrc19dot2MHz && ctl != clockSrcPLLD {
 		return errors.New("invalid clock control")
 	}
+	return c.setDiv(ctl, clockDiv(div<<clockDiviShift))
+}
+
+// setDiv sets the clock control and the 12.12 fixed point divisor.
+func (c *clock) setDiv(ctl clockCtl, d clockDiv) error {
 	// Stop the clock.
 	// TODO(maruel): Do not stop the clock if the current clock rate is the one
 	// desired.
 	for c.ctl&clockBusy != 0 {
 		c.ctl = clockPasswdCtl | clockKill
 	}
-	d := clockDiv(div << clockDiviShift)
 	c.div = clockPasswdDiv | d
 	Nanospin(10 * time.Nanosecond)
 	// Page 107
@@ -66,6 +66,42 @@ func TestClock(t *testing.T) {
 	}
 }
 
+func TestClock_setFrac(t *testing.T) {
+	oldErrClockRegister := errClockRegister
+	errClockRegister = nil
+	defer func() {
+		errClockRegister = oldErrClockRegister
+	}()
+	c := clock{}
+	// Exact.
+	if f, err := c.setFrac(256 * physic.KiloHertz); err != nil || f != 256*physic.KiloHertz {
+		t.Fatal(f, err)
+	}
+	if s := c.String(); s != "PWD|Enable|19.2MHz / 75.(1509949440/4095)" {
+		t.Fatal(s)
+	}
+	// Fractional; 48kHz with 16 bits stereo frames.
+	f, err := c.setFrac(1536 * physic.KiloHertz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := f.String(); s != "1.536MHz" {
+		t.Fatal(s)
+	}
+	if s := c.String(); s != "PWD|Mash1|Enable|PLLD(500MHz) / 325.(1509951573/4095)" {
+		t.Fatal(s)
+	}
+	if _, err := c.setFrac(0); err == nil {
+		t.Fatal("0Hz")
+	}
+	if _, err := c.setFrac(100 * physic.MegaHertz); err == nil {
+		t.Fatal("too high")
+	}
+	if _, err := c.setFrac(100 * physic.Hertz); err == nil {
+		t.Fatal("too low")
+	}
+}
+
 func TestClockMap(t *testing.T) {
 	c := clockMap{}
 	expected := "{\n  gp0: GND(0Hz) / 0.0,\n  gp1: GND(0Hz) / 0.0,\n  gp2: GND(0Hz) / 0.0,\n  pcm: GND(0Hz) / 0.0w,\n  pwm: GND(0Hz) / 0.0,\n}"
@@ -50,6 +50,7 @@ import (
 
 	"periph.io/x/periph"
 	"periph.io/x/periph/conn/gpio/gpiostream"
+	"periph.io/x/periph/conn/i2s/i2sreg"
 	"periph.io/x/periph/conn/physic"
 	"periph.io/x/periph/host/pmem"
 	"periph.io/x/periph/host/videocore"
@@ -1190,6 +1191,9 @@ func (d *driverDMA) Init() (bool, error) {
 	if err := pmem.MapAsPOD(uint64(drvGPIO.baseAddr+0x100000), &d.gpioPadMemory); err != nil {
 		return true, err
 	}
+	if err := i2sreg.Register("I2S0", []string{"PCM0"}, 0, openI2S); err != nil {
+		return true, err
+	}
 	// Do not run smokeTest() unless it's clear it is not dangerous.
 	return true, nil
 }
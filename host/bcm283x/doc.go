@@ -15,6 +15,11 @@
 // Aliases for GPCLK0, GPCLK1, GPCLK2 are created for corresponding CLKn pins.
 // Same for PWM0_OUT and PWM1_OUT, which point respectively to PWM0 and PWM1.
 //
+// I²S
+//
+// The PCM controller is registered in i2sreg as "I2S0" when the bcm283x-dma
+// driver is loaded. It uses GPIO18 to GPIO21.
+//
 // Datasheet
 //
 // https://www.raspberrypi.org/wp-content/uploads/2012/02/BCM2835-ARM-Peripherals.pdf
@@ -5,6 +5,7 @@
 package bcm283x
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
@@ -473,6 +474,17 @@ func (p *Pin) WaitForEdge(timeout time.Duration) bool {
 	return false
 }
 
+// WaitForEdgeContext implements gpio.EdgeWaiter.
+//
+// Edge detection is done by the sysfs driver, so the same restrictions as
+// WaitForEdge() apply.
+func (p *Pin) WaitForEdgeContext(ctx context.Context) (gpio.Edge, time.Time, error) {
+	if p.sysfsPin != nil {
+		return p.sysfsPin.WaitForEdgeContext(ctx)
+	}
+	return gpio.NoEdge, time.Time{}, p.wrap(errors.New("edge detection is not supported"))
+}
+
 // Pull implements gpio.PinIn.
 //
 // bcm283x doesn't support querying the pull resistor of any GPIO pin.
@@ -1356,6 +1368,7 @@ var drvGPIO driverGPIO
 var _ gpio.PinIO = &Pin{}
 var _ gpio.PinIn = &Pin{}
 var _ gpio.PinOut = &Pin{}
+var _ gpio.EdgeWaiter = &Pin{}
 var _ gpiostream.PinIn = &Pin{}
 var _ gpiostream.PinOut = &Pin{}
 var _ pin.PinFunc = &Pin{}
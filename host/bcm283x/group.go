@@ -0,0 +1,134 @@
+// Copyright 2019 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package bcm283x
+
+import (
+	"strings"
+
+	"periph.io/x/periph/conn/gpio"
+)
+
+// Group implements gpio.PinGrouper.
+//
+// It returns a gpio.Group that reads the pins with a single register read and
+// writes them with at most two register writes, one to set the high pins and
+// one to clear the low pins.
+//
+// All the pins must be on the same bank, either GPIO0 to GPIO31 or GPIO32 to
+// GPIO46, and the subsystem gpiomem must be initialized. Returns nil
+// otherwise.
+func (p *Pin) Group(pins []gpio.PinIO) gpio.Group {
+	if drvGPIO.gpioMemory == nil {
+		return nil
+	}
+	g := &group{pins: pins, real: make([]*Pin, len(pins)), bank: p.number / 32}
+	for i, pin := range pins {
+		for {
+			r, ok := pin.(gpio.RealPin)
+			if !ok {
+				break
+			}
+			pin = r.Real()
+		}
+		b, ok := pin.(*Pin)
+		if !ok || b.number/32 != g.bank {
+			return nil
+		}
+		g.real[i] = b
+	}
+	return g
+}
+
+//
+
+// group implements gpio.Group for pins on the same bank.
+type group struct {
+	pins []gpio.PinIO
+	real []*Pin
+	bank int
+}
+
+func (g *group) String() string {
+	names := make([]string, len(g.pins))
+	for i, p := range g.pins {
+		names[i] = p.String()
+	}
+	return "Group(" + strings.Join(names, ", ") + ")"
+}
+
+// Halt implements conn.Resource.
+func (g *group) Halt() error {
+	var err error
+	for _, p := range g.real {
+		if err2 := p.Halt(); err == nil {
+			err = err2
+		}
+	}
+	return err
+}
+
+// Pins implements gpio.Group.
+func (g *group) Pins() []gpio.PinIO {
+	return g.pins
+}
+
+// In implements gpio.Group.
+func (g *group) In(pull gpio.Pull) error {
+	for _, p := range g.real {
+		if err := p.In(pull, gpio.NoEdge); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Read implements gpio.Group.
+//
+// All the pins are sampled at the same time.
+func (g *group) Read(mask uint64) uint64 {
+	l := drvGPIO.gpioMemory.level[g.bank]
+	var out uint64
+	for i, p := range g.real {
+		if mask&(1<<uint(i)) != 0 && l&(1<<uint(p.number&31)) != 0 {
+			out |= 1 << uint(i)
+		}
+	}
+	return out
+}
+
+// Out implements gpio.Group.
+//
+// The pins already set as output are changed with at most two register writes.
+func (g *group) Out(bits, mask uint64) error {
+	var set, clear uint32
+	for i, p := range g.real {
+		if mask&(1<<uint(i)) == 0 {
+			continue
+		}
+		l := gpio.Level(bits&(1<<uint(i)) != 0)
+		if p.function() != out {
+			// First use; this also disables edge detection and clocks.
+			if err := p.Out(l); err != nil {
+				return err
+			}
+			continue
+		}
+		if l {
+			set |= 1 << uint(p.number&31)
+		} else {
+			clear |= 1 << uint(p.number&31)
+		}
+	}
+	if set != 0 {
+		drvGPIO.gpioMemory.outputSet[g.bank] = set
+	}
+	if clear != 0 {
+		drvGPIO.gpioMemory.outputClear[g.bank] = clear
+	}
+	return nil
+}
+
+var _ gpio.Group = &group{}
+var _ gpio.PinGrouper = &Pin{}
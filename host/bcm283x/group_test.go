@@ -0,0 +1,67 @@
+// Copyright 2019 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package bcm283x
+
+import (
+	"testing"
+
+	"periph.io/x/periph/conn/gpio"
+)
+
+func TestGroup(t *testing.T) {
+	defer reset()
+	// Set GPIO4 and GPIO12 as output.
+	drvGPIO.gpioMemory.functionSelect[0] = 1 << 12
+	drvGPIO.gpioMemory.functionSelect[1] = 1 << 6
+	g, err := gpio.NewGroup(GPIO4, GPIO12, GPIO16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := g.(*group); !ok {
+		t.Fatalf("expected optimized group, got %T", g)
+	}
+	if s := g.String(); s != "Group(GPIO4, GPIO12, GPIO16)" {
+		t.Fatal(s)
+	}
+	// GPIO4, GPIO12 and GPIO16 are high in setMemory().
+	if v := g.Read(0x7); v != 0x7 {
+		t.Fatal(v)
+	}
+	if v := g.Read(0x2); v != 0x2 {
+		t.Fatal(v)
+	}
+	if err := g.Out(0x1, 0x3); err != nil {
+		t.Fatal(err)
+	}
+	if v := drvGPIO.gpioMemory.outputSet[0]; v != 1<<4 {
+		t.Fatalf("%#x", v)
+	}
+	if v := drvGPIO.gpioMemory.outputClear[0]; v != 1<<12 {
+		t.Fatalf("%#x", v)
+	}
+	// GPIO16 is switched to output on first use.
+	if err := g.Out(0x4, 0x4); err != nil {
+		t.Fatal(err)
+	}
+	if f := GPIO16.function(); f != out {
+		t.Fatal(f)
+	}
+}
+
+func TestGroup_Fallback(t *testing.T) {
+	defer reset()
+	// Different banks.
+	g, err := gpio.NewGroup(GPIO4, GPIO32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := g.(*group); ok {
+		t.Fatal("expected generic group")
+	}
+	drvGPIO.gpioMemory = nil
+	if GPIO4.Group([]gpio.PinIO{GPIO4}) != nil {
+		t.Fatal("expected nil without gpiomem")
+	}
+}
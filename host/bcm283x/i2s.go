@@ -0,0 +1,323 @@
+// Copyright 2019 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package bcm283x
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"periph.io/x/periph/conn"
+	"periph.io/x/periph/conn/gpio"
+	"periph.io/x/periph/conn/i2s"
+	"periph.io/x/periph/conn/physic"
+	"periph.io/x/periph/conn/pin"
+)
+
+// i2sPort implements i2s.PortCloser over the PCM controller.
+//
+// The controller is the clock and frame master; SCK and WS are generated from
+// the PCM clock. The pins used are GPIO18 (SCK), GPIO19 (WS), GPIO20 (IN) and
+// GPIO21 (OUT).
+//
+// The frames are transferred with the standard I²S timing: WS is low for the
+// left channel, data is delayed by one bit clock after WS changes and the
+// slot width is the sample size, so 16 bits samples use 32 bit clocks per
+// frame and 24 or 32 bits samples use 64 bit clocks per frame. This is what
+// most DACs and MEMS microphones like the INMP441 or the SPH0645 expect.
+//
+// The FIFOs are serviced by the CPU during Tx(), so the call must not be
+// interrupted for more than the time it takes to play 64 samples. This is not
+// an issue at usual audio sample rates.
+//
+// The port must not be used concurrently with the Linux audio driver
+// snd_soc_bcm2835_i2s, nor with StreamOut() on GPIO21.
+type i2sPort struct {
+	mu        sync.Mutex
+	connected bool
+	closed    bool
+	conn      i2sConn
+}
+
+func (p *i2sPort) String() string {
+	return "I2S0"
+}
+
+// Close implements i2s.PortCloser.
+func (p *i2sPort) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return errors.New("bcm283x-i2s: port already closed")
+	}
+	p.closed = true
+	err := p.halt()
+	i2sMu.Lock()
+	i2sInUse = false
+	i2sMu.Unlock()
+	return err
+}
+
+// Connect implements i2s.PortCloser.
+func (p *i2sPort) Connect(f i2s.Format) (i2s.Conn, error) {
+	if err := f.Validate(); err != nil {
+		return nil, err
+	}
+	if f.Channels > 2 {
+		return nil, fmt.Errorf("bcm283x-i2s: invalid number of channels %d; at most 2 are supported", f.Channels)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return nil, errors.New("bcm283x-i2s: port is closed")
+	}
+	if p.connected {
+		return nil, errors.New("bcm283x-i2s: Connect() cannot be called twice")
+	}
+	if drvDMA.pcmMemory == nil || drvDMA.clockMemory == nil {
+		return nil, errors.New("bcm283x-i2s: subsystem PCM not initialized")
+	}
+	for i, fn := range []pin.Func{i2s.SCK, i2s.WS, i2s.IN, i2s.OUT} {
+		if err := i2sPins[i].SetFunc(fn); err != nil {
+			return nil, err
+		}
+	}
+	// The slot width is the sample size, and there are always two slots per
+	// frame.
+	slot := 8 * f.SampleSize()
+	drvDMA.pcmMemory.reset()
+	actual, err := drvDMA.clockMemory.pcm.setFrac(f.SampleRate * physic.Frequency(2*slot))
+	if err != nil {
+		return nil, fmt.Errorf("bcm283x-i2s: %v", err)
+	}
+	f.SampleRate = actual / physic.Frequency(2*slot)
+	if err := drvDMA.pcmMemory.setI2S(f); err != nil {
+		return nil, fmt.Errorf("bcm283x-i2s: %v", err)
+	}
+	p.connected = true
+	p.conn.f = f
+	return &p.conn, nil
+}
+
+// SCK implements i2s.Pins.
+func (p *i2sPort) SCK() gpio.PinOut {
+	return i2sPins[0]
+}
+
+// WS implements i2s.Pins.
+func (p *i2sPort) WS() gpio.PinOut {
+	return i2sPins[1]
+}
+
+// IN implements i2s.Pins.
+func (p *i2sPort) IN() gpio.PinIn {
+	return i2sPins[2]
+}
+
+// OUT implements i2s.Pins.
+func (p *i2sPort) OUT() gpio.PinOut {
+	return i2sPins[3]
+}
+
+func (p *i2sPort) halt() error {
+	if !p.connected || drvDMA.pcmMemory == nil {
+		return nil
+	}
+	drvDMA.pcmMemory.reset()
+	_, _, err := drvDMA.clockMemory.pcm.set(0, 1)
+	return err
+}
+
+// i2sConn implements i2s.Conn.
+type i2sConn struct {
+	mu sync.Mutex
+	f  i2s.Format
+}
+
+func (c *i2sConn) String() string {
+	return "I2S0"
+}
+
+// Duplex implements conn.Conn.
+func (c *i2sConn) Duplex() conn.Duplex {
+	return conn.Full
+}
+
+// Format implements i2s.Conn.
+func (c *i2sConn) Format() i2s.Format {
+	return c.f
+}
+
+// Tx implements conn.Conn.
+//
+// It busy loops until all the frames are transferred. It returns an error if
+// the controller stops making progress for a few frames, which happens when
+// the PCM clock is stopped.
+func (c *i2sConn) Tx(w, r []byte) error {
+	if len(w) == 0 && len(r) == 0 {
+		return errors.New("bcm283x-i2s: Tx() with empty buffers")
+	}
+	if len(w) != 0 && len(r) != 0 && len(w) != len(r) {
+		return errors.New("bcm283x-i2s: Tx() buffers must have the same length")
+	}
+	if s := c.f.FrameSize(); len(w)%s != 0 || len(r)%s != 0 {
+		return errors.New("bcm283x-i2s: Tx() buffers must contain whole frames")
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	p := drvDMA.pcmMemory
+	if p == nil {
+		return errors.New("bcm283x-i2s: subsystem PCM not initialized")
+	}
+	size := c.f.SampleSize()
+	nw := len(w) / size
+	nr := len(r) / size
+
+	timeout := i2sTimeout(c.f.SampleRate)
+
+	// Start from a clean state.
+	p.cs |= pcmTXClear | pcmRXClear
+	if err := p.sync(timeout); err != nil {
+		return fmt.Errorf("bcm283x-i2s: %v", err)
+	}
+	if e := p.cs & (pcmTXErr | pcmRXErr); e != 0 {
+		// Write 1 to clear.
+		p.cs |= e
+	}
+	// Prefill the TX FIFO so it doesn't underrun at start.
+	wi := 0
+	for ; wi < nw && wi < pcmFIFODepth && p.cs&pcmTXData != 0; wi++ {
+		p.fifo = getSample(w[wi*size:], size)
+	}
+	on := pcmCS(0)
+	if nw != 0 {
+		on |= pcmTXEnable
+	}
+	if nr != 0 {
+		on |= pcmRXEnable
+	}
+	p.cs |= on
+	defer func() {
+		p.cs &^= pcmTXEnable | pcmRXEnable
+	}()
+	last := time.Now()
+	for ri := 0; wi < nw || ri < nr; {
+		cs := p.cs
+		progress := false
+		if wi < nw {
+			if cs&pcmTXErr != 0 {
+				return errors.New("bcm283x-i2s: TX FIFO underrun")
+			}
+			if cs&pcmTXData != 0 {
+				p.fifo = getSample(w[wi*size:], size)
+				wi++
+				progress = true
+			}
+		}
+		if ri < nr {
+			if cs&pcmRXErr != 0 {
+				return errors.New("bcm283x-i2s: RX FIFO overrun")
+			}
+			if cs&pcmRXData != 0 {
+				putSample(r[ri*size:], size, p.fifo)
+				ri++
+				progress = true
+			}
+		}
+		if progress {
+			last = time.Now()
+		} else if time.Since(last) > timeout {
+			return errors.New("bcm283x-i2s: timed out waiting for the FIFO; is the PCM clock running?")
+		}
+	}
+	if nw != 0 {
+		// Let the TX FIFO drain, which takes up to pcmFIFODepth samples.
+		drain := timeout + time.Duration(pcmFIFODepth/c.f.Channels)*c.f.SampleRate.Period()
+		for start := time.Now(); p.cs&pcmTXEmpty == 0; {
+			if time.Since(start) > drain {
+				return errors.New("bcm283x-i2s: timed out draining the TX FIFO; is the PCM clock running?")
+			}
+		}
+	}
+	return nil
+}
+
+// i2sTimeout returns how long to wait for the controller at sample rate f.
+//
+// It is a few frames, with a minimum to tolerate the process being scheduled
+// out.
+func i2sTimeout(f physic.Frequency) time.Duration {
+	d := 8 * f.Period()
+	if d < 10*time.Millisecond {
+		d = 10 * time.Millisecond
+	}
+	return d
+}
+
+// SCK implements i2s.Pins.
+func (c *i2sConn) SCK() gpio.PinOut {
+	return i2sPins[0]
+}
+
+// WS implements i2s.Pins.
+func (c *i2sConn) WS() gpio.PinOut {
+	return i2sPins[1]
+}
+
+// IN implements i2s.Pins.
+func (c *i2sConn) IN() gpio.PinIn {
+	return i2sPins[2]
+}
+
+// OUT implements i2s.Pins.
+func (c *i2sConn) OUT() gpio.PinOut {
+	return i2sPins[3]
+}
+
+// pcmFIFODepth is the number of 32 bits words in each of the PCM FIFOs.
+const pcmFIFODepth = 64
+
+var (
+	i2sMu    sync.Mutex
+	i2sInUse bool
+	// i2sPins are SCK, WS, IN and OUT; GPIO18 to GPIO21.
+	i2sPins = []*Pin{&cpuPins[18], &cpuPins[19], &cpuPins[20], &cpuPins[21]}
+)
+
+// openI2S implements i2sreg.Opener.
+func openI2S() (i2s.PortCloser, error) {
+	i2sMu.Lock()
+	defer i2sMu.Unlock()
+	if i2sInUse {
+		return nil, errors.New("bcm283x-i2s: port is already opened")
+	}
+	i2sInUse = true
+	return &i2sPort{}, nil
+}
+
+// getSample returns the signed little endian sample in b as a FIFO word.
+func getSample(b []byte, size int) uint32 {
+	switch size {
+	case 1:
+		return uint32(int32(int8(b[0])))
+	case 2:
+		return uint32(int32(int16(uint16(b[0]) | uint16(b[1])<<8)))
+	default:
+		return uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16 | uint32(b[3])<<24
+	}
+}
+
+// putSample stores the FIFO word v as a little endian sample in b.
+func putSample(b []byte, size int, v uint32) {
+	for i := 0; i < size; i++ {
+		b[i] = byte(v >> uint(8*i))
+	}
+}
+
+var _ i2s.PortCloser = &i2sPort{}
+var _ i2s.Pins = &i2sPort{}
+var _ i2s.Conn = &i2sConn{}
+var _ i2s.Pins = &i2sConn{}
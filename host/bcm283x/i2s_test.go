@@ -0,0 +1,190 @@
+// Copyright 2019 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package bcm283x
+
+import (
+	"bytes"
+	"testing"
+
+	"periph.io/x/periph/conn/i2s"
+	"periph.io/x/periph/conn/physic"
+)
+
+func TestI2S(t *testing.T) {
+	defer setI2SMemory()()
+	p, err := openI2S()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := openI2S(); err == nil {
+		t.Fatal("already opened")
+	}
+	f := i2s.Format{SampleRate: 48 * physic.KiloHertz, Bits: 16, Channels: 2}
+	if _, err := p.Connect(f); err == nil {
+		t.Fatal("PCM not initialized")
+	}
+	if _, err := p.Connect(i2s.Format{SampleRate: 48 * physic.KiloHertz, Bits: 16, Channels: 4}); err == nil {
+		t.Fatal("too many channels")
+	}
+	drvDMA.pcmMemory = &pcmMap{}
+	drvDMA.clockMemory = &clockMap{}
+	c, err := p.Connect(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Connect(f); err == nil {
+		t.Fatal("Connect twice")
+	}
+	if s := c.Format().String(); s != "48kHz 16bits 2ch" {
+		t.Fatal(s)
+	}
+	m := drvDMA.pcmMemory
+	if m.mode != (31<<pcmFrameLengthShift|16)|pcmClockInverted|pcmFSInverted {
+		t.Fatalf("%#x", m.mode)
+	}
+	if m.txc != 0x40184118 || m.rxc != 0x40184118 {
+		t.Fatalf("%#x %#x", m.txc, m.rxc)
+	}
+	if f := GPIO21.Func(); f != i2s.OUT {
+		t.Fatal(f)
+	}
+	if c.(i2s.Pins).SCK() != GPIO18 || p.(i2s.Pins).IN() != GPIO20 {
+		t.Fatal("unexpected pins")
+	}
+
+	if c.Tx(nil, nil) == nil {
+		t.Fatal("empty buffers")
+	}
+	if c.Tx(make([]byte, 4), make([]byte, 8)) == nil {
+		t.Fatal("different lengths")
+	}
+	if c.Tx(make([]byte, 6), nil) == nil {
+		t.Fatal("partial frame")
+	}
+	// Fake FIFOs that are always ready.
+	m.cs |= pcmTXData | pcmRXData | pcmTXEmpty
+	if err := c.Tx([]byte{1, 0, 0xFF, 0xFF, 2, 0, 3, 0}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if m.fifo != 3 {
+		t.Fatal(m.fifo)
+	}
+	m.fifo = 0xFFFFFFFE
+	r := make([]byte, 4)
+	if err := c.Tx(nil, r); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(r, []byte{0xFE, 0xFF, 0xFE, 0xFF}) {
+		t.Fatal(r)
+	}
+	if m.cs&(pcmTXEnable|pcmRXEnable) != 0 {
+		t.Fatal("expected TX and RX to be disabled")
+	}
+	m.cs |= pcmTXErr
+	if c.Tx(make([]byte, 4*100), nil) == nil {
+		t.Fatal("underrun")
+	}
+	if err := p.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Close(); err == nil {
+		t.Fatal("closed twice")
+	}
+}
+
+func TestI2S_mono24(t *testing.T) {
+	defer setI2SMemory()()
+	drvDMA.pcmMemory = &pcmMap{}
+	drvDMA.clockMemory = &clockMap{}
+	p, err := openI2S()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	c, err := p.Connect(i2s.Format{SampleRate: 16 * physic.KiloHertz, Bits: 24, Channels: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := drvDMA.pcmMemory
+	if m.mode != (63<<pcmFrameLengthShift|32)|pcmClockInverted|pcmFSInverted {
+		t.Fatalf("%#x", m.mode)
+	}
+	if m.txc != 0xC0100000 {
+		t.Fatalf("%#x", m.txc)
+	}
+	m.cs |= pcmTXData | pcmRXData | pcmTXEmpty
+	m.fifo = 0xFFF00001
+	r := make([]byte, 4)
+	if err := c.Tx(nil, r); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(r, []byte{1, 0, 0xF0, 0xFF}) {
+		t.Fatal(r)
+	}
+}
+
+func TestI2S_timeout(t *testing.T) {
+	defer setI2SMemory()()
+	drvDMA.pcmMemory = &pcmMap{}
+	drvDMA.clockMemory = &clockMap{}
+	p, err := openI2S()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	c, err := p.Connect(i2s.Format{SampleRate: 48 * physic.KiloHertz, Bits: 16, Channels: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The FIFOs never get ready, like when the PCM clock is stopped.
+	if c.Tx(nil, make([]byte, 4)) == nil {
+		t.Fatal("expected timeout")
+	}
+	m := drvDMA.pcmMemory
+	m.cs |= pcmTXData
+	if c.Tx(make([]byte, 4), nil) == nil {
+		t.Fatal("expected timeout draining the FIFO")
+	}
+}
+
+func TestSample(t *testing.T) {
+	data := []struct {
+		b    []byte
+		size int
+		v    uint32
+	}{
+		{[]byte{0x80}, 1, 0xFFFFFF80},
+		{[]byte{0x7F}, 1, 0x7F},
+		{[]byte{0x00, 0x80}, 2, 0xFFFF8000},
+		{[]byte{0x34, 0x12}, 2, 0x1234},
+		{[]byte{0x78, 0x56, 0x34, 0x12}, 4, 0x12345678},
+	}
+	for i, line := range data {
+		if v := getSample(line.b, line.size); v != line.v {
+			t.Fatalf("#%d: %#x != %#x", i, v, line.v)
+		}
+		b := make([]byte, line.size)
+		putSample(b, line.size, line.v)
+		if !bytes.Equal(b, line.b) {
+			t.Fatalf("#%d: %v != %v", i, b, line.b)
+		}
+	}
+}
+
+//
+
+// setI2SMemory zaps out setRaw failing on non-working fake CPU memory map and
+// returns a function to reset the state.
+func setI2SMemory() func() {
+	oldErrClockRegister := errClockRegister
+	errClockRegister = nil
+	return func() {
+		errClockRegister = oldErrClockRegister
+		reset()
+		i2sMu.Lock()
+		i2sInUse = false
+		i2sMu.Unlock()
+	}
+}
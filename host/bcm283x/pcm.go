@@ -11,6 +11,7 @@ import (
 	"fmt"
 	"time"
 
+	"periph.io/x/periph/conn/i2s"
 	"periph.io/x/periph/conn/physic"
 )
 
@@ -213,6 +214,53 @@ func (p *pcmMap) set() {
 	p.cs |= pcmTXEnable
 }
 
+// setI2S initializes the controller as I²S clock and frame master for the
+// format f.
+//
+// Each sample uses a slot of 8*f.SampleSize() bit clocks and there are two
+// slots per frame. With a single channel, only the left slot is used.
+//
+// It returns an error if the PCM clock is not running.
+func (p *pcmMap) setI2S(f i2s.Format) error {
+	slot := uint32(8 * f.SampleSize())
+	// Outputs change on the falling edge of SCK, inputs are sampled on the
+	// rising edge. WS is low for the left channel.
+	p.mode = pcmMode((2*slot-1)<<pcmFrameLengthShift|slot) | pcmClockInverted | pcmFSInverted
+	// Data is delayed by one bit clock after WS changes.
+	ch1 := pcmChannel(uint32(f.Bits), 1)
+	ch2 := uint32(0)
+	if f.Channels == 2 {
+		ch2 = pcmChannel(uint32(f.Bits), slot+1)
+	}
+	p.txc = pcmTX(ch1<<16 | ch2)
+	p.rxc = pcmRX(ch1<<16 | ch2)
+	p.cs = pcmEnable | pcmRXSignExtend
+	return p.sync(i2sTimeout(f.SampleRate))
+}
+
+// sync waits for two PCM clocks to occur.
+//
+// It returns an error if they didn't occur within timeout, which happens when
+// the PCM clock is stopped.
+func (p *pcmMap) sync(timeout time.Duration) error {
+	s := ^p.cs & pcmSync
+	p.cs = p.cs&^pcmSync | s
+	for start := time.Now(); p.cs&pcmSync != s; {
+		if time.Since(start) > timeout {
+			return errors.New("timed out waiting for the PCM clock")
+		}
+	}
+	return nil
+}
+
+// pcmChannel returns the 16 bits configuration for a TX or RX channel of
+// width bits at position pos.
+func pcmChannel(bits, pos uint32) uint32 {
+	// Width is 8 + CHWID + 16*CHWEX.
+	w := bits - 8
+	return 1<<14 | (w>>4)<<15 | pos<<4 | w&0xF
+}
+
 // setPCMClockSource sets the PCM clock.
 //
 // It may select an higher frequency than the one requested.
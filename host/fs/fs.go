@@ -86,11 +86,28 @@ func (e *Event) MakeEvent(fd uintptr) error {
 	return e.event.makeEvent(fd)
 }
 
+// MakeReadEvent initializes an epoll *level* triggered event on linux that is
+// signaled as long as there is data to read from fd.
+//
+// Unlike MakeEvent, the event is not reset by waiting on it but by reading the
+// data. This is useful for character devices that queue records, like the GPIO
+// character device line events, where each record must be read.
+func (e *Event) MakeReadEvent(fd uintptr) error {
+	return e.event.makeReadEvent(fd)
+}
+
 // Wait waits for an event or the specified amount of time.
 func (e *Event) Wait(timeoutms int) (int, error) {
 	return e.event.wait(timeoutms)
 }
 
+// Close releases the epoll handle.
+//
+// The Event can be initialized again afterward.
+func (e *Event) Close() error {
+	return e.event.close()
+}
+
 //
 
 var (
@@ -95,6 +95,19 @@ type event struct {
 // syscall.EpollCreate: http://man7.org/linux/man-pages/man2/epoll_create.2.html
 // syscall.EpollCtl: http://man7.org/linux/man-pages/man2/epoll_ctl.2.html
 func (e *event) makeEvent(fd uintptr) error {
+	// EPOLLWAKEUP could be used to force the system to not go do sleep while
+	// waiting for an edge. This is generally a bad idea, as we'd instead have
+	// the system to *wake up* when an edge is triggered. Achieving this is
+	// outside the scope of this interface.
+	return e.makeEventFlags(fd, epollPRI|epollET)
+}
+
+// makeReadEvent creates an epoll *level* triggered event on available data.
+func (e *event) makeReadEvent(fd uintptr) error {
+	return e.makeEventFlags(fd, epollIN)
+}
+
+func (e *event) makeEventFlags(fd uintptr, flags epollEvent) error {
 	epollFd, err := syscall.EpollCreate(1)
 	switch {
 	case err == nil:
@@ -109,11 +122,7 @@ func (e *event) makeEvent(fd uintptr) error {
 	}
 	e.epollFd = epollFd
 	e.fd = int(fd)
-	// EPOLLWAKEUP could be used to force the system to not go do sleep while
-	// waiting for an edge. This is generally a bad idea, as we'd instead have
-	// the system to *wake up* when an edge is triggered. Achieving this is
-	// outside the scope of this interface.
-	e.event[0].Events = uint32(epollPRI | epollET)
+	e.event[0].Events = uint32(flags)
 	e.event[0].Fd = int32(e.fd)
 	return syscall.EpollCtl(e.epollFd, epollCTLAdd, e.fd, &e.event[0])
 }
@@ -122,3 +131,13 @@ func (e *event) wait(timeoutms int) (int, error) {
 	// http://man7.org/linux/man-pages/man2/epoll_wait.2.html
 	return syscall.EpollWait(e.epollFd, e.event[:], timeoutms)
 }
+
+func (e *event) close() error {
+	if e.epollFd == 0 {
+		return nil
+	}
+	err := syscall.Close(e.epollFd)
+	e.epollFd = 0
+	e.fd = 0
+	return err
+}
@@ -9,6 +9,7 @@ import (
 	"log"
 
 	"periph.io/x/periph/conn/gpio"
+	"periph.io/x/periph/conn/physic"
 	"periph.io/x/periph/host"
 	"periph.io/x/periph/host/sysfs"
 )
@@ -30,3 +31,22 @@ func ExampleLEDByName() {
 		log.Fatal(err)
 	}
 }
+
+func ExamplePWMByName() {
+	// Make sure periph is initialized.
+	if _, err := host.Init(); err != nil {
+		log.Fatal(err)
+	}
+
+	for _, p := range sysfs.PWMs {
+		fmt.Printf("- %s: %s\n", p, p.Function())
+	}
+	p, err := sysfs.PWMByName("pwmchip0_0")
+	if err != nil {
+		log.Fatalf("failed to find PWM: %v", err)
+	}
+	// Drive a servo at its middle position.
+	if err := p.PWM(gpio.DutyMax*15/200, 50*physic.Hertz); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -5,6 +5,7 @@
 package sysfs
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -12,6 +13,7 @@ import (
 	"path/filepath"
 	"strconv"
 	"sync"
+	"syscall"
 	"time"
 
 	"periph.io/x/periph"
@@ -248,6 +250,69 @@ func (p *Pin) WaitForEdge(timeout time.Duration) bool {
 	}
 }
 
+// WaitForEdgeContext implements gpio.EdgeWaiter.
+//
+// The time returned is when the wait returned and the edge direction is
+// deduced by reading the pin right after, unless edge detection was
+// configured for a single direction. Linux doesn't support cancelling the
+// wait, so ctx is verified every gpio.EdgePollTimeout.
+func (p *Pin) WaitForEdgeContext(ctx context.Context) (gpio.Edge, time.Time, error) {
+	// Run lockless, like WaitForEdge().
+	edge := p.edge
+	if edge == gpio.NoEdge {
+		return gpio.NoEdge, time.Time{}, p.wrap(errors.New("edge detection is not enabled"))
+	}
+	if err := waitEvent(ctx, &p.event); err != nil {
+		if err == ctx.Err() {
+			return gpio.NoEdge, time.Time{}, err
+		}
+		return gpio.NoEdge, time.Time{}, p.wrap(err)
+	}
+	now := time.Now()
+	if edge == gpio.BothEdges {
+		edge = gpio.FallingEdge
+		if p.Read() == gpio.High {
+			edge = gpio.RisingEdge
+		}
+	}
+	return edge, now, nil
+}
+
+// StreamEdges implements gpio.EdgeStreamer.
+//
+// The events are timestamped when the goroutine servicing the stream wakes
+// up, which is less accurate than the timestamps of the gpiochip driver. Edges
+// happening while the goroutine is not scheduled are merged by the kernel.
+func (p *Pin) StreamEdges(pull gpio.Pull, edge gpio.Edge, size int) (*gpio.EdgeStream, error) {
+	if edge == gpio.NoEdge {
+		return nil, p.wrap(errors.New("edge detection is required"))
+	}
+	if size <= 0 {
+		return nil, p.wrap(errors.New("invalid buffer size"))
+	}
+	if err := p.In(pull, edge); err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	s := gpio.NewEdgeStream(size, func() error {
+		cancel()
+		<-done
+		return nil
+	})
+	go func() {
+		defer close(done)
+		for {
+			e, t, err := p.WaitForEdgeContext(ctx)
+			if err != nil {
+				return
+			}
+			s.Push(gpio.EdgeEvent{Level: e == gpio.RisingEdge, Time: t})
+		}
+	}()
+	return s, nil
+}
+
 // Pull implements gpio.PinIn.
 //
 // It returns gpio.PullNoChange since gpio sysfs has no support for input pull
@@ -383,6 +448,43 @@ func (p *Pin) wrap(err error) error {
 
 //
 
+// waitEvent waits for e to be signaled or for ctx to be done.
+//
+// The epoll wait can't be interrupted, so it is done in slices of
+// gpio.EdgePollTimeout. Returns ctx.Err() if ctx is done first.
+func waitEvent(ctx context.Context, e *fs.Event) error {
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		nr, err := e.Wait(waitTimeout(ctx))
+		if err != nil && err != syscall.EINTR {
+			return err
+		}
+		if nr == 1 {
+			return nil
+		}
+	}
+}
+
+// waitTimeout returns the timeout in milliseconds of the next epoll wait done
+// by waitEvent.
+//
+// It is never negative, since a negative timeout blocks forever, even when
+// ctx's deadline passed.
+func waitTimeout(ctx context.Context) int {
+	ms := int(gpio.EdgePollTimeout / time.Millisecond)
+	if dl, ok := ctx.Deadline(); ok {
+		if r := int(dl.Sub(time.Now())/time.Millisecond) + 1; r < ms {
+			ms = r
+		}
+	}
+	if ms < 0 {
+		ms = 0
+	}
+	return ms
+}
+
 type direction int
 
 const (
@@ -517,4 +619,6 @@ var _ conn.Resource = &Pin{}
 var _ gpio.PinIn = &Pin{}
 var _ gpio.PinOut = &Pin{}
 var _ gpio.PinIO = &Pin{}
+var _ gpio.EdgeWaiter = &Pin{}
+var _ gpio.EdgeStreamer = &Pin{}
 var _ pin.PinFunc = &Pin{}
@@ -5,8 +5,10 @@
 package sysfs
 
 import (
+	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"periph.io/x/periph/conn/gpio"
 	"periph.io/x/periph/conn/physic"
@@ -138,6 +140,23 @@ func TestPin_WaitForEdges(t *testing.T) {
 	}
 }
 
+func TestPin_WaitForEdgeContext(t *testing.T) {
+	p := Pin{number: 42, name: "foo", root: "/tmp/gpio/priv/"}
+	if _, _, err := p.WaitForEdgeContext(context.Background()); err == nil {
+		t.Fatal("edge detection is not enabled")
+	}
+}
+
+func TestPin_StreamEdges(t *testing.T) {
+	p := Pin{number: 42, name: "foo", root: "/tmp/gpio/priv/"}
+	if _, err := p.StreamEdges(gpio.PullNoChange, gpio.NoEdge, 16); err == nil {
+		t.Fatal("edge detection is required")
+	}
+	if _, err := p.StreamEdges(gpio.PullNoChange, gpio.BothEdges, 0); err == nil {
+		t.Fatal("invalid buffer size")
+	}
+}
+
 func TestPin_Pull(t *testing.T) {
 	p := Pin{number: 42, name: "foo", root: "/tmp/gpio/priv/"}
 	if pull := p.Pull(); pull != gpio.PullNoChange {
@@ -202,6 +221,18 @@ func TestPin_readInt(t *testing.T) {
 	}
 }
 
+func TestWaitTimeout(t *testing.T) {
+	if ms := waitTimeout(context.Background()); ms != int(gpio.EdgePollTimeout/time.Millisecond) {
+		t.Fatal(ms)
+	}
+	// The deadline passed; the timeout must not be negative.
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	if ms := waitTimeout(ctx); ms != 0 {
+		t.Fatal(ms)
+	}
+}
+
 func TestGPIODriver(t *testing.T) {
 	if len((&driverGPIO{}).Prerequisites()) != 0 {
 		t.Fatal("unexpected GPIO prerequisites")
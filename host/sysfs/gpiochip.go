@@ -0,0 +1,1125 @@
+// Copyright 2019 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package sysfs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+	"unsafe"
+
+	"periph.io/x/periph"
+	"periph.io/x/periph/conn"
+	"periph.io/x/periph/conn/gpio"
+	"periph.io/x/periph/conn/gpio/gpioreg"
+	"periph.io/x/periph/conn/physic"
+	"periph.io/x/periph/conn/pin"
+	"periph.io/x/periph/host/fs"
+)
+
+// GPIOChips is all the GPIO controllers exposed by the GPIO character device
+// interface, sorted by chip number.
+//
+// This global variable is initialized once at driver initialization and isn't
+// mutated afterward. Do not modify it.
+var GPIOChips []*GPIOChip
+
+// GPIOChip is a GPIO controller exposed by the kernel as /dev/gpiochipN.
+//
+// Its lines are registered in gpioreg as "gpiochipN_<offset>". When the
+// kernel gives a name to a line, for example "GPIO17" on a Raspberry Pi, this
+// name is registered as an alias if it is not already in use. This means that
+// boards keep working when the GPIO sysfs interface is compiled out of the
+// kernel.
+type GPIOChip struct {
+	number int
+	name   string // Something like gpiochip0
+	label  string // Something like pinctrl-bcm2835
+	lines  []*Line
+
+	mu sync.Mutex
+	f  ioctlCloser // handle to /dev/gpiochipN; never closed
+	v1 bool        // true if the kernel only supports the uAPI v1
+}
+
+// String implements conn.Resource.
+func (c *GPIOChip) String() string {
+	return c.name
+}
+
+// Name returns the name of the chip, like "gpiochip0".
+func (c *GPIOChip) Name() string {
+	return c.name
+}
+
+// Label returns the label of the chip as provided by the kernel driver, like
+// "pinctrl-bcm2835".
+func (c *GPIOChip) Label() string {
+	return c.label
+}
+
+// Lines returns all the GPIO lines of this chip, ordered by offset.
+//
+// Do not modify the returned slice.
+func (c *GPIOChip) Lines() []*Line {
+	return c.lines
+}
+
+// Line represents one GPIO line as exposed by the GPIO character device
+// interface.
+//
+// The line is requested from the kernel on the first call to In() or Out()
+// and is released on Halt(). While requested, the line cannot be used by
+// another process.
+//
+// Unlike Pin, Line supports the internal pull resistor, kernel debouncing and
+// timestamped edges.
+type Line struct {
+	chip   *GPIOChip
+	offset uint32
+	name   string // Something like gpiochip0_17
+	label  string // Name given by the kernel, like GPIO17; may be empty
+
+	mu        sync.Mutex
+	f         lineFile      // handle to the line request; nil when not requested
+	event     fs.Event      // Initialized on each request when edges can be read
+	direction direction     // Cache of the last known direction
+	pull      gpio.Pull     // Cache of the last pull used
+	edge      gpio.Edge     // Cache of the last edge used
+	debounce  time.Duration // Debounce period to use while in input mode
+	lastLevel gpio.Level    // Level after the last edge read
+	lastTime  time.Time     // Kernel timestamp of the last edge read
+	lastSeqno uint32        // Kernel sequence number of the last edge read
+}
+
+// String implements conn.Resource.
+func (l *Line) String() string {
+	return l.name
+}
+
+// Halt implements conn.Resource.
+//
+// It releases the line back to the kernel, which stops edge detection if
+// enabled.
+func (l *Line) Halt() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := l.release(); err != nil {
+		return l.wrap(err)
+	}
+	return nil
+}
+
+// Name implements pin.Pin.
+func (l *Line) Name() string {
+	return l.name
+}
+
+// Number implements pin.Pin.
+//
+// It returns the offset of the line on its chip.
+func (l *Line) Number() int {
+	return int(l.offset)
+}
+
+// Function implements pin.Pin.
+func (l *Line) Function() string {
+	return string(l.Func())
+}
+
+// Func implements pin.PinFunc.
+func (l *Line) Func() pin.Func {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	switch l.direction {
+	case dIn:
+		if l.read() {
+			return gpio.IN_HIGH
+		}
+		return gpio.IN_LOW
+	case dOut:
+		if l.read() {
+			return gpio.OUT_HIGH
+		}
+		return gpio.OUT_LOW
+	}
+	// The line is not requested by this process, ask the kernel.
+	info, err := l.chip.lineInfo(l.offset)
+	if err != nil {
+		return pin.Func("ERR")
+	}
+	if info.flags&lineFlagOutput != 0 {
+		return gpio.OUT
+	}
+	return gpio.IN
+}
+
+// SupportedFuncs implements pin.PinFunc.
+func (l *Line) SupportedFuncs() []pin.Func {
+	return []pin.Func{gpio.IN, gpio.OUT}
+}
+
+// SetFunc implements pin.PinFunc.
+func (l *Line) SetFunc(f pin.Func) error {
+	switch f {
+	case gpio.IN:
+		return l.In(gpio.PullNoChange, gpio.NoEdge)
+	case gpio.OUT_HIGH:
+		return l.Out(gpio.High)
+	case gpio.OUT, gpio.OUT_LOW:
+		return l.Out(gpio.Low)
+	default:
+		return l.wrap(errors.New("unsupported function"))
+	}
+}
+
+// In implements gpio.PinIn.
+//
+// Contrary to Pin, all the gpio.Pull values are supported, as long as the
+// kernel driver for the GPIO controller supports them.
+func (l *Line) In(pull gpio.Pull, edge gpio.Edge) error {
+	flags := lineFlagInput
+	switch pull {
+	case gpio.PullNoChange:
+	case gpio.Float:
+		flags |= lineFlagBiasDisabled
+	case gpio.PullDown:
+		flags |= lineFlagBiasPullDown
+	case gpio.PullUp:
+		flags |= lineFlagBiasPullUp
+	default:
+		return l.wrap(fmt.Errorf("invalid pull %s", pull))
+	}
+	switch edge {
+	case gpio.NoEdge:
+	case gpio.RisingEdge:
+		flags |= lineFlagEdgeRising
+	case gpio.FallingEdge:
+		flags |= lineFlagEdgeFalling
+	case gpio.BothEdges:
+		flags |= lineFlagEdgeRising | lineFlagEdgeFalling
+	default:
+		return l.wrap(fmt.Errorf("invalid edge %s", edge))
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := l.request(flags, gpio.Low); err != nil {
+		return l.wrap(err)
+	}
+	l.direction = dIn
+	if pull != gpio.PullNoChange {
+		l.pull = pull
+	}
+	l.edge = edge
+	// Flush the edges that may have accumulated before the reconfiguration.
+	if edge != gpio.NoEdge {
+		for {
+			if nr, err := l.event.Wait(0); err != nil || nr != 1 {
+				break
+			}
+			if _, err := l.readEvent(); err != nil {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// Read implements gpio.PinIn.
+func (l *Line) Read() gpio.Level {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.read()
+}
+
+// WaitForEdge implements gpio.PinIn.
+//
+// Each edge detected by the kernel is queued and returned in order. Use
+// LastEdge() to retrieve the timestamp of the edge.
+func (l *Line) WaitForEdge(timeout time.Duration) bool {
+	// Run lockless, as the normal use is to call in a busy loop.
+	var ms int
+	if timeout == -1 {
+		ms = -1
+	} else {
+		ms = int(timeout / time.Millisecond)
+	}
+	start := time.Now()
+	for {
+		if nr, err := l.event.Wait(ms); err != nil {
+			return false
+		} else if nr == 1 {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			_, err := l.readEvent()
+			return err == nil
+		}
+		// A signal occurred.
+		if timeout != -1 {
+			ms = int((timeout - time.Since(start)) / time.Millisecond)
+		}
+		if ms <= 0 {
+			return false
+		}
+	}
+}
+
+// WaitForEdgeContext implements gpio.EdgeWaiter.
+//
+// The time returned is the one at which the kernel detected the edge. Linux
+// doesn't support cancelling the wait, so ctx is verified every
+// gpio.EdgePollTimeout.
+func (l *Line) WaitForEdgeContext(ctx context.Context) (gpio.Edge, time.Time, error) {
+	if err := waitEvent(ctx, &l.event); err != nil {
+		if err == ctx.Err() {
+			return gpio.NoEdge, time.Time{}, err
+		}
+		return gpio.NoEdge, time.Time{}, l.wrap(err)
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, err := l.readEvent(); err != nil {
+		return gpio.NoEdge, time.Time{}, l.wrap(err)
+	}
+	if l.lastLevel == gpio.High {
+		return gpio.RisingEdge, l.lastTime, nil
+	}
+	return gpio.FallingEdge, l.lastTime, nil
+}
+
+// StreamEdges implements gpio.EdgeStreamer.
+//
+// The events carry the kernel timestamps. The kernel queues the edges too;
+// when its queue overflows, the dropped edges are counted by
+// gpio.EdgeStream.Missed() with the uAPI v2.
+func (l *Line) StreamEdges(pull gpio.Pull, edge gpio.Edge, size int) (*gpio.EdgeStream, error) {
+	if edge == gpio.NoEdge {
+		return nil, l.wrap(errors.New("edge detection is required"))
+	}
+	if size <= 0 {
+		return nil, l.wrap(errors.New("invalid buffer size"))
+	}
+	if err := l.In(pull, edge); err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	s := gpio.NewEdgeStream(size, func() error {
+		cancel()
+		<-done
+		return nil
+	})
+	go func() {
+		defer close(done)
+		for {
+			if err := waitEvent(ctx, &l.event); err != nil {
+				return
+			}
+			l.mu.Lock()
+			missed, err := l.readEvent()
+			e := gpio.EdgeEvent{Level: l.lastLevel, Time: l.lastTime}
+			l.mu.Unlock()
+			if err != nil {
+				return
+			}
+			if missed != 0 {
+				s.AddMissed(uint64(missed))
+			}
+			s.Push(e)
+		}
+	}()
+	return s, nil
+}
+
+// LastEdge returns the level of the line right after the last edge returned by
+// WaitForEdge() and the time at which the kernel detected it.
+//
+// Returns the zero time if no edge was read yet.
+func (l *Line) LastEdge() (gpio.Level, time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.lastLevel, l.lastTime
+}
+
+// Pull implements gpio.PinIn.
+//
+// It returns the pull used in the last In() call, or the bias reported by the
+// kernel otherwise.
+func (l *Line) Pull() gpio.Pull {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.pull != gpio.PullNoChange {
+		return l.pull
+	}
+	info, err := l.chip.lineInfo(l.offset)
+	if err != nil {
+		return gpio.PullNoChange
+	}
+	switch {
+	case info.flags&lineFlagBiasPullUp != 0:
+		return gpio.PullUp
+	case info.flags&lineFlagBiasPullDown != 0:
+		return gpio.PullDown
+	case info.flags&lineFlagBiasDisabled != 0:
+		return gpio.Float
+	default:
+		return gpio.PullNoChange
+	}
+}
+
+// DefaultPull implements gpio.PinIn.
+//
+// It returns gpio.PullNoChange since the GPIO character device interface
+// doesn't expose the reset value.
+func (l *Line) DefaultPull() gpio.Pull {
+	return gpio.PullNoChange
+}
+
+// Out implements gpio.PinOut.
+func (l *Line) Out(level gpio.Level) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.direction != dOut {
+		// The initial value is set as part of the request, so the line doesn't
+		// glitch.
+		if err := l.request(lineFlagOutput, level); err != nil {
+			return l.wrap(err)
+		}
+		l.direction = dOut
+		l.edge = gpio.NoEdge
+		return nil
+	}
+	if err := l.setValue(level); err != nil {
+		return l.wrap(err)
+	}
+	return nil
+}
+
+// PWM implements gpio.PinOut.
+//
+// This is not supported on the GPIO character device interface.
+func (l *Line) PWM(gpio.Duty, physic.Frequency) error {
+	return l.wrap(errors.New("pwm is not supported via gpiochip"))
+}
+
+// Debounce sets the debounce period that the kernel applies to the line while
+// it is used as input.
+//
+// It takes effect immediately if the line is already an input, otherwise on
+// the next In() call. Use 0 to disable debouncing.
+//
+// This requires the GPIO character device uAPI v2, available since Linux
+// 5.10.
+func (l *Line) Debounce(d time.Duration) error {
+	if d < 0 || d/time.Microsecond > 0xFFFFFFFF {
+		return l.wrap(fmt.Errorf("invalid debounce period %s", d))
+	}
+	if l.chip.v1 {
+		if d != 0 {
+			return l.wrap(errors.New("debounce requires the GPIO character device uAPI v2"))
+		}
+		// Debouncing is never enabled on v1, there's nothing to change. Do not
+		// request the line again, as it would lose the edge configuration.
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.debounce = d
+	if l.direction == dIn {
+		if err := l.request(l.inFlags(), gpio.Low); err != nil {
+			return l.wrap(err)
+		}
+	}
+	return nil
+}
+
+//
+
+// inFlags returns the flags matching the current input configuration.
+//
+// lock must be held.
+func (l *Line) inFlags() uint64 {
+	flags := lineFlagInput
+	switch l.pull {
+	case gpio.Float:
+		flags |= lineFlagBiasDisabled
+	case gpio.PullDown:
+		flags |= lineFlagBiasPullDown
+	case gpio.PullUp:
+		flags |= lineFlagBiasPullUp
+	}
+	if l.edge == gpio.RisingEdge || l.edge == gpio.BothEdges {
+		flags |= lineFlagEdgeRising
+	}
+	if l.edge == gpio.FallingEdge || l.edge == gpio.BothEdges {
+		flags |= lineFlagEdgeFalling
+	}
+	return flags
+}
+
+// request requests the line from the kernel with the specified flags or
+// reconfigures it if it was already requested.
+//
+// value is the initial value when flags specifies an output.
+//
+// lock must be held.
+func (l *Line) request(flags uint64, value gpio.Level) error {
+	if l.chip.v1 {
+		return l.requestV1(flags, value)
+	}
+	var cfg gpioV2LineConfig
+	cfg.flags = flags
+	if flags&lineFlagOutput != 0 && value == gpio.High {
+		cfg.attrs[cfg.numAttrs] = gpioV2LineConfigAttribute{
+			attr: gpioV2LineAttribute{id: lineAttrIDOutputValues, value: 1},
+			mask: 1,
+		}
+		cfg.numAttrs++
+	}
+	if flags&lineFlagInput != 0 && l.debounce != 0 {
+		cfg.attrs[cfg.numAttrs] = gpioV2LineConfigAttribute{
+			attr: gpioV2LineAttribute{id: lineAttrIDDebounce, value: uint64(l.debounce / time.Microsecond)},
+			mask: 1,
+		}
+		cfg.numAttrs++
+	}
+	if l.f != nil {
+		// The line is already owned by this process; reconfigure it in place,
+		// which is glitch free.
+		return l.f.Ioctl(gpioV2LineSetConfigIoctl, uintptr(unsafe.Pointer(&cfg)))
+	}
+	req := gpioV2LineRequest{config: cfg, numLines: 1}
+	req.offsets[0] = l.offset
+	copy(req.consumer[:], consumer)
+	if err := l.chip.ioctl(gpioV2GetLineIoctl, uintptr(unsafe.Pointer(&req))); err != nil {
+		return err
+	}
+	// The v2 line request always supports polling for events.
+	return l.open(uintptr(req.fd), true)
+}
+
+// requestV1 requests the line using the legacy uAPI v1.
+//
+// The v1 interface can't reconfigure a line requested for events, so the line
+// is always released and requested again.
+//
+// lock must be held.
+func (l *Line) requestV1(flags uint64, value gpio.Level) error {
+	if err := l.release(); err != nil {
+		return err
+	}
+	hflags := v1HandleFlags(flags)
+	if flags&(lineFlagEdgeRising|lineFlagEdgeFalling) == 0 {
+		req := gpioHandleRequest{flags: hflags, lines: 1}
+		req.lineOffsets[0] = l.offset
+		if value == gpio.High {
+			req.defaultValues[0] = 1
+		}
+		copy(req.consumerLabel[:], consumer)
+		if err := l.chip.ioctl(gpioGetLineHandleIoctl, uintptr(unsafe.Pointer(&req))); err != nil {
+			return err
+		}
+		// Line handles do not support polling.
+		return l.open(uintptr(req.fd), false)
+	}
+	req := gpioEventRequest{lineOffset: l.offset, handleFlags: hflags}
+	if flags&lineFlagEdgeRising != 0 {
+		req.eventFlags |= gpioEventRequestRisingEdge
+	}
+	if flags&lineFlagEdgeFalling != 0 {
+		req.eventFlags |= gpioEventRequestFallingEdge
+	}
+	copy(req.consumerLabel[:], consumer)
+	if err := l.chip.ioctl(gpioGetLineEventIoctl, uintptr(unsafe.Pointer(&req))); err != nil {
+		return err
+	}
+	return l.open(uintptr(req.fd), true)
+}
+
+// open wraps the file descriptor returned by the kernel.
+//
+// lock must be held.
+func (l *Line) open(fd uintptr, poll bool) error {
+	l.f = newLineFile(fd, fmt.Sprintf("%s:%d", l.chip.name, l.offset))
+	l.lastSeqno = 0
+	if poll {
+		if err := l.event.MakeReadEvent(l.f.Fd()); err != nil {
+			_ = l.release()
+			return err
+		}
+	}
+	return nil
+}
+
+// release releases the line back to the kernel.
+//
+// lock must be held.
+func (l *Line) release() error {
+	if l.f == nil {
+		return nil
+	}
+	err := l.event.Close()
+	if err2 := l.f.Close(); err == nil {
+		err = err2
+	}
+	l.f = nil
+	l.direction = dUnknown
+	l.edge = gpio.NoEdge
+	return err
+}
+
+// read returns the current level of the line.
+//
+// lock must be held.
+func (l *Line) read() gpio.Level {
+	if l.f == nil {
+		return gpio.Low
+	}
+	if l.chip.v1 {
+		var data gpioHandleData
+		if err := l.f.Ioctl(gpioHandleGetLineValuesIoctl, uintptr(unsafe.Pointer(&data))); err != nil {
+			return gpio.Low
+		}
+		return data.values[0] != 0
+	}
+	values := gpioV2LineValues{mask: 1}
+	if err := l.f.Ioctl(gpioV2LineGetValuesIoctl, uintptr(unsafe.Pointer(&values))); err != nil {
+		return gpio.Low
+	}
+	return values.bits&1 != 0
+}
+
+// setValue sets the level of a line already requested as output.
+//
+// lock must be held.
+func (l *Line) setValue(level gpio.Level) error {
+	if l.chip.v1 {
+		var data gpioHandleData
+		if level == gpio.High {
+			data.values[0] = 1
+		}
+		return l.f.Ioctl(gpioHandleSetLineValuesIoctl, uintptr(unsafe.Pointer(&data)))
+	}
+	values := gpioV2LineValues{mask: 1}
+	if level == gpio.High {
+		values.bits = 1
+	}
+	return l.f.Ioctl(gpioV2LineSetValuesIoctl, uintptr(unsafe.Pointer(&values)))
+}
+
+// readEvent reads one edge event queued by the kernel.
+//
+// It returns the number of edges the kernel dropped since the previous event
+// because its queue was full. This is only detected with the uAPI v2.
+//
+// lock must be held.
+func (l *Line) readEvent() (uint32, error) {
+	if l.f == nil {
+		return 0, errors.New("line is not requested")
+	}
+	var id, missed uint32
+	var ts uint64
+	if l.chip.v1 {
+		var ev gpioEventData
+		if err := readFull(l.f, (*[unsafe.Sizeof(ev)]byte)(unsafe.Pointer(&ev))[:]); err != nil {
+			return 0, err
+		}
+		id, ts = ev.id, ev.timestamp
+	} else {
+		var ev gpioV2LineEvent
+		if err := readFull(l.f, (*[unsafe.Sizeof(ev)]byte)(unsafe.Pointer(&ev))[:]); err != nil {
+			return 0, err
+		}
+		id, ts = ev.id, ev.timestampNs
+		// The sequence number starts at 1 for each request.
+		if ev.lineSeqno > l.lastSeqno+1 {
+			missed = ev.lineSeqno - l.lastSeqno - 1
+		}
+		l.lastSeqno = ev.lineSeqno
+	}
+	l.lastLevel = id == lineEventRisingEdge
+	l.lastTime = kernelTime(ts)
+	return missed, nil
+}
+
+func (l *Line) wrap(err error) error {
+	return fmt.Errorf("sysfs-gpiochip (%s): %v", l, err)
+}
+
+// lineInfo is the information about a line as returned by the kernel,
+// normalized to the uAPI v2 flags.
+type lineInfo struct {
+	name     string
+	consumer string
+	flags    uint64
+}
+
+// lineInfo queries the kernel for information about a line.
+func (c *GPIOChip) lineInfo(offset uint32) (lineInfo, error) {
+	if !c.v1 {
+		info := gpioV2LineInfo{offset: offset}
+		if err := c.ioctl(gpioV2GetLineInfoIoctl, uintptr(unsafe.Pointer(&info))); err != nil {
+			return lineInfo{}, err
+		}
+		return lineInfo{name: cString(info.name[:]), consumer: cString(info.consumer[:]), flags: info.flags}, nil
+	}
+	info := gpioLineInfo{lineOffset: offset}
+	if err := c.ioctl(gpioGetLineInfoIoctl, uintptr(unsafe.Pointer(&info))); err != nil {
+		return lineInfo{}, err
+	}
+	return lineInfo{name: cString(info.name[:]), consumer: cString(info.consumer[:]), flags: v1InfoFlags(info.flags)}, nil
+}
+
+func (c *GPIOChip) ioctl(op uint, data uintptr) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.f.Ioctl(op, data)
+}
+
+// newGPIOChip opens a GPIO character device and enumerates its lines.
+func newGPIOChip(number int) (*GPIOChip, error) {
+	path := fmt.Sprintf("/dev/gpiochip%d", number)
+	f, err := ioctlOpen(path, os.O_RDWR)
+	if err != nil {
+		if os.IsPermission(err) {
+			return nil, fmt.Errorf("sysfs-gpiochip: need more access, try as root or setup udev rules: %v", err)
+		}
+		return nil, fmt.Errorf("sysfs-gpiochip: %v", err)
+	}
+	c := &GPIOChip{number: number, f: f}
+	var info gpioChipInfo
+	if err := c.f.Ioctl(gpioGetChipInfoIoctl, uintptr(unsafe.Pointer(&info))); err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("sysfs-gpiochip: %s: %v", path, err)
+	}
+	c.name = cString(info.name[:])
+	c.label = cString(info.label[:])
+	if len(c.name) == 0 {
+		c.name = filepath.Base(path)
+	}
+	c.lines = make([]*Line, info.lines)
+	for i := range c.lines {
+		li, err := c.lineInfo(uint32(i))
+		if i == 0 && err != nil {
+			// Kernels before 5.10 only support the uAPI v1.
+			c.v1 = true
+			li, err = c.lineInfo(uint32(i))
+		}
+		if err != nil {
+			_ = f.Close()
+			return nil, fmt.Errorf("sysfs-gpiochip: %s: %v", path, err)
+		}
+		c.lines[i] = &Line{
+			chip:   c,
+			offset: uint32(i),
+			name:   c.name + "_" + strconv.Itoa(i),
+			label:  li.name,
+		}
+	}
+	return c, nil
+}
+
+// kernelTime converts a GPIO event timestamp into a time.Time.
+//
+// The kernel uses CLOCK_MONOTONIC since Linux 5.7 and CLOCK_REALTIME before.
+// Since the monotonic clock is the time since boot, a value that is more than
+// 30 years is necessarily the realtime clock.
+func kernelTime(ns uint64) time.Time {
+	if ns >= 1e18 {
+		return time.Unix(0, int64(ns))
+	}
+	now := time.Now()
+	m, err := monotonicNow()
+	if err != nil {
+		return now
+	}
+	return now.Add(time.Duration(ns) - m)
+}
+
+// readFull reads exactly len(b) bytes; the kernel returns whole records.
+func readFull(r io.Reader, b []byte) error {
+	n, err := r.Read(b)
+	if err != nil {
+		return err
+	}
+	if n != len(b) {
+		return fmt.Errorf("short read: %d bytes; expected %d", n, len(b))
+	}
+	return nil
+}
+
+// cString converts a NUL terminated C string buffer into a Go string.
+func cString(b []byte) string {
+	for i, c := range b {
+		if c == 0 {
+			return string(b[:i])
+		}
+	}
+	return string(b)
+}
+
+// v1HandleFlags converts uAPI v2 line flags into uAPI v1 handle flags.
+func v1HandleFlags(flags uint64) uint32 {
+	var out uint32
+	if flags&lineFlagInput != 0 {
+		out |= gpioHandleRequestInput
+	}
+	if flags&lineFlagOutput != 0 {
+		out |= gpioHandleRequestOutput
+	}
+	if flags&lineFlagActiveLow != 0 {
+		out |= gpioHandleRequestActiveLow
+	}
+	if flags&lineFlagOpenDrain != 0 {
+		out |= gpioHandleRequestOpenDrain
+	}
+	if flags&lineFlagOpenSource != 0 {
+		out |= gpioHandleRequestOpenSource
+	}
+	if flags&lineFlagBiasPullUp != 0 {
+		out |= gpioHandleRequestBiasPullUp
+	}
+	if flags&lineFlagBiasPullDown != 0 {
+		out |= gpioHandleRequestBiasPullDown
+	}
+	if flags&lineFlagBiasDisabled != 0 {
+		out |= gpioHandleRequestBiasDisable
+	}
+	return out
+}
+
+// v1InfoFlags converts uAPI v1 line info flags into uAPI v2 line flags.
+func v1InfoFlags(flags uint32) uint64 {
+	var out uint64
+	if flags&gpioLineFlagKernel != 0 {
+		out |= lineFlagUsed
+	}
+	if flags&gpioLineFlagIsOut != 0 {
+		out |= lineFlagOutput
+	} else {
+		out |= lineFlagInput
+	}
+	if flags&gpioLineFlagActiveLow != 0 {
+		out |= lineFlagActiveLow
+	}
+	if flags&gpioLineFlagOpenDrain != 0 {
+		out |= lineFlagOpenDrain
+	}
+	if flags&gpioLineFlagOpenSource != 0 {
+		out |= lineFlagOpenSource
+	}
+	if flags&gpioLineFlagBiasPullUp != 0 {
+		out |= lineFlagBiasPullUp
+	}
+	if flags&gpioLineFlagBiasPullDown != 0 {
+		out |= lineFlagBiasPullDown
+	}
+	if flags&gpioLineFlagBiasDisable != 0 {
+		out |= lineFlagBiasDisabled
+	}
+	return out
+}
+
+// lineFile is the handle to a line request returned by the kernel.
+type lineFile interface {
+	Fd() uintptr
+	fs.Ioctler
+	io.Closer
+	io.Reader
+}
+
+var newLineFile = newLineFileDefault
+
+func newLineFileDefault(fd uintptr, name string) lineFile {
+	return &fs.File{File: os.NewFile(fd, name)}
+}
+
+// consumer is the label shown by the kernel for the lines requested by this
+// process.
+const consumer = "periph"
+
+// GPIO character device IOCTL control codes.
+//
+// Constants and structure definition can be found at
+// /usr/include/linux/gpio.h.
+const gpioIOCMagic uint = 0xB4
+
+var (
+	gpioGetChipInfoIoctl         = fs.IOR(gpioIOCMagic, 0x01, uint(unsafe.Sizeof(gpioChipInfo{})))       // GPIO_GET_CHIPINFO_IOCTL
+	gpioGetLineInfoIoctl         = fs.IOWR(gpioIOCMagic, 0x02, uint(unsafe.Sizeof(gpioLineInfo{})))      // GPIO_GET_LINEINFO_IOCTL
+	gpioGetLineHandleIoctl       = fs.IOWR(gpioIOCMagic, 0x03, uint(unsafe.Sizeof(gpioHandleRequest{}))) // GPIO_GET_LINEHANDLE_IOCTL
+	gpioGetLineEventIoctl        = fs.IOWR(gpioIOCMagic, 0x04, uint(unsafe.Sizeof(gpioEventRequest{})))  // GPIO_GET_LINEEVENT_IOCTL
+	gpioHandleGetLineValuesIoctl = fs.IOWR(gpioIOCMagic, 0x08, uint(unsafe.Sizeof(gpioHandleData{})))    // GPIOHANDLE_GET_LINE_VALUES_IOCTL
+	gpioHandleSetLineValuesIoctl = fs.IOWR(gpioIOCMagic, 0x09, uint(unsafe.Sizeof(gpioHandleData{})))    // GPIOHANDLE_SET_LINE_VALUES_IOCTL
+	gpioV2GetLineInfoIoctl       = fs.IOWR(gpioIOCMagic, 0x05, uint(unsafe.Sizeof(gpioV2LineInfo{})))    // GPIO_V2_GET_LINEINFO_IOCTL
+	gpioV2GetLineIoctl           = fs.IOWR(gpioIOCMagic, 0x07, uint(unsafe.Sizeof(gpioV2LineRequest{}))) // GPIO_V2_GET_LINE_IOCTL
+	gpioV2LineSetConfigIoctl     = fs.IOWR(gpioIOCMagic, 0x0D, uint(unsafe.Sizeof(gpioV2LineConfig{})))  // GPIO_V2_LINE_SET_CONFIG_IOCTL
+	gpioV2LineGetValuesIoctl     = fs.IOWR(gpioIOCMagic, 0x0E, uint(unsafe.Sizeof(gpioV2LineValues{})))  // GPIO_V2_LINE_GET_VALUES_IOCTL
+	gpioV2LineSetValuesIoctl     = fs.IOWR(gpioIOCMagic, 0x0F, uint(unsafe.Sizeof(gpioV2LineValues{})))  // GPIO_V2_LINE_SET_VALUES_IOCTL
+)
+
+// enum gpio_v2_line_flag
+const (
+	lineFlagUsed         uint64 = 1 << 0  // GPIO_V2_LINE_FLAG_USED
+	lineFlagActiveLow    uint64 = 1 << 1  // GPIO_V2_LINE_FLAG_ACTIVE_LOW
+	lineFlagInput        uint64 = 1 << 2  // GPIO_V2_LINE_FLAG_INPUT
+	lineFlagOutput       uint64 = 1 << 3  // GPIO_V2_LINE_FLAG_OUTPUT
+	lineFlagEdgeRising   uint64 = 1 << 4  // GPIO_V2_LINE_FLAG_EDGE_RISING
+	lineFlagEdgeFalling  uint64 = 1 << 5  // GPIO_V2_LINE_FLAG_EDGE_FALLING
+	lineFlagOpenDrain    uint64 = 1 << 6  // GPIO_V2_LINE_FLAG_OPEN_DRAIN
+	lineFlagOpenSource   uint64 = 1 << 7  // GPIO_V2_LINE_FLAG_OPEN_SOURCE
+	lineFlagBiasPullUp   uint64 = 1 << 8  // GPIO_V2_LINE_FLAG_BIAS_PULL_UP
+	lineFlagBiasPullDown uint64 = 1 << 9  // GPIO_V2_LINE_FLAG_BIAS_PULL_DOWN
+	lineFlagBiasDisabled uint64 = 1 << 10 // GPIO_V2_LINE_FLAG_BIAS_DISABLED
+)
+
+// enum gpio_v2_line_attr_id
+const (
+	lineAttrIDFlags        uint32 = 1 // GPIO_V2_LINE_ATTR_ID_FLAGS
+	lineAttrIDOutputValues uint32 = 2 // GPIO_V2_LINE_ATTR_ID_OUTPUT_VALUES
+	lineAttrIDDebounce     uint32 = 3 // GPIO_V2_LINE_ATTR_ID_DEBOUNCE
+)
+
+// enum gpio_v2_line_event_id; also GPIOEVENT_EVENT_* in uAPI v1.
+const (
+	lineEventRisingEdge  uint32 = 1
+	lineEventFallingEdge uint32 = 2
+)
+
+// uAPI v1 flags.
+const (
+	gpioLineFlagKernel       uint32 = 1 << 0 // GPIOLINE_FLAG_KERNEL
+	gpioLineFlagIsOut        uint32 = 1 << 1 // GPIOLINE_FLAG_IS_OUT
+	gpioLineFlagActiveLow    uint32 = 1 << 2 // GPIOLINE_FLAG_ACTIVE_LOW
+	gpioLineFlagOpenDrain    uint32 = 1 << 3 // GPIOLINE_FLAG_OPEN_DRAIN
+	gpioLineFlagOpenSource   uint32 = 1 << 4 // GPIOLINE_FLAG_OPEN_SOURCE
+	gpioLineFlagBiasPullUp   uint32 = 1 << 5 // GPIOLINE_FLAG_BIAS_PULL_UP
+	gpioLineFlagBiasPullDown uint32 = 1 << 6 // GPIOLINE_FLAG_BIAS_PULL_DOWN
+	gpioLineFlagBiasDisable  uint32 = 1 << 7 // GPIOLINE_FLAG_BIAS_DISABLE
+
+	gpioHandleRequestInput        uint32 = 1 << 0 // GPIOHANDLE_REQUEST_INPUT
+	gpioHandleRequestOutput       uint32 = 1 << 1 // GPIOHANDLE_REQUEST_OUTPUT
+	gpioHandleRequestActiveLow    uint32 = 1 << 2 // GPIOHANDLE_REQUEST_ACTIVE_LOW
+	gpioHandleRequestOpenDrain    uint32 = 1 << 3 // GPIOHANDLE_REQUEST_OPEN_DRAIN
+	gpioHandleRequestOpenSource   uint32 = 1 << 4 // GPIOHANDLE_REQUEST_OPEN_SOURCE
+	gpioHandleRequestBiasPullUp   uint32 = 1 << 5 // GPIOHANDLE_REQUEST_BIAS_PULL_UP
+	gpioHandleRequestBiasPullDown uint32 = 1 << 6 // GPIOHANDLE_REQUEST_BIAS_PULL_DOWN
+	gpioHandleRequestBiasDisable  uint32 = 1 << 7 // GPIOHANDLE_REQUEST_BIAS_DISABLE
+
+	gpioEventRequestRisingEdge  uint32 = 1 << 0 // GPIOEVENT_REQUEST_RISING_EDGE
+	gpioEventRequestFallingEdge uint32 = 1 << 1 // GPIOEVENT_REQUEST_FALLING_EDGE
+)
+
+const (
+	gpioMaxNameSize    = 32 // GPIO_MAX_NAME_SIZE
+	gpioLinesMax       = 64 // GPIO_V2_LINES_MAX and GPIOHANDLES_MAX
+	gpioLineNumAttrMax = 10 // GPIO_V2_LINE_NUM_ATTRS_MAX
+)
+
+// gpioChipInfo is struct gpiochip_info.
+type gpioChipInfo struct {
+	name  [gpioMaxNameSize]byte
+	label [gpioMaxNameSize]byte
+	lines uint32
+}
+
+// gpioV2LineAttribute is struct gpio_v2_line_attribute.
+//
+// value is a union of flags, values and debounce_period_us.
+type gpioV2LineAttribute struct {
+	id      uint32
+	padding uint32
+	value   uint64
+}
+
+// gpioV2LineConfigAttribute is struct gpio_v2_line_config_attribute.
+type gpioV2LineConfigAttribute struct {
+	attr gpioV2LineAttribute
+	mask uint64
+}
+
+// gpioV2LineConfig is struct gpio_v2_line_config.
+type gpioV2LineConfig struct {
+	flags    uint64
+	numAttrs uint32
+	padding  [5]uint32
+	attrs    [gpioLineNumAttrMax]gpioV2LineConfigAttribute
+}
+
+// gpioV2LineRequest is struct gpio_v2_line_request.
+type gpioV2LineRequest struct {
+	offsets         [gpioLinesMax]uint32
+	consumer        [gpioMaxNameSize]byte
+	config          gpioV2LineConfig
+	numLines        uint32
+	eventBufferSize uint32
+	padding         [5]uint32
+	fd              int32
+}
+
+// gpioV2LineValues is struct gpio_v2_line_values.
+type gpioV2LineValues struct {
+	bits uint64
+	mask uint64
+}
+
+// gpioV2LineInfo is struct gpio_v2_line_info.
+type gpioV2LineInfo struct {
+	name     [gpioMaxNameSize]byte
+	consumer [gpioMaxNameSize]byte
+	offset   uint32
+	numAttrs uint32
+	flags    uint64
+	attrs    [gpioLineNumAttrMax]gpioV2LineAttribute
+	padding  [4]uint32
+}
+
+// gpioV2LineEvent is struct gpio_v2_line_event.
+type gpioV2LineEvent struct {
+	timestampNs uint64
+	id          uint32
+	offset      uint32
+	seqno       uint32
+	lineSeqno   uint32
+	padding     [6]uint32
+}
+
+// gpioLineInfo is struct gpioline_info.
+type gpioLineInfo struct {
+	lineOffset uint32
+	flags      uint32
+	name       [gpioMaxNameSize]byte
+	consumer   [gpioMaxNameSize]byte
+}
+
+// gpioHandleRequest is struct gpiohandle_request.
+type gpioHandleRequest struct {
+	lineOffsets   [gpioLinesMax]uint32
+	flags         uint32
+	defaultValues [gpioLinesMax]uint8
+	consumerLabel [gpioMaxNameSize]byte
+	lines         uint32
+	fd            int32
+}
+
+// gpioHandleData is struct gpiohandle_data.
+type gpioHandleData struct {
+	values [gpioLinesMax]uint8
+}
+
+// gpioEventRequest is struct gpioevent_request.
+type gpioEventRequest struct {
+	lineOffset    uint32
+	handleFlags   uint32
+	eventFlags    uint32
+	consumerLabel [gpioMaxNameSize]byte
+	fd            int32
+}
+
+// gpioEventData is struct gpioevent_data.
+type gpioEventData struct {
+	timestamp uint64
+	id        uint32
+	padding   uint32
+}
+
+// driverGPIOChip implements periph.Driver.
+type driverGPIOChip struct {
+}
+
+func (d *driverGPIOChip) String() string {
+	return "sysfs-gpiochip"
+}
+
+func (d *driverGPIOChip) Prerequisites() []string {
+	return nil
+}
+
+// After returns the drivers registering GPIO names, so the line names
+// provided by the kernel are only registered as aliases when these drivers
+// didn't register these names.
+func (d *driverGPIOChip) After() []string {
+	return []string{"allwinner-gpio", "allwinner-gpio-pl", "bcm283x-gpio", "sysfs-gpio"}
+}
+
+// Init initializes the GPIO character device handling code.
+//
+// Uses the GPIO character device as described at
+// https://www.kernel.org/doc/html/latest/userspace-api/gpio/chardev.html
+//
+// This is the replacement of GPIO sysfs, which is deprecated. It supports
+// setting the internal pull resistor and timestamps the edges in the kernel.
+func (d *driverGPIOChip) Init() (bool, error) {
+	prefix := "/dev/gpiochip"
+	items, err := filepath.Glob(prefix + "*")
+	if err != nil {
+		return true, err
+	}
+	if len(items) == 0 {
+		return false, errors.New("no GPIO chip found")
+	}
+	var numbers []int
+	for _, item := range items {
+		if n, err := strconv.Atoi(item[len(prefix):]); err == nil {
+			numbers = append(numbers, n)
+		}
+	}
+	// Make sure they are registered in order.
+	sort.Ints(numbers)
+	for _, n := range numbers {
+		c, err := newGPIOChip(n)
+		if err != nil {
+			return true, err
+		}
+		GPIOChips = append(GPIOChips, c)
+		if err := registerLines(c); err != nil {
+			return true, err
+		}
+	}
+	return true, nil
+}
+
+// registerLines registers the lines of a chip in gpioreg.
+func registerLines(c *GPIOChip) error {
+	for _, l := range c.lines {
+		if err := gpioreg.Register(l); err != nil {
+			return err
+		}
+	}
+	for _, l := range c.lines {
+		// Only use the name provided by the kernel if it's not already used, as
+		// it is not guaranteed to be unique. This happens when sysfs-gpio or a
+		// CPU driver registered the same name.
+		if len(l.label) != 0 && gpioreg.ByName(l.label) == nil {
+			if err := gpioreg.RegisterAlias(l.label, l.name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func init() {
+	if isLinux {
+		periph.MustRegister(&drvGPIOChip)
+	}
+}
+
+var drvGPIOChip driverGPIOChip
+
+var _ conn.Resource = &Line{}
+var _ gpio.PinIn = &Line{}
+var _ gpio.PinOut = &Line{}
+var _ gpio.PinIO = &Line{}
+var _ gpio.EdgeWaiter = &Line{}
+var _ gpio.EdgeStreamer = &Line{}
+var _ pin.PinFunc = &Line{}
@@ -0,0 +1,477 @@
+// Copyright 2019 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package sysfs
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+	"unsafe"
+
+	"periph.io/x/periph/conn/gpio"
+)
+
+func TestGPIOChip_Lines(t *testing.T) {
+	defer resetGPIOChip()
+	chip := &fakeChip{lines: []string{"GPIO0", ""}}
+	c := newFakeGPIOChip(t, chip)
+	if s := c.String(); s != "gpiochip0" {
+		t.Fatal(s)
+	}
+	if s := c.Label(); s != "fake-label" {
+		t.Fatal(s)
+	}
+	if c.v1 {
+		t.Fatal("expected v2")
+	}
+	lines := c.Lines()
+	if len(lines) != 2 {
+		t.Fatal(len(lines))
+	}
+	if s := lines[0].Name(); s != "gpiochip0_0" {
+		t.Fatal(s)
+	}
+	if lines[0].label != "GPIO0" || lines[1].label != "" {
+		t.Fatal(lines[0].label, lines[1].label)
+	}
+	if n := lines[1].Number(); n != 1 {
+		t.Fatal(n)
+	}
+	if f := lines[0].Func(); f != gpio.IN {
+		t.Fatal(f)
+	}
+}
+
+func TestGPIOChip_V1(t *testing.T) {
+	defer resetGPIOChip()
+	chip := &fakeChip{lines: []string{"GPIO0"}, v1: true}
+	c := newFakeGPIOChip(t, chip)
+	if !c.v1 {
+		t.Fatal("expected v1")
+	}
+	l := c.Lines()[0]
+	if l.label != "GPIO0" {
+		t.Fatal(l.label)
+	}
+	if err := l.Out(gpio.High); err != nil {
+		t.Fatal(err)
+	}
+	if chip.lastOp != gpioGetLineHandleIoctl {
+		t.Fatal("expected line handle request")
+	}
+	if err := l.Debounce(time.Millisecond); err == nil {
+		t.Fatal("debounce is not supported on v1")
+	}
+	// Disabling debounce keeps the input configuration.
+	if err := l.In(gpio.PullNoChange, gpio.RisingEdge); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Debounce(0); err != nil {
+		t.Fatal(err)
+	}
+	if l.direction != dIn || l.edge != gpio.RisingEdge {
+		t.Fatal(l.direction, l.edge)
+	}
+	if err := l.Halt(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGPIOChip_Err(t *testing.T) {
+	defer resetGPIOChip()
+	ioctlOpen = func(path string, flag int) (ioctlCloser, error) {
+		return nil, errors.New("foo")
+	}
+	if _, err := newGPIOChip(0); err == nil || err.Error() != "sysfs-gpiochip: foo" {
+		t.Fatal(err)
+	}
+}
+
+func TestLine_Out(t *testing.T) {
+	defer resetGPIOChip()
+	chip := &fakeChip{lines: []string{"GPIO0"}}
+	l := newFakeGPIOChip(t, chip).Lines()[0]
+	if err := l.Out(gpio.High); err != nil {
+		t.Fatal(err)
+	}
+	if chip.lastOp != gpioV2GetLineIoctl {
+		t.Fatal("expected line request")
+	}
+	if chip.req.config.flags != lineFlagOutput || chip.req.config.numAttrs != 1 || chip.req.config.attrs[0].attr.value != 1 {
+		t.Fatalf("%#v", chip.req.config)
+	}
+	if l.Read() != gpio.High {
+		t.Fatal("expected high")
+	}
+	if f := l.Func(); f != gpio.OUT_HIGH {
+		t.Fatal(f)
+	}
+	if err := l.Out(gpio.Low); err != nil {
+		t.Fatal(err)
+	}
+	if l.Read() != gpio.Low {
+		t.Fatal("expected low")
+	}
+	if err := l.PWM(gpio.DutyHalf, 0); err == nil {
+		t.Fatal("pwm is not supported")
+	}
+	if err := l.Halt(); err != nil {
+		t.Fatal(err)
+	}
+	if l.f != nil {
+		t.Fatal("expected release")
+	}
+}
+
+func TestLine_In(t *testing.T) {
+	defer resetGPIOChip()
+	chip := &fakeChip{lines: []string{"GPIO0"}}
+	l := newFakeGPIOChip(t, chip).Lines()[0]
+	if err := l.In(gpio.PullUp, gpio.NoEdge); err != nil {
+		t.Fatal(err)
+	}
+	if chip.req.config.flags != lineFlagInput|lineFlagBiasPullUp {
+		t.Fatal(chip.req.config.flags)
+	}
+	if p := l.Pull(); p != gpio.PullUp {
+		t.Fatal(p)
+	}
+	if err := l.In(gpio.PullDown, gpio.NoEdge); err != nil {
+		t.Fatal(err)
+	}
+	// The line was reconfigured in place.
+	if chip.lastOp != gpioV2GetLineIoctl || chip.nbRequests != 1 {
+		t.Fatal("expected reconfiguration")
+	}
+	if l.f.(*fakeLine).cfg.flags != lineFlagInput|lineFlagBiasPullDown {
+		t.Fatal(l.f.(*fakeLine).cfg.flags)
+	}
+	if err := l.Debounce(10 * time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	cfg := l.f.(*fakeLine).cfg
+	if cfg.numAttrs != 1 || cfg.attrs[0].attr.id != lineAttrIDDebounce || cfg.attrs[0].attr.value != 10000 {
+		t.Fatalf("%#v", cfg)
+	}
+	if err := l.Debounce(-1); err == nil {
+		t.Fatal("invalid debounce")
+	}
+	if err := l.In(gpio.Pull(100), gpio.NoEdge); err == nil {
+		t.Fatal("invalid pull")
+	}
+	if err := l.In(gpio.PullNoChange, gpio.Edge(100)); err == nil {
+		t.Fatal("invalid edge")
+	}
+	if err := l.Halt(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLine_WaitForEdge(t *testing.T) {
+	defer resetGPIOChip()
+	chip := &fakeChip{lines: []string{"GPIO0"}}
+	l := newFakeGPIOChip(t, chip).Lines()[0]
+	if err := l.In(gpio.PullNoChange, gpio.BothEdges); err != nil {
+		t.Fatal(err)
+	}
+	defer l.Halt()
+	if l.WaitForEdge(0) {
+		t.Fatal("no edge queued")
+	}
+	f := l.f.(*fakeLine)
+	// A realtime timestamp is used as-is.
+	ts := time.Date(2019, 1, 2, 3, 4, 5, 6, time.UTC)
+	f.queue(t, lineEventRisingEdge, uint64(ts.UnixNano()))
+	f.queue(t, lineEventFallingEdge, uint64(ts.UnixNano()+1000))
+	if !l.WaitForEdge(time.Second) {
+		t.Fatal("expected edge")
+	}
+	if level, when := l.LastEdge(); level != gpio.High || !when.Equal(ts) {
+		t.Fatal(level, when)
+	}
+	if !l.WaitForEdge(time.Second) {
+		t.Fatal("expected edge")
+	}
+	if level, when := l.LastEdge(); level != gpio.Low || !when.Equal(ts.Add(time.Microsecond)) {
+		t.Fatal(level, when)
+	}
+	if l.WaitForEdge(0) {
+		t.Fatal("queue should be empty")
+	}
+}
+
+func TestLine_WaitForEdgeContext(t *testing.T) {
+	defer resetGPIOChip()
+	chip := &fakeChip{lines: []string{"GPIO0"}}
+	l := newFakeGPIOChip(t, chip).Lines()[0]
+	if err := l.In(gpio.PullNoChange, gpio.BothEdges); err != nil {
+		t.Fatal(err)
+	}
+	defer l.Halt()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	if _, _, err := l.WaitForEdgeContext(ctx); err != context.DeadlineExceeded {
+		t.Fatal(err)
+	}
+	f := l.f.(*fakeLine)
+	ts := time.Date(2019, 1, 2, 3, 4, 5, 6, time.UTC)
+	f.queue(t, lineEventFallingEdge, uint64(ts.UnixNano()))
+	e, when, err := l.WaitForEdgeContext(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e != gpio.FallingEdge || !when.Equal(ts) {
+		t.Fatal(e, when)
+	}
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	if _, _, err := l.WaitForEdgeContext(ctx); err != context.Canceled {
+		t.Fatal(err)
+	}
+}
+
+func TestLine_StreamEdges(t *testing.T) {
+	defer resetGPIOChip()
+	chip := &fakeChip{lines: []string{"GPIO0"}}
+	l := newFakeGPIOChip(t, chip).Lines()[0]
+	if _, err := l.StreamEdges(gpio.PullNoChange, gpio.NoEdge, 16); err == nil {
+		t.Fatal("edge detection is required")
+	}
+	s, err := l.StreamEdges(gpio.PullNoChange, gpio.BothEdges, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Halt()
+	f := l.f.(*fakeLine)
+	ts := time.Date(2019, 1, 2, 3, 4, 5, 6, time.UTC)
+	f.queueSeqno(t, lineEventRisingEdge, uint64(ts.UnixNano()), 1)
+	// The kernel dropped 2 edges.
+	f.queueSeqno(t, lineEventFallingEdge, uint64(ts.UnixNano()+1000), 4)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	expected := []gpio.EdgeEvent{
+		{Level: gpio.High, Time: ts},
+		{Level: gpio.Low, Time: ts.Add(time.Microsecond)},
+	}
+	for i, want := range expected {
+		e, err := s.Next(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if e.Level != want.Level || !e.Time.Equal(want.Time) {
+			t.Fatal(i, e)
+		}
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if m := s.Missed(); m != 2 {
+		t.Fatal(m)
+	}
+	if o := s.Overflows(); o != 0 {
+		t.Fatal(o)
+	}
+}
+
+func TestKernelTime(t *testing.T) {
+	ts := time.Date(2019, 1, 2, 3, 4, 5, 6, time.UTC)
+	if v := kernelTime(uint64(ts.UnixNano())); !v.Equal(ts) {
+		t.Fatal(v)
+	}
+	m, err := monotonicNow()
+	if err != nil {
+		t.Skip(err)
+	}
+	if d := time.Since(kernelTime(uint64(m))); d < 0 || d > time.Second {
+		t.Fatal(d)
+	}
+}
+
+func TestCString(t *testing.T) {
+	if s := cString([]byte{'a', 'b', 0, 'c'}); s != "ab" {
+		t.Fatal(s)
+	}
+	if s := cString([]byte{'a', 'b'}); s != "ab" {
+		t.Fatal(s)
+	}
+}
+
+func TestGPIOChipStructSizes(t *testing.T) {
+	// Sizes from the kernel headers, verified on amd64 and arm.
+	data := []struct {
+		name string
+		got  uintptr
+		want uintptr
+	}{
+		{"gpiochip_info", unsafe.Sizeof(gpioChipInfo{}), 68},
+		{"gpio_v2_line_config", unsafe.Sizeof(gpioV2LineConfig{}), 272},
+		{"gpio_v2_line_request", unsafe.Sizeof(gpioV2LineRequest{}), 592},
+		{"gpio_v2_line_info", unsafe.Sizeof(gpioV2LineInfo{}), 256},
+		{"gpio_v2_line_event", unsafe.Sizeof(gpioV2LineEvent{}), 48},
+		{"gpioline_info", unsafe.Sizeof(gpioLineInfo{}), 72},
+		{"gpiohandle_request", unsafe.Sizeof(gpioHandleRequest{}), 364},
+		{"gpioevent_request", unsafe.Sizeof(gpioEventRequest{}), 48},
+		{"gpioevent_data", unsafe.Sizeof(gpioEventData{}), 16},
+	}
+	for _, line := range data {
+		if line.got != line.want {
+			t.Errorf("%s: %d != %d", line.name, line.got, line.want)
+		}
+	}
+}
+
+func TestDriverGPIOChip(t *testing.T) {
+	d := driverGPIOChip{}
+	if s := d.String(); s != "sysfs-gpiochip" {
+		t.Fatal(s)
+	}
+	if s := d.Prerequisites(); s != nil {
+		t.Fatal(s)
+	}
+	if s := d.After(); len(s) != 4 || s[3] != "sysfs-gpio" {
+		t.Fatal(s)
+	}
+}
+
+//
+
+func resetGPIOChip() {
+	reset()
+	newLineFile = newLineFileDefault
+}
+
+func newFakeGPIOChip(t *testing.T, chip *fakeChip) *GPIOChip {
+	ioctlOpen = func(path string, flag int) (ioctlCloser, error) {
+		if path != "/dev/gpiochip0" {
+			t.Fatal(path)
+		}
+		return chip, nil
+	}
+	newLineFile = func(fd uintptr, name string) lineFile {
+		r, w, err := os.Pipe()
+		if err != nil {
+			t.Fatal(err)
+		}
+		l := &fakeLine{r: r, w: w}
+		if chip.v1 {
+			l.v1 = true
+		} else {
+			l.cfg = chip.req.config
+			l.bits = l.cfg.attrs[0].attr.value
+		}
+		return l
+	}
+	c, err := newGPIOChip(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+// fakeChip emulates /dev/gpiochip0.
+type fakeChip struct {
+	lines      []string
+	v1         bool
+	lastOp     uint
+	nbRequests int
+	req        gpioV2LineRequest
+}
+
+func (f *fakeChip) Close() error {
+	return nil
+}
+
+func (f *fakeChip) Ioctl(op uint, data uintptr) error {
+	f.lastOp = op
+	switch op {
+	case gpioGetChipInfoIoctl:
+		info := (*gpioChipInfo)(ptr(data))
+		copy(info.name[:], "gpiochip0")
+		copy(info.label[:], "fake-label")
+		info.lines = uint32(len(f.lines))
+	case gpioV2GetLineInfoIoctl:
+		if f.v1 {
+			return errors.New("inappropriate ioctl for device")
+		}
+		info := (*gpioV2LineInfo)(ptr(data))
+		copy(info.name[:], f.lines[info.offset])
+		info.flags = lineFlagInput
+	case gpioGetLineInfoIoctl:
+		info := (*gpioLineInfo)(ptr(data))
+		copy(info.name[:], f.lines[info.lineOffset])
+	case gpioV2GetLineIoctl:
+		f.req = *(*gpioV2LineRequest)(ptr(data))
+		f.nbRequests++
+		(*gpioV2LineRequest)(ptr(data)).fd = 100
+	case gpioGetLineHandleIoctl:
+		(*gpioHandleRequest)(ptr(data)).fd = 100
+	case gpioGetLineEventIoctl:
+		(*gpioEventRequest)(ptr(data)).fd = 100
+	default:
+		return errors.New("unexpected ioctl")
+	}
+	return nil
+}
+
+// fakeLine emulates a line request file descriptor. Events are queued in a
+// pipe so they can be polled.
+type fakeLine struct {
+	r, w *os.File
+	v1   bool
+	cfg  gpioV2LineConfig
+	bits uint64
+}
+
+func (f *fakeLine) Fd() uintptr {
+	return f.r.Fd()
+}
+
+func (f *fakeLine) Read(b []byte) (int, error) {
+	return f.r.Read(b)
+}
+
+func (f *fakeLine) Close() error {
+	_ = f.w.Close()
+	return f.r.Close()
+}
+
+func (f *fakeLine) Ioctl(op uint, data uintptr) error {
+	switch op {
+	case gpioV2LineSetConfigIoctl:
+		f.cfg = *(*gpioV2LineConfig)(ptr(data))
+	case gpioV2LineGetValuesIoctl:
+		v := (*gpioV2LineValues)(ptr(data))
+		v.bits = f.bits & v.mask
+	case gpioV2LineSetValuesIoctl:
+		v := (*gpioV2LineValues)(ptr(data))
+		f.bits = (f.bits &^ v.mask) | (v.bits & v.mask)
+	case gpioHandleGetLineValuesIoctl:
+		(*gpioHandleData)(ptr(data)).values[0] = uint8(f.bits)
+	case gpioHandleSetLineValuesIoctl:
+		f.bits = uint64((*gpioHandleData)(ptr(data)).values[0])
+	default:
+		return errors.New("unexpected ioctl")
+	}
+	return nil
+}
+
+func (f *fakeLine) queue(t *testing.T, id uint32, ts uint64) {
+	f.queueSeqno(t, id, ts, 0)
+}
+
+func (f *fakeLine) queueSeqno(t *testing.T, id uint32, ts uint64, seqno uint32) {
+	ev := gpioV2LineEvent{timestampNs: ts, id: id, lineSeqno: seqno}
+	if _, err := f.w.Write((*[unsafe.Sizeof(ev)]byte)(unsafe.Pointer(&ev))[:]); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// ptr converts the ioctl argument back into the pointer passed by the code
+// under test.
+func ptr(data uintptr) unsafe.Pointer {
+	return *(*unsafe.Pointer)(unsafe.Pointer(&data))
+}
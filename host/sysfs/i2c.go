@@ -5,6 +5,7 @@
 package sysfs
 
 import (
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"os"
@@ -20,7 +21,9 @@ import (
 	"periph.io/x/periph/conn/gpio/gpioreg"
 	"periph.io/x/periph/conn/i2c"
 	"periph.io/x/periph/conn/i2c/i2creg"
+	"periph.io/x/periph/conn/i2c/smbus"
 	"periph.io/x/periph/conn/physic"
+	"periph.io/x/periph/conn/pin"
 )
 
 // I2CSetSpeedHook can be set by a driver to enable changing the I²C buses
@@ -90,8 +93,9 @@ func (i *I2C) String() string {
 
 // Tx execute a transaction as a single operation unit.
 func (i *I2C) Tx(addr uint16, w, r []byte) error {
-	if addr >= 0x400 || (addr >= 0x80 && i.fn&func10BitAddr == 0) {
-		return errors.New("sysfs-i2c: invalid address")
+	a, flags, err := i.checkAddr(addr, 0)
+	if err != nil {
+		return err
 	}
 	if len(w) == 0 && len(r) == 0 {
 		return nil
@@ -102,15 +106,16 @@ func (i *I2C) Tx(addr uint16, w, r []byte) error {
 	msgs := buf[0:0]
 	if len(w) != 0 {
 		msgs = buf[:1]
-		buf[0].addr = addr
+		buf[0].addr = a
+		buf[0].flags = flags
 		buf[0].length = uint16(len(w))
 		buf[0].buf = uintptr(unsafe.Pointer(&w[0]))
 	}
 	if len(r) != 0 {
 		l := len(msgs)
 		msgs = msgs[:l+1] // extend the slice by one
-		buf[l].addr = addr
-		buf[l].flags = flagRD
+		buf[l].addr = a
+		buf[l].flags = flags | flagRD
 		buf[l].length = uint16(len(r))
 		buf[l].buf = uintptr(unsafe.Pointer(&r[0]))
 	}
@@ -127,6 +132,169 @@ func (i *I2C) Tx(addr uint16, w, r []byte) error {
 	return nil
 }
 
+// TxMsgs implements i2c.MsgBus.
+//
+// All the messages are sent in a single I2C_RDWR ioctl.
+func (i *I2C) TxMsgs(msgs []i2c.Msg) error {
+	if len(msgs) == 0 {
+		return nil
+	}
+	if len(msgs) > rdwrMaxMsgs {
+		return fmt.Errorf("sysfs-i2c: too many messages; maximum is %d", rdwrMaxMsgs)
+	}
+	buf := make([]i2cMsg, len(msgs))
+	for j := range msgs {
+		m := &msgs[j]
+		if err := i.checkMsgFlags(m.Flags); err != nil {
+			return err
+		}
+		a, flags, err := i.checkAddr(m.Addr, m.Flags)
+		if err != nil {
+			return err
+		}
+		buf[j].addr = a
+		buf[j].flags = flags | msgFlags(m.Flags)
+		buf[j].length = uint16(len(m.Buf))
+		if len(m.Buf) != 0 {
+			buf[j].buf = uintptr(unsafe.Pointer(&m.Buf[0]))
+		}
+	}
+	p := rdwrIoctlData{
+		msgs:  uintptr(unsafe.Pointer(&buf[0])),
+		nmsgs: uint32(len(buf)),
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if err := i.f.Ioctl(ioctlRdwr, uintptr(unsafe.Pointer(&p))); err != nil {
+		return fmt.Errorf("sysfs-i2c: %v", err)
+	}
+	return nil
+}
+
+// SMBusSupported implements smbus.Bus.
+//
+// It returns true when the adapter advertises the transaction in its
+// functionality bits.
+func (i *I2C) SMBusSupported(op smbus.Op, pec bool) bool {
+	if pec && i.fn&funcSMBusPEC == 0 {
+		return false
+	}
+	f, ok := smbusFuncs[op]
+	return ok && i.fn&f != 0
+}
+
+// SMBusTx implements smbus.Bus.
+//
+// It uses the I2C_SMBUS ioctl, which lets the kernel use the adapter's native
+// SMBus support.
+//
+// The device is selected with the I2C_SLAVE ioctl, which fails with EBUSY when
+// a kernel driver is bound to the address. Tx() works in this case since
+// I2C_RDWR doesn't check it; use an smbus.Dev over a bus that doesn't
+// implement smbus.Bus to emulate the transaction, or unbind the kernel driver.
+func (i *I2C) SMBusTx(addr uint16, op smbus.Op, cmd byte, w, r []byte, pec bool) (int, error) {
+	a, flags, err := i.checkAddr(addr, 0)
+	if err != nil {
+		return 0, err
+	}
+	var tenBit uintptr
+	if flags&flagTEN != 0 {
+		tenBit = 1
+	}
+	if len(w) > smbus.MaxBlockSize {
+		return 0, errors.New("sysfs-i2c: block too long")
+	}
+	// Union i2c_smbus_data; the word is in host endianness.
+	var data [smbus.MaxBlockSize + 2]byte
+	p := smbusIoctlData{command: cmd, data: uintptr(unsafe.Pointer(&data[0]))}
+	var nw, nr int // minimum lengths of w and r
+	switch op {
+	case smbus.QuickWrite:
+		p.size = smbusQuick
+	case smbus.QuickRead:
+		p.readWrite = smbusRead
+		p.size = smbusQuick
+	case smbus.SendByte:
+		p.size = smbusByte
+	case smbus.ReceiveByte:
+		p.readWrite = smbusRead
+		p.size = smbusByte
+		nr = 1
+	case smbus.WriteByteData:
+		p.size = smbusByteData
+		nw = 1
+	case smbus.ReadByteData:
+		p.readWrite = smbusRead
+		p.size = smbusByteData
+		nr = 1
+	case smbus.WriteWordData:
+		p.size = smbusWordData
+		nw = 2
+	case smbus.ReadWordData:
+		p.readWrite = smbusRead
+		p.size = smbusWordData
+		nr = 2
+	case smbus.ProcessCall:
+		p.size = smbusProcCall
+		nw = 2
+		nr = 2
+	case smbus.WriteBlockData:
+		p.size = smbusBlockData
+	case smbus.ReadBlockData:
+		p.readWrite = smbusRead
+		p.size = smbusBlockData
+	case smbus.BlockProcessCall:
+		p.size = smbusBlockProcCall
+	default:
+		return 0, errors.New("sysfs-i2c: unsupported SMBus " + op.String())
+	}
+	if len(w) < nw || len(r) < nr {
+		return 0, fmt.Errorf("sysfs-i2c: SMBus %s requires %d bytes to write and %d bytes to read", op, nw, nr)
+	}
+	switch op {
+	case smbus.WriteByteData:
+		data[0] = w[0]
+	case smbus.WriteWordData, smbus.ProcessCall:
+		nativeEndian.PutUint16(data[:2], binary.LittleEndian.Uint16(w))
+	case smbus.WriteBlockData, smbus.BlockProcessCall:
+		data[0] = byte(len(w))
+		copy(data[1:], w)
+	}
+	var enable uintptr
+	if pec {
+		enable = 1
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if err := i.f.Ioctl(ioctlTenBits, tenBit); err != nil {
+		return 0, fmt.Errorf("sysfs-i2c: %v", err)
+	}
+	if err := i.f.Ioctl(ioctlSlave, uintptr(a)); err != nil {
+		return 0, fmt.Errorf("sysfs-i2c: %v", err)
+	}
+	if err := i.f.Ioctl(ioctlPEC, enable); err != nil {
+		return 0, fmt.Errorf("sysfs-i2c: %v", err)
+	}
+	if err := i.f.Ioctl(ioctlSMBus, uintptr(unsafe.Pointer(&p))); err != nil {
+		return 0, fmt.Errorf("sysfs-i2c: %v", err)
+	}
+	switch op {
+	case smbus.ReceiveByte, smbus.ReadByteData:
+		r[0] = data[0]
+		return 1, nil
+	case smbus.ReadWordData, smbus.ProcessCall:
+		binary.LittleEndian.PutUint16(r, nativeEndian.Uint16(data[:2]))
+		return 2, nil
+	case smbus.ReadBlockData, smbus.BlockProcessCall:
+		n := int(data[0])
+		if n > smbus.MaxBlockSize {
+			return 0, fmt.Errorf("sysfs-i2c: invalid block length %d", n)
+		}
+		return copy(r, data[1:1+n]), nil
+	}
+	return 0, nil
+}
+
 // SetSpeed implements i2c.Bus.
 func (i *I2C) SetSpeed(f physic.Frequency) error {
 	if f > 100*physic.MegaHertz {
@@ -155,6 +323,38 @@ func (i *I2C) SDA() gpio.PinIO {
 	return i.sda
 }
 
+// Recover implements i2c.Recoverer.
+//
+// The controller's pins are temporarily used as GPIOs to clock the bus at
+// 100kHz, then their function is restored. This requires the GPIO driver to
+// implement pin.PinFunc.
+func (i *I2C) Recover() error {
+	i.initPins()
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.scl == gpio.INVALID || i.sda == gpio.INVALID {
+		return errors.New("sysfs-i2c: bus recovery requires the SCL and SDA pins to be known")
+	}
+	scl, ok1 := pinFunc(i.scl)
+	sda, ok2 := pinFunc(i.sda)
+	if !ok1 || !ok2 {
+		return errors.New("sysfs-i2c: bus recovery requires pins implementing pin.PinFunc")
+	}
+	fSCL := scl.Func()
+	fSDA := sda.Func()
+	err := i2c.RecoverPins(i.scl, i.sda, 100*physic.KiloHertz)
+	if err2 := scl.SetFunc(fSCL); err == nil {
+		err = err2
+	}
+	if err2 := sda.SetFunc(fSDA); err == nil {
+		err = err2
+	}
+	if err != nil {
+		return fmt.Errorf("sysfs-i2c: %v", err)
+	}
+	return nil
+}
+
 // Private details.
 
 func newI2C(busNumber int) (*I2C, error) {
@@ -184,6 +384,66 @@ func newI2C(busNumber int) (*I2C, error) {
 	return i, nil
 }
 
+// checkAddr validates addr and returns the address to send to the kernel and
+// flagTEN if it is a 10 bits address.
+func (i *I2C) checkAddr(addr uint16, f i2c.MsgFlags) (uint16, uint16, error) {
+	a := i2c.Addr(addr)
+	if !a.Valid() {
+		return 0, 0, errors.New("sysfs-i2c: invalid address")
+	}
+	if !a.IsTenBit() && f&i2c.MsgTenBit == 0 {
+		return a.Value(), 0, nil
+	}
+	if i.fn&func10BitAddr == 0 {
+		return 0, 0, errors.New("sysfs-i2c: 10 bits addressing is not supported")
+	}
+	return a.Value(), flagTEN, nil
+}
+
+// checkMsgFlags returns an error if the adapter doesn't support f.
+func (i *I2C) checkMsgFlags(f i2c.MsgFlags) error {
+	if f&i2c.MsgNoStart != 0 && i.fn&(funcNOSTART|funcProtocolMangling) == 0 {
+		return errors.New("sysfs-i2c: MsgNoStart is not supported")
+	}
+	if f&(i2c.MsgRevDirAddr|i2c.MsgIgnoreNAK|i2c.MsgNoReadACK|i2c.MsgStop) != 0 && i.fn&funcProtocolMangling == 0 {
+		return errors.New("sysfs-i2c: " + f.String() + " is not supported")
+	}
+	return nil
+}
+
+// msgFlags converts i2c.MsgFlags to the kernel's i2c_msg flags.
+func msgFlags(f i2c.MsgFlags) uint16 {
+	var out uint16
+	if f&i2c.MsgRead != 0 {
+		out |= flagRD
+	}
+	if f&i2c.MsgNoStart != 0 {
+		out |= flagNOSTART
+	}
+	if f&i2c.MsgRevDirAddr != 0 {
+		out |= flagRevDirAddr
+	}
+	if f&i2c.MsgIgnoreNAK != 0 {
+		out |= flagIgnoreNAK
+	}
+	if f&i2c.MsgNoReadACK != 0 {
+		out |= flagNoRDACK
+	}
+	if f&i2c.MsgStop != 0 {
+		out |= flagSTOP
+	}
+	return out
+}
+
+// pinFunc returns the pin.PinFunc of p, resolving aliases.
+func pinFunc(p gpio.PinIO) (pin.PinFunc, bool) {
+	if r, ok := p.(gpio.RealPin); ok {
+		p = r.Real()
+	}
+	f, ok := p.(pin.PinFunc)
+	return f, ok
+}
+
 func (i *I2C) initPins() {
 	i.mu.Lock()
 	if i.scl == nil {
@@ -205,11 +465,16 @@ const (
 	ioctlRetries = 0x701 // TODO(maruel): Expose this
 	ioctlTimeout = 0x702 // TODO(maruel): Expose this; in units of 10ms
 	ioctlSlave   = 0x703
-	ioctlTenBits = 0x704 // TODO(maruel): Expose this but the header says it's broken (!?)
+	ioctlTenBits = 0x704
 	ioctlFuncs   = 0x705
 	ioctlRdwr    = 0x707
+	ioctlPEC     = 0x708
+	ioctlSMBus   = 0x720
 )
 
+// rdwrMaxMsgs is I2C_RDWR_IOCTL_MAX_MSGS.
+const rdwrMaxMsgs = 42
+
 // flags
 const (
 	flagTEN        = 0x0010 // this is a ten bit chip address
@@ -305,6 +570,57 @@ func (f functionality) String() string {
 	return strings.Join(out, "|")
 }
 
+// smbusFuncs maps the SMBus transactions to the functionality bit advertising
+// them.
+var smbusFuncs = map[smbus.Op]functionality{
+	smbus.QuickWrite:       funcSMBusQuick,
+	smbus.QuickRead:        funcSMBusQuick,
+	smbus.SendByte:         funcSMBusWriteByte,
+	smbus.ReceiveByte:      funcSMBusReadByte,
+	smbus.WriteByteData:    funcSMBusWriteByteData,
+	smbus.ReadByteData:     funcSMBusReadByteData,
+	smbus.WriteWordData:    funcSMBusWriteWordData,
+	smbus.ReadWordData:     funcSMBusReadWordData,
+	smbus.ProcessCall:      funcSMBusProcCall,
+	smbus.WriteBlockData:   funcSMBusWriteBlockData,
+	smbus.ReadBlockData:    funcSMBusReadBlockData,
+	smbus.BlockProcessCall: funcSMBusBlockProcCall,
+}
+
+// nativeEndian is the byte order of the host, used for the word in
+// i2c_smbus_data.
+//
+// binary.NativeEndian requires go1.21.
+var nativeEndian = func() binary.ByteOrder {
+	x := uint16(1)
+	if *(*byte)(unsafe.Pointer(&x)) == 1 {
+		return binary.LittleEndian
+	}
+	return binary.BigEndian
+}()
+
+// I2C_SMBUS ioctl read_write and size values.
+const (
+	smbusWrite = 0
+	smbusRead  = 1
+
+	smbusQuick         = 0
+	smbusByte          = 1
+	smbusByteData      = 2
+	smbusWordData      = 3
+	smbusProcCall      = 4
+	smbusBlockData     = 5
+	smbusBlockProcCall = 7
+)
+
+// smbusIoctlData is struct i2c_smbus_ioctl_data.
+type smbusIoctlData struct {
+	readWrite uint8
+	command   uint8
+	size      uint32
+	data      uintptr // Pointer to union i2c_smbus_data
+}
+
 type rdwrIoctlData struct {
 	msgs  uintptr // Pointer to i2cMsg
 	nmsgs uint32
@@ -386,3 +702,6 @@ var drvI2C driverI2C
 
 var _ i2c.Bus = &I2C{}
 var _ i2c.BusCloser = &I2C{}
+var _ i2c.MsgBus = &I2C{}
+var _ smbus.Bus = &I2C{}
+var _ i2c.Recoverer = &I2C{}
@@ -0,0 +1,202 @@
+// Copyright 2019 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package sysfs
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"periph.io/x/periph/conn/i2c"
+)
+
+// Listen implements i2c.TargetBus.
+//
+// It uses the Linux i2c-slave-eeprom backend as described at
+// https://www.kernel.org/doc/Documentation/i2c/slave-eeprom-backend, so it
+// requires a kernel built with CONFIG_I2C_SLAVE_EEPROM and a bus driver
+// supporting the slave mode. Writing to new_device requires root.
+//
+// The backend emulates a 256 bytes memory, so only *i2c.Registers is
+// supported. The memory is synchronized with the registers every few
+// milliseconds; i2c.Registers.OnChange is called when the master changed it.
+func (i *I2C) Listen(addr uint16, h i2c.TargetHandler) (i2c.Target, error) {
+	regs, ok := h.(*i2c.Registers)
+	if !ok {
+		return nil, errors.New("sysfs-i2c: the slave-eeprom backend only supports *i2c.Registers")
+	}
+	a := i2c.Addr(addr)
+	if !a.Valid() {
+		return nil, fmt.Errorf("sysfs-i2c: invalid address %s", a)
+	}
+	id := a.Value() | i2cAddrSlave
+	if a.IsTenBit() {
+		id |= i2cAddrTenBit
+	}
+	t := &i2cTarget{
+		bus:  i,
+		addr: a,
+		root: fmt.Sprintf("/sys/bus/i2c/devices/i2c-%d/", i.busNumber),
+		id:   id,
+		regs: regs,
+		last: make([]byte, regs.Len()),
+		cur:  make([]byte, regs.Len()),
+		mem:  make([]byte, regs.Len()),
+		done: make(chan struct{}),
+	}
+	if err := writeFile(t.root+"new_device", fmt.Sprintf("slave-24c02 0x%04x", id)); err != nil {
+		return nil, fmt.Errorf("sysfs-i2c: failed to instantiate slave-eeprom: %v", err)
+	}
+	var err error
+	if t.f, err = fileIOOpen(fmt.Sprintf("/sys/bus/i2c/devices/%d-%04x/slave-eeprom", i.busNumber, id), os.O_RDWR); err != nil {
+		_ = writeFile(t.root+"delete_device", fmt.Sprintf("0x%04x", id))
+		return nil, fmt.Errorf("sysfs-i2c: %v", err)
+	}
+	regs.Get(0, t.last)
+	if err := seekWrite(t.f, t.last); err != nil {
+		_ = t.close()
+		return nil, fmt.Errorf("sysfs-i2c: %v", err)
+	}
+	t.wg.Add(1)
+	go t.run()
+	return t, nil
+}
+
+//
+
+// Kernel flags for the addresses written to new_device, from
+// include/linux/i2c.h.
+const (
+	i2cAddrTenBit = 0xA000 // I2C_ADDR_OFFSET_TEN_BIT
+	i2cAddrSlave  = 0x1000 // I2C_ADDR_OFFSET_SLAVE
+)
+
+// i2cTargetPoll is the interval at which the slave-eeprom memory is
+// synchronized with the registers.
+var i2cTargetPoll = 10 * time.Millisecond
+
+// i2cTarget is a device emulated with the slave-eeprom backend.
+type i2cTarget struct {
+	bus  *I2C
+	addr i2c.Addr
+	root string
+	id   uint16
+	regs *i2c.Registers
+	done chan struct{}
+	wg   sync.WaitGroup
+
+	mu     sync.Mutex
+	f      fileIO
+	closed bool
+	last   []byte // content at the last synchronization
+	cur    []byte // registers
+	mem    []byte // slave-eeprom memory
+}
+
+func (t *i2cTarget) String() string {
+	return fmt.Sprintf("%s(%s)", t.bus, t.addr)
+}
+
+// Close stops the synchronization and deletes the kernel device.
+func (t *i2cTarget) Close() error {
+	t.mu.Lock()
+	closed := t.closed
+	t.closed = true
+	t.mu.Unlock()
+	if closed {
+		return fmt.Errorf("sysfs-i2c: %s is already closed", t)
+	}
+	close(t.done)
+	t.wg.Wait()
+	return t.close()
+}
+
+func (t *i2cTarget) close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	err := t.f.Close()
+	if err2 := writeFile(t.root+"delete_device", fmt.Sprintf("0x%04x", t.id)); err == nil {
+		err = err2
+	}
+	if err != nil {
+		return fmt.Errorf("sysfs-i2c: %v", err)
+	}
+	return nil
+}
+
+func (t *i2cTarget) run() {
+	defer t.wg.Done()
+	tick := time.NewTicker(i2cTargetPoll)
+	defer tick.Stop()
+	for {
+		select {
+		case <-t.done:
+			return
+		case <-tick.C:
+			// There's nothing better to do than retrying at the next tick.
+			_ = t.sync()
+		}
+	}
+}
+
+// sync reconciles the slave-eeprom memory with the registers.
+//
+// A byte that changed in the memory since the last synchronization was written
+// by the master and is copied to the registers. Otherwise a byte that changed
+// in the registers is copied to the memory.
+func (t *i2cTarget) sync() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, err := seekRead(t.f, t.mem); err != nil {
+		return err
+	}
+	t.regs.Get(0, t.cur)
+	for start := 0; start < len(t.mem); {
+		// Find the next run of bytes changed on the same side.
+		fromMaster := t.mem[start] != t.last[start]
+		if !fromMaster && t.cur[start] == t.last[start] {
+			start++
+			continue
+		}
+		end := start + 1
+		for ; end < len(t.mem); end++ {
+			if fromMaster != (t.mem[end] != t.last[end]) || (!fromMaster && t.cur[end] == t.last[end]) {
+				break
+			}
+		}
+		if fromMaster {
+			copy(t.last[start:end], t.mem[start:end])
+			t.regs.OnWrite(append([]byte{byte(start)}, t.mem[start:end]...))
+		} else {
+			if _, err := t.f.Seek(int64(start), io.SeekStart); err != nil {
+				return err
+			}
+			if _, err := t.f.Write(t.cur[start:end]); err != nil {
+				return err
+			}
+			copy(t.last[start:end], t.cur[start:end])
+		}
+		start = end
+	}
+	return nil
+}
+
+// writeFile writes s to the sysfs file at path.
+func writeFile(path, s string) error {
+	f, err := fileIOOpen(path, os.O_WRONLY)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write([]byte(s))
+	if err2 := f.Close(); err == nil {
+		err = err2
+	}
+	return err
+}
+
+var _ i2c.TargetBus = &I2C{}
@@ -0,0 +1,160 @@
+// Copyright 2019 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package sysfs
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"periph.io/x/periph/conn/i2c"
+)
+
+func TestI2C_Listen(t *testing.T) {
+	defer reset()
+	defer func(d time.Duration) { i2cTargetPoll = d }(i2cTargetPoll)
+	i2cTargetPoll = time.Hour
+	var devices []string
+	eeprom := &fileEEPROM{data: make([]byte, 256)}
+	fileIOOpen = func(path string, flag int) (fileIO, error) {
+		switch path {
+		case "/sys/bus/i2c/devices/i2c-1/new_device":
+			return &fileMem{check: func(v string) { devices = append(devices, "new "+v) }}, nil
+		case "/sys/bus/i2c/devices/i2c-1/delete_device":
+			return &fileMem{check: func(v string) { devices = append(devices, "delete "+v) }}, nil
+		case "/sys/bus/i2c/devices/1-1064/slave-eeprom":
+			return eeprom, nil
+		}
+		t.Fatalf("unexpected %q", path)
+		return nil, nil
+	}
+	bus := I2C{busNumber: 1}
+	regs := i2c.NewRegisters(4)
+	regs.Set(0, []byte{1, 2, 3, 4})
+	var changed []byte
+	regs.OnChange = func(reg int, v []byte) {
+		changed = append([]byte{byte(reg)}, v...)
+	}
+	tgt, err := bus.Listen(0x64, regs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := tgt.String(); s != "I2C1(0x64)" {
+		t.Fatal(s)
+	}
+	if len(devices) != 1 || devices[0] != "new slave-24c02 0x1064" {
+		t.Fatal(devices)
+	}
+	if !bytes.Equal(eeprom.data[:5], []byte{1, 2, 3, 4, 0}) {
+		t.Fatal(eeprom.data[:5])
+	}
+
+	// The master wrote register 1, the device updated register 3.
+	eeprom.data[1] = 0x20
+	regs.Set(3, []byte{0x40})
+	s := tgt.(*i2cTarget)
+	if err := s.sync(); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(changed, []byte{1, 0x20}) {
+		t.Fatal(changed)
+	}
+	b := make([]byte, 4)
+	regs.Get(0, b)
+	if !bytes.Equal(b, []byte{1, 0x20, 3, 0x40}) {
+		t.Fatal(b)
+	}
+	if !bytes.Equal(eeprom.data[:4], b) {
+		t.Fatal(eeprom.data[:4])
+	}
+	// Nothing changed.
+	changed = nil
+	if err := s.sync(); err != nil || changed != nil {
+		t.Fatal(err, changed)
+	}
+
+	if err := tgt.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if len(devices) != 2 || devices[1] != "delete 0x1064" {
+		t.Fatal(devices)
+	}
+	if err := tgt.Close(); err == nil {
+		t.Fatal("already closed")
+	}
+	if len(devices) != 2 {
+		t.Fatal(devices)
+	}
+}
+
+func TestI2C_Listen_Err(t *testing.T) {
+	defer reset()
+	bus := I2C{busNumber: 1}
+	if _, err := bus.Listen(0x64, &fakeHandler{}); err == nil {
+		t.Fatal("only Registers is supported")
+	}
+	if _, err := bus.Listen(0x400, i2c.NewRegisters(1)); err == nil {
+		t.Fatal("invalid address")
+	}
+	var devices []string
+	fileIOOpen = func(path string, flag int) (fileIO, error) {
+		switch path {
+		case "/sys/bus/i2c/devices/i2c-1/new_device":
+			return &fileMem{check: func(v string) { devices = append(devices, "new "+v) }}, nil
+		case "/sys/bus/i2c/devices/i2c-1/delete_device":
+			return &fileMem{check: func(v string) { devices = append(devices, "delete "+v) }}, nil
+		}
+		return nil, os.ErrNotExist
+	}
+	if _, err := bus.Listen(0x150, i2c.NewRegisters(1)); err == nil {
+		t.Fatal("slave-eeprom is missing")
+	}
+	if len(devices) != 2 || devices[0] != "new slave-24c02 0xb150" || devices[1] != "delete 0xb150" {
+		t.Fatal(devices)
+	}
+	fileIOOpen = func(path string, flag int) (fileIO, error) {
+		return nil, os.ErrPermission
+	}
+	if _, err := bus.Listen(0x64, i2c.NewRegisters(1)); err == nil {
+		t.Fatal("new_device is not writable")
+	}
+}
+
+//
+
+// fileEEPROM is a fake slave-eeprom file.
+type fileEEPROM struct {
+	file
+	data []byte
+	off  int
+}
+
+func (f *fileEEPROM) Read(p []byte) (int, error) {
+	if f.off >= len(f.data) {
+		return 0, io.EOF
+	}
+	n := copy(p, f.data[f.off:])
+	f.off += n
+	return n, nil
+}
+
+func (f *fileEEPROM) Seek(offset int64, whence int) (int64, error) {
+	f.off = int(offset)
+	return offset, nil
+}
+
+func (f *fileEEPROM) Write(p []byte) (int, error) {
+	n := copy(f.data[f.off:], p)
+	f.off += n
+	return n, nil
+}
+
+type fakeHandler struct{}
+
+func (f *fakeHandler) OnWrite(w []byte) {}
+
+func (f *fakeHandler) OnRead(r []byte) {}
@@ -5,10 +5,18 @@
 package sysfs
 
 import (
+	"bytes"
+	"errors"
 	"testing"
+	"unsafe"
 
+	"periph.io/x/periph/conn/gpio"
+	"periph.io/x/periph/conn/gpio/gpiotest"
+	"periph.io/x/periph/conn/i2c"
 	"periph.io/x/periph/conn/i2c/i2creg"
+	"periph.io/x/periph/conn/i2c/smbus"
 	"periph.io/x/periph/conn/physic"
+	"periph.io/x/periph/conn/pin"
 )
 
 func TestNewI2C(t *testing.T) {
@@ -58,6 +66,225 @@ func TestI2C_functionality(t *testing.T) {
 	}
 }
 
+func TestI2C_TxMsgs(t *testing.T) {
+	f := ioctlRdwrFake{}
+	bus := I2C{f: &f, fn: funcI2C | funcNOSTART | funcProtocolMangling}
+	msgs := []i2c.Msg{
+		{Addr: 0x50, Buf: []byte{0, 0x20}},
+		{Addr: 0x50, Flags: i2c.MsgNoStart, Buf: []byte{1, 2}},
+		{Addr: 0x50, Flags: i2c.MsgRead | i2c.MsgIgnoreNAK, Buf: []byte{0}},
+		{Addr: 0x51},
+	}
+	if err := bus.TxMsgs(msgs); err != nil {
+		t.Fatal(err)
+	}
+	if len(f.msgs) != 4 {
+		t.Fatal(f.msgs)
+	}
+	if f.msgs[0].addr != 0x50 || f.msgs[0].flags != 0 || f.msgs[0].length != 2 {
+		t.Fatalf("%#v", f.msgs[0])
+	}
+	if f.msgs[1].flags != flagNOSTART || f.msgs[2].flags != flagRD|flagIgnoreNAK || f.msgs[3].buf != 0 {
+		t.Fatalf("%#v", f.msgs)
+	}
+	if err := bus.TxMsgs(nil); err != nil {
+		t.Fatal(err)
+	}
+	if bus.TxMsgs(make([]i2c.Msg, rdwrMaxMsgs+1)) == nil {
+		t.Fatal("too many messages")
+	}
+	if bus.TxMsgs([]i2c.Msg{{Addr: 0x80}}) == nil {
+		t.Fatal("invalid address")
+	}
+	if bus.TxMsgs([]i2c.Msg{{Addr: 0x80, Flags: i2c.MsgTenBit}}) == nil {
+		t.Fatal("10 bits addressing is not supported")
+	}
+	bus.fn = funcI2C
+	if bus.TxMsgs([]i2c.Msg{{Addr: 0x50, Flags: i2c.MsgNoStart}}) == nil {
+		t.Fatal("MsgNoStart is not supported")
+	}
+	if bus.TxMsgs([]i2c.Msg{{Addr: 0x50, Flags: i2c.MsgStop}}) == nil {
+		t.Fatal("MsgStop is not supported")
+	}
+	f.err = errors.New("EIO")
+	if bus.TxMsgs([]i2c.Msg{{Addr: 0x50}}) == nil {
+		t.Fatal("ioctl failed")
+	}
+}
+
+func TestI2C_TenBit(t *testing.T) {
+	f := ioctlRdwrFake{}
+	bus := I2C{f: &f, fn: funcI2C}
+	if bus.Tx(0x150, []byte{1}, nil) == nil {
+		t.Fatal("10 bits addressing is not supported")
+	}
+	if bus.Tx(i2c.TenBit|0x50, []byte{1}, nil) == nil {
+		t.Fatal("10 bits addressing is not supported")
+	}
+	if err := bus.Tx(0x50, []byte{1}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if f.msgs[0].addr != 0x50 || f.msgs[0].flags != 0 {
+		t.Fatalf("%#v", f.msgs)
+	}
+	bus.fn |= func10BitAddr
+	if err := bus.Tx(i2c.TenBit|0x50, []byte{1}, []byte{0}); err != nil {
+		t.Fatal(err)
+	}
+	if f.msgs[0].addr != 0x50 || f.msgs[0].flags != flagTEN || f.msgs[1].flags != flagTEN|flagRD {
+		t.Fatalf("%#v", f.msgs)
+	}
+	if err := bus.Tx(0x150, []byte{1}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if f.msgs[0].addr != 0x150 || f.msgs[0].flags != flagTEN {
+		t.Fatalf("%#v", f.msgs)
+	}
+	if err := bus.TxMsgs([]i2c.Msg{{Addr: 0x50, Flags: i2c.MsgTenBit | i2c.MsgRead}}); err != nil {
+		t.Fatal(err)
+	}
+	if f.msgs[0].addr != 0x50 || f.msgs[0].flags != flagTEN|flagRD {
+		t.Fatalf("%#v", f.msgs)
+	}
+	if bus.Tx(i2c.TenBit|0x400, nil, nil) == nil {
+		t.Fatal("invalid address")
+	}
+
+	s := ioctlSMBusFake{}
+	bus = I2C{f: &s, fn: 0xFFFFFFFF}
+	if err := (&smbus.Dev{Bus: &bus, Addr: i2c.TenBit | 0x50}).SendByte(1); err != nil {
+		t.Fatal(err)
+	}
+	if s.addr != 0x50 || !s.tenBit {
+		t.Fatalf("%#v", s)
+	}
+	if err := (&smbus.Dev{Bus: &bus, Addr: 0x50}).SendByte(1); err != nil {
+		t.Fatal(err)
+	}
+	if s.addr != 0x50 || s.tenBit {
+		t.Fatalf("%#v", s)
+	}
+}
+
+func TestMsgFlags(t *testing.T) {
+	// MsgTenBit is handled by checkAddr().
+	if f := msgFlags(i2c.MsgRead | i2c.MsgTenBit | i2c.MsgNoStart | i2c.MsgRevDirAddr | i2c.MsgIgnoreNAK | i2c.MsgNoReadACK | i2c.MsgStop); f != 0xF801 {
+		t.Fatalf("%#x", f)
+	}
+}
+
+func TestI2C_SMBusSupported(t *testing.T) {
+	bus := I2C{f: &ioctlClose{}, fn: funcI2C | funcSMBusReadWordData}
+	if !bus.SMBusSupported(smbus.ReadWordData, false) {
+		t.Fatal("ReadWordData is supported")
+	}
+	if bus.SMBusSupported(smbus.ReadWordData, true) {
+		t.Fatal("PEC is not supported")
+	}
+	if bus.SMBusSupported(smbus.ReadBlockData, false) {
+		t.Fatal("ReadBlockData is not supported")
+	}
+	bus.fn |= funcSMBusPEC
+	if !bus.SMBusSupported(smbus.ReadWordData, true) {
+		t.Fatal("PEC is supported")
+	}
+}
+
+func TestI2C_SMBusTx(t *testing.T) {
+	f := ioctlSMBusFake{reply: []byte{3, 1, 2, 3}}
+	bus := I2C{f: &f, fn: 0xFFFFFFFF}
+	d := smbus.Dev{Bus: &bus, Addr: 0x0B, PEC: true}
+	if err := d.WriteWordData(0x16, 0x1234); err != nil {
+		t.Fatal(err)
+	}
+	if f.addr != 0x0B || !f.pec || f.p.readWrite != smbusWrite || f.p.command != 0x16 || f.p.size != smbusWordData {
+		t.Fatalf("%#v", f)
+	}
+	if v := *(*uint16)(unsafe.Pointer(&f.data[0])); v != 0x1234 {
+		t.Fatalf("%#x", v)
+	}
+	var buf [smbus.MaxBlockSize]byte
+	n, err := d.BlockRead(0x20, buf[:])
+	if err != nil || !bytes.Equal(buf[:n], []byte{1, 2, 3}) {
+		t.Fatal(buf[:n], err)
+	}
+	if f.p.readWrite != smbusRead || f.p.size != smbusBlockData {
+		t.Fatalf("%#v", f.p)
+	}
+	d.PEC = false
+	if err := d.Quick(true); err != nil {
+		t.Fatal(err)
+	}
+	if f.pec || f.p.readWrite != smbusRead || f.p.size != smbusQuick {
+		t.Fatalf("%#v", f)
+	}
+	f.reply = []byte{0x34, 0x12}
+	if v, err := d.ProcessCall(0x17, 1); err != nil || v != 0x1234 {
+		t.Fatal(v, err)
+	}
+	if f.p.readWrite != smbusWrite || f.p.size != smbusProcCall {
+		t.Fatalf("%#v", f.p)
+	}
+}
+
+func TestI2C_SMBusTx_Err(t *testing.T) {
+	f := ioctlSMBusFake{reply: []byte{33}}
+	bus := I2C{f: &f, fn: 0xFFFFFFFF}
+	var buf [smbus.MaxBlockSize]byte
+	if _, err := bus.SMBusTx(0x400, smbus.ReadByteData, 0, nil, buf[:], false); err == nil {
+		t.Fatal("invalid address")
+	}
+	if _, err := bus.SMBusTx(0x0B, smbus.Op(100), 0, nil, buf[:], false); err == nil {
+		t.Fatal("invalid op")
+	}
+	if _, err := bus.SMBusTx(0x0B, smbus.ReadBlockData, 0, nil, buf[:], false); err == nil {
+		t.Fatal("invalid block length")
+	}
+	if _, err := bus.SMBusTx(0x0B, smbus.WriteByteData, 0, nil, nil, false); err == nil {
+		t.Fatal("w too short")
+	}
+	if _, err := bus.SMBusTx(0x0B, smbus.WriteWordData, 0, []byte{1}, nil, false); err == nil {
+		t.Fatal("w too short")
+	}
+	if _, err := bus.SMBusTx(0x0B, smbus.ProcessCall, 0, []byte{1, 2}, buf[:1], false); err == nil {
+		t.Fatal("r too short")
+	}
+	if _, err := bus.SMBusTx(0x0B, smbus.ReceiveByte, 0, nil, nil, false); err == nil {
+		t.Fatal("r too short")
+	}
+	f.err = errors.New("EBUSY")
+	if _, err := bus.SMBusTx(0x0B, smbus.ReadByteData, 0, nil, buf[:], false); err == nil {
+		t.Fatal("ioctl failed")
+	}
+}
+
+func TestI2C_Recover(t *testing.T) {
+	scl := &funcPin{Pin: gpiotest.Pin{N: "GPIO3", Fn: "I2C1_SCL"}}
+	sda := &funcPin{Pin: gpiotest.Pin{N: "GPIO2", Fn: "I2C1_SDA"}}
+	bus := I2C{f: &ioctlClose{}, busNumber: 1, scl: scl, sda: sda}
+	if err := bus.Recover(); err != nil {
+		t.Fatal(err)
+	}
+	// The pins are released and their function restored.
+	if scl.L != gpio.High || sda.L != gpio.High {
+		t.Fatal(scl.L, sda.L)
+	}
+	if scl.set != "I2C1_SCL" || sda.set != "I2C1_SDA" {
+		t.Fatal(scl.set, sda.set)
+	}
+}
+
+func TestI2C_Recover_Err(t *testing.T) {
+	bus := I2C{f: &ioctlClose{}, busNumber: 1, scl: gpio.INVALID, sda: gpio.INVALID}
+	if bus.Recover() == nil {
+		t.Fatal("unknown pins")
+	}
+	bus = I2C{f: &ioctlClose{}, busNumber: 1, scl: &gpiotest.Pin{N: "GPIO3"}, sda: &gpiotest.Pin{N: "GPIO2"}}
+	if bus.Recover() == nil {
+		t.Fatal("SetFunc is not supported")
+	}
+}
+
 func TestDriver_Init(t *testing.T) {
 	d := driverI2C{}
 	if _, err := d.Init(); err == nil {
@@ -114,3 +341,69 @@ func BenchmarkI2C(b *testing.B) {
 		}
 	}
 }
+
+//
+
+// ioctlSMBusFake emulates the I2C_TENBIT, I2C_SLAVE, I2C_PEC and I2C_SMBUS
+// ioctls.
+type ioctlSMBusFake struct {
+	ioctlClose
+	tenBit bool
+	addr   uintptr
+	pec    bool
+	p      smbusIoctlData
+	data   [smbus.MaxBlockSize + 2]byte
+	reply  []byte
+	err    error
+}
+
+func (i *ioctlSMBusFake) Ioctl(op uint, arg uintptr) error {
+	switch op {
+	case ioctlTenBits:
+		i.tenBit = arg != 0
+	case ioctlSlave:
+		i.addr = arg
+		return i.err
+	case ioctlPEC:
+		i.pec = arg != 0
+	case ioctlSMBus:
+		i.p = *(*smbusIoctlData)(ptr(arg))
+		data := (*[smbus.MaxBlockSize + 2]byte)(ptr(i.p.data))
+		i.data = *data
+		if i.p.readWrite == smbusRead || i.p.size == smbusProcCall {
+			copy(data[:], i.reply)
+		}
+	}
+	return nil
+}
+
+// ioctlRdwrFake records the messages of the I2C_RDWR ioctl.
+type ioctlRdwrFake struct {
+	ioctlClose
+	msgs []i2cMsg
+	err  error
+}
+
+func (i *ioctlRdwrFake) Ioctl(op uint, arg uintptr) error {
+	if op == ioctlRdwr {
+		p := (*rdwrIoctlData)(ptr(arg))
+		// Copy the messages one at a time, converting to an array larger than
+		// the allocation trips checkptr. unsafe.Slice requires go1.17.
+		i.msgs = make([]i2cMsg, p.nmsgs)
+		for j := range i.msgs {
+			i.msgs[j] = *(*i2cMsg)(ptr(p.msgs + uintptr(j)*unsafe.Sizeof(i2cMsg{})))
+		}
+	}
+	return i.err
+}
+
+// funcPin is a gpiotest.Pin that supports SetFunc.
+type funcPin struct {
+	gpiotest.Pin
+	set pin.Func
+}
+
+func (f *funcPin) SetFunc(fn pin.Func) error {
+	f.set = fn
+	return nil
+}
@@ -0,0 +1,488 @@
+// Copyright 2019 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package sysfs
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"periph.io/x/periph"
+	"periph.io/x/periph/conn"
+	"periph.io/x/periph/conn/gpio"
+	"periph.io/x/periph/conn/gpio/gpioreg"
+	"periph.io/x/periph/conn/physic"
+	"periph.io/x/periph/conn/pin"
+)
+
+// PWMs is all the PWM channels discovered on this host via sysfs.
+//
+// The channels are registered in gpioreg with names like "pwmchip0_1".
+var PWMs []*PWM
+
+// PWMByName returns a *PWM for the channel name, if any.
+func PWMByName(name string) (*PWM, error) {
+	for _, p := range PWMs {
+		if p.name == name {
+			return p, nil
+		}
+	}
+	return nil, errors.New("sysfs-pwm: invalid PWM name")
+}
+
+// PWM represents one channel of a PWM controller exposed by the kernel at
+// /sys/class/pwm/pwmchipN/pwmM.
+//
+// For all practical purpose, a PWM channel is considered an output-only
+// gpio.PinOut. The channel is exported on first use and left exported.
+type PWM struct {
+	number int    // Channel number in the chip
+	name   string // Something like pwmchip0_1
+	root   string // Something like /sys/class/pwm/pwmchip0/
+
+	mu       sync.Mutex
+	fEnable  fileIO        // handle to pwmM/enable
+	fPeriod  fileIO        // handle to pwmM/period
+	fDuty    fileIO        // handle to pwmM/duty_cycle
+	period   time.Duration // Cache of the last period written
+	duty     time.Duration // Cache of the last duty cycle written
+	enabled  bool          // Cache of the last enable written
+	inverted bool          // Cache of the last polarity written
+}
+
+// String implements conn.Resource.
+func (p *PWM) String() string {
+	return p.name
+}
+
+// Halt implements conn.Resource.
+//
+// It disables the output.
+func (p *PWM) Halt() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.fEnable == nil {
+		return nil
+	}
+	if err := p.enable(false); err != nil {
+		return p.wrap(err)
+	}
+	return nil
+}
+
+// Name implements pin.Pin.
+func (p *PWM) Name() string {
+	return p.name
+}
+
+// Number implements pin.Pin.
+//
+// It returns the channel number in the chip.
+func (p *PWM) Number() int {
+	return p.number
+}
+
+// Function implements pin.Pin.
+func (p *PWM) Function() string {
+	return string(p.Func())
+}
+
+// Func implements pin.PinFunc.
+func (p *PWM) Func() pin.Func {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.enabled {
+		return pin.FuncNone
+	}
+	switch p.duty {
+	case 0:
+		return gpio.OUT_LOW
+	case p.period:
+		return gpio.OUT_HIGH
+	default:
+		return gpio.PWM
+	}
+}
+
+// SupportedFuncs implements pin.PinFunc.
+func (p *PWM) SupportedFuncs() []pin.Func {
+	return []pin.Func{gpio.OUT, gpio.PWM}
+}
+
+// SetFunc implements pin.PinFunc.
+func (p *PWM) SetFunc(f pin.Func) error {
+	switch f {
+	case gpio.OUT_HIGH:
+		return p.Out(gpio.High)
+	case gpio.OUT, gpio.OUT_LOW:
+		return p.Out(gpio.Low)
+	case gpio.PWM:
+		return p.PWM(gpio.DutyHalf, 0)
+	default:
+		return p.wrap(errors.New("unsupported function"))
+	}
+}
+
+// In implements gpio.PinIn.
+func (p *PWM) In(pull gpio.Pull, edge gpio.Edge) error {
+	return p.wrap(errors.New("not supported"))
+}
+
+// Read implements gpio.PinIn.
+//
+// It returns High when the output is enabled with a non-zero duty cycle.
+func (p *PWM) Read() gpio.Level {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return gpio.Level(p.enabled && p.duty != 0)
+}
+
+// WaitForEdge implements gpio.PinIn.
+func (p *PWM) WaitForEdge(timeout time.Duration) bool {
+	return false
+}
+
+// Pull implements gpio.PinIn.
+func (p *PWM) Pull() gpio.Pull {
+	return gpio.PullNoChange
+}
+
+// DefaultPull implements gpio.PinIn.
+func (p *PWM) DefaultPull() gpio.Pull {
+	return gpio.PullNoChange
+}
+
+// Out implements gpio.PinOut.
+//
+// It is implemented as a duty cycle of 0% or 100%.
+func (p *PWM) Out(l gpio.Level) error {
+	if l {
+		return p.PWM(gpio.DutyMax, 0)
+	}
+	return p.PWM(0, 0)
+}
+
+// PWM implements gpio.PinOut.
+//
+// Using 0 as frequency keeps the current period, or uses 1kHz if none was set.
+// The period is expressed in nanoseconds by the kernel, so the frequency must
+// be at most 1GHz.
+func (p *PWM) PWM(duty gpio.Duty, f physic.Frequency) error {
+	if !duty.Valid() {
+		return p.wrap(errors.New("invalid duty cycle " + duty.String()))
+	}
+	if f < 0 || f > physic.GigaHertz {
+		return p.wrap(errors.New("invalid frequency " + f.String()))
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.open(); err != nil {
+		return p.wrap(err)
+	}
+	period := p.period
+	if f != 0 {
+		period = f.Period()
+	} else if period == 0 {
+		period = time.Millisecond
+	}
+	d := time.Duration(int64(period) * int64(duty) / int64(gpio.DutyMax))
+	// The kernel rejects a duty cycle longer than the period at all time, so
+	// the order of the writes depends on the previous values.
+	if period >= p.duty {
+		if err := p.setPeriod(period); err != nil {
+			return p.wrap(err)
+		}
+		if err := p.setDuty(d); err != nil {
+			return p.wrap(err)
+		}
+	} else {
+		if err := p.setDuty(d); err != nil {
+			return p.wrap(err)
+		}
+		if err := p.setPeriod(period); err != nil {
+			return p.wrap(err)
+		}
+	}
+	if err := p.enable(true); err != nil {
+		return p.wrap(err)
+	}
+	return nil
+}
+
+// Inverted returns true if the polarity is inversed, that is the output is
+// Low during the duty cycle.
+func (p *PWM) Inverted() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.inverted
+}
+
+// SetInverted sets the polarity of the output.
+//
+// The output is temporarily disabled while changing the polarity, since most
+// controllers do not support changing it while enabled. Not all controllers
+// support inversed polarity.
+func (p *PWM) SetInverted(inverted bool) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.open(); err != nil {
+		return p.wrap(err)
+	}
+	enabled := p.enabled
+	if enabled {
+		if err := p.enable(false); err != nil {
+			return p.wrap(err)
+		}
+	}
+	// The polarity is rarely changed, so the file is not kept open.
+	f, err := fileIOOpen(p.channelRoot()+"polarity", os.O_WRONLY)
+	if err != nil {
+		return p.wrap(err)
+	}
+	defer f.Close()
+	v := "normal"
+	if inverted {
+		v = "inversed"
+	}
+	if err := seekWrite(f, []byte(v)); err != nil {
+		return p.wrap(err)
+	}
+	p.inverted = inverted
+	if enabled {
+		if err := p.enable(true); err != nil {
+			return p.wrap(err)
+		}
+		return nil
+	}
+	return nil
+}
+
+//
+
+func (p *PWM) channelRoot() string {
+	return p.root + "pwm" + strconv.Itoa(p.number) + "/"
+}
+
+// open exports the channel if necessary and opens its files.
+//
+// lock must be held.
+func (p *PWM) open() error {
+	if p.fEnable != nil {
+		return nil
+	}
+	root := p.channelRoot()
+	f, err := fileIOOpen(root+"period", os.O_RDWR)
+	if err != nil {
+		// The channel is likely not exported yet.
+		if err := p.export(); err != nil {
+			return err
+		}
+		// udev may take a moment to fix the permissions of the files.
+		for i := 0; ; i++ {
+			if f, err = fileIOOpen(root+"period", os.O_RDWR); err == nil || i == 10 {
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	p.fPeriod = f
+	if p.fDuty, err = fileIOOpen(root+"duty_cycle", os.O_RDWR); err != nil {
+		p.close()
+		return err
+	}
+	if p.fEnable, err = fileIOOpen(root+"enable", os.O_RDWR); err != nil {
+		p.close()
+		return err
+	}
+	// Load the current state.
+	var n int64
+	if n, err = seekReadInt(p.fPeriod); err != nil {
+		p.close()
+		return err
+	}
+	p.period = time.Duration(n)
+	if n, err = seekReadInt(p.fDuty); err != nil {
+		p.close()
+		return err
+	}
+	p.duty = time.Duration(n)
+	if n, err = seekReadInt(p.fEnable); err != nil {
+		p.close()
+		return err
+	}
+	p.enabled = n != 0
+	if f, err := fileIOOpen(root+"polarity", os.O_RDONLY); err == nil {
+		var b [16]byte
+		if n, err := seekRead(f, b[:]); err == nil {
+			p.inverted = strings.TrimSpace(string(b[:n])) == "inversed"
+		}
+		_ = f.Close()
+	}
+	return nil
+}
+
+// close closes the files opened by open().
+//
+// lock must be held.
+func (p *PWM) close() {
+	for _, f := range []*fileIO{&p.fPeriod, &p.fDuty, &p.fEnable} {
+		if *f != nil {
+			_ = (*f).Close()
+			*f = nil
+		}
+	}
+}
+
+func (p *PWM) export() error {
+	f, err := fileIOOpen(p.root+"export", os.O_WRONLY)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return seekWrite(f, []byte(strconv.Itoa(p.number)))
+}
+
+// setPeriod writes the period.
+//
+// lock must be held.
+func (p *PWM) setPeriod(d time.Duration) error {
+	if d == p.period {
+		return nil
+	}
+	if err := seekWrite(p.fPeriod, []byte(strconv.FormatInt(int64(d), 10))); err != nil {
+		return err
+	}
+	p.period = d
+	return nil
+}
+
+// setDuty writes the duty cycle.
+//
+// lock must be held.
+func (p *PWM) setDuty(d time.Duration) error {
+	if d == p.duty {
+		return nil
+	}
+	if err := seekWrite(p.fDuty, []byte(strconv.FormatInt(int64(d), 10))); err != nil {
+		return err
+	}
+	p.duty = d
+	return nil
+}
+
+// enable enables or disables the output.
+//
+// lock must be held.
+func (p *PWM) enable(e bool) error {
+	if e == p.enabled {
+		return nil
+	}
+	v := []byte("0")
+	if e {
+		v = []byte("1")
+	}
+	if err := seekWrite(p.fEnable, v); err != nil {
+		return err
+	}
+	p.enabled = e
+	return nil
+}
+
+func (p *PWM) wrap(err error) error {
+	return fmt.Errorf("sysfs-pwm (%s): %v", p, err)
+}
+
+// seekReadInt reads an integer from an opened sysfs file.
+func seekReadInt(f fileIO) (int64, error) {
+	var b [24]byte
+	n, err := seekRead(f, b[:])
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(strings.TrimSpace(string(b[:n])), 10, 64)
+}
+
+// driverPWM implements periph.Driver.
+type driverPWM struct {
+}
+
+func (d *driverPWM) String() string {
+	return "sysfs-pwm"
+}
+
+func (d *driverPWM) Prerequisites() []string {
+	return nil
+}
+
+func (d *driverPWM) After() []string {
+	return nil
+}
+
+// Init initializes the PWM sysfs handling code.
+//
+// Uses the PWM sysfs interface as described at
+// https://www.kernel.org/doc/Documentation/pwm.txt
+func (d *driverPWM) Init() (bool, error) {
+	items, err := filepath.Glob("/sys/class/pwm/pwmchip*")
+	if err != nil {
+		return true, err
+	}
+	if len(items) == 0 {
+		return false, errors.New("no PWM chip found")
+	}
+	return true, registerPWMChips(items)
+}
+
+// registerPWMChips discovers the channels of each chip and registers them in
+// gpioreg.
+func registerPWMChips(items []string) error {
+	var numbers []int
+	for _, item := range items {
+		if n, err := strconv.Atoi(strings.TrimPrefix(filepath.Base(item), "pwmchip")); err == nil {
+			numbers = append(numbers, n)
+		}
+	}
+	// Make sure they are registered in order.
+	sort.Ints(numbers)
+	for _, n := range numbers {
+		chip := "pwmchip" + strconv.Itoa(n)
+		root := "/sys/class/pwm/" + chip + "/"
+		npwm, err := readInt(root + "npwm")
+		if err != nil {
+			return fmt.Errorf("sysfs-pwm: %s: %v", chip, err)
+		}
+		for i := 0; i < npwm; i++ {
+			p := &PWM{number: i, name: chip + "_" + strconv.Itoa(i), root: root}
+			if err := gpioreg.Register(p); err != nil {
+				return err
+			}
+			PWMs = append(PWMs, p)
+		}
+	}
+	return nil
+}
+
+func init() {
+	if isLinux {
+		periph.MustRegister(&drvPWM)
+	}
+}
+
+var drvPWM driverPWM
+
+var _ conn.Resource = &PWM{}
+var _ gpio.PinIn = &PWM{}
+var _ gpio.PinOut = &PWM{}
+var _ gpio.PinIO = &PWM{}
+var _ pin.PinFunc = &PWM{}
@@ -0,0 +1,304 @@
+// Copyright 2019 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package sysfs
+
+import (
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	"periph.io/x/periph/conn/gpio"
+	"periph.io/x/periph/conn/gpio/gpioreg"
+	"periph.io/x/periph/conn/physic"
+	"periph.io/x/periph/conn/pin"
+)
+
+func TestPWMByName(t *testing.T) {
+	defer resetPWM()
+	PWMs = []*PWM{{name: "pwmchip0_0"}}
+	if _, err := PWMByName("FOO"); err == nil {
+		t.Fatal("expected error")
+	}
+	if p, err := PWMByName("pwmchip0_0"); err != nil || p != PWMs[0] {
+		t.Fatal(p, err)
+	}
+}
+
+func TestPWM(t *testing.T) {
+	defer resetPWM()
+	fs := newFakePWMChip(t, 2)
+	p := &PWM{number: 1, name: "pwmchip0_1", root: "/sys/class/pwm/pwmchip0/"}
+	if s := p.String(); s != "pwmchip0_1" {
+		t.Fatal(s)
+	}
+	if s := p.Name(); s != "pwmchip0_1" {
+		t.Fatal(s)
+	}
+	if n := p.Number(); n != 1 {
+		t.Fatal(n)
+	}
+	if f := p.Func(); f != pin.FuncNone {
+		t.Fatal(f)
+	}
+	// Halt is a no-op until the channel is used.
+	if err := p.Halt(); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.PWM(gpio.DutyMax/4, physic.KiloHertz); err != nil {
+		t.Fatal(err)
+	}
+	if s := fs.read("export"); s != "1" {
+		t.Fatalf("channel not exported: %q", s)
+	}
+	if s := fs.read("pwm1/period"); s != "1000000" {
+		t.Fatal(s)
+	}
+	if s := fs.read("pwm1/duty_cycle"); s != "250000" {
+		t.Fatal(s)
+	}
+	if s := fs.read("pwm1/enable"); s != "1" {
+		t.Fatal(s)
+	}
+	if f := p.Func(); f != gpio.PWM {
+		t.Fatal(f)
+	}
+	if l := p.Read(); l != gpio.High {
+		t.Fatal(l)
+	}
+	// Shorten the period below the current duty cycle; the duty cycle must be
+	// written first.
+	fs.files["pwm1/period"].check = func(v string) {
+		if d := fs.read("pwm1/duty_cycle"); d != "50000" {
+			t.Fatalf("duty cycle %s longer than period %s", d, v)
+		}
+	}
+	if err := p.PWM(gpio.DutyHalf, 10*physic.KiloHertz); err != nil {
+		t.Fatal(err)
+	}
+	fs.files["pwm1/period"].check = nil
+	if s := fs.read("pwm1/period"); s != "100000" {
+		t.Fatal(s)
+	}
+	// The period is kept.
+	if err := p.Out(gpio.High); err != nil {
+		t.Fatal(err)
+	}
+	if s := fs.read("pwm1/duty_cycle"); s != "100000" {
+		t.Fatal(s)
+	}
+	if f := p.Func(); f != gpio.OUT_HIGH {
+		t.Fatal(f)
+	}
+	if err := p.SetFunc(gpio.OUT_LOW); err != nil {
+		t.Fatal(err)
+	}
+	if s := fs.read("pwm1/duty_cycle"); s != "0" {
+		t.Fatal(s)
+	}
+	if f := p.Function(); f != string(gpio.OUT_LOW) {
+		t.Fatal(f)
+	}
+	if err := p.Halt(); err != nil {
+		t.Fatal(err)
+	}
+	if s := fs.read("pwm1/enable"); s != "0" {
+		t.Fatal(s)
+	}
+	if l := p.Read(); l != gpio.Low {
+		t.Fatal(l)
+	}
+}
+
+func TestPWM_Exported(t *testing.T) {
+	defer resetPWM()
+	fs := newFakePWMChip(t, 1)
+	fs.export(0)
+	fs.write("pwm0/period", "20000000")
+	fs.write("pwm0/duty_cycle", "1500000")
+	fs.write("pwm0/enable", "1")
+	fs.write("pwm0/polarity", "inversed")
+	p := &PWM{number: 0, name: "pwmchip0_0", root: "/sys/class/pwm/pwmchip0/"}
+	// The current period is used.
+	if err := p.PWM(gpio.DutyHalf, 0); err != nil {
+		t.Fatal(err)
+	}
+	if s := fs.read("export"); s != "" {
+		t.Fatalf("unexpected export %q", s)
+	}
+	if s := fs.read("pwm0/duty_cycle"); s != "10000000" {
+		t.Fatal(s)
+	}
+	if !p.Inverted() {
+		t.Fatal("expected inversed polarity")
+	}
+	var writes []string
+	fs.files["pwm0/enable"].check = func(v string) {
+		writes = append(writes, "enable="+v)
+	}
+	fs.files["pwm0/polarity"].check = func(v string) {
+		writes = append(writes, "polarity="+v)
+	}
+	if err := p.SetInverted(false); err != nil {
+		t.Fatal(err)
+	}
+	if s := strings.Join(writes, ","); s != "enable=0,polarity=normal,enable=1" {
+		t.Fatal(s)
+	}
+	if p.Inverted() {
+		t.Fatal("expected normal polarity")
+	}
+}
+
+func TestPWM_Err(t *testing.T) {
+	defer resetPWM()
+	p := &PWM{number: 0, name: "pwmchip0_0", root: "/sys/class/pwm/pwmchip0/"}
+	if err := p.PWM(-1, 0); err == nil {
+		t.Fatal("invalid duty")
+	}
+	if err := p.PWM(gpio.DutyHalf, -physic.Hertz); err == nil {
+		t.Fatal("invalid frequency")
+	}
+	if err := p.PWM(gpio.DutyHalf, 0); err == nil || !strings.HasPrefix(err.Error(), "sysfs-pwm (pwmchip0_0): ") {
+		t.Fatal(err)
+	}
+	if err := p.SetInverted(true); err == nil {
+		t.Fatal("file I/O is inhibited")
+	}
+	if err := p.SetFunc(gpio.IN); err == nil {
+		t.Fatal("unsupported function")
+	}
+	if err := p.In(gpio.PullNoChange, gpio.NoEdge); err == nil {
+		t.Fatal("not supported")
+	}
+	if p.WaitForEdge(-1) {
+		t.Fatal("not supported")
+	}
+	if p.Pull() != gpio.PullNoChange || p.DefaultPull() != gpio.PullNoChange {
+		t.Fatal("unexpected pull")
+	}
+	if f := p.SupportedFuncs(); len(f) != 2 {
+		t.Fatal(f)
+	}
+}
+
+func TestRegisterPWMChips(t *testing.T) {
+	defer resetPWM()
+	newFakePWMChip(t, 2)
+	if err := registerPWMChips([]string{"/sys/class/pwm/pwmchip0", "/sys/class/pwm/foo"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(PWMs) != 2 {
+		t.Fatal(PWMs)
+	}
+	for _, p := range PWMs {
+		if r := gpioreg.ByName(p.Name()); r != p {
+			t.Fatal(r)
+		}
+	}
+}
+
+func TestPWMDriver(t *testing.T) {
+	defer resetPWM()
+	d := &driverPWM{}
+	if s := d.String(); s != "sysfs-pwm" {
+		t.Fatal(s)
+	}
+	if len(d.Prerequisites()) != 0 || len(d.After()) != 0 {
+		t.Fatal("unexpected dependencies")
+	}
+	// It may pass or fail, as long as it doesn't panic.
+	_, _ = d.Init()
+}
+
+//
+
+func resetPWM() {
+	for _, p := range PWMs {
+		_ = gpioreg.Unregister(p.Name())
+	}
+	PWMs = nil
+	reset()
+}
+
+// fakePWMChip emulates /sys/class/pwm/pwmchip0/.
+type fakePWMChip struct {
+	files map[string]*fileMem
+}
+
+func newFakePWMChip(t *testing.T, npwm int) *fakePWMChip {
+	const root = "/sys/class/pwm/pwmchip0/"
+	f := &fakePWMChip{files: map[string]*fileMem{}}
+	f.write("npwm", strconv.Itoa(npwm)+"\n")
+	f.write("export", "")
+	f.files["export"].check = func(v string) {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.export(n)
+	}
+	fileIOOpen = func(path string, flag int) (fileIO, error) {
+		if !strings.HasPrefix(path, root) {
+			t.Fatalf("unexpected %q", path)
+		}
+		if m, ok := f.files[path[len(root):]]; ok {
+			return m, nil
+		}
+		return nil, os.ErrNotExist
+	}
+	return f
+}
+
+func (f *fakePWMChip) export(n int) {
+	d := "pwm" + strconv.Itoa(n) + "/"
+	f.write(d+"period", "0\n")
+	f.write(d+"duty_cycle", "0\n")
+	f.write(d+"enable", "0\n")
+	f.write(d+"polarity", "normal\n")
+}
+
+func (f *fakePWMChip) write(name, v string) {
+	if m, ok := f.files[name]; ok {
+		m.data = []byte(v)
+		return
+	}
+	f.files[name] = &fileMem{data: []byte(v)}
+}
+
+func (f *fakePWMChip) read(name string) string {
+	return strings.TrimSpace(string(f.files[name].data))
+}
+
+// fileMem is an in-memory sysfs file; each write replaces the content.
+type fileMem struct {
+	file
+	data  []byte
+	off   int
+	check func(v string)
+}
+
+func (f *fileMem) Read(p []byte) (int, error) {
+	if f.off >= len(f.data) {
+		return 0, io.EOF
+	}
+	n := copy(p, f.data[f.off:])
+	f.off += n
+	return n, nil
+}
+
+func (f *fileMem) Seek(offset int64, whence int) (int64, error) {
+	f.off = 0
+	return f.file.Seek(offset, whence)
+}
+
+func (f *fileMem) Write(p []byte) (int, error) {
+	if f.check != nil {
+		f.check(string(p))
+	}
+	f.data = append([]byte{}, p...)
+	return len(p), nil
+}
@@ -143,6 +143,27 @@ func (s *SPI) MaxTxSize() int {
 	return drvSPI.bufSize
 }
 
+// Caps implements spi.Capabilities.
+//
+// spidev doesn't report the controller capabilities directly. The mode flags
+// and word sizes are probed by trying to set them, as the kernel validates
+// them against the controller, then the previous settings are restored.
+// MaxSpeed is the maximum speed configured for this device, usually in the
+// device tree.
+//
+// CSHigh is not probed, since setting it drives the chip select line active
+// on the bus. It is only reported when the device is already configured with
+// CS active high; otherwise it is false, which means unknown.
+func (s *SPI) Caps() (spi.Caps, error) {
+	s.conn.mu.Lock()
+	defer s.conn.mu.Unlock()
+	c, err := s.conn.caps()
+	if err != nil {
+		return c, fmt.Errorf("sysfs-spi: %v", err)
+	}
+	return c, nil
+}
+
 // CLK implements spi.Pins.
 func (s *SPI) CLK() gpio.PinOut {
 	return s.conn.CLK()
@@ -411,6 +432,50 @@ func (s *spiConn) txPackets(p []spi.Packet) error {
 	return s.f.Ioctl(spiIOCTx(len(m)), uintptr(unsafe.Pointer(&m[0])))
 }
 
+// caps probes the capabilities of the controller.
+//
+// s.mu must be held.
+func (s *spiConn) caps() (spi.Caps, error) {
+	c := spi.Caps{}
+	mode, err := s.GetFlag(spiIOCRdMode32)
+	if err != nil {
+		return c, fmt.Errorf("reading mode failed: %v", err)
+	}
+	bits, err := s.GetFlag(spiIOCRdBitsPerWord)
+	if err != nil {
+		return c, fmt.Errorf("reading bits per word failed: %v", err)
+	}
+	speed, err := s.GetFlag(spiIOCRdMaxSpeedHz)
+	if err != nil {
+		return c, fmt.Errorf("reading max speed failed: %v", err)
+	}
+	c.MaxSpeed = physic.Frequency(speed) * physic.Hertz
+	flags := []struct {
+		m  spi.Mode
+		to spi.Mode
+	}{{threeWire, spi.HalfDuplex}, {noCS, spi.NoCS}, {lSBFirst, spi.LSBFirst}}
+	for _, f := range flags {
+		if s.setFlag(spiIOCMode32, mode|uint64(f.m)) == nil {
+			c.Mode |= f.to
+		}
+	}
+	// Setting CS_HIGH would select the device, so only report the current
+	// setting.
+	c.CSHigh = mode&uint64(cSHigh) != 0
+	if err := s.setFlag(spiIOCMode32, mode); err != nil {
+		return c, fmt.Errorf("restoring mode failed: %v", err)
+	}
+	for i := uint(1); i <= 32; i++ {
+		if s.setFlag(spiIOCBitsPerWord, uint64(i)) == nil {
+			c.BitsPerWord |= 1 << (i - 1)
+		}
+	}
+	if err := s.setFlag(spiIOCBitsPerWord, bits); err != nil {
+		return c, fmt.Errorf("restoring bits per word failed: %v", err)
+	}
+	return c, nil
+}
+
 func (s *spiConn) setFlag(op uint, arg uint64) error {
 	return s.f.Ioctl(op, uintptr(unsafe.Pointer(&arg)))
 }
@@ -470,6 +535,10 @@ var (
 	spiIOCBitsPerWord = fs.IOW(spiIOCMagic, 3, 1) // SPI_IOC_WR_BITS_PER_WORD
 	spiIOCMaxSpeedHz  = fs.IOW(spiIOCMagic, 4, 4) // SPI_IOC_WR_MAX_SPEED_HZ
 	spiIOCMode32      = fs.IOW(spiIOCMagic, 5, 4) // SPI_IOC_WR_MODE32 (32 bits)
+
+	spiIOCRdBitsPerWord = fs.IOR(spiIOCMagic, 3, 1) // SPI_IOC_RD_BITS_PER_WORD
+	spiIOCRdMaxSpeedHz  = fs.IOR(spiIOCMagic, 4, 4) // SPI_IOC_RD_MAX_SPEED_HZ
+	spiIOCRdMode32      = fs.IOR(spiIOCMagic, 5, 4) // SPI_IOC_RD_MODE32 (32 bits)
 )
 
 // spiIOCTx(l) calculates the equivalent of SPI_IOC_MESSAGE(l) to execute a
@@ -615,6 +684,7 @@ var _ conn.Limits = &spiConn{}
 var _ io.Reader = &spiConn{}
 var _ io.Writer = &spiConn{}
 var _ spi.Conn = &spiConn{}
+var _ spi.Capabilities = &SPI{}
 var _ spi.Pins = &SPI{}
 var _ spi.Pins = &spiConn{}
 var _ spi.Port = &SPI{}
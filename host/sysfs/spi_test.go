@@ -8,6 +8,7 @@ import (
 	"errors"
 	"io"
 	"testing"
+	"unsafe"
 
 	"periph.io/x/periph/conn"
 	"periph.io/x/periph/conn/gpio"
@@ -303,6 +304,37 @@ func TestSPI_Close_Err(t *testing.T) {
 	}
 }
 
+func TestSPI_Caps(t *testing.T) {
+	f := spidev{mode: 0x3, bits: 8, speed: 50000000, modeMask: 0x3 | uint64(lSBFirst|noCS|cSHigh), bitsMask: 0x80008080}
+	p := SPI{spiConn{f: &f}}
+	c, err := p.Caps()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// CSHigh is not probed.
+	expected := spi.Caps{Mode: spi.NoCS | spi.LSBFirst, MaxSpeed: 50 * physic.MegaHertz, BitsPerWord: 0x80008080}
+	if c != expected {
+		t.Fatal(c)
+	}
+	// The settings are restored.
+	if f.mode != 0x3 || f.bits != 8 {
+		t.Fatal(f.mode, f.bits)
+	}
+	if f.csHigh {
+		t.Fatal("CS_HIGH must not be set")
+	}
+	// CSHigh is reported when already set.
+	f = spidev{mode: uint64(cSHigh), bits: 8, modeMask: uint64(cSHigh), bitsMask: 0x80}
+	p = SPI{spiConn{f: &f}}
+	if c, err = p.Caps(); err != nil || !c.CSHigh || c.Mode != 0 {
+		t.Fatal(c, err)
+	}
+	p = SPI{spiConn{f: &ioctlClose{ioctlErr: errors.New("foo")}}}
+	if _, err := p.Caps(); err == nil || err.Error() != "sysfs-spi: reading mode failed: foo" {
+		t.Fatal(err)
+	}
+}
+
 func BenchmarkSPI_Tx(b *testing.B) {
 	b.ReportAllocs()
 	f := ioctlClose{}
@@ -367,6 +399,48 @@ func BenchmarkSPI_TxPackets5(b *testing.B) {
 
 //
 
+// spidev simulates the ioctls of the spidev driver to read and write the
+// device settings, which are validated against the controller capabilities.
+type spidev struct {
+	ioctlClose
+	mode     uint64
+	bits     uint64
+	speed    uint64
+	modeMask uint64
+	bitsMask uint64
+	csHigh   bool // CS_HIGH was set while it wasn't before
+}
+
+func (s *spidev) Ioctl(op uint, data uintptr) error {
+	// data is the address of the uint64 passed by setFlag() and GetFlag().
+	var arg *uint64
+	*(*uintptr)(unsafe.Pointer(&arg)) = data
+	switch op {
+	case spiIOCRdMode32:
+		*arg = s.mode
+	case spiIOCRdBitsPerWord:
+		*arg = s.bits
+	case spiIOCRdMaxSpeedHz:
+		*arg = s.speed
+	case spiIOCMode32:
+		if *arg&^s.modeMask != 0 {
+			return errors.New("invalid argument")
+		}
+		if *arg&^s.mode&uint64(cSHigh) != 0 {
+			s.csHigh = true
+		}
+		s.mode = *arg
+	case spiIOCBitsPerWord:
+		if *arg < 1 || *arg > 32 || s.bitsMask&(1<<(*arg-1)) == 0 {
+			return errors.New("invalid argument")
+		}
+		s.bits = *arg
+	default:
+		return errors.New("unexpected ioctl")
+	}
+	return nil
+}
+
 func init() {
 	drvSPI.bufSize = 4096
 }
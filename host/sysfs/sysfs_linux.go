@@ -7,6 +7,8 @@ package sysfs
 import (
 	"os"
 	"syscall"
+	"time"
+	"unsafe"
 )
 
 const isLinux = true
@@ -15,3 +17,16 @@ func isErrBusy(err error) bool {
 	e, ok := err.(*os.PathError)
 	return ok && e.Err == syscall.EBUSY
 }
+
+// monotonicNow returns the current value of CLOCK_MONOTONIC.
+//
+// This is the clock used by the kernel to timestamp GPIO line events.
+func monotonicNow() (time.Duration, error) {
+	var ts syscall.Timespec
+	if _, _, errno := syscall.Syscall(syscall.SYS_CLOCK_GETTIME, clockMonotonic, uintptr(unsafe.Pointer(&ts)), 0); errno != 0 {
+		return 0, syscall.Errno(errno)
+	}
+	return time.Duration(ts.Nano()), nil
+}
+
+const clockMonotonic = 1 // CLOCK_MONOTONIC in linux/time.h
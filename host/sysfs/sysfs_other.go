@@ -6,9 +6,18 @@
 
 package sysfs
 
+import (
+	"errors"
+	"time"
+)
+
 const isLinux = false
 
 func isErrBusy(err error) bool {
 	// This function is not used on non-linux.
 	return false
 }
+
+func monotonicNow() (time.Duration, error) {
+	return 0, errors.New("sysfs: CLOCK_MONOTONIC is not supported on this platform")
+}